@@ -14,13 +14,23 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 	_ "maspos-be-go/docs"
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/mailer"
+	"maspos-be-go/internal/metrics"
+	"maspos-be-go/internal/receiptmail"
+	"maspos-be-go/internal/salesrollup"
 	"maspos-be-go/internal/server"
+	"maspos-be-go/internal/tracing"
 )
 
 func gracefulShutdown(apiServer *http.Server, done chan bool) {
@@ -31,7 +41,7 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	// Listen for the interrupt signal.
 	<-ctx.Done()
 
-	log.Println("shutting down gracefully, press Ctrl+C again to force")
+	slog.Info("shutting down gracefully, press Ctrl+C again to force")
 	stop() // Allow Ctrl+C to force shutdown
 
 	// The context is used to inform the server it has 5 seconds to finish
@@ -39,10 +49,10 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+		slog.Error("server forced to shutdown", "error", err)
 	}
 
-	log.Println("Server exiting")
+	slog.Info("server exiting")
 
 	// Notify the main goroutine that the shutdown is complete
 	done <- true
@@ -50,7 +60,40 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 
 func main() {
 
-	server := server.NewServer()
+	cfg, err := config.Load()
+	if err != nil {
+		// logger belum bisa dikonfigurasi, pakai format JSON default
+		logger.New(os.Stderr, "info", "json").Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	log := logger.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(log)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		log.Error("failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+
+	m := metrics.New(db.DB(), cfg.Database.Name)
+	server := server.NewServer(cfg, db, log, m)
+
+	// pengiriman struk email dan rollup penjualan berjalan di latar belakang
+	// sampai server berhenti
+	workers, stopWorkers := context.WithCancel(context.Background())
+	if cfg.Mail.Enabled() {
+		go newReceiptMailer(cfg.Mail, db, log, m).Run(workers)
+	}
+	if cfg.Rollup.Interval > 0 {
+		go newSalesRollup(cfg.Rollup, db, log).Run(workers)
+	}
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
@@ -58,12 +101,52 @@ func main() {
 	// Run graceful shutdown in a separate goroutine
 	go gracefulShutdown(server, done)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		panic(fmt.Sprintf("http server error: %s", err))
 	}
 
 	// Wait for the graceful shutdown to complete
 	<-done
-	log.Println("Graceful shutdown complete.")
+	stopWorkers()
+	if err := db.Close(); err != nil {
+		log.Error("failed to close database", "error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("failed to flush traces", "error", err)
+	}
+	log.Info("graceful shutdown complete")
+}
+
+func newReceiptMailer(cfg config.Mail, db database.Service, log *slog.Logger, m *metrics.Metrics) *receiptmail.Worker {
+	return &receiptmail.Worker{
+		Queue:     repository.NewReceiptEmailRepository(db.DB()),
+		Templates: repository.NewReceiptTemplateRepository(db.DB()),
+		Tenants:   repository.NewTenantRepository(db.DB()),
+		Sender: &mailer.SMTP{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+		},
+		Logger:      log.With("worker", "receipt_email"),
+		Metrics:     m,
+		MaxAttempts: cfg.MaxAttempts,
+		RetryDelay:  cfg.RetryDelay,
+		Interval:    cfg.PollInterval,
+	}
+}
+
+func newSalesRollup(cfg config.Rollup, db database.Service, log *slog.Logger) *salesrollup.Worker {
+	return &salesrollup.Worker{
+		Rollups:  repository.NewSalesRollupRepository(db.DB()),
+		Tenants:  repository.NewTenantRepository(db.DB()),
+		Logger:   log.With("worker", "sales_rollup"),
+		Timezone: cfg.Timezone,
+		Lag:      cfg.Lag,
+		Interval: cfg.Interval,
+	}
 }
@@ -0,0 +1,115 @@
+// Command catalog mengelola katalog produk dari baris perintah, memakai
+// konfigurasi dan database yang sama dengan API.
+//
+//	catalog import [-dry-run] [-tenant slug] [-outlet id] produk.csv
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"maspos-be-go/internal/catalogimport"
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/scope"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: catalog import [-dry-run] [-tenant slug] [-outlet id] <file.csv|file.xlsx>")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "import":
+		os.Exit(runImport(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+// runImport mencetak laporan impor sebagai JSON ke stdout dan mengembalikan
+// exit code 1 bila ada baris yang salah atau impor gagal.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	tenant := fs.String("tenant", "", "import into this tenant (default DEFAULT_TENANT)")
+	outlet := fs.String("outlet", "", "import into this outlet instead of the central catalog")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load configuration:", err)
+		return 1
+	}
+	if *tenant == "" {
+		*tenant = cfg.Tenancy.DefaultTenant
+	}
+	// log ke stderr supaya stdout hanya berisi laporan
+	log := logger.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(log)
+
+	path := fs.Arg(0)
+	format, err := catalogimport.FormatOf(path)
+	if err != nil {
+		log.Error("cannot import file", "file", path, "error", err)
+		return 1
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Error("cannot open file", "error", err)
+		return 1
+	}
+	defer f.Close()
+	table, err := catalogimport.ReadTable(f, format)
+	if err != nil {
+		log.Error("cannot read file", "file", path, "error", err)
+		return 1
+	}
+
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		log.Error("failed to connect to database", "error", err)
+		return 1
+	}
+	defer db.Close()
+
+	// tanpa tenant, RLS tidak menampilkan kategori apa pun
+	t, err := repository.NewTenantRepository(db.DB()).GetBySlug(context.Background(), *tenant)
+	if err != nil {
+		log.Error("unknown tenant", "tenant", *tenant, "error", err)
+		return 1
+	}
+	ctx := scope.WithTenant(context.Background(), t.ID)
+	if *outlet != "" {
+		ctx = scope.WithOutlet(ctx, *outlet)
+	}
+	report, err := catalogimport.Run(ctx,
+		repository.NewCategoryRepository(db.DB()),
+		repository.NewProductRepository(db.DB()),
+		table, *dryRun)
+	if err != nil {
+		log.Error("import failed", "error", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
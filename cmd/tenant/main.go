@@ -0,0 +1,157 @@
+// Command tenant mendaftarkan dan menampilkan merchant (tenant), memakai
+// konfigurasi dan database yang sama dengan API.
+//
+//	tenant create -slug kopi-senja -name "Kopi Senja"
+//	tenant list
+//	tenant head-office -slug kopi-senja -email owner@kopisenja.id [-revoke]
+//	tenant supervisor -slug kopi-senja -email spv@kopisenja.id [-revoke]
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/scope"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: tenant create -slug slug -name name\n       tenant list\n       tenant head-office -slug slug -email email [-revoke]\n       tenant supervisor -slug slug -email email [-revoke]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "create":
+		os.Exit(runCreate(os.Args[2:]))
+	case "list":
+		os.Exit(runList(os.Args[2:]))
+	case "head-office":
+		os.Exit(runHeadOffice(os.Args[2:]))
+	case "supervisor":
+		os.Exit(runSupervisor(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+// open memuat konfigurasi dan membuka database; log ditulis ke stderr
+// supaya stdout hanya berisi hasil perintah.
+func open() (*sql.DB, func(), error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load configuration: %w", err)
+	}
+	slog.SetDefault(logger.New(os.Stderr, cfg.Log.Level, cfg.Log.Format))
+
+	db, err := database.New(cfg.Database)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to database: %w", err)
+	}
+	return db.DB(), func() { db.Close() }, nil
+}
+
+func runCreate(args []string) int {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	slug := fs.String("slug", "", "subdomain of the tenant, e.g. kopi-senja")
+	name := fs.String("name", "", "display name of the tenant")
+	fs.Parse(args)
+	if *slug == "" || *name == "" || fs.NArg() != 0 {
+		usage()
+	}
+
+	db, closeDB, err := open()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeDB()
+
+	id, err := repository.NewTenantRepository(db).Create(context.Background(), *slug, *name)
+	if err != nil {
+		slog.Error("failed to create tenant", "slug", *slug, "error", err)
+		return 1
+	}
+	fmt.Println(id)
+	return 0
+}
+
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		usage()
+	}
+
+	db, closeDB, err := open()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeDB()
+
+	list, err := repository.NewTenantRepository(db).GetAll(context.Background())
+	if err != nil {
+		slog.Error("failed to list tenants", "error", err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(list)
+	return 0
+}
+
+// runHeadOffice memberi (atau dengan -revoke mencabut) hak kantor pusat
+// seorang user. Hanya user kantor pusat yang boleh mengelola outlet dan
+// memilih outlet mana pun; hak ini tidak bisa diberikan lewat API.
+func runHeadOffice(args []string) int {
+	return runGrant("head-office", args, (*repository.UserRepository).SetHeadOffice)
+}
+
+// runSupervisor memberi (atau dengan -revoke mencabut) hak menyetujui
+// refund seorang user.
+func runSupervisor(args []string) int {
+	return runGrant("supervisor", args, (*repository.UserRepository).SetSupervisor)
+}
+
+func runGrant(name string, args []string, set func(*repository.UserRepository, context.Context, string, bool) error) int {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	slug := fs.String("slug", "", "subdomain of the tenant, e.g. kopi-senja")
+	email := fs.String("email", "", "email the user logs in with")
+	revoke := fs.Bool("revoke", false, "revoke the right instead of granting it")
+	fs.Parse(args)
+	if *slug == "" || *email == "" || fs.NArg() != 0 {
+		usage()
+	}
+
+	db, closeDB, err := open()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeDB()
+
+	ctx := context.Background()
+	t, err := repository.NewTenantRepository(db).GetBySlug(ctx, *slug)
+	if err != nil {
+		slog.Error("unknown tenant", "slug", *slug, "error", err)
+		return 1
+	}
+	users := repository.NewUserRepository(db)
+	if err := set(users, scope.WithTenant(ctx, t.ID), *email, !*revoke); err != nil {
+		slog.Error("failed to update user", "slug", *slug, "email", *email, "error", err)
+		return 1
+	}
+	return 0
+}
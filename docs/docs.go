@@ -67,6 +67,13 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
@@ -129,6 +136,20 @@ const docTemplate = `{
                     "Category"
                 ],
                 "summary": "Get all categories",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only categories shown on the POS grid",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -138,6 +159,9 @@ const docTemplate = `{
                                 "$ref": "#/definitions/repository.Category"
                             }
                         }
+                    },
+                    "304": {
+                        "description": "Not modified"
                     }
                 }
             },
@@ -169,51 +193,54 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.CategoryResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/categories/{id}": {
+        "/categories/export": {
             "get": {
+                "description": "Streams every category with its full path as CSV, NDJSON or XLSX.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
                 "tags": [
                     "Category"
                 ],
-                "summary": "Get category by ID",
+                "summary": "Export categories",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "csv (default), ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only categories shown on the POS grid",
+                        "name": "active",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/repository.Category"
+                            "type": "file"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "tags": [
-                    "Category"
-                ],
-                "summary": "Delete category",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -222,27 +249,26 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/categories/reorder": {
+            "post": {
+                "description": "Sets the positions of several categories in one transaction. Nothing is changed when any ID does not exist or appears more than once.",
+                "consumes": [
+                    "application/json"
+                ],
                 "tags": [
                     "Category"
                 ],
-                "summary": "Update category",
+                "summary": "Reorder categories",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Category Name",
+                        "description": "New positions",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CategoryRequest"
+                            "$ref": "#/definitions/dto.ReorderRequest"
                         }
                     }
                 ],
@@ -255,118 +281,119 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/products": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Product"
-                ],
-                "summary": "Get all products",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/repository.Product"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
-            },
-            "post": {
-                "consumes": [
-                    "multipart/form-data"
-                ],
+            }
+        },
+        "/categories/tree": {
+            "get": {
+                "description": "All categories nested under their parents, each level ordered by position.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Product"
+                    "Category"
                 ],
-                "summary": "Create new product",
+                "summary": "Get category tree",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Category ID",
-                        "name": "category_id",
-                        "in": "formData",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Only categories shown on the POS grid",
+                        "name": "active",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Product Name",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "Price",
-                        "name": "price",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Product Picture",
-                        "name": "picture",
-                        "in": "formData",
-                        "required": true
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.ProductResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CategoryNode"
+                            }
                         }
+                    },
+                    "304": {
+                        "description": "Not modified"
                     }
                 }
             }
         },
-        "/products/{id}": {
+        "/categories/{id}": {
             "get": {
                 "tags": [
-                    "Product"
+                    "Category"
                 ],
-                "summary": "Get product by ID",
+                "summary": "Get category by ID",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/repository.Product"
+                            "$ref": "#/definitions/repository.Category"
                         }
+                    },
+                    "304": {
+                        "description": "Not modified"
                     }
                 }
             },
             "delete": {
                 "tags": [
-                    "Product"
+                    "Category"
                 ],
-                "summary": "Delete product",
+                "summary": "Delete category",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -378,51 +405,76 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             },
             "patch": {
+                "description": "Partial update with JSON Merge Patch; only the fields sent are changed. Changing parent_id moves the whole subtree; null moves it to the top level.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "tags": [
-                    "Product"
+                    "Category"
                 ],
-                "summary": "Update product",
+                "summary": "Update category",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID",
+                        "description": "Category ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Category ID",
-                        "name": "category_id",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Product Name",
-                        "name": "name",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "type": "number",
-                        "description": "Price",
-                        "name": "price",
-                        "in": "formData",
-                        "required": true
+                        "description": "ETag of the version being edited",
+                        "name": "If-Match",
+                        "in": "header"
                     },
                     {
-                        "type": "file",
-                        "description": "Product Picture",
-                        "name": "picture",
-                        "in": "formData"
+                        "description": "Fields to change",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CategoryPatch"
+                        }
                     }
                 ],
                 "responses": {
@@ -434,124 +486,5379 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "dto.CategoryRequest": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "name": {
-                    "type": "string",
-                    "example": "Makanan"
-                }
-            }
-        },
-        "dto.CategoryResponse": {
-            "type": "object",
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{id}/tree": {
+            "get": {
+                "description": "The category with all of its descendants nested under it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Category"
+                ],
+                "summary": "Get category subtree",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CategoryNode"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/customers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customer"
+                ],
+                "summary": "Get all customers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search by name or phone",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Customer"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Phone numbers are normalized (62xxx) and must be unique",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customer"
+                ],
+                "summary": "Create new customer",
+                "parameters": [
+                    {
+                        "description": "Customer payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CustomerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}": {
+            "get": {
+                "tags": [
+                    "Customer"
+                ],
+                "summary": "Get customer by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Customer"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Customer"
+                ],
+                "summary": "Delete customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customer"
+                ],
+                "summary": "Update customer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Customer payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CustomerRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}/loyalty": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Get customer loyalty balance and ledger",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}/loyalty/adjust": {
+            "post": {
+                "description": "A negative adjustment cannot take more points than the balance",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Manually adjust loyalty points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Adjustment",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoyaltyAdjustRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoyaltyEntryResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}/loyalty/redeem": {
+            "post": {
+                "description": "Burns points and returns their Rupiah value to apply as a tender",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Redeem loyalty points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Points to redeem",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoyaltyRedeemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoyaltyRedeemResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/customers/{id}/purchases": {
+            "get": {
+                "description": "Number of orders, total and net spend (after refunds), average order, first and last visit, and the latest orders of the customer. Inside an outlet only its orders count.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Customer"
+                ],
+                "summary": "Get customer purchase history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Customer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest orders to include, default 20, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.PurchaseHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/loyalty/entries/{id}/reverse": {
+            "post": {
+                "description": "Writes an opposite entry, e.g. when the originating order is refunded. Reversing points that were already spent returns 422.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Reverse a loyalty ledger entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ledger entry ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoyaltyReverseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/loyalty/expire": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Expire loyalty points past their validity",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/loyalty/settings": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Get loyalty program rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/loyalty.Rules"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Category multipliers must name categories of the tenant",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loyalty"
+                ],
+                "summary": "Update loyalty program rules",
+                "parameters": [
+                    {
+                        "description": "Rules",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoyaltyRulesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "get": {
+                "description": "Orders without their lines, newest first. Inside an outlet only its orders are listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Get all orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only orders of this customer",
+                        "name": "customer_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only orders of this shift",
+                        "name": "shift_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the order with this number",
+                        "name": "number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "At most this many orders, default 100, max 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Order"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Stores a paid sale on an open shift of the active outlet. Prices, names and categories come from the outlet catalog. Tenders must cover the total; only cash may exceed it, the rest is change. A loyalty tender redeems the customer's points at the configured point value, and an order with a customer earns points on the part not paid with points.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Complete order",
+                "parameters": [
+                    {
+                        "description": "Lines, taxes and tenders",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "get": {
+                "description": "The order with its lines, taxes and tenders.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Get order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Order"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/receipt": {
+            "get": {
+                "description": "Renders a completed order with the receipt template of the order's outlet: its lines with modifiers and discounts, taxes, tenders, change and cashier. escpos returns printer commands ready to be sent to a thermal printer; html and pdf are meant for email.",
+                "produces": [
+                    "application/vnd.escpos",
+                    "text/plain",
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Print order receipt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "escpos",
+                            "text",
+                            "html",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Output format, default escpos",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            58,
+                            80
+                        ],
+                        "type": "integer",
+                        "description": "Paper width in mm, default from the outlet template",
+                        "name": "paper",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/receipt-emails": {
+            "get": {
+                "description": "Deliveries of the order's receipt with their status, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Get order receipt emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.ReceiptEmail"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Queues the receipt of a completed order to be emailed as HTML with a PDF attachment, rendered from the stored order. Without email the receipt goes to the order's customer. Failed deliveries are retried in the background.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Email order receipt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Other address than the customer's",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/receipt-emails/resend": {
+            "post": {
+                "description": "Queues the receipt of the order again, rendered from the stored order, to email or to the address of its latest delivery. Earlier deliveries are kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Resend order receipt",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Other address",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptResendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}/refunds": {
+            "get": {
+                "description": "Refunds of an order without their lines, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Get order refunds",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Refund"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Returns some or all units of the lines of an order. Line amounts are prorated from the line total after discount and the order tax is refunded in proportion. A line cannot be refunded beyond the units not refunded yet. The refund is paid with one method of the order and cannot exceed what was paid with it; cash comes out of the drawer of the given open shift of the order's outlet, a loyalty refund gives the redeemed points back. Points earned on the order are taken back in proportion, up to the customer's balance. Goods go back to stock when restock is set, by default for wrong_item and changed_mind. A supervisor of the outlet or a head office user approves with their email and password; wrong passwords count towards the login lockout of that email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Refund order lines",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lines, method and approval",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RefundRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/outlets": {
+            "get": {
+                "description": "Inside an outlet only that outlet is listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Get all outlets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Outlet"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Head office only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Create outlet",
+                "parameters": [
+                    {
+                        "description": "Outlet payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OutletRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OutletResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/outlets/{id}": {
+            "get": {
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Get outlet by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Outlet"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Head office only. Replaces the name, address and catalog setting.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Update outlet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Outlet payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OutletRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Head office only. An outlet that still has its own categories, products, shifts or Z-reports cannot be deleted.",
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Delete outlet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/outlets/{id}/receipt-template": {
+            "get": {
+                "description": "Outlets without a template get the default: outlet name and address as header, \"Terima kasih\" as footer, 80 mm paper.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Receipt"
+                ],
+                "summary": "Get outlet receipt template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/receipt.Template"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "An outlet may change its own template; the head office may change any outlet's.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Receipt"
+                ],
+                "summary": "Update outlet receipt template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Header and footer lines, paper width",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/outlets/{id}/users/{userID}": {
+            "put": {
+                "description": "Head office only. Takes effect at the user's next login.",
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Assign user to outlet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Head office only. Takes effect at the user's next login.",
+                "tags": [
+                    "Outlet"
+                ],
+                "summary": "Remove user from outlet",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Get all products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only products in this category",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include products of subcategories (default true)",
+                        "name": "include_descendants",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active products in visible categories, as shown on the POS grid",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Product"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Create new product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stock keeping unit, unique per product",
+                        "name": "sku",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product Name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Price",
+                        "name": "price",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Product Picture",
+                        "name": "picture",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Position on the POS grid",
+                        "name": "position",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Button color, e.g. #8d6e63",
+                        "name": "color",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Icon name",
+                        "name": "icon",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shown on the POS grid (default true)",
+                        "name": "active",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProductResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/export": {
+            "get": {
+                "description": "Streams every matching product with its category path as CSV, NDJSON or XLSX. Takes the same filters as GET /products. The CSV and XLSX files can be edited and imported again with POST /products/import.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Export products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only products in this category",
+                        "name": "category_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include products of subcategories (default true)",
+                        "name": "include_descendants",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active products in visible categories",
+                        "name": "active",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/import": {
+            "post": {
+                "description": "Creates or updates products from a CSV or XLSX file, matched by SKU. Columns: sku, name, category, price (required) and position, color, icon, active. category is a category name or a path such as \"Minuman \u003e Kopi\". Every row is validated first; if any row is invalid nothing is saved and 422 lists the errors. The whole import runs in one transaction.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Import products",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate and report without saving",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/catalogimport.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/catalogimport.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/reorder": {
+            "post": {
+                "description": "Sets the positions of several products in one transaction. Nothing is changed when any ID does not exist or appears more than once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Reorder products",
+                "parameters": [
+                    {
+                        "description": "New positions",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReorderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Get product by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Product"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Delete product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partial update: send JSON Merge Patch or multipart form fields; only the fields sent are changed.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Update product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stock keeping unit; empty removes it",
+                        "name": "sku",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "category_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product Name",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Price",
+                        "name": "price",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Product Picture",
+                        "name": "picture",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Position on the POS grid",
+                        "name": "position",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Button color, e.g. #8d6e63",
+                        "name": "color",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Icon name",
+                        "name": "icon",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Shown on the POS grid",
+                        "name": "active",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/outlet-price": {
+            "put": {
+                "description": "Overrides the price of a product for the outlet selected with X-Outlet-ID, typically for a product of the central catalog.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Set outlet price",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "X-Outlet-ID",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Outlet price",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OutletPriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The product goes back to its own price for the outlet selected with X-Outlet-ID.",
+                "tags": [
+                    "Product"
+                ],
+                "summary": "Remove outlet price",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Outlet ID",
+                        "name": "X-Outlet-ID",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/receipts/emails": {
+            "get": {
+                "description": "Deliveries with their status, newest first. Inside an outlet only its deliveries are listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Receipt"
+                ],
+                "summary": "Get receipt emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only deliveries of this receipt number",
+                        "name": "number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only deliveries of this order",
+                        "name": "order_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.ReceiptEmail"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/receipts/emails/{id}/resend": {
+            "post": {
+                "description": "Queues the receipt of an earlier delivery again, to a new address when email is given. The earlier delivery is kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Receipt"
+                ],
+                "summary": "Resend receipt email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Receipt email ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Other address",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptResendRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/receipts/render": {
+            "post": {
+                "description": "Preview only: renders the sale sent in the body, which is not checked against any stored order, with the receipt template of the active outlet. Receipts of completed orders are printed with GET /orders/{id}/receipt. escpos returns printer commands ready to be sent to a thermal printer; html and pdf are meant for email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/vnd.escpos",
+                    "text/plain",
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Receipt"
+                ],
+                "summary": "Preview receipt",
+                "parameters": [
+                    {
+                        "description": "Sale to preview",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReceiptRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "escpos",
+                            "text",
+                            "html",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Output format, default escpos",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            58,
+                            80
+                        ],
+                        "type": "integer",
+                        "description": "Paper width in mm, default from the outlet template",
+                        "name": "paper",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refunds/{id}": {
+            "get": {
+                "description": "The refund document with its lines.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Order"
+                ],
+                "summary": "Get refund",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Refund ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.Refund"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/product-rollups": {
+            "get": {
+                "description": "Best selling products by net over the period, read from per product rollups kept up to date in the background with the daily sales rollups. Fast over long periods, but orders and refunds after as_of are not counted yet. Days are in the rollup time zone. Deleted products are listed together with an empty id. Inside an outlet only that outlet is counted.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Product sales rollups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2026-01-01",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2026-12-31",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of products, 1 to 500, default 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProductRollupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales-rollups": {
+            "get": {
+                "description": "Sales per outlet and day with the same figures as /reports/sales/summary, read from rollups of orders and refunds kept up to date in the background. Fast over long periods, but orders and refunds after as_of are not counted yet. Days are in the rollup time zone. Inside an outlet only that outlet is listed.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Daily sales rollups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2026-01-01",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2026-12-31",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SalesRollupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales/cashiers": {
+            "get": {
+                "description": "Totals per user who created the orders and refunds, highest net first. Inside an outlet only that outlet is counted.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Sales by cashier",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone of the days, default Asia/Jakarta",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.CashierSales"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales/categories": {
+            "get": {
+                "description": "Sales per product category, highest net first, from the order lines. Lines without a category have an empty id. Inside an outlet only that outlet is counted.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Sales by category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone of the days, default Asia/Jakarta",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.ItemSales"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales/hourly": {
+            "get": {
+                "description": "All 24 hours, summed over the period. Inside an outlet only that outlet is counted.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Sales by hour of day",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone of the hours, default Asia/Jakarta",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.HourlySales"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales/products": {
+            "get": {
+                "description": "Best selling products by net, from the order lines. Gross is before line discounts; order discounts are not split over the lines. Refunded units count on the refund date. Inside an outlet only that outlet is counted.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Top products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone of the days, default Asia/Jakarta",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of products, 1 to 500, default 20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.ItemSales"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/sales/summary": {
+            "get": {
+                "description": "Sales of completed orders in total and per day: gross, discounts, refunds (by refund date, without tax), net, tax, total and average ticket. Inside an outlet only that outlet is counted.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Sales summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2026-01-01",
+                        "description": "First day, default today",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2026-01-31",
+                        "description": "Last day, default today",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time zone of the days, default Asia/Jakarta",
+                        "name": "tz",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json (default), csv, ndjson or xlsx; files list the days",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SalesSummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts": {
+            "get": {
+                "description": "Newest first. Inside an outlet only its shifts are listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shift"
+                ],
+                "summary": "Get shifts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only this register",
+                        "name": "register",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "open"
+                        ],
+                        "type": "string",
+                        "description": "open to list only open shifts",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Shift"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Opens a shift on a register of the active outlet with the opening cash float. A register has at most one open shift, and no shift can be opened once the business day of the outlet is closed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shift"
+                ],
+                "summary": "Open cashier shift",
+                "parameters": [
+                    {
+                        "description": "Register and opening float",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ShiftOpenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ShiftResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/{id}": {
+            "get": {
+                "description": "Cash totals and movements of a shift. For an open shift expected_cash is what the drawer should hold now; for a closed shift it is the stored close report with counted cash and variance.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shift"
+                ],
+                "summary": "Get shift report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shift ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.ShiftReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/{id}/cash-movements": {
+            "post": {
+                "description": "Cash added to or taken from the drawer outside of sales, with a required note. A pay-out cannot exceed the cash expected in the drawer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shift"
+                ],
+                "summary": "Record pay-in or pay-out",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shift ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cash movement",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CashMovementRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CashMovementResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shifts/{id}/close": {
+            "post": {
+                "description": "Stores the counted cash, the expected cash (opening float + cash sales - cash refunds + pay-ins - pay-outs) and their variance, and returns the shift report.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Shift"
+                ],
+                "summary": "Close cashier shift",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Shift ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Counted cash",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ShiftCloseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.ShiftReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/z-reports": {
+            "get": {
+                "description": "Closed business days, newest first. Inside an outlet only its Z-reports are listed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Get Z-reports",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.ZReport"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Closes a business day of the active outlet and stores its Z-report with the next number of the outlet. The report covers every cash movement since the previous Z-report up to the end of the day. Every shift opened before the end of the day must be closed first; afterwards no shift can be opened and no cash can be recorded in the closed day. Z-reports cannot be changed or deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Close business day",
+                "parameters": [
+                    {
+                        "description": "Day to close, default today",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CloseDayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/repository.ZReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/z-reports/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Get Z-report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Z-report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repository.ZReport"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/z-reports/{id}/print": {
+            "get": {
+                "description": "Reprints a Z-report with the receipt template header of its outlet. The content is the snapshot stored when the day was closed.",
+                "produces": [
+                    "application/vnd.escpos",
+                    "text/plain",
+                    "text/html",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Print Z-report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Z-report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "escpos",
+                            "text",
+                            "html",
+                            "pdf"
+                        ],
+                        "type": "string",
+                        "description": "Output format, default escpos",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            58,
+                            80
+                        ],
+                        "type": "integer",
+                        "description": "Paper width in mm, default from the outlet template",
+                        "name": "paper",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "catalogimport.Report": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/catalogimport.RowError"
+                    }
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "catalogimport.RowError": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ApprovalRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "spv@kopisenja.id"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "rahasia123"
+                }
+            }
+        },
+        "dto.CashMovementRequest": {
+            "type": "object",
+            "required": [
+                "amount",
+                "note",
+                "type"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "example": 25000
+                },
+                "note": {
+                    "type": "string",
+                    "example": "Beli galon"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "pay_in",
+                        "pay_out"
+                    ],
+                    "example": "pay_out"
+                }
+            }
+        },
+        "dto.CashMovementResponse": {
+            "type": "object",
+            "properties": {
+                "expected_cash": {
+                    "description": "ExpectedCash adalah kas yang seharusnya ada di laci setelah mutasi ini.",
+                    "type": "number",
+                    "example": 538000
+                },
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                }
+            }
+        },
+        "dto.CategoryNode": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CategoryNode"
+                    }
+                },
+                "color": {
+                    "type": "string",
+                    "example": "#8d6e63"
+                },
+                "icon": {
+                    "type": "string",
+                    "example": "coffee"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Kopi"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.CategoryPatch": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "color": {
+                    "type": "string",
+                    "example": "#8d6e63"
+                },
+                "icon": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "coffee"
+                },
+                "name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "Makanan"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 0
+                }
+            }
+        },
+        "dto.CategoryRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "color": {
+                    "type": "string",
+                    "example": "#8d6e63"
+                },
+                "icon": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "coffee"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Makanan"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 0
+                }
+            }
+        },
+        "dto.CategoryResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "color": {
+                    "type": "string",
+                    "example": "#8d6e63"
+                },
+                "icon": {
+                    "type": "string",
+                    "example": "coffee"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Makanan"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.CloseDayRequest": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2026-01-31"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Jakarta"
+                }
+            }
+        },
+        "dto.CustomerRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "phone"
+            ],
+            "properties": {
+                "birthday": {
+                    "type": "string",
+                    "example": "1990-08-17"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "budi@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Budi Santoso"
+                },
+                "notes": {
+                    "type": "string",
+                    "example": "Suka kopi tanpa gula"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "0812-3456-7890"
+                }
+            }
+        },
+        "dto.CustomerResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Budi Santoso"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "6281234567890"
+                }
+            }
+        },
+        "dto.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "admin@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "password"
+                }
+            }
+        },
+        "dto.LoyaltyAdjustRequest": {
+            "type": "object",
+            "required": [
+                "note",
+                "points"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Koreksi input kasir"
+                },
+                "points": {
+                    "type": "integer",
+                    "example": -20
+                }
+            }
+        },
+        "dto.LoyaltyEntryResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.LoyaltyRedeemRequest": {
+            "type": "object",
+            "required": [
+                "points",
+                "reference"
+            ],
+            "properties": {
+                "points": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "reference": {
+                    "type": "string",
+                    "example": "INV-20260101-001"
+                }
+            }
+        },
+        "dto.LoyaltyRedeemResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.LoyaltyReverseRequest": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Order di-refund"
+                }
+            }
+        },
+        "dto.LoyaltyRulesRequest": {
+            "type": "object",
+            "properties": {
+                "category_multipliers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "expiry_days": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 365
+                },
+                "point_value": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 100
+                },
+                "rupiah_per_point": {
+                    "type": "number",
+                    "example": 10000
+                }
+            }
+        },
+        "dto.OrderLineRequest": {
+            "type": "object",
+            "required": [
+                "product_id"
+            ],
+            "properties": {
+                "discount": {
+                    "description": "Discount berlaku untuk seluruh baris, bukan per unit.",
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 5000
+                },
+                "modifiers": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderModifierRequest"
+                    }
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Tanpa gula"
+                },
+                "product_id": {
+                    "type": "string",
+                    "example": "uuid-string-789"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 2
+                }
+            }
+        },
+        "dto.OrderModifierRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Extra shot"
+                },
+                "price": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 5000
+                }
+            }
+        },
+        "dto.OrderRequest": {
+            "type": "object",
+            "required": [
+                "lines",
+                "shift_id",
+                "tenders"
+            ],
+            "properties": {
+                "customer_id": {
+                    "type": "string",
+                    "example": "uuid-string-456"
+                },
+                "lines": {
+                    "type": "array",
+                    "maxItems": 200,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderLineRequest"
+                    }
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Meja 4"
+                },
+                "shift_id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "taxes": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/dto.OrderTaxRequest"
+                    }
+                },
+                "tenders": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.TenderRequest"
+                    }
+                }
+            }
+        },
+        "dto.OrderTaxRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 4100
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "PB1 10%"
+                }
+            }
+        },
+        "dto.OutletPriceRequest": {
+            "type": "object",
+            "required": [
+                "price"
+            ],
+            "properties": {
+                "price": {
+                    "type": "number",
+                    "example": 20000
+                }
+            }
+        },
+        "dto.OutletRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "Jl. Kemang Raya No. 8, Jakarta"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "MasPOS Kemang"
+                },
+                "use_central_catalog": {
+                    "description": "UseCentralCatalog defaults to true: the outlet sees the central catalog\nnext to its own categories and products.",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "dto.OutletResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "MasPOS Kemang"
+                }
+            }
+        },
+        "dto.ProductResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "picture": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ProductRollupResponse": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.ItemSales"
+                    }
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Jakarta"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2026-01-31"
+                }
+            }
+        },
+        "dto.ReceiptEmailRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "budi@example.com"
+                }
+            }
+        },
+        "dto.ReceiptEmailResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "pending"
+                }
+            }
+        },
+        "dto.ReceiptRequest": {
+            "type": "object",
+            "required": [
+                "lines",
+                "number"
+            ],
+            "properties": {
+                "cashier": {
+                    "type": "string",
+                    "example": "Rina"
+                },
+                "lines": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/receipt.Line"
+                    }
+                },
+                "number": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "INV-20260101-001"
+                },
+                "taxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/receipt.Tax"
+                    }
+                },
+                "tenders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/receipt.Tender"
+                    }
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2026-01-01T09:30:00+07:00"
+                }
+            }
+        },
+        "dto.ReceiptResendRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "budi@example.com"
+                }
+            }
+        },
+        "dto.ReceiptTemplateRequest": {
+            "type": "object",
+            "required": [
+                "paper"
+            ],
+            "properties": {
+                "footer": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Terima kasih"
+                    ]
+                },
+                "header": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Kopi Senja",
+                        "Jl. Kemang Raya No. 8"
+                    ]
+                },
+                "paper": {
+                    "type": "integer",
+                    "enum": [
+                        58,
+                        80
+                    ],
+                    "example": 80
+                }
+            }
+        },
+        "dto.RefundLineRequest": {
+            "type": "object",
+            "required": [
+                "order_line_id"
+            ],
+            "properties": {
+                "order_line_id": {
+                    "type": "string",
+                    "example": "uuid-string-789"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 1
+                },
+                "restock": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "dto.RefundRequest": {
+            "type": "object",
+            "required": [
+                "approval",
+                "lines",
+                "method",
+                "reason",
+                "shift_id"
+            ],
+            "properties": {
+                "approval": {
+                    "$ref": "#/definitions/dto.ApprovalRequest"
+                },
+                "lines": {
+                    "type": "array",
+                    "maxItems": 200,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.RefundLineRequest"
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "cash",
+                        "card",
+                        "qris",
+                        "transfer",
+                        "loyalty"
+                    ],
+                    "example": "cash"
+                },
+                "note": {
+                    "type": "string",
+                    "maxLength": 500,
+                    "example": "Pesanan tertukar"
+                },
+                "reason": {
+                    "type": "string",
+                    "enum": [
+                        "wrong_item",
+                        "changed_mind",
+                        "damaged",
+                        "expired",
+                        "other"
+                    ],
+                    "example": "wrong_item"
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "RRN-123456"
+                },
+                "shift_id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                }
+            }
+        },
+        "dto.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "admin@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Jonatan"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "password"
+                }
+            }
+        },
+        "dto.ReorderRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/repository.Position"
+                    }
+                }
+            }
+        },
+        "dto.SalesRollupResponse": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.OutletDailySales"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Jakarta"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2026-01-31"
+                },
+                "totals": {
+                    "$ref": "#/definitions/repository.Sales"
+                }
+            }
+        },
+        "dto.SalesSummaryResponse": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.DailySales"
+                    }
+                },
+                "from": {
+                    "type": "string",
+                    "example": "2026-01-01"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Jakarta"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2026-01-31"
+                },
+                "totals": {
+                    "$ref": "#/definitions/repository.Sales"
+                }
+            }
+        },
+        "dto.ShiftCloseRequest": {
+            "type": "object",
+            "required": [
+                "counted_cash"
+            ],
+            "properties": {
+                "counted_cash": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 742000
+                },
+                "note": {
+                    "type": "string",
+                    "example": "Selisih karena kembalian"
+                }
+            }
+        },
+        "dto.ShiftOpenRequest": {
+            "type": "object",
+            "required": [
+                "register"
+            ],
+            "properties": {
+                "note": {
+                    "type": "string",
+                    "example": "Modal dari brankas"
+                },
+                "opening_float": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 500000
+                },
+                "register": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "kasir-1"
+                }
+            }
+        },
+        "dto.ShiftResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "uuid-string-123"
+                },
+                "register": {
+                    "type": "string",
+                    "example": "kasir-1"
+                }
+            }
+        },
+        "dto.TenderRequest": {
+            "type": "object",
+            "required": [
+                "method"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 50000
+                },
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "cash",
+                        "card",
+                        "qris",
+                        "transfer",
+                        "loyalty"
+                    ],
+                    "example": "cash"
+                },
+                "points": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 0
+                },
+                "reference": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "APPR-123456"
+                }
+            }
+        },
+        "loyalty.Rules": {
+            "type": "object",
+            "properties": {
+                "category_multipliers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "expiry_days": {
+                    "type": "integer"
+                },
+                "point_value": {
+                    "type": "number"
+                },
+                "rupiah_per_point": {
+                    "type": "number"
+                }
+            }
+        },
+        "order.Modifier": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "order.Tax": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "order.Tender": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                }
+            }
+        },
+        "receipt.Line": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "discount": {
+                    "description": "Discount berlaku untuk seluruh baris, bukan per unit.",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/receipt.Modifier"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "unit_price": {
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "receipt.Modifier": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "receipt.Tax": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "receipt.Template": {
+            "type": "object",
+            "properties": {
+                "footer": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "header": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "paper": {
+                    "description": "Paper adalah lebar kertas printer outlet (58 atau 80).",
+                    "type": "integer"
+                }
+            }
+        },
+        "receipt.Tender": {
+            "type": "object",
+            "required": [
+                "method"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "method": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.CashMovement": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.CashierSales": {
+            "type": "object",
+            "properties": {
+                "average_ticket": {
+                    "type": "number"
+                },
+                "discounts": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "net": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "refund_count": {
+                    "type": "integer"
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.Category": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "outlet_id": {
+                    "description": "OutletID kosong (null) untuk kategori katalog pusat.",
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.Customer": {
+            "type": "object",
+            "properties": {
+                "birthday": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.DailySales": {
+            "type": "object",
+            "properties": {
+                "average_ticket": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "discounts": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "net": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "refund_count": {
+                    "type": "integer"
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.HourlySales": {
+            "type": "object",
+            "properties": {
+                "average_ticket": {
+                    "type": "number"
+                },
+                "discounts": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "hour": {
+                    "type": "integer"
+                },
+                "net": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "refund_count": {
+                    "type": "integer"
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.ItemSales": {
+            "type": "object",
+            "properties": {
+                "discounts": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "net": {
+                    "type": "number"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "refunded_quantity": {
+                    "type": "number"
+                },
+                "refunds": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.Order": {
+            "type": "object",
+            "properties": {
+                "cashier": {
+                    "description": "Cashier adalah nama user yang membuat order; hanya diisi oleh GetByID.",
+                    "type": "string"
+                },
+                "change": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.OrderLine"
+                    }
+                },
+                "note": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "paid": {
+                    "type": "number"
+                },
+                "points_earned": {
+                    "description": "PointsEarned dan PointsRedeemed adalah poin loyalty yang didapat dan\ndipakai pelanggan pada order ini.",
+                    "type": "integer"
+                },
+                "points_redeemed": {
+                    "type": "integer"
+                },
+                "shift_id": {
+                    "type": "string"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "taxes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/order.Tax"
+                    }
+                },
+                "tenders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/order.Tender"
+                    }
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.OrderLine": {
+            "type": "object",
+            "properties": {
+                "category_id": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "modifiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/order.Modifier"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "unit_price": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.Outlet": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "use_central_catalog": {
+                    "description": "UseCentralCatalog false berarti outlet hanya melihat kategori dan\nproduknya sendiri.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "repository.OutletDailySales": {
+            "type": "object",
+            "properties": {
+                "average_ticket": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "discounts": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "net": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "refund_count": {
+                    "type": "integer"
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.Position": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "repository.Product": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "category_id": {
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "outlet_id": {
+                    "description": "OutletID kosong (null) untuk produk katalog pusat.",
+                    "type": "string"
+                },
+                "picture": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "price": {
+                    "type": "number"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.PurchaseHistory": {
+            "type": "object",
+            "properties": {
+                "average_order": {
+                    "type": "number"
+                },
+                "customer_id": {
+                    "type": "string"
+                },
+                "first_visit": {
+                    "type": "string"
+                },
+                "last_visit": {
+                    "type": "string"
+                },
+                "net_spend": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "recent_orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.Order"
+                    }
+                },
+                "refunded": {
+                    "type": "number"
+                },
+                "total_spend": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.ReceiptEmail": {
+            "type": "object",
             "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
                 "id": {
-                    "type": "string",
-                    "example": "uuid-string-123"
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "approved_by": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.RefundLine"
+                    }
+                },
+                "method": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "string"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "order_number": {
+                    "type": "string"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "points": {
+                    "description": "Points adalah poin yang dikembalikan ke pelanggan pada refund\nloyalty; PointsReversed adalah poin hasil order yang ditarik kembali.",
+                    "type": "integer"
+                },
+                "points_reversed": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "shift_id": {
+                    "type": "string"
+                },
+                "subtotal": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.RefundLine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
                 },
                 "name": {
-                    "type": "string",
-                    "example": "Makanan"
+                    "type": "string"
+                },
+                "order_line_id": {
+                    "type": "string"
+                },
+                "product_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "number"
+                },
+                "restock": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.LoginRequest": {
+        "repository.Sales": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "admin@example.com"
+                "average_ticket": {
+                    "type": "number"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "password"
+                "discounts": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
+                "net": {
+                    "type": "number"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "refund_count": {
+                    "type": "integer"
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "number"
                 }
             }
         },
-        "dto.ProductResponse": {
+        "repository.Shift": {
             "type": "object",
             "properties": {
-                "category_id": {
+                "close_note": {
+                    "type": "string"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "closed_by": {
+                    "type": "integer"
+                },
+                "counted_cash": {
+                    "type": "number"
+                },
+                "expected_cash": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "open_note": {
+                    "type": "string"
+                },
+                "opened_at": {
+                    "type": "string"
+                },
+                "opened_by": {
+                    "type": "integer"
+                },
+                "opening_float": {
+                    "type": "number"
+                },
+                "outlet_id": {
                     "type": "string"
                 },
+                "register": {
+                    "type": "string"
+                },
+                "variance": {
+                    "type": "number"
+                }
+            }
+        },
+        "repository.ShiftReport": {
+            "type": "object",
+            "properties": {
+                "cash_sales": {
+                    "type": "number"
+                },
+                "close_note": {
+                    "type": "string"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "closed_by": {
+                    "type": "integer"
+                },
+                "counted_cash": {
+                    "type": "number"
+                },
+                "expected_cash": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "movements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.CashMovement"
+                    }
+                },
+                "open_note": {
                     "type": "string"
                 },
-                "picture": {
+                "opened_at": {
                     "type": "string"
                 },
-                "price": {
+                "opened_by": {
+                    "type": "integer"
+                },
+                "opening_float": {
+                    "type": "number"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "pay_ins": {
+                    "type": "number"
+                },
+                "pay_outs": {
+                    "type": "number"
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "register": {
+                    "type": "string"
+                },
+                "variance": {
                     "type": "number"
                 }
             }
         },
-        "dto.RegisterRequest": {
+        "repository.TenderTotal": {
             "type": "object",
-            "required": [
-                "email",
-                "name",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "admin@example.com"
+                "amount": {
+                    "type": "number"
                 },
-                "name": {
-                    "type": "string",
-                    "example": "Jonatan"
+                "count": {
+                    "type": "integer"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 6,
-                    "example": "password"
+                "method": {
+                    "type": "string"
                 }
             }
         },
-        "repository.Category": {
+        "repository.ZReport": {
             "type": "object",
             "properties": {
+                "average_ticket": {
+                    "type": "number"
+                },
+                "business_date": {
+                    "type": "string"
+                },
+                "closed_at": {
+                    "type": "string"
+                },
+                "closed_by": {
+                    "type": "integer"
+                },
+                "counted_cash": {
+                    "type": "number"
+                },
+                "discounts": {
+                    "type": "number"
+                },
+                "expected_cash": {
+                    "type": "number"
+                },
+                "gross": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "net": {
+                    "type": "number"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "orders": {
+                    "type": "integer"
+                },
+                "outlet_id": {
+                    "type": "string"
+                },
+                "pay_ins": {
+                    "type": "number"
+                },
+                "pay_outs": {
+                    "type": "number"
+                },
+                "period_end": {
+                    "type": "string"
+                },
+                "period_start": {
+                    "type": "string"
+                },
+                "refund_count": {
+                    "type": "integer"
+                },
+                "refund_tenders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.TenderTotal"
+                    }
+                },
+                "refunds": {
+                    "type": "number"
+                },
+                "shifts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.ZReportShift"
+                    }
+                },
+                "tax": {
+                    "type": "number"
+                },
+                "tenders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/repository.TenderTotal"
+                    }
+                },
+                "timezone": {
                     "type": "string"
+                },
+                "total": {
+                    "type": "number"
+                },
+                "variance": {
+                    "type": "number"
                 }
             }
         },
-        "repository.Product": {
+        "repository.ZReportShift": {
             "type": "object",
             "properties": {
-                "category_id": {
+                "closed_at": {
                     "type": "string"
                 },
+                "counted_cash": {
+                    "type": "number"
+                },
+                "expected_cash": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "string"
                 },
-                "name": {
+                "opened_at": {
                     "type": "string"
                 },
-                "picture": {
+                "opening_float": {
+                    "type": "number"
+                },
+                "register": {
                     "type": "string"
                 },
-                "price": {
+                "variance": {
                     "type": "number"
                 }
             }
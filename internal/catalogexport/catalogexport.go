@@ -0,0 +1,188 @@
+// Package catalogexport menulis produk dan kategori sebagai CSV, NDJSON atau
+// XLSX baris demi baris, sehingga ekspor sebesar apa pun memakai memori
+// yang tetap.
+package catalogexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/xlsx"
+)
+
+// Format ekspor yang didukung.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New("unsupported export format; use csv, ndjson or xlsx")
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) (string, error) {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8", nil
+	case FormatNDJSON:
+		return "application/x-ndjson", nil
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ProductColumns dimulai dengan kolom impor produk, jadi file ekspor bisa
+// disunting lalu diimpor kembali; kolom sisanya diabaikan oleh impor.
+var ProductColumns = []string{"sku", "name", "category", "price", "position", "color", "icon", "active", "id", "category_id", "picture", "version"}
+
+func ProductRow(p repository.ProductExport) []any {
+	return []any{p.SKU, p.Name, p.CategoryPath, p.Price, p.Position, p.Color, p.Icon, p.Active, p.ID, p.CategoryID, p.Picture, p.Version}
+}
+
+var CategoryColumns = []string{"id", "name", "path", "parent_id", "position", "color", "icon", "active", "version"}
+
+func CategoryRow(c repository.CategoryExport) []any {
+	var parentID any
+	if c.ParentID != nil {
+		parentID = *c.ParentID
+	}
+	return []any{c.ID, c.Name, c.Path, parentID, c.Position, c.Color, c.Icon, c.Active, c.Version}
+}
+
+// Writer writes rows in one of the export formats. Nothing is written to the
+// underlying writer until the first Write or Close, so a caller can still
+// report an error instead of a half-written file when the query fails
+// before producing a row.
+type Writer struct {
+	w       io.Writer
+	format  string
+	name    string
+	columns []string
+	started bool
+
+	csv  *csv.Writer
+	json *bufio.Writer
+	enc  *json.Encoder
+	buf  bytes.Buffer
+	xlsx *xlsx.Writer
+}
+
+// NewWriter returns a Writer for format. name is used as the XLSX sheet
+// name.
+func NewWriter(w io.Writer, format, name string, columns []string) (*Writer, error) {
+	if _, err := ContentType(format); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, format: format, name: name, columns: columns}, nil
+}
+
+func (w *Writer) start() error {
+	w.started = true
+	switch w.format {
+	case FormatCSV:
+		w.csv = csv.NewWriter(w.w)
+		return w.csv.Write(w.columns)
+	case FormatNDJSON:
+		w.json = bufio.NewWriter(w.w)
+		// tanpa escape HTML supaya "Minuman > Kopi" tetap terbaca
+		w.enc = json.NewEncoder(&w.buf)
+		w.enc.SetEscapeHTML(false)
+		return nil
+	default:
+		var err error
+		if w.xlsx, err = xlsx.NewWriter(w.w, w.name); err != nil {
+			return err
+		}
+		header := make([]any, len(w.columns))
+		for i, c := range w.columns {
+			header[i] = c
+		}
+		return w.xlsx.WriteRow(header)
+	}
+}
+
+// Write appends one row; values follow the column order given to
+// NewWriter.
+func (w *Writer) Write(values []any) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	switch w.format {
+	case FormatCSV:
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = formatCell(v)
+		}
+		return w.csv.Write(record)
+	case FormatNDJSON:
+		// objek ditulis manual supaya urutan kolomnya sama dengan CSV
+		w.json.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				w.json.WriteByte(',')
+			}
+			if err := w.writeJSON(w.columns[i]); err != nil {
+				return err
+			}
+			w.json.WriteByte(':')
+			if err := w.writeJSON(v); err != nil {
+				return err
+			}
+		}
+		_, err := w.json.WriteString("}\n")
+		return err
+	default:
+		return w.xlsx.WriteRow(values)
+	}
+}
+
+func (w *Writer) writeJSON(v any) error {
+	w.buf.Reset()
+	if err := w.enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := w.json.Write(bytes.TrimSuffix(w.buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// Close flushes buffered rows. An export without rows still produces a
+// header row (CSV, XLSX) or an empty body (NDJSON).
+func (w *Writer) Close() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	switch w.format {
+	case FormatCSV:
+		w.csv.Flush()
+		return w.csv.Error()
+	case FormatNDJSON:
+		return w.json.Flush()
+	default:
+		return w.xlsx.Close()
+	}
+}
+
+func formatCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
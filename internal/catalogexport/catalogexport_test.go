@@ -0,0 +1,91 @@
+package catalogexport
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"maspos-be-go/internal/catalogimport"
+	"maspos-be-go/internal/database/repository"
+)
+
+func testProducts() []repository.ProductExport {
+	return []repository.ProductExport{
+		{Product: repository.Product{ID: "p-1", SKU: "K-1", CategoryID: "c-kopi", Name: "Kopi \"Tubruk\", Panas", Price: 18000.5, Active: true, Version: 2}, CategoryPath: "Minuman > Kopi"},
+		{Product: repository.Product{ID: "p-2", SKU: "T-1", CategoryID: "c-teh", Name: "Es Teh", Price: 8000, Position: 1, Color: "#ffcc00"}, CategoryPath: "Minuman > Teh"},
+	}
+}
+
+func export(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, "Produk", ProductColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range testProducts() {
+		if err := w.Write(ProductRow(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestNDJSON(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(export(t, FormatNDJSON))), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", lines)
+	}
+	want := `{"sku":"T-1","name":"Es Teh","category":"Minuman > Teh","price":8000,"position":1,"color":"#ffcc00","icon":"","active":false,"id":"p-2","category_id":"c-teh","picture":"","version":0}`
+	if lines[1] != want {
+		t.Errorf("got  %s\nwant %s", lines[1], want)
+	}
+}
+
+// File CSV dan XLSX hasil ekspor harus bisa diimpor kembali tanpa diubah.
+func TestExportReimports(t *testing.T) {
+	categories := []repository.Category{
+		{ID: "c-minuman", Name: "Minuman"},
+		{ID: "c-kopi", ParentID: &[]string{"c-minuman"}[0], Name: "Kopi"},
+		{ID: "c-teh", ParentID: &[]string{"c-minuman"}[0], Name: "Teh"},
+	}
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		table, err := catalogimport.ReadTable(bytes.NewReader(export(t, format)), format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		items, rowErrs, err := catalogimport.Parse(table, categories)
+		if err != nil || len(rowErrs) > 0 {
+			t.Fatalf("%s: unexpected errors %v %v", format, err, rowErrs)
+		}
+		var got []string
+		for _, it := range items {
+			got = append(got, it.SKU+"|"+it.Name+"|"+it.CategoryID+"|"+strconv.FormatFloat(it.Price, 'f', -1, 64))
+		}
+		want := []string{`K-1|Kopi "Tubruk", Panas|c-kopi|18000.5`, "T-1|Es Teh|c-teh|8000"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestEmptyExport(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatCSV, "Produk", CategoryColumns)
+	if buf.Len() != 0 {
+		t.Error("nothing should be written before the first row")
+	}
+	w.Close()
+	if got := buf.String(); got != strings.Join(CategoryColumns, ",")+"\n" {
+		t.Errorf("expected only the header, got %q", got)
+	}
+
+	if _, err := NewWriter(&buf, "pdf", "Produk", CategoryColumns); err != ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
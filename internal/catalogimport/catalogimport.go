@@ -0,0 +1,364 @@
+// Package catalogimport membaca daftar produk dari file CSV atau XLSX,
+// memvalidasi setiap baris dan menyimpannya sekaligus. Impor bersifat
+// semua-atau-tidak-sama-sekali: bila ada satu baris yang salah, tidak ada
+// produk yang disimpan dan semua kesalahan dilaporkan per baris.
+package catalogimport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/xlsx"
+)
+
+// Format file yang didukung.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// MaxRows membatasi jumlah baris data dalam satu file supaya satu impor
+// tidak menahan transaksi terlalu lama.
+const MaxRows = 10000
+
+var (
+	ErrUnknownFormat = errors.New("unsupported file format; use .csv or .xlsx")
+	ErrEmptyFile     = errors.New("file has no header row")
+	ErrTooManyRows   = fmt.Errorf("file has more than %d rows", MaxRows)
+)
+
+// Columns lists the header names understood by Parse, required ones first.
+var Columns = []string{"sku", "name", "category", "price", "position", "color", "icon", "active"}
+
+var requiredColumns = []string{"sku", "name", "category", "price"}
+
+// ignoredColumns ditulis oleh ekspor katalog tetapi tidak bisa diubah lewat
+// impor; kolom ini dilewati supaya file ekspor bisa langsung diimpor ulang.
+var ignoredColumns = []string{"id", "category_id", "picture", "version"}
+
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// RowError describes one invalid cell or row. Row is the line number in
+// the file, counting the header as row 1.
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("row %d, %s: %s", e.Row, e.Column, e.Message)
+}
+
+// Report is the outcome of an import. When Errors is not empty nothing was
+// saved and Created/Updated are zero.
+type Report struct {
+	DryRun  bool       `json:"dry_run"`
+	Rows    int        `json:"rows"`
+	Created int        `json:"created"`
+	Updated int        `json:"updated"`
+	Errors  []RowError `json:"errors"`
+}
+
+// FormatOf menebak format dari ekstensi nama file.
+func FormatOf(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ReadTable reads the whole file into rows of cells. Index i holds line
+// i+1 of the file, so blank lines are kept as empty rows.
+func ReadTable(r io.Reader, format string) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatCSV:
+		return readCSV(data)
+	case FormatXLSX:
+		return xlsx.ReadRows(bytes.NewReader(data), int64(len(data)))
+	}
+	return nil, ErrUnknownFormat
+}
+
+// readCSV menerima CSV dengan koma maupun titik koma (default Excel dengan
+// locale Indonesia) dan membuang BOM UTF-8 yang ditambahkan Excel.
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	header, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		cr.Comma = ';'
+	}
+
+	var rows [][]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		for len(rows) < line-1 {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, record)
+	}
+}
+
+// Parse validates the table and resolves category names. The category
+// column holds either a category name or, when names repeat under different
+// parents, the full path such as "Minuman > Kopi". Row errors are collected
+// for the whole file instead of stopping at the first one.
+func Parse(table [][]string, categories []repository.Category) (items []repository.ProductImport, rowErrs []RowError, err error) {
+	if len(table) == 0 || isBlank(table[0]) {
+		return nil, nil, ErrEmptyFile
+	}
+
+	index := map[string]int{}
+	for i, name := range table[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, dup := index[name]; dup {
+			rowErrs = append(rowErrs, RowError{Row: 1, Column: name, Message: "duplicate column"})
+			continue
+		}
+		if slices.Contains(ignoredColumns, name) {
+			continue
+		}
+		if !slices.Contains(Columns, name) {
+			rowErrs = append(rowErrs, RowError{Row: 1, Column: name, Message: "unknown column; expected one of " + strings.Join(Columns, ", ")})
+			continue
+		}
+		index[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := index[name]; !ok {
+			rowErrs = append(rowErrs, RowError{Row: 1, Column: name, Message: "required column is missing"})
+		}
+	}
+	if len(rowErrs) > 0 {
+		return nil, rowErrs, nil
+	}
+
+	resolve := newCategoryResolver(categories)
+	seen := map[string]int{}
+	rows := 0
+	for i, record := range table[1:] {
+		if isBlank(record) {
+			continue
+		}
+		if rows++; rows > MaxRows {
+			return nil, nil, ErrTooManyRows
+		}
+
+		line := i + 2
+		cell := func(name string) (string, bool) {
+			col, ok := index[name]
+			if !ok || col >= len(record) {
+				return "", ok
+			}
+			return strings.TrimSpace(record[col]), true
+		}
+		fail := func(column, format string, args ...any) {
+			rowErrs = append(rowErrs, RowError{Row: line, Column: column, Message: fmt.Sprintf(format, args...)})
+		}
+
+		var it repository.ProductImport
+		it.SKU, _ = cell("sku")
+		switch first, dup := seen[it.SKU]; {
+		case it.SKU == "":
+			fail("sku", "is required")
+		case dup:
+			fail("sku", "duplicate SKU %q, first used on row %d", it.SKU, first)
+		default:
+			seen[it.SKU] = line
+		}
+
+		if it.Name, _ = cell("name"); it.Name == "" {
+			fail("name", "is required")
+		}
+
+		category, _ := cell("category")
+		if id, err := resolve(category); err != nil {
+			fail("category", "%s", err)
+		} else {
+			it.CategoryID = id
+		}
+
+		price, _ := cell("price")
+		if v, err := strconv.ParseFloat(price, 64); err != nil || v <= 0 {
+			fail("price", "must be a number greater than 0, got %q", price)
+		} else {
+			it.Price = v
+		}
+
+		if v, ok := cell("position"); ok && v != "" {
+			if n, err := strconv.Atoi(v); err != nil || n < 0 {
+				fail("position", "must be a whole number of at least 0, got %q", v)
+			} else {
+				it.Position = &n
+			}
+		}
+		if v, ok := cell("color"); ok && v != "" {
+			if !hexColor.MatchString(v) {
+				fail("color", "must be a hex color such as #8d6e63, got %q", v)
+			}
+			it.Color = &v
+		}
+		if v, ok := cell("icon"); ok && v != "" {
+			if len(v) > 64 {
+				fail("icon", "must be at most 64 characters")
+			}
+			it.Icon = &v
+		}
+		if v, ok := cell("active"); ok && v != "" {
+			if b, ok := parseBool(v); !ok {
+				fail("active", "must be true or false, got %q", v)
+			} else {
+				it.Active = &b
+			}
+		}
+		items = append(items, it)
+	}
+	if len(rowErrs) > 0 {
+		return nil, rowErrs, nil
+	}
+	return items, nil, nil
+}
+
+// Categories dan Products adalah bagian dari store kategori dan produk yang
+// dipakai impor.
+type Categories interface {
+	GetAll(ctx context.Context, f repository.CategoryFilter) ([]repository.Category, error)
+}
+
+type Products interface {
+	Import(ctx context.Context, items []repository.ProductImport, dryRun bool) (repository.ImportResult, error)
+}
+
+// Run parses table and, when every row is valid, imports it. Invalid rows
+// are reported in the Report rather than as an error; err is reserved for
+// unreadable files and database failures.
+func Run(ctx context.Context, categories Categories, products Products, table [][]string, dryRun bool) (*Report, error) {
+	all, err := categories.GetAll(ctx, repository.CategoryFilter{})
+	if err != nil {
+		return nil, err
+	}
+	items, rowErrs, err := Parse(table, all)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: dryRun, Errors: rowErrs}
+	for _, record := range table[1:] {
+		if !isBlank(record) {
+			report.Rows++
+		}
+	}
+	if len(rowErrs) > 0 {
+		return report, nil
+	}
+	res, err := products.Import(ctx, items, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	report.Created, report.Updated = res.Created, res.Updated
+	report.Errors = []RowError{}
+	return report, nil
+}
+
+// newCategoryResolver mencocokkan nama atau path kategori tanpa membedakan
+// huruf besar kecil.
+func newCategoryResolver(categories []repository.Category) func(string) (string, error) {
+	byID := make(map[string]repository.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	byName := map[string][]string{}
+	byPath := map[string]string{}
+	pathOf := map[string]string{}
+	for _, c := range categories {
+		byName[strings.ToLower(c.Name)] = append(byName[strings.ToLower(c.Name)], c.ID)
+
+		names := []string{c.Name}
+		for p := c.ParentID; p != nil; {
+			parent, ok := byID[*p]
+			if !ok || len(names) > len(categories) {
+				break
+			}
+			names = append([]string{parent.Name}, names...)
+			p = parent.ParentID
+		}
+		byPath[categoryPath(names)] = c.ID
+		pathOf[c.ID] = strings.Join(names, " > ")
+	}
+
+	return func(value string) (string, error) {
+		parts := strings.Split(value, ">")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		key := categoryPath(parts)
+		switch {
+		case key == "":
+			return "", errors.New("is required")
+		case len(parts) > 1:
+			if id, ok := byPath[key]; ok {
+				return id, nil
+			}
+		case len(byName[key]) == 1:
+			return byName[key][0], nil
+		case len(byName[key]) > 1:
+			return "", fmt.Errorf("%q matches %d categories; use the full path, e.g. %q", value, len(byName[key]), pathOf[byName[key][0]])
+		}
+		return "", fmt.Errorf("unknown category %q", value)
+	}
+}
+
+func categoryPath(names []string) string {
+	return strings.ToLower(strings.Join(names, " > "))
+}
+
+func parseBool(v string) (bool, bool) {
+	switch strings.ToLower(v) {
+	case "1", "true", "yes", "ya", "y":
+		return true, true
+	case "0", "false", "no", "tidak", "n":
+		return false, true
+	}
+	return false, false
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
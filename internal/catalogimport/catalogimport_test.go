@@ -0,0 +1,144 @@
+package catalogimport
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"maspos-be-go/internal/database/repository"
+)
+
+func testCategories() []repository.Category {
+	parent := func(id string) *string { return &id }
+	return []repository.Category{
+		{ID: "c-minuman", Name: "Minuman"},
+		{ID: "c-kopi", ParentID: parent("c-minuman"), Name: "Kopi"},
+		{ID: "c-makanan", Name: "Makanan"},
+		{ID: "c-snack-makanan", ParentID: parent("c-makanan"), Name: "Snack"},
+		{ID: "c-snack-minuman", ParentID: parent("c-minuman"), Name: "Snack"},
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	// Excel berlocale Indonesia menyimpan CSV dengan BOM dan titik koma
+	table, err := ReadTable(strings.NewReader("\xef\xbb\xbfsku;name;price\n\nK-1;\"Kopi; Susu\";18000\n"), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"sku", "name", "price"}, nil, {"K-1", "Kopi; Susu", "18000"}}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("got %q, want %q", table, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	table, err := ReadTable(strings.NewReader(`SKU,Name,Category,Price,Active,Position
+K-1,Kopi Susu,kopi,18000,ya,
+K-2,Keripik,Makanan > Snack,12000.5,,3
+`), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, rowErrs, err := Parse(table, testCategories())
+	if err != nil || len(rowErrs) > 0 {
+		t.Fatalf("unexpected errors: %v %v", err, rowErrs)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if it := items[0]; it.CategoryID != "c-kopi" || it.Price != 18000 || it.Active == nil || !*it.Active || it.Position != nil {
+		t.Errorf("unexpected first item %+v", it)
+	}
+	if it := items[1]; it.CategoryID != "c-snack-makanan" || it.Price != 12000.5 || it.Active != nil || *it.Position != 3 || it.Color != nil {
+		t.Errorf("unexpected second item %+v", it)
+	}
+}
+
+func TestParseRowErrors(t *testing.T) {
+	table, _ := ReadTable(strings.NewReader(`sku,name,category,price,color
+K-1,Kopi Susu,Kopi,18000,
+K-1,Kopi Hitam,Kopi,15000,
+K-3,,Teh,gratis,coklat
+K-4,Keripik,Snack,10000,
+`), FormatCSV)
+
+	items, rowErrs, err := Parse(table, testCategories())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items != nil {
+		t.Error("no items should be returned when any row is invalid")
+	}
+
+	got := map[string]bool{}
+	for _, e := range rowErrs {
+		got[fmt.Sprintf("row %d, %s", e.Row, e.Column)] = true
+	}
+	for _, want := range []string{"row 3, sku", "row 4, name", "row 4, category", "row 4, price", "row 4, color", "row 5, category"} {
+		if !got[want] {
+			t.Errorf("expected an error for %s, got %v", want, rowErrs)
+		}
+	}
+	if len(rowErrs) != 6 {
+		t.Errorf("expected 6 errors, got %v", rowErrs)
+	}
+	if !strings.Contains(rowErrs[5].Message, `"Makanan > Snack"`) {
+		t.Errorf("expected an ambiguous category to suggest a full path, got %q", rowErrs[5].Message)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	_, rowErrs, err := Parse([][]string{{"sku", "nama", "price"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cols []string
+	for _, e := range rowErrs {
+		cols = append(cols, e.Column)
+	}
+	if strings.Join(cols, ",") != "nama,name,category" {
+		t.Errorf("expected unknown nama and missing name, category; got %v", rowErrs)
+	}
+
+	if _, _, err := Parse(nil, nil); err != ErrEmptyFile {
+		t.Errorf("expected ErrEmptyFile, got %v", err)
+	}
+}
+
+type fakeCatalog struct {
+	imported []repository.ProductImport
+}
+
+func (f *fakeCatalog) GetAll(ctx context.Context, filter repository.CategoryFilter) ([]repository.Category, error) {
+	return testCategories(), nil
+}
+
+func (f *fakeCatalog) Import(ctx context.Context, items []repository.ProductImport, dryRun bool) (repository.ImportResult, error) {
+	f.imported = items
+	return repository.ImportResult{Created: len(items)}, nil
+}
+
+func TestRun(t *testing.T) {
+	f := &fakeCatalog{}
+	table := [][]string{{"sku", "name", "category", "price"}, {"K-1", "Kopi Susu", "Kopi", "18000"}, {"K-2", "Teh", "Teh", "8000"}}
+
+	report, err := Run(context.Background(), f, f, table, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 2 || len(report.Errors) != 1 || f.imported != nil {
+		t.Errorf("expected one error and nothing imported, got %+v", report)
+	}
+
+	table = table[:2]
+	report, err = Run(context.Background(), f, f, table, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.DryRun || report.Created != 1 || len(f.imported) != 1 || report.Errors == nil {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,506 @@
+// Package config loads the application configuration once at startup.
+//
+// Values are read, in increasing order of precedence, from an optional YAML
+// file (path in CONFIG_FILE), a .env file in the working directory and the
+// process environment. Load validates the result and reports every missing
+// or malformed value at once so the process can fail fast.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // ROLLUP_TIMEZONE tidak bergantung pada image
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Port     int      `yaml:"port"`
+	Database Database `yaml:"database"`
+	JWT      JWT      `yaml:"jwt"`
+	Health   Health   `yaml:"health"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Concurrency Concurrency `yaml:"concurrency"`
+	Tenancy     Tenancy     `yaml:"tenancy"`
+	Mail        Mail        `yaml:"mail"`
+	Rollup      Rollup      `yaml:"rollup"`
+	// TrustedProxies lists the proxy addresses or CIDRs whose
+	// X-Forwarded-For header is believed when resolving the client IP.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type Database struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Name     string `yaml:"name"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Schema   string `yaml:"schema"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type JWT struct {
+	Secret string        `yaml:"secret"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+type Health struct {
+	// CacheTTL is how long a readiness result is reused before the checks
+	// run again.
+	CacheTTL      time.Duration `yaml:"cache_ttl"`
+	MinFreeDiskMB int           `yaml:"min_free_disk_mb"`
+}
+
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type Tracing struct {
+	// Exporter is none, stdout or otlp.
+	Exporter    string  `yaml:"exporter"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type RateLimit struct {
+	// Backend is off, memory or postgres. Use postgres when running more
+	// than one replica.
+	Backend string `yaml:"backend"`
+	// Default applies to every route without an entry in Routes.
+	Default Limit `yaml:"default"`
+	// Routes is keyed by method and route template, e.g. "POST /auth/login".
+	Routes map[string]Limit `yaml:"routes"`
+	Login  Lockout          `yaml:"login"`
+}
+
+// Limit allows Requests per Per on average with bursts of up to Burst.
+// Requests 0 disables the limit.
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+// Lockout locks an email address after MaxFailures failed logins, each less
+// than Window apart. The lock starts at BaseDelay and doubles with every
+// further failure up to MaxDelay. MaxFailures 0 disables it.
+type Lockout struct {
+	MaxFailures int           `yaml:"max_failures"`
+	Window      time.Duration `yaml:"window"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	MaxDelay    time.Duration `yaml:"max_delay"`
+}
+
+type Idempotency struct {
+	// TTL is how long a stored response is replayed for the same
+	// Idempotency-Key.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type Concurrency struct {
+	// RequireIfMatch makes PATCH and DELETE on versioned resources fail
+	// with 428 when the If-Match header is missing.
+	RequireIfMatch bool `yaml:"require_if_match"`
+}
+
+type Tenancy struct {
+	// BaseDomain enables tenant subdomains: a request to
+	// kopi-senja.maspos.id is served as tenant kopi-senja when BaseDomain is
+	// maspos.id.
+	BaseDomain string `yaml:"base_domain"`
+	// DefaultTenant is the slug used for requests without a tenant in the
+	// token or the host. When empty such requests run without a tenant and
+	// see no tenant data.
+	DefaultTenant string `yaml:"default_tenant"`
+}
+
+type Mail struct {
+	// SMTPHost empty disables email receipts.
+	SMTPHost string `yaml:"smtp_host"`
+	SMTPPort int    `yaml:"smtp_port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// From is the sender address, e.g. "MasPOS <struk@maspos.id>".
+	From string `yaml:"from"`
+	// MaxAttempts is how often a receipt email is tried before it is
+	// marked failed. RetryDelay is the wait after the first failed attempt
+	// and doubles after every further one.
+	MaxAttempts  int           `yaml:"max_attempts"`
+	RetryDelay   time.Duration `yaml:"retry_delay"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+type Rollup struct {
+	// Timezone decides the day of a sales rollup row. Rollups built in
+	// another time zone must be rebuilt (cmd/rollup) after a change.
+	Timezone string `yaml:"timezone"`
+	// Interval is how often the API adds new orders and refunds to the
+	// rollups; 0 disables the worker, e.g. on all but one instance.
+	Interval time.Duration `yaml:"interval"`
+	// Lag keeps the newest orders and refunds out of a run until the
+	// transactions that recorded them had time to commit.
+	Lag time.Duration `yaml:"lag"`
+}
+
+// Enabled reports whether an SMTP server is configured.
+func (m Mail) Enabled() bool {
+	return m.SMTPHost != ""
+}
+
+// DSN returns the connection string for the pgx driver.
+func (d Database) DSN() string {
+	q := url.Values{}
+	q.Set("sslmode", d.SSLMode)
+	if d.Schema != "" {
+		q.Set("search_path", d.Schema)
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.Username, d.Password),
+		Host:     net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:     "/" + d.Name,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+func defaults() Config {
+	return Config{
+		Port: 8080,
+		Database: Database{
+			Port:    5432,
+			Schema:  "public",
+			SSLMode: "disable",
+		},
+		JWT: JWT{TTL: 24 * time.Hour},
+		Health: Health{
+			CacheTTL:      5 * time.Second,
+			MinFreeDiskMB: 100,
+		},
+		Log: Log{Level: "info", Format: "json"},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "maspos-api",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimit{
+			Backend: "memory",
+			Default: Limit{Requests: 20, Per: time.Second, Burst: 40},
+			Routes: map[string]Limit{
+				"POST /auth/login":    {Requests: 10, Per: time.Minute, Burst: 5},
+				"POST /auth/register": {Requests: 5, Per: time.Minute, Burst: 5},
+			},
+			Login: Lockout{
+				MaxFailures: 5,
+				Window:      15 * time.Minute,
+				BaseDelay:   time.Minute,
+				MaxDelay:    time.Hour,
+			},
+		},
+		Idempotency: Idempotency{TTL: 24 * time.Hour},
+		Concurrency: Concurrency{RequireIfMatch: true},
+		Tenancy:     Tenancy{DefaultTenant: "default"},
+		Mail: Mail{
+			SMTPPort:     587,
+			MaxAttempts:  5,
+			RetryDelay:   time.Minute,
+			PollInterval: 10 * time.Second,
+		},
+		Rollup: Rollup{
+			Timezone: "Asia/Jakarta",
+			Interval: time.Minute,
+			Lag:      time.Minute,
+		},
+	}
+}
+
+// Load builds and validates the configuration.
+func Load() (*Config, error) {
+	// .env bersifat opsional; variabel yang sudah ada di environment tidak ditimpa
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config: read .env: %w", err)
+	}
+
+	cfg := defaults()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("config: read %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			return nil, fmt.Errorf("config: parse %s: %w", path, err)
+		}
+	}
+
+	var errs []error
+	envInt(&errs, "PORT", &cfg.Port)
+	envString("BLUEPRINT_DB_HOST", &cfg.Database.Host)
+	envInt(&errs, "BLUEPRINT_DB_PORT", &cfg.Database.Port)
+	envString("BLUEPRINT_DB_DATABASE", &cfg.Database.Name)
+	envString("BLUEPRINT_DB_USERNAME", &cfg.Database.Username)
+	envString("BLUEPRINT_DB_PASSWORD", &cfg.Database.Password)
+	envString("BLUEPRINT_DB_SCHEMA", &cfg.Database.Schema)
+	envString("BLUEPRINT_DB_SSLMODE", &cfg.Database.SSLMode)
+	envString("JWT_SECRET", &cfg.JWT.Secret)
+	envDuration(&errs, "JWT_TTL", &cfg.JWT.TTL)
+	envDuration(&errs, "HEALTH_CACHE_TTL", &cfg.Health.CacheTTL)
+	envInt(&errs, "HEALTH_MIN_FREE_DISK_MB", &cfg.Health.MinFreeDiskMB)
+	envString("LOG_LEVEL", &cfg.Log.Level)
+	envString("LOG_FORMAT", &cfg.Log.Format)
+	envString("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	envString("OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	envFloat(&errs, "TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+	envString("RATE_LIMIT_BACKEND", &cfg.RateLimit.Backend)
+	envInt(&errs, "RATE_LIMIT_REQUESTS", &cfg.RateLimit.Default.Requests)
+	envDuration(&errs, "RATE_LIMIT_PER", &cfg.RateLimit.Default.Per)
+	envInt(&errs, "RATE_LIMIT_BURST", &cfg.RateLimit.Default.Burst)
+	envInt(&errs, "LOGIN_MAX_FAILURES", &cfg.RateLimit.Login.MaxFailures)
+	envDuration(&errs, "LOGIN_FAILURE_WINDOW", &cfg.RateLimit.Login.Window)
+	envDuration(&errs, "LOGIN_LOCKOUT", &cfg.RateLimit.Login.BaseDelay)
+	envDuration(&errs, "LOGIN_MAX_LOCKOUT", &cfg.RateLimit.Login.MaxDelay)
+	envList("TRUSTED_PROXIES", &cfg.TrustedProxies)
+	envDuration(&errs, "IDEMPOTENCY_TTL", &cfg.Idempotency.TTL)
+	envBool(&errs, "REQUIRE_IF_MATCH", &cfg.Concurrency.RequireIfMatch)
+	envString("TENANT_BASE_DOMAIN", &cfg.Tenancy.BaseDomain)
+	envString("DEFAULT_TENANT", &cfg.Tenancy.DefaultTenant)
+	envString("SMTP_HOST", &cfg.Mail.SMTPHost)
+	envInt(&errs, "SMTP_PORT", &cfg.Mail.SMTPPort)
+	envString("SMTP_USERNAME", &cfg.Mail.Username)
+	envString("SMTP_PASSWORD", &cfg.Mail.Password)
+	envString("MAIL_FROM", &cfg.Mail.From)
+	envInt(&errs, "MAIL_MAX_ATTEMPTS", &cfg.Mail.MaxAttempts)
+	envDuration(&errs, "MAIL_RETRY_DELAY", &cfg.Mail.RetryDelay)
+	envDuration(&errs, "MAIL_POLL_INTERVAL", &cfg.Mail.PollInterval)
+	envString("ROLLUP_TIMEZONE", &cfg.Rollup.Timezone)
+	envDuration(&errs, "ROLLUP_INTERVAL", &cfg.Rollup.Interval)
+	envDuration(&errs, "ROLLUP_LAG", &cfg.Rollup.Lag)
+
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("config: invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() []error {
+	var errs []error
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.Database.Host == "" {
+		errs = append(errs, errors.New("BLUEPRINT_DB_HOST is required"))
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		errs = append(errs, fmt.Errorf("BLUEPRINT_DB_PORT must be between 1 and 65535, got %d", c.Database.Port))
+	}
+	if c.Database.Name == "" {
+		errs = append(errs, errors.New("BLUEPRINT_DB_DATABASE is required"))
+	}
+	if c.Database.Username == "" {
+		errs = append(errs, errors.New("BLUEPRINT_DB_USERNAME is required"))
+	}
+	if len(c.JWT.Secret) < 16 {
+		errs = append(errs, errors.New("JWT_SECRET is required and must be at least 16 characters"))
+	}
+	if c.JWT.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("JWT_TTL must be positive, got %s", c.JWT.TTL))
+	}
+	if c.Health.CacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_CACHE_TTL must not be negative, got %s", c.Health.CacheTTL))
+	}
+	if c.Health.MinFreeDiskMB < 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_MIN_FREE_DISK_MB must not be negative, got %d", c.Health.MinFreeDiskMB))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be one of debug, info, warn, error, got %q", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be json or text, got %q", c.Log.Format))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER must be none, stdout or otlp, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.Tracing.SampleRatio))
+	}
+	for _, p := range c.TrustedProxies {
+		if net.ParseIP(p) == nil {
+			if _, _, err := net.ParseCIDR(p); err != nil {
+				errs = append(errs, fmt.Errorf("TRUSTED_PROXIES must list IP addresses or CIDRs, got %q", p))
+			}
+		}
+	}
+	switch c.RateLimit.Backend {
+	case "off", "memory", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_BACKEND must be off, memory or postgres, got %q", c.RateLimit.Backend))
+	}
+	errs = append(errs, c.RateLimit.Default.validate(func(field string) string {
+		return "RATE_LIMIT_" + strings.ToUpper(field)
+	})...)
+	for route, l := range c.RateLimit.Routes {
+		method, path, ok := strings.Cut(route, " ")
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			errs = append(errs, fmt.Errorf("rate_limit.routes key must look like \"POST /auth/login\", got %q", route))
+			continue
+		}
+		errs = append(errs, l.validate(func(field string) string {
+			return fmt.Sprintf("rate_limit.routes[%q].%s", route, field)
+		})...)
+	}
+	if l := c.RateLimit.Login; l.MaxFailures < 0 {
+		errs = append(errs, fmt.Errorf("LOGIN_MAX_FAILURES must not be negative, got %d", l.MaxFailures))
+	} else if l.MaxFailures > 0 {
+		if l.Window <= 0 {
+			errs = append(errs, fmt.Errorf("LOGIN_FAILURE_WINDOW must be positive, got %s", l.Window))
+		}
+		if l.BaseDelay <= 0 {
+			errs = append(errs, fmt.Errorf("LOGIN_LOCKOUT must be positive, got %s", l.BaseDelay))
+		}
+		if l.MaxDelay < l.BaseDelay {
+			errs = append(errs, fmt.Errorf("LOGIN_MAX_LOCKOUT must be at least LOGIN_LOCKOUT, got %s", l.MaxDelay))
+		}
+	}
+	if c.Idempotency.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("IDEMPOTENCY_TTL must be positive, got %s", c.Idempotency.TTL))
+	}
+	if strings.HasPrefix(c.Tenancy.BaseDomain, ".") || strings.ContainsAny(c.Tenancy.BaseDomain, "/:") {
+		errs = append(errs, fmt.Errorf("TENANT_BASE_DOMAIN must be a bare domain such as maspos.id, got %q", c.Tenancy.BaseDomain))
+	}
+	if m := c.Mail; m.Enabled() {
+		if m.SMTPPort < 1 || m.SMTPPort > 65535 {
+			errs = append(errs, fmt.Errorf("SMTP_PORT must be between 1 and 65535, got %d", m.SMTPPort))
+		}
+		if _, err := mail.ParseAddress(m.From); err != nil {
+			errs = append(errs, fmt.Errorf("MAIL_FROM must be an email address when SMTP_HOST is set, got %q", m.From))
+		}
+		if m.MaxAttempts < 1 {
+			errs = append(errs, fmt.Errorf("MAIL_MAX_ATTEMPTS must be at least 1, got %d", m.MaxAttempts))
+		}
+		if m.RetryDelay <= 0 {
+			errs = append(errs, fmt.Errorf("MAIL_RETRY_DELAY must be positive, got %s", m.RetryDelay))
+		}
+		if m.PollInterval <= 0 {
+			errs = append(errs, fmt.Errorf("MAIL_POLL_INTERVAL must be positive, got %s", m.PollInterval))
+		}
+	}
+	if _, err := time.LoadLocation(c.Rollup.Timezone); err != nil || c.Rollup.Timezone == "" {
+		errs = append(errs, fmt.Errorf("ROLLUP_TIMEZONE must be an IANA time zone such as Asia/Jakarta, got %q", c.Rollup.Timezone))
+	}
+	if c.Rollup.Interval < 0 {
+		errs = append(errs, fmt.Errorf("ROLLUP_INTERVAL must not be negative, got %s", c.Rollup.Interval))
+	}
+	if c.Rollup.Lag < 0 {
+		errs = append(errs, fmt.Errorf("ROLLUP_LAG must not be negative, got %s", c.Rollup.Lag))
+	}
+	return errs
+}
+
+// validate memakai name untuk menyebut field dalam pesan error, karena Limit
+// bisa berasal dari variabel environment maupun dari file YAML.
+func (l Limit) validate(name func(field string) string) []error {
+	if l.Requests < 0 {
+		return []error{fmt.Errorf("%s must not be negative, got %d", name("requests"), l.Requests)}
+	}
+	if l.Requests == 0 {
+		return nil
+	}
+	var errs []error
+	if l.Per <= 0 {
+		errs = append(errs, fmt.Errorf("%s must be positive, got %s", name("per"), l.Per))
+	}
+	if l.Burst < 1 {
+		errs = append(errs, fmt.Errorf("%s must be at least 1, got %d", name("burst"), l.Burst))
+	}
+	return errs
+}
+
+func envString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+// envList membaca daftar yang dipisah koma; spasi di sekitar item dibuang.
+func envList(key string, dst *[]string) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return
+	}
+	*dst = nil
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*dst = append(*dst, item)
+		}
+	}
+}
+
+func envBool(errs *[]error, key string, dst *bool) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be true or false, got %q", key, v))
+		return
+	}
+	*dst = b
+}
+
+func envInt(errs *[]error, key string, dst *int) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be an integer, got %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func envDuration(errs *[]error, key string, dst *time.Duration) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be a duration such as 24h, got %q", key, v))
+		return
+	}
+	*dst = d
+}
+
+func envFloat(errs *[]error, key string, dst *float64) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s must be a number, got %q", key, v))
+		return
+	}
+	*dst = f
+}
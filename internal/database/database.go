@@ -4,13 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"strconv"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"maspos-be-go/internal/config"
 )
 
 type Service interface {
@@ -20,50 +21,39 @@ type Service interface {
 }
 
 type service struct {
-	db *sql.DB
+	db   *sql.DB
+	name string
 }
 
-var (
-	database   = os.Getenv("BLUEPRINT_DB_DATABASE")
-	password   = os.Getenv("BLUEPRINT_DB_PASSWORD")
-	username   = os.Getenv("BLUEPRINT_DB_USERNAME")
-	port       = os.Getenv("BLUEPRINT_DB_PORT")
-	host       = os.Getenv("BLUEPRINT_DB_HOST")
-	schema     = os.Getenv("BLUEPRINT_DB_SCHEMA")
-	dbInstance *service
-)
-
-func New() Service {
-	if dbInstance != nil {
-		return dbInstance
-	}
-
-	connStr := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s",
-		username,
-		password,
-		host,
-		port,
-		database,
-		schema,
-	)
-
-	db, err := sql.Open("pgx", connStr)
+// New membuka koneksi ke PostgreSQL sesuai cfg dan memastikan database bisa
+// dijangkau. Setiap pemanggilan menghasilkan pool koneksi sendiri, jadi
+// pemanggil bertanggung jawab menutupnya lewat Close. Setiap transaksi pada
+// pool ini dibatasi ke tenant di context-nya (lihat tenantConnector).
+func New(cfg config.Database) (Service, error) {
+	connConfig, err := pgx.ParseConfig(cfg.DSN())
 	if err != nil {
-		log.Fatal("failed to open db:", err)
+		return nil, fmt.Errorf("open db: %w", err)
 	}
+	db := sql.OpenDB(tenantConnector{stdlib.GetConnector(*connConfig)})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
-		log.Fatal("failed to connect db:", err)
+		db.Close()
+		return nil, fmt.Errorf("connect db %s: %w", cfg.Name, err)
 	}
 
-	log.Println("PostgreSQL connected:", database)
+	slog.Info("postgres connected", "database", cfg.Name)
+
+	// superuser dan role BYPASSRLS tidak terkena policy RLS sama sekali
+	var bypass bool
+	err = db.QueryRowContext(ctx, `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypass)
+	if err == nil && bypass {
+		slog.Warn("database role bypasses row-level security; tenants are not isolated", "user", cfg.Username)
+	}
 
-	dbInstance = &service{db: db}
-	return dbInstance
+	return &service{db: db, name: cfg.Name}, nil
 }
 
 func (s *service) DB() *sql.DB {
@@ -84,7 +74,7 @@ func (s *service) Health() map[string]string {
 	if err != nil {
 		stats["status"] = "down"
 		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Fatalf("db down: %v", err) // Log the error and terminate the program
+		slog.Error("db down", "error", err)
 		return stats
 	}
 
@@ -127,6 +117,6 @@ func (s *service) Health() map[string]string {
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
-	log.Printf("Disconnected from database: %s", database)
+	slog.Info("disconnected from database", "database", s.name)
 	return s.db.Close()
 }
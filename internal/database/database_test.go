@@ -9,8 +9,12 @@ import (
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+
+	"maspos-be-go/internal/config"
 )
 
+var testConfig config.Database
+
 func mustStartPostgresContainer() (func(context.Context, ...testcontainers.TerminateOption) error, error) {
 	var (
 		dbName = "database"
@@ -33,9 +37,13 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return nil, err
 	}
 
-	database = dbName
-	password = dbPwd
-	username = dbUser
+	testConfig = config.Database{
+		Name:     dbName,
+		Password: dbPwd,
+		Username: dbUser,
+		Schema:   "public",
+		SSLMode:  "disable",
+	}
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -47,8 +55,8 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return dbContainer.Terminate, err
 	}
 
-	host = dbHost
-	port = dbPort.Port()
+	testConfig.Host = dbHost
+	testConfig.Port = dbPort.Int()
 
 	return dbContainer.Terminate, err
 }
@@ -67,14 +75,49 @@ func TestMain(m *testing.M) {
 }
 
 func TestNew(t *testing.T) {
-	srv := New()
-	if srv == nil {
-		t.Fatal("New() returned nil")
+	srv, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	defer srv.Close()
+}
+
+func TestNewReturnsIndependentServices(t *testing.T) {
+	first, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	second, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	defer second.Close()
+
+	if first.DB() == second.DB() {
+		t.Fatal("expected each New() call to return its own pool")
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	if err := second.DB().Ping(); err != nil {
+		t.Fatalf("closing one service must not affect another: %v", err)
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	cfg := testConfig
+	cfg.Password = "wrong-password"
+	if _, err := New(cfg); err == nil {
+		t.Fatal("expected New() to return an error for bad credentials")
 	}
 }
 
 func TestHealth(t *testing.T) {
-	srv := New()
+	srv, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	defer srv.Close()
 
 	stats := srv.Health()
 
@@ -92,7 +135,10 @@ func TestHealth(t *testing.T) {
 }
 
 func TestClose(t *testing.T) {
-	srv := New()
+	srv, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
 
 	if srv.Close() != nil {
 		t.Fatalf("expected Close() to return nil")
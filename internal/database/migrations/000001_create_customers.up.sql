@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT NOT NULL,
+    phone      TEXT NOT NULL,
+    email      TEXT NOT NULL DEFAULT '',
+    birthday   DATE,
+    notes      TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- phone disimpan dalam bentuk ternormalisasi (62xxx) sehingga unik per pelanggan
+CREATE UNIQUE INDEX IF NOT EXISTS customers_phone_key ON customers (phone);
+CREATE INDEX IF NOT EXISTS customers_name_idx ON customers (lower(name));
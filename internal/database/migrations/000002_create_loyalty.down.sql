@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS loyalty_ledger;
+DROP TABLE IF EXISTS loyalty_category_multipliers;
+DROP TABLE IF EXISTS loyalty_settings;
@@ -0,0 +1,29 @@
+-- satu baris pengaturan program poin
+CREATE TABLE IF NOT EXISTS loyalty_settings (
+    id               SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    rupiah_per_point NUMERIC(12,2) NOT NULL DEFAULT 10000,
+    point_value      NUMERIC(12,2) NOT NULL DEFAULT 100,
+    expiry_days      INT NOT NULL DEFAULT 365
+);
+
+INSERT INTO loyalty_settings (id) VALUES (1) ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS loyalty_category_multipliers (
+    category_id UUID PRIMARY KEY REFERENCES categories(id) ON DELETE CASCADE,
+    multiplier  NUMERIC(6,2) NOT NULL CHECK (multiplier >= 0)
+);
+
+-- saldo poin selalu dihitung dari ledger ini, tidak pernah disimpan terpisah
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    type        TEXT NOT NULL CHECK (type IN ('earn', 'burn', 'expire', 'adjust')),
+    points      BIGINT NOT NULL,
+    reference   TEXT NOT NULL DEFAULT '',
+    reverses_id UUID UNIQUE REFERENCES loyalty_ledger(id),
+    expires_at  TIMESTAMPTZ,
+    note        TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS loyalty_ledger_customer_idx ON loyalty_ledger (customer_id, created_at);
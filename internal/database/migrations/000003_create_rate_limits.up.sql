@@ -0,0 +1,19 @@
+-- UNLOGGED: state pembatas request boleh hilang saat crash, tidak perlu WAL
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_expires_at_idx ON rate_limit_buckets (expires_at);
+
+CREATE UNLOGGED TABLE IF NOT EXISTS login_failures (
+    key             TEXT PRIMARY KEY,
+    failures        INT NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until    TIMESTAMPTZ,
+    expires_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS login_failures_expires_at_idx ON login_failures (expires_at);
@@ -0,0 +1,3 @@
+-- nomor versi untuk optimistic concurrency; naik satu setiap kali baris diubah
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS categories_parent_id_idx;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+ALTER TABLE categories DROP COLUMN IF EXISTS position;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,8 @@
+-- kategori bertingkat: parent_id NULL berarti kategori paling atas.
+-- position mengurutkan kategori di antara saudaranya.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES categories(id) ON DELETE RESTRICT;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS position INT NOT NULL DEFAULT 0;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id, position);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS products_category_id_position_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS active;
+ALTER TABLE products DROP COLUMN IF EXISTS icon;
+ALTER TABLE products DROP COLUMN IF EXISTS color;
+ALTER TABLE products DROP COLUMN IF EXISTS position;
+ALTER TABLE categories DROP COLUMN IF EXISTS active;
+ALTER TABLE categories DROP COLUMN IF EXISTS icon;
+ALTER TABLE categories DROP COLUMN IF EXISTS color;
@@ -0,0 +1,4 @@
+-- SKU dipakai sebagai kunci impor katalog; produk lama boleh tanpa SKU (NULL)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (sku);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS products_outlet_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_key ON products (sku);
+
+DROP TABLE IF EXISTS product_outlet_prices;
+DROP INDEX IF EXISTS products_outlet_id_idx;
+DROP INDEX IF EXISTS categories_outlet_id_idx;
+ALTER TABLE products DROP COLUMN IF EXISTS outlet_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS outlet_id;
+DROP TABLE IF EXISTS user_outlets;
+DROP TABLE IF EXISTS outlets;
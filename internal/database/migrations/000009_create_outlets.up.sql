@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS outlets (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name                TEXT NOT NULL,
+    address             TEXT NOT NULL DEFAULT '',
+    -- false: outlet hanya melihat kategori dan produknya sendiri
+    use_central_catalog BOOLEAN NOT NULL DEFAULT true,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS user_outlets (
+    user_id   INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    outlet_id UUID NOT NULL REFERENCES outlets (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, outlet_id)
+);
+
+-- outlet_id NULL berarti katalog pusat yang dipakai bersama semua outlet
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS outlet_id UUID REFERENCES outlets (id) ON DELETE RESTRICT;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS outlet_id UUID REFERENCES outlets (id) ON DELETE RESTRICT;
+CREATE INDEX IF NOT EXISTS categories_outlet_id_idx ON categories (outlet_id);
+CREATE INDEX IF NOT EXISTS products_outlet_id_idx ON products (outlet_id);
+
+-- harga khusus outlet untuk produk katalog pusat
+CREATE TABLE IF NOT EXISTS product_outlet_prices (
+    product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    outlet_id  UUID NOT NULL REFERENCES outlets (id) ON DELETE CASCADE,
+    price      NUMERIC(14, 2) NOT NULL CHECK (price > 0),
+    PRIMARY KEY (product_id, outlet_id)
+);
+
+-- SKU unik per outlet; katalog pusat memakai UUID nol sebagai kuncinya
+DROP INDEX IF EXISTS products_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_outlet_sku_key
+    ON products (COALESCE(outlet_id, '00000000-0000-0000-0000-000000000000'::uuid), sku);
@@ -0,0 +1,33 @@
+-- hanya aman dijalankan selama baru ada tenant "default"
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (scope, key);
+
+ALTER TABLE loyalty_settings DROP CONSTRAINT IF EXISTS loyalty_settings_pkey;
+ALTER TABLE loyalty_settings ADD PRIMARY KEY (id);
+
+DROP INDEX IF EXISTS products_tenant_outlet_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_outlet_sku_key
+    ON products (COALESCE(outlet_id, '00000000-0000-0000-0000-000000000000'::uuid), sku);
+
+DROP INDEX IF EXISTS customers_tenant_phone_key;
+CREATE UNIQUE INDEX IF NOT EXISTS customers_phone_key ON customers (phone);
+
+DROP INDEX IF EXISTS users_tenant_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'outlets', 'user_outlets', 'categories', 'products', 'product_outlet_prices',
+        'customers', 'loyalty_settings', 'loyalty_category_multipliers', 'loyalty_ledger', 'idempotency_keys'
+    ] LOOP
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS tenant_id', t);
+    END LOOP;
+END $$;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,57 @@
+-- setiap merchant adalah tenant; slug dipakai sebagai subdomain
+CREATE TABLE IF NOT EXISTS tenants (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug       TEXT NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9]([a-z0-9-]*[a-z0-9])?$'),
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- data yang sudah ada menjadi milik tenant "default"
+INSERT INTO tenants (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default')
+ON CONFLICT DO NOTHING;
+
+-- Setiap tabel bisnis mendapat tenant_id yang diisi otomatis dari variabel
+-- app.tenant_id, yang di-set lapisan database di awal setiap transaksi.
+-- Policy RLS hanya menampilkan dan menerima baris tenant tersebut; tanpa
+-- variabel itu tidak ada baris yang terlihat dan INSERT gagal (NOT NULL).
+-- FORCE berlaku juga untuk pemilik tabel; role aplikasi tidak boleh
+-- superuser atau BYPASSRLS.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'users', 'outlets', 'user_outlets', 'categories', 'products', 'product_outlet_prices',
+        'customers', 'loyalty_settings', 'loyalty_category_multipliers', 'loyalty_ledger', 'idempotency_keys'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id UUID NOT NULL
+            DEFAULT ''00000000-0000-0000-0000-000000000001'' REFERENCES tenants (id)', t);
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id
+            SET DEFAULT NULLIF(current_setting(''app.tenant_id'', true), '''')::uuid', t);
+        EXECUTE format('CREATE INDEX IF NOT EXISTS %I ON %I (tenant_id)', t || '_tenant_id_idx', t);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+        EXECUTE format('CREATE POLICY tenant_isolation ON %I
+            USING (tenant_id = NULLIF(current_setting(''app.tenant_id'', true), '''')::uuid)', t);
+    END LOOP;
+END $$;
+
+-- kunci unik berlaku per tenant
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_key ON users (tenant_id, email);
+
+DROP INDEX IF EXISTS customers_phone_key;
+CREATE UNIQUE INDEX IF NOT EXISTS customers_tenant_phone_key ON customers (tenant_id, phone);
+
+DROP INDEX IF EXISTS products_outlet_sku_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_tenant_outlet_sku_key
+    ON products (tenant_id, COALESCE(outlet_id, '00000000-0000-0000-0000-000000000000'::uuid), sku);
+
+ALTER TABLE loyalty_settings DROP CONSTRAINT IF EXISTS loyalty_settings_pkey;
+ALTER TABLE loyalty_settings ADD PRIMARY KEY (tenant_id, id);
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, scope, key);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shift_cash_movements;
+DROP TABLE IF EXISTS shifts;
@@ -0,0 +1,54 @@
+-- satu shift kasir per register; closed_at NULL berarti laci masih dipakai
+CREATE TABLE IF NOT EXISTS shifts (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id     UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id     UUID NOT NULL REFERENCES outlets (id) ON DELETE RESTRICT,
+    register      TEXT NOT NULL,
+    opening_float NUMERIC(14, 2) NOT NULL CHECK (opening_float >= 0),
+    opened_by     INT REFERENCES users (id) ON DELETE SET NULL,
+    opened_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    open_note     TEXT NOT NULL DEFAULT '',
+    -- diisi saat tutup; variance = counted_cash - expected_cash
+    closed_by     INT REFERENCES users (id) ON DELETE SET NULL,
+    closed_at     TIMESTAMPTZ,
+    expected_cash NUMERIC(14, 2),
+    counted_cash  NUMERIC(14, 2) CHECK (counted_cash >= 0),
+    variance      NUMERIC(14, 2),
+    close_note    TEXT NOT NULL DEFAULT ''
+);
+
+-- hanya satu shift terbuka per register di setiap outlet
+CREATE UNIQUE INDEX IF NOT EXISTS shifts_open_register_key
+    ON shifts (tenant_id, outlet_id, register) WHERE closed_at IS NULL;
+CREATE INDEX IF NOT EXISTS shifts_outlet_opened_at_idx ON shifts (outlet_id, opened_at);
+CREATE INDEX IF NOT EXISTS shifts_tenant_id_idx ON shifts (tenant_id);
+
+-- mutasi kas laci selama shift: penjualan tunai (uang diterima dikurangi
+-- kembalian), setoran tambahan (pay_in) dan pengambilan (pay_out)
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id  UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    shift_id   UUID NOT NULL REFERENCES shifts (id) ON DELETE CASCADE,
+    type       TEXT NOT NULL CHECK (type IN ('sale', 'pay_in', 'pay_out')),
+    amount     NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    reference  TEXT NOT NULL DEFAULT '',
+    note       TEXT NOT NULL DEFAULT '',
+    created_by INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS shift_cash_movements_shift_id_idx ON shift_cash_movements (shift_id, created_at);
+CREATE INDEX IF NOT EXISTS shift_cash_movements_tenant_id_idx ON shift_cash_movements (tenant_id);
+
+-- isolasi tenant sama seperti tabel lain (lihat 000010_add_tenants)
+ALTER TABLE shifts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shifts FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON shifts;
+CREATE POLICY tenant_isolation ON shifts
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE shift_cash_movements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE shift_cash_movements FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON shift_cash_movements;
+CREATE POLICY tenant_isolation ON shift_cash_movements
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
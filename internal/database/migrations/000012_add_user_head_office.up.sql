@@ -0,0 +1,3 @@
+-- hak kantor pusat diberikan secara eksplisit (lihat perintah tenant
+-- head-office); user tanpa outlet tidak lagi otomatis menjadi kantor pusat
+ALTER TABLE users ADD COLUMN IF NOT EXISTS head_office BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+-- ETag respons yang disimpan, dikirim ulang bersama respons saat key dipakai lagi
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS etag TEXT;
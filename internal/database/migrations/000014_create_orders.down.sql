@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS loyalty_ledger_order_id_idx;
+ALTER TABLE loyalty_ledger DROP COLUMN IF EXISTS order_id;
+DROP TABLE IF EXISTS order_tenders;
+DROP TABLE IF EXISTS order_lines;
+DROP TABLE IF EXISTS orders;
+DROP SEQUENCE IF EXISTS orders_number_seq;
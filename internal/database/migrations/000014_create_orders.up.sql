@@ -0,0 +1,91 @@
+-- penjualan yang sudah selesai. Setiap order dibuat di shift yang masih
+-- terbuka, jadi outlet dan register-nya mengikuti shift tersebut. Nilai
+-- uang disimpan apa adanya saat penjualan supaya laporan tidak berubah bila
+-- harga katalog berubah.
+CREATE SEQUENCE IF NOT EXISTS orders_number_seq;
+
+CREATE TABLE IF NOT EXISTS orders (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id   UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id   UUID NOT NULL REFERENCES outlets (id) ON DELETE RESTRICT,
+    shift_id    UUID NOT NULL REFERENCES shifts (id) ON DELETE RESTRICT,
+    number      TEXT NOT NULL,
+    customer_id UUID REFERENCES customers (id) ON DELETE SET NULL,
+    -- gross = sebelum diskon; subtotal = gross - discount; total = subtotal + tax
+    gross       NUMERIC(14, 2) NOT NULL CHECK (gross >= 0),
+    discount    NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    subtotal    NUMERIC(14, 2) NOT NULL CHECK (subtotal >= 0),
+    tax         NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (tax >= 0),
+    total       NUMERIC(14, 2) NOT NULL CHECK (total >= 0),
+    paid        NUMERIC(14, 2) NOT NULL CHECK (paid >= total),
+    change      NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (change >= 0),
+    -- rincian pajak dalam bentuk [{"name": ..., "amount": ...}]
+    taxes       JSONB NOT NULL DEFAULT '[]',
+    note        TEXT NOT NULL DEFAULT '',
+    created_by  INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, number)
+);
+
+CREATE INDEX IF NOT EXISTS orders_outlet_created_at_idx ON orders (outlet_id, created_at);
+CREATE INDEX IF NOT EXISTS orders_shift_id_idx ON orders (shift_id);
+CREATE INDEX IF NOT EXISTS orders_customer_id_idx ON orders (customer_id, created_at) WHERE customer_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS orders_tenant_id_idx ON orders (tenant_id);
+
+-- nama, kategori dan harga disalin dari katalog saat penjualan
+CREATE TABLE IF NOT EXISTS order_lines (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id   UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    order_id    UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    line_no     INT NOT NULL,
+    product_id  UUID REFERENCES products (id) ON DELETE SET NULL,
+    category_id UUID REFERENCES categories (id) ON DELETE SET NULL,
+    name        TEXT NOT NULL,
+    quantity    NUMERIC(12, 3) NOT NULL CHECK (quantity > 0),
+    unit_price  NUMERIC(14, 2) NOT NULL CHECK (unit_price >= 0),
+    -- [{"name": ..., "price": ...}], harga per unit
+    modifiers   JSONB NOT NULL DEFAULT '[]',
+    discount    NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (discount >= 0),
+    total       NUMERIC(14, 2) NOT NULL CHECK (total >= 0),
+    note        TEXT NOT NULL DEFAULT '',
+    UNIQUE (order_id, line_no)
+);
+
+CREATE INDEX IF NOT EXISTS order_lines_product_id_idx ON order_lines (product_id);
+CREATE INDEX IF NOT EXISTS order_lines_tenant_id_idx ON order_lines (tenant_id);
+
+CREATE TABLE IF NOT EXISTS order_tenders (
+    id        UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    order_id  UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    method    TEXT NOT NULL CHECK (method IN ('cash', 'card', 'qris', 'transfer', 'loyalty')),
+    amount    NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    reference TEXT NOT NULL DEFAULT '',
+    -- poin yang ditukar pada pembayaran loyalty; amount adalah nilai Rupiahnya
+    points    BIGINT NOT NULL DEFAULT 0 CHECK (points >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS order_tenders_order_id_idx ON order_tenders (order_id);
+CREATE INDEX IF NOT EXISTS order_tenders_tenant_id_idx ON order_tenders (tenant_id);
+
+-- entri ledger yang dibuat oleh order (earn dan burn) atau refund-nya
+ALTER TABLE loyalty_ledger ADD COLUMN IF NOT EXISTS order_id UUID REFERENCES orders (id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS loyalty_ledger_order_id_idx ON loyalty_ledger (order_id) WHERE order_id IS NOT NULL;
+
+ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE orders FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON orders;
+CREATE POLICY tenant_isolation ON orders
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE order_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_lines FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON order_lines;
+CREATE POLICY tenant_isolation ON order_lines
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE order_tenders ENABLE ROW LEVEL SECURITY;
+ALTER TABLE order_tenders FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON order_tenders;
+CREATE POLICY tenant_isolation ON order_tenders
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS refund_lines;
+DROP TABLE IF EXISTS refunds;
+DROP SEQUENCE IF EXISTS refunds_number_seq;
+ALTER TABLE users DROP COLUMN IF EXISTS supervisor;
+-- refund tunai yang sudah tercatat ikut terhapus; laporan shift lama berubah
+DELETE FROM shift_cash_movements WHERE type = 'refund';
+ALTER TABLE shift_cash_movements DROP CONSTRAINT IF EXISTS shift_cash_movements_type_check;
+ALTER TABLE shift_cash_movements ADD CONSTRAINT shift_cash_movements_type_check
+    CHECK (type IN ('sale', 'pay_in', 'pay_out'));
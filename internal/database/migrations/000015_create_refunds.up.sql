@@ -0,0 +1,90 @@
+-- supervisor boleh menyetujui refund di outlet-nya (lihat perintah tenant
+-- supervisor); user kantor pusat selalu boleh
+ALTER TABLE users ADD COLUMN IF NOT EXISTS supervisor BOOLEAN NOT NULL DEFAULT false;
+
+-- refund tunai mengambil uang dari laci shift yang sedang terbuka
+ALTER TABLE shift_cash_movements DROP CONSTRAINT IF EXISTS shift_cash_movements_type_check;
+ALTER TABLE shift_cash_movements ADD CONSTRAINT shift_cash_movements_type_check
+    CHECK (type IN ('sale', 'refund', 'pay_in', 'pay_out'));
+
+-- refund mengembalikan sebagian atau seluruh baris sebuah order. Nilainya
+-- dihitung dari baris order secara proporsional, termasuk pajaknya, dan
+-- dibayarkan dengan satu metode pembayaran order tersebut.
+CREATE SEQUENCE IF NOT EXISTS refunds_number_seq;
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id       UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    order_id        UUID NOT NULL REFERENCES orders (id) ON DELETE RESTRICT,
+    outlet_id       UUID NOT NULL REFERENCES outlets (id) ON DELETE RESTRICT,
+    shift_id        UUID NOT NULL REFERENCES shifts (id) ON DELETE RESTRICT,
+    number          TEXT NOT NULL,
+    reason          TEXT NOT NULL CHECK (reason IN ('wrong_item', 'changed_mind', 'damaged', 'expired', 'other')),
+    method          TEXT NOT NULL CHECK (method IN ('cash', 'card', 'qris', 'transfer', 'loyalty')),
+    subtotal        NUMERIC(14, 2) NOT NULL CHECK (subtotal >= 0),
+    tax             NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (tax >= 0),
+    amount          NUMERIC(14, 2) NOT NULL CHECK (amount >= 0),
+    -- poin yang dikembalikan (refund loyalty) dan poin hasil order yang
+    -- ditarik kembali
+    points          BIGINT NOT NULL DEFAULT 0 CHECK (points >= 0),
+    points_reversed BIGINT NOT NULL DEFAULT 0 CHECK (points_reversed >= 0),
+    reference       TEXT NOT NULL DEFAULT '',
+    note            TEXT NOT NULL DEFAULT '',
+    approved_by     INT REFERENCES users (id) ON DELETE SET NULL,
+    created_by      INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, number)
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds (order_id);
+CREATE INDEX IF NOT EXISTS refunds_outlet_created_at_idx ON refunds (outlet_id, created_at);
+CREATE INDEX IF NOT EXISTS refunds_tenant_id_idx ON refunds (tenant_id);
+
+CREATE TABLE IF NOT EXISTS refund_lines (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id     UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    refund_id     UUID NOT NULL REFERENCES refunds (id) ON DELETE CASCADE,
+    order_line_id UUID NOT NULL REFERENCES order_lines (id) ON DELETE RESTRICT,
+    quantity      NUMERIC(12, 3) NOT NULL CHECK (quantity > 0),
+    amount        NUMERIC(14, 2) NOT NULL CHECK (amount >= 0),
+    restock       BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS refund_lines_refund_id_idx ON refund_lines (refund_id);
+CREATE INDEX IF NOT EXISTS refund_lines_order_line_id_idx ON refund_lines (order_line_id);
+CREATE INDEX IF NOT EXISTS refund_lines_tenant_id_idx ON refund_lines (tenant_id);
+
+-- mutasi stok per outlet dan produk: keluar saat dijual, masuk lagi saat
+-- barang refund layak jual. Stok opname dan penerimaan barang belum ada,
+-- jadi tabel ini belum menjadi saldo stok.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id  UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id  UUID NOT NULL REFERENCES outlets (id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products (id) ON DELETE SET NULL,
+    type       TEXT NOT NULL CHECK (type IN ('sale', 'return')),
+    quantity   NUMERIC(12, 3) NOT NULL,
+    reference  TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_outlet_product_idx ON stock_movements (outlet_id, product_id, created_at);
+CREATE INDEX IF NOT EXISTS stock_movements_tenant_id_idx ON stock_movements (tenant_id);
+
+ALTER TABLE refunds ENABLE ROW LEVEL SECURITY;
+ALTER TABLE refunds FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON refunds;
+CREATE POLICY tenant_isolation ON refunds
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE refund_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE refund_lines FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON refund_lines;
+CREATE POLICY tenant_isolation ON refund_lines
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE stock_movements ENABLE ROW LEVEL SECURITY;
+ALTER TABLE stock_movements FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON stock_movements;
+CREATE POLICY tenant_isolation ON stock_movements
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
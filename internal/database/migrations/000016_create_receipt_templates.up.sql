@@ -0,0 +1,18 @@
+-- kop dan penutup struk per outlet; header dan footer disimpan per baris
+-- dipisah newline. Outlet tanpa baris di sini memakai template bawaan.
+CREATE TABLE IF NOT EXISTS receipt_templates (
+    outlet_id  UUID PRIMARY KEY REFERENCES outlets (id) ON DELETE CASCADE,
+    tenant_id  UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    header     TEXT NOT NULL DEFAULT '',
+    footer     TEXT NOT NULL DEFAULT '',
+    paper      INT NOT NULL DEFAULT 80 CHECK (paper IN (58, 80)),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS receipt_templates_tenant_id_idx ON receipt_templates (tenant_id);
+
+ALTER TABLE receipt_templates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE receipt_templates FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON receipt_templates;
+CREATE POLICY tenant_isolation ON receipt_templates
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
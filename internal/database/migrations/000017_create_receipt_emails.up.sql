@@ -0,0 +1,31 @@
+-- antrean struk email. Worker mengambil baris pending yang next_attempt_at
+-- sudah lewat; gagal sementara dijadwalkan ulang, gagal permanen atau
+-- setelah percobaan terakhir menjadi failed. Kirim ulang membuat baris baru.
+CREATE TABLE IF NOT EXISTS receipt_emails (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id       UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id       UUID NOT NULL REFERENCES outlets (id) ON DELETE CASCADE,
+    order_id        UUID REFERENCES orders (id) ON DELETE SET NULL,
+    number          TEXT NOT NULL,
+    email           TEXT NOT NULL,
+    -- penjualan yang dicetak, dalam bentuk receipt.Receipt
+    receipt         JSONB NOT NULL,
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+    attempts        INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_error      TEXT NOT NULL DEFAULT '',
+    sent_at         TIMESTAMPTZ,
+    created_by      INT REFERENCES users (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS receipt_emails_due_idx ON receipt_emails (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS receipt_emails_outlet_number_idx ON receipt_emails (outlet_id, number);
+CREATE INDEX IF NOT EXISTS receipt_emails_order_id_idx ON receipt_emails (order_id, created_at) WHERE order_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS receipt_emails_tenant_id_idx ON receipt_emails (tenant_id);
+
+ALTER TABLE receipt_emails ENABLE ROW LEVEL SECURITY;
+ALTER TABLE receipt_emails FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON receipt_emails;
+CREATE POLICY tenant_isolation ON receipt_emails
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
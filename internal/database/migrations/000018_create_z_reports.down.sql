@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS shift_cash_movements_day_open ON shift_cash_movements;
+DROP FUNCTION IF EXISTS shift_cash_movements_day_open();
+DROP TABLE IF EXISTS z_reports;
+DROP FUNCTION IF EXISTS z_reports_immutable();
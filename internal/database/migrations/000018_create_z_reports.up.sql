@@ -0,0 +1,56 @@
+-- laporan tutup hari (Z-report) per outlet. Nomornya berurutan per outlet
+-- dan isinya tidak bisa diubah atau dihapus. Setiap laporan mencakup mutasi
+-- kas dengan period_start <= created_at < period_end; period_start adalah
+-- period_end laporan sebelumnya (NULL untuk laporan pertama).
+CREATE TABLE IF NOT EXISTS z_reports (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id     UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id     UUID NOT NULL REFERENCES outlets (id) ON DELETE RESTRICT,
+    number        INT NOT NULL CHECK (number > 0),
+    business_date DATE NOT NULL,
+    timezone      TEXT NOT NULL,
+    period_start  TIMESTAMPTZ,
+    period_end    TIMESTAMPTZ NOT NULL,
+    -- total dan daftar shift saat ditutup, dalam bentuk repository.ZReportTotals
+    totals        JSONB NOT NULL,
+    closed_by     INT REFERENCES users (id) ON DELETE SET NULL,
+    closed_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, outlet_id, number),
+    UNIQUE (tenant_id, outlet_id, business_date)
+);
+
+CREATE INDEX IF NOT EXISTS z_reports_tenant_id_idx ON z_reports (tenant_id);
+
+ALTER TABLE z_reports ENABLE ROW LEVEL SECURITY;
+ALTER TABLE z_reports FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON z_reports;
+CREATE POLICY tenant_isolation ON z_reports
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+-- Z-report adalah catatan untuk pemeriksa pajak: sekali dibuat tidak boleh
+-- berubah, termasuk lewat SQL langsung
+CREATE OR REPLACE FUNCTION z_reports_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'z_reports are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS z_reports_immutable ON z_reports;
+CREATE TRIGGER z_reports_immutable BEFORE UPDATE OR DELETE ON z_reports
+    FOR EACH ROW EXECUTE FUNCTION z_reports_immutable();
+
+-- mutasi kas tidak boleh masuk ke hari yang sudah ditutup. ShiftRepository
+-- memeriksa ini lebih dulu; trigger menjaga jalur lain.
+CREATE OR REPLACE FUNCTION shift_cash_movements_day_open() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM z_reports z JOIN shifts s ON s.outlet_id = z.outlet_id
+               WHERE s.id = NEW.shift_id AND z.period_end > NEW.created_at) THEN
+        RAISE EXCEPTION 'the business day of this cash movement is already closed';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS shift_cash_movements_day_open ON shift_cash_movements;
+CREATE TRIGGER shift_cash_movements_day_open BEFORE INSERT ON shift_cash_movements
+    FOR EACH ROW EXECUTE FUNCTION shift_cash_movements_day_open();
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS product_daily_rollups_product_deleted ON products;
+DROP FUNCTION IF EXISTS product_daily_rollups_product_deleted();
+DROP INDEX IF EXISTS refunds_created_at_idx;
+DROP INDEX IF EXISTS orders_created_at_idx;
+DROP TABLE IF EXISTS sales_rollup_checkpoints;
+DROP TABLE IF EXISTS product_daily_rollups;
+DROP TABLE IF EXISTS sales_daily_rollups;
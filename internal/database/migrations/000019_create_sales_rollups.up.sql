@@ -0,0 +1,97 @@
+-- penjualan per outlet per hari dari order dan refund, diperbarui bertahap
+-- oleh worker rollup supaya dashboard tidak perlu membaca semua order.
+-- Tanggal dihitung menurut zona waktu di sales_rollup_checkpoints. Order
+-- dihitung menurut tanggal order dan refund menurut tanggal refund;
+-- refunds tanpa pajak dan tax sudah dikurangi pajak refund.
+CREATE TABLE IF NOT EXISTS sales_daily_rollups (
+    tenant_id     UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id     UUID NOT NULL REFERENCES outlets (id) ON DELETE CASCADE,
+    business_date DATE NOT NULL,
+    orders        INT NOT NULL DEFAULT 0,
+    gross         NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    discount      NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    tax           NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    refund_count  INT NOT NULL DEFAULT 0,
+    refunds       NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, outlet_id, business_date)
+);
+
+CREATE INDEX IF NOT EXISTS sales_daily_rollups_date_idx ON sales_daily_rollups (tenant_id, business_date);
+
+-- penjualan per produk per outlet per hari dari baris order dan baris
+-- refund, diperbarui bersama sales_daily_rollups dengan checkpoint yang
+-- sama. Baris produk yang sudah dihapus dikumpulkan di product_id
+-- 00000000-0000-0000-0000-000000000000.
+CREATE TABLE IF NOT EXISTS product_daily_rollups (
+    tenant_id         UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    outlet_id         UUID NOT NULL REFERENCES outlets (id) ON DELETE CASCADE,
+    business_date     DATE NOT NULL,
+    product_id        UUID NOT NULL,
+    quantity          NUMERIC(14, 3) NOT NULL DEFAULT 0,
+    refunded_quantity NUMERIC(14, 3) NOT NULL DEFAULT 0,
+    -- gross = sebelum diskon baris; refunds tanpa pajak
+    gross             NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    discount          NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    refunds           NUMERIC(14, 2) NOT NULL DEFAULT 0,
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, outlet_id, business_date, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS product_daily_rollups_date_idx ON product_daily_rollups (tenant_id, business_date);
+
+-- satu baris per tenant: order dan refund dengan created_at <= position
+-- sudah masuk kedua rollup. position NULL berarti belum ada yang dihitung.
+CREATE TABLE IF NOT EXISTS sales_rollup_checkpoints (
+    tenant_id  UUID PRIMARY KEY DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid REFERENCES tenants (id),
+    timezone   TEXT NOT NULL,
+    position   TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- worker membaca order dan refund per rentang waktu di semua outlet
+CREATE INDEX IF NOT EXISTS orders_created_at_idx ON orders (created_at);
+CREATE INDEX IF NOT EXISTS refunds_created_at_idx ON refunds (created_at);
+
+-- product_id baris order menjadi NULL saat produknya dihapus; rollup produk
+-- itu ikut dipindah ke product_id nol supaya tetap sama dengan hitung ulang.
+CREATE OR REPLACE FUNCTION product_daily_rollups_product_deleted() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO product_daily_rollups (tenant_id, outlet_id, business_date, product_id,
+                                       quantity, refunded_quantity, gross, discount, refunds)
+    SELECT tenant_id, outlet_id, business_date, '00000000-0000-0000-0000-000000000000',
+           quantity, refunded_quantity, gross, discount, refunds
+    FROM product_daily_rollups WHERE product_id = OLD.id
+    ON CONFLICT (tenant_id, outlet_id, business_date, product_id) DO UPDATE SET
+        quantity = product_daily_rollups.quantity + EXCLUDED.quantity,
+        refunded_quantity = product_daily_rollups.refunded_quantity + EXCLUDED.refunded_quantity,
+        gross = product_daily_rollups.gross + EXCLUDED.gross,
+        discount = product_daily_rollups.discount + EXCLUDED.discount,
+        refunds = product_daily_rollups.refunds + EXCLUDED.refunds,
+        updated_at = now();
+    DELETE FROM product_daily_rollups WHERE product_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS product_daily_rollups_product_deleted ON products;
+CREATE TRIGGER product_daily_rollups_product_deleted AFTER DELETE ON products
+    FOR EACH ROW EXECUTE FUNCTION product_daily_rollups_product_deleted();
+
+ALTER TABLE sales_daily_rollups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sales_daily_rollups FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON sales_daily_rollups;
+CREATE POLICY tenant_isolation ON sales_daily_rollups
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE product_daily_rollups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE product_daily_rollups FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON product_daily_rollups;
+CREATE POLICY tenant_isolation ON product_daily_rollups
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
+
+ALTER TABLE sales_rollup_checkpoints ENABLE ROW LEVEL SECURITY;
+ALTER TABLE sales_rollup_checkpoints FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON sales_rollup_checkpoints;
+CREATE POLICY tenant_isolation ON sales_rollup_checkpoints
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::uuid);
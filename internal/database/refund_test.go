@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+	"maspos-be-go/internal/scope"
+)
+
+// salesFixture adalah tenant baru dengan satu outlet, satu produk seharga
+// 18.000, satu pelanggan dan satu shift terbuka. ctx membawa tenant dan
+// outlet itu.
+type salesFixture struct {
+	ctx                    context.Context
+	outlet, product, shift string
+	customer               string
+}
+
+func newSalesFixture(t *testing.T, db *sql.DB, slug string, rules loyalty.Rules) salesFixture {
+	t.Helper()
+	tenant, err := repository.NewTenantRepository(db).Create(context.Background(), slug, slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := salesFixture{ctx: scope.WithTenant(context.Background(), tenant)}
+
+	if f.outlet, err = repository.NewOutletRepository(db).Create(f.ctx, repository.Outlet{Name: "Kemang", UseCentralCatalog: true}); err != nil {
+		t.Fatal(err)
+	}
+	cat, err := repository.NewCategoryRepository(db).Create(f.ctx, repository.Category{Name: "Kopi", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.product, err = repository.NewProductRepository(db).Create(f.ctx, repository.Product{CategoryID: cat, Name: "Kopi Susu", Price: 18000, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	if f.customer, err = repository.NewCustomerRepository(db).Create(f.ctx, "Budi", "0812"+slug, "", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.NewLoyaltyRepository(db).UpdateRules(f.ctx, rules); err != nil {
+		t.Fatal(err)
+	}
+
+	f.ctx = scope.WithOutlet(f.ctx, f.outlet)
+	if f.shift, err = repository.NewShiftRepository(db).Open(f.ctx, "kasir-1", 100000, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestRefundPointsAreNotExpiredAgain(t *testing.T) {
+	db := migratedAsApp(t)
+	fx := newSalesFixture(t, db, "kopi-refund", loyalty.Rules{RupiahPerPoint: 180, PointValue: 100, ExpiryDays: 30})
+	ctx, shift, customer := fx.ctx, fx.shift, fx.customer
+	points := repository.NewLoyaltyRepository(db)
+
+	o, err := repository.NewOrderRepository(db).Create(ctx, repository.NewOrder{
+		ShiftID: shift, CustomerID: customer,
+		Lines:   []repository.NewOrderLine{{ProductID: fx.product, Quantity: 1}},
+		Tenders: []order.Tender{{Method: order.TenderCash, Amount: 18000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.PointsEarned != 100 {
+		t.Fatalf("expected 100 points earned, got %d", o.PointsEarned)
+	}
+
+	f, err := repository.NewRefundRepository(db).Create(ctx, repository.NewRefund{
+		OrderID: o.ID, ShiftID: shift, Reason: "changed_mind", Method: order.TenderCash,
+		Lines: []repository.NewRefundLine{{OrderLineID: o.Lines[0].ID, Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.PointsReversed != 100 {
+		t.Fatalf("expected 100 points reversed, got %d", f.PointsReversed)
+	}
+
+	// poin baru yang belum kedaluwarsa tidak boleh ikut hangus karena earn
+	// order yang sudah ditarik
+	if _, err := points.AddEntry(ctx, customer, loyalty.TypeEarn, 50, "", nil, "bonus"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := points.ExpireDue(ctx, time.Now().AddDate(0, 0, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("expected no customer to expire, got %d", n)
+	}
+	if balance, err := points.Balance(ctx, customer); err != nil || balance != 50 {
+		t.Errorf("expected balance 50, got %d, %v", balance, err)
+	}
+}
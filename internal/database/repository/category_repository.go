@@ -3,11 +3,26 @@ package repository
 import (
     "context"
     "database/sql"
+    "errors"
+)
+
+var (
+    ErrCategoryCycle       = errors.New("a category cannot be moved under itself or its descendants")
+    ErrParentNotFound      = errors.New("parent category not found")
+    ErrCategoryHasChildren = errors.New("category still has subcategories")
 )
 
 type Category struct {
-    ID   string `json:"id"`
-    Name string `json:"name"`
+    ID       string  `json:"id"`
+    ParentID *string `json:"parent_id"`
+    Name     string  `json:"name"`
+    Position int     `json:"position"`
+    Color    string  `json:"color"`
+    Icon     string  `json:"icon"`
+    Active   bool    `json:"active"`
+    Version  int     `json:"version"`
+    // OutletID kosong (null) untuk kategori katalog pusat.
+    OutletID *string `json:"outlet_id"`
 }
 
 type CategoryRepository struct {
@@ -18,47 +33,307 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
     return &CategoryRepository{db}
 }
 
-func (r *CategoryRepository) Create(ctx context.Context, name string) (string, error) {
-    var id string
-    query := `INSERT INTO categories (name) VALUES ($1) RETURNING id`
-    err := r.db.QueryRowContext(ctx, query, name).Scan(&id)
+const categoryColumns = `id, parent_id, name, position, color, icon, active, version, outlet_id`
+
+// visibleCategories adalah CTE berisi kategori yang tampil di POS: kategori
+// aktif yang seluruh leluhurnya juga aktif. Menonaktifkan sebuah kategori
+// ikut menyembunyikan semua subkategorinya.
+const visibleCategories = `visible AS (
+            SELECT id FROM categories WHERE parent_id IS NULL AND active
+            UNION ALL
+            SELECT c.id FROM categories c JOIN visible v ON c.parent_id = v.id WHERE c.active
+        )`
+
+// categoryOrder mengurutkan kategori untuk tampilan di POS.
+const categoryOrder = ` ORDER BY position, name`
+
+// categoryPaths adalah CTE berisi path lengkap setiap kategori, misalnya
+// "Minuman > Kopi", sama dengan format kolom category pada impor produk.
+const categoryPaths = `paths AS (
+            SELECT id, name::text AS path FROM categories WHERE parent_id IS NULL
+            UNION ALL
+            SELECT c.id, pa.path || ' > ' || c.name FROM categories c JOIN paths pa ON c.parent_id = pa.id
+        )`
+
+func scanCategory(row interface{ Scan(...any) error }, extra ...any) (*Category, error) {
+    var c Category
+    var parentID, outletID sql.NullString
+    dest := append([]any{&c.ID, &parentID, &c.Name, &c.Position, &c.Color, &c.Icon, &c.Active, &c.Version, &outletID}, extra...)
+    if err := row.Scan(dest...); err != nil {
+        return nil, err
+    }
+    c.ParentID = nullableString(parentID)
+    c.OutletID = nullableString(outletID)
+    return &c, nil
+}
+
+func nullableString(s sql.NullString) *string {
+    if !s.Valid {
+        return nil
+    }
+    return &s.String
+}
+
+// hierarchyLock menyerialkan perubahan parent_id supaya dua pemindahan yang
+// berjalan bersamaan tidak bisa membentuk siklus.
+const hierarchyLock = `SELECT pg_advisory_xact_lock(hashtext('categories.parent_id'))`
+
+// Create menyimpan kategori baru milik outlet di ctx di bawah c.ParentID
+// (nil untuk kategori paling atas). ErrParentNotFound dikembalikan bila
+// parent-nya tidak ada atau tidak terlihat oleh outlet tersebut.
+func (r *CategoryRepository) Create(ctx context.Context, c Category) (id string, err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.Create")
+    defer func() { endSpan(span, -1, err) }()
+
+    query := `INSERT INTO categories (parent_id, name, position, color, icon, active, outlet_id)
+        SELECT $1, $2, $3, $4, $5, $6, $7
+        WHERE $1::uuid IS NULL OR EXISTS (SELECT 1 FROM categories WHERE id = $1 AND ` + readableBy("categories", 7) + `)
+        RETURNING id`
+    err = r.db.QueryRowContext(ctx, query, c.ParentID, c.Name, c.Position, c.Color, c.Icon, c.Active, outletParam(ctx)).Scan(&id)
+    if errors.Is(err, sql.ErrNoRows) {
+        err = ErrParentNotFound
+    }
     return id, err
 }
 
-func (r *CategoryRepository) GetAll(ctx context.Context) ([]Category, error) {
-    query := `SELECT id, name FROM categories`
-    rows, err := r.db.QueryContext(ctx, query)
+// CategoryFilter narrows GetAll. ActiveOnly keeps only visible categories
+// (see visibleCategories). Every query only sees the categories readable by
+// the outlet in the context (see readableBy).
+type CategoryFilter struct {
+    ActiveOnly bool
+}
+
+func (r *CategoryRepository) GetAll(ctx context.Context, f CategoryFilter) (categories []Category, err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.GetAll")
+    defer func() { endSpan(span, len(categories), err) }()
+
+    query := `WITH RECURSIVE ` + visibleCategories + `
+        SELECT ` + categoryColumns + ` FROM categories
+        WHERE (NOT $1 OR id IN (SELECT id FROM visible)) AND ` + readableBy("categories", 2) + categoryOrder
+    rows, err := r.db.QueryContext(ctx, query, f.ActiveOnly, outletParam(ctx))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        c, err := scanCategory(rows)
+        if err != nil {
+            return nil, err
+        }
+        categories = append(categories, *c)
+    }
+    return categories, rows.Err()
+}
+
+// GetSubtree returns the category with the given ID followed by all of its
+// descendants, each level ordered by position. It returns sql.ErrNoRows
+// when the root does not exist.
+func (r *CategoryRepository) GetSubtree(ctx context.Context, rootID string) (categories []Category, err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.GetSubtree")
+    defer func() { endSpan(span, len(categories), err) }()
+
+    query := `
+        WITH RECURSIVE subtree AS (
+            SELECT ` + categoryColumns + `, 0 AS depth FROM categories WHERE id = $1 AND ` + readableBy("categories", 2) + `
+            UNION ALL
+            SELECT c.id, c.parent_id, c.name, c.position, c.color, c.icon, c.active, c.version, c.outlet_id, s.depth + 1
+            FROM categories c JOIN subtree s ON c.parent_id = s.id
+            WHERE ` + readableBy("c", 2) + `
+        )
+        SELECT ` + categoryColumns + ` FROM subtree ORDER BY depth, position, name`
+    rows, err := r.db.QueryContext(ctx, query, rootID, outletParam(ctx))
     if err != nil {
         return nil, err
     }
     defer rows.Close()
 
-    var categories []Category
     for rows.Next() {
-        var c Category
-        if err := rows.Scan(&c.ID, &c.Name); err != nil {
+        c, err := scanCategory(rows)
+        if err != nil {
             return nil, err
-		}
-        categories = append(categories, c)
+        }
+        categories = append(categories, *c)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    if len(categories) == 0 {
+        return nil, sql.ErrNoRows
     }
     return categories, nil
 }
 
-func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*Category, error) {
-    var c Category
-    query := `SELECT id, name FROM categories WHERE id = $1`
-    err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.Name)
-    return &c, err
+// CategoryExport is a category with its full path.
+type CategoryExport struct {
+    Category
+    Path string
 }
 
-func (r *CategoryRepository) Update(ctx context.Context, id string, name string) error {
-    query := `UPDATE categories SET name = $1 WHERE id = $2`
-    _, err := r.db.ExecContext(ctx, query, name, id)
-    return err
+// Export memanggil fn untuk setiap kategori yang cocok dengan f, diurutkan
+// menurut path, dengan membaca baris satu per satu dari database.
+func (r *CategoryRepository) Export(ctx context.Context, f CategoryFilter, fn func(CategoryExport) error) (err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.Export")
+    n := 0
+    defer func() { endSpan(span, n, err) }()
+
+    query := `WITH RECURSIVE ` + visibleCategories + `, ` + categoryPaths + `
+        SELECT ` + categoryColumns + `, paths.path FROM categories JOIN paths USING (id)
+        WHERE (NOT $1 OR id IN (SELECT id FROM visible)) AND ` + readableBy("categories", 2) + `
+        ORDER BY paths.path`
+    rows, err := r.db.QueryContext(ctx, query, f.ActiveOnly, outletParam(ctx))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var path string
+        c, err := scanCategory(rows, &path)
+        if err != nil {
+            return err
+        }
+        if err := fn(CategoryExport{Category: *c, Path: path}); err != nil {
+            return err
+        }
+        n++
+    }
+    return rows.Err()
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id string) (_ *Category, err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.GetByID")
+    defer func() { endSpan(span, rowCount(err), err) }()
+
+    query := `SELECT ` + categoryColumns + ` FROM categories WHERE id = $1 AND ` + readableBy("categories", 2)
+    return scanCategory(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)))
 }
 
-func (r *CategoryRepository) Delete(ctx context.Context, id string) error {
-    query := `DELETE FROM categories WHERE id = $1`
-    _, err := r.db.ExecContext(ctx, query, id)
-    return err
-}
\ No newline at end of file
+// CategoryChanges lists the fields of a partial update; nil fields are
+// left unchanged. A ParentID that is not Valid moves the category to the
+// top level.
+type CategoryChanges struct {
+    Name     *string
+    ParentID *sql.NullString
+    Position *int
+    Color    *string
+    Icon     *string
+    Active   *bool
+}
+
+// Update mengubah field kategori yang diisi di changes dan mengembalikan versi
+// barunya. version 0 berarti tanpa pemeriksaan versi; selain itu
+// ErrVersionConflict dikembalikan bila kategori sudah diubah orang lain.
+// Outlet tidak bisa mengubah kategori katalog pusat (ErrSharedCatalog).
+// Mengganti parent memindahkan seluruh subtree; ErrCategoryCycle dikembalikan
+// bila parent baru adalah kategori itu sendiri atau turunannya.
+func (r *CategoryRepository) Update(ctx context.Context, id string, changes CategoryChanges, version int) (newVersion int, err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.Update")
+    defer func() { endSpan(span, rowCount(err), err) }()
+
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    var b updateBuilder
+    if changes.Name != nil {
+        b.set("name", *changes.Name)
+    }
+    if changes.Position != nil {
+        b.set("position", *changes.Position)
+    }
+    if changes.Color != nil {
+        b.set("color", *changes.Color)
+    }
+    if changes.Icon != nil {
+        b.set("icon", *changes.Icon)
+    }
+    if changes.Active != nil {
+        b.set("active", *changes.Active)
+    }
+    if changes.ParentID != nil {
+        if changes.ParentID.Valid {
+            if err := checkNewParent(ctx, tx, id, changes.ParentID.String); err != nil {
+                return 0, err
+            }
+        }
+        b.set("parent_id", *changes.ParentID)
+    }
+
+    newVersion, err = updateVersioned(ctx, tx, "categories", id, version, b)
+    if err != nil {
+        return 0, err
+    }
+    return newVersion, tx.Commit()
+}
+
+// checkNewParent mengunci hierarki lalu memastikan parentID ada, terlihat oleh
+// outlet di ctx, dan bukan id sendiri maupun turunannya, dengan menelusuri
+// leluhur parentID ke atas.
+func checkNewParent(ctx context.Context, tx *sql.Tx, id, parentID string) error {
+    if _, err := tx.ExecContext(ctx, hierarchyLock); err != nil {
+        return err
+    }
+
+    query := `
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM categories WHERE id = $1 AND ` + readableBy("categories", 3) + `
+            UNION ALL
+            SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+        )
+        SELECT count(*), count(*) FILTER (WHERE id = $2) FROM ancestors`
+    var found, cycle int
+    if err := tx.QueryRowContext(ctx, query, parentID, id, outletParam(ctx)).Scan(&found, &cycle); err != nil {
+        return err
+    }
+    switch {
+    case found == 0:
+        return ErrParentNotFound
+    case cycle > 0:
+        return ErrCategoryCycle
+    }
+    return nil
+}
+
+// Delete menghapus kategori dengan aturan versi yang sama seperti Update.
+// Kategori yang masih punya subkategori tidak bisa dihapus
+// (ErrCategoryHasChildren). Subkategori hanya diperiksa untuk kategori milik
+// outlet di ctx, jadi kategori outlet lain tetap dilaporkan tidak ada.
+func (r *CategoryRepository) Delete(ctx context.Context, id string, version int) (err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.Delete")
+    defer func() { endSpan(span, -1, err) }()
+
+    var hasChildren bool
+    query := `SELECT EXISTS (
+        SELECT 1 FROM categories c JOIN categories p ON p.id = c.parent_id
+        WHERE c.parent_id = $1 AND ` + ownedBy("p", 2) + `)`
+    if err := r.db.QueryRowContext(ctx, query, id, outletParam(ctx)).Scan(&hasChildren); err != nil {
+        return err
+    }
+    if hasChildren {
+        return ErrCategoryHasChildren
+    }
+
+    query = `DELETE FROM categories WHERE id = $1 AND ($2 = 0 OR version = $2) AND ` + ownedBy("categories", 3)
+    res, err := r.db.ExecContext(ctx, query, id, version, outletParam(ctx))
+    if err != nil {
+        return err
+    }
+    if n, err := res.RowsAffected(); err != nil {
+        return err
+    } else if n == 0 {
+        return versionMismatch(ctx, r.db, "categories", id)
+    }
+    return nil
+}
+
+// Reorder mengubah posisi beberapa kategori sekaligus, misalnya setelah
+// kasir menggeser urutan tombol di POS.
+func (r *CategoryRepository) Reorder(ctx context.Context, positions []Position) (err error) {
+    ctx, span := startSpan(ctx, "CategoryRepository.Reorder")
+    defer func() { endSpan(span, len(positions), err) }()
+
+    return reorder(ctx, r.db, "categories", positions)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation melaporkan apakah err adalah pelanggaran unique constraint
+// atau unique index bernama constraint. Pemeriksaan lebih dulu dengan SELECT
+// tidak menutup balapan dua request yang bersamaan; constraint di database
+// yang menjadi penentu terakhirnya.
+func uniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
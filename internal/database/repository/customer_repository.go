@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrPhoneTaken is returned when another customer of the tenant already
+// has the phone number.
+var ErrPhoneTaken = errors.New("phone number already registered")
+
+// customerPhoneKey adalah unique index nomor telepon per tenant.
+const customerPhoneKey = "customers_tenant_phone_key"
+
+type Customer struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Phone     string     `json:"phone"`
+	Email     string     `json:"email"`
+	Birthday  *time.Time `json:"birthday"`
+	Notes     string     `json:"notes"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db}
+}
+
+const customerColumns = `id, name, phone, email, birthday, notes, created_at`
+
+func scanCustomer(row interface{ Scan(...any) error }) (*Customer, error) {
+	var c Customer
+	var birthday sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &birthday, &c.Notes, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	if birthday.Valid {
+		c.Birthday = &birthday.Time
+	}
+	return &c, nil
+}
+
+// Create menyimpan pelanggan baru. phone harus sudah dinormalisasi.
+func (r *CustomerRepository) Create(ctx context.Context, name, phone, email string, birthday *time.Time, notes string) (id string, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `INSERT INTO customers (name, phone, email, birthday, notes) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err = r.db.QueryRowContext(ctx, query, name, phone, email, birthday, notes).Scan(&id)
+	if uniqueViolation(err, customerPhoneKey) {
+		err = ErrPhoneTaken
+	}
+	return id, err
+}
+
+// GetAll returns customers ordered by name. A non-empty search matches
+// the name (case-insensitive) or a fragment of the phone number.
+func (r *CustomerRepository) GetAll(ctx context.Context, search string) (customers []Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetAll")
+	defer func() { endSpan(span, len(customers), err) }()
+
+	query := `SELECT ` + customerColumns + ` FROM customers
+		WHERE $1 = '' OR lower(name) LIKE '%' || lower($1) || '%' OR phone LIKE '%' || $1 || '%'
+		ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query, search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, *c)
+	}
+	return customers, rows.Err()
+}
+
+func (r *CustomerRepository) GetByID(ctx context.Context, id string) (_ *Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByID")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE id = $1`
+	return scanCustomer(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetByPhone mencari pelanggan berdasarkan nomor telepon yang sudah dinormalisasi.
+func (r *CustomerRepository) GetByPhone(ctx context.Context, phone string) (_ *Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByPhone")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE phone = $1`
+	return scanCustomer(r.db.QueryRowContext(ctx, query, phone))
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, id, name, phone, email string, birthday *time.Time, notes string) (err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.Update")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `UPDATE customers SET name=$1, phone=$2, email=$3, birthday=$4, notes=$5 WHERE id=$6`
+	_, err = r.db.ExecContext(ctx, query, name, phone, email, birthday, notes, id)
+	if uniqueViolation(err, customerPhoneKey) {
+		err = ErrPhoneTaken
+	}
+	return err
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.Delete")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `DELETE FROM customers WHERE id = $1`
+	_, err = r.db.ExecContext(ctx, query, id)
+	return err
+}
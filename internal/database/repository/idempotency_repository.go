@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"maspos-be-go/internal/scope"
+)
+
+// IdempotencyRecord is a stored Idempotency-Key. Status is 0 while the
+// first request is still being processed.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	ETag        string
+	Body        []byte
+}
+
+type IdempotencyRepository struct {
+	db *sql.DB
+	// lastSweep menyimpan waktu sweep terakhir per tenant (*atomic.Int64);
+	// RLS membatasi DELETE ke tenant request, jadi setiap tenant disapu
+	// sendiri-sendiri
+	lastSweep sync.Map
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve mengklaim key untuk request baru dan mengembalikan nil bila
+// berhasil. Bila key sudah dipakai, record yang tersimpan dikembalikan.
+// Key yang sudah kedaluwarsa (ttl) atau yang klaimnya tidak pernah diselesaikan
+// dalam lockFor, misalnya karena proses mati, boleh diklaim ulang.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, scope, key, fingerprint string, lockFor, ttl time.Duration) (rec *IdempotencyRecord, err error) {
+	ctx, span := startSpan(ctx, "IdempotencyRepository.Reserve")
+	defer func() { endSpan(span, -1, err) }()
+
+	r.sweep(ctx)
+
+	query := `
+		INSERT INTO idempotency_keys (scope, key, fingerprint, locked_until, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4), now() + make_interval(secs => $5))
+		ON CONFLICT (tenant_id, scope, key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint,
+		    status = NULL,
+		    content_type = NULL,
+		    etag = NULL,
+		    body = NULL,
+		    locked_until = EXCLUDED.locked_until,
+		    expires_at = EXCLUDED.expires_at,
+		    created_at = now()
+		WHERE idempotency_keys.expires_at < now()
+		   OR (idempotency_keys.status IS NULL AND idempotency_keys.locked_until < now())
+		RETURNING true
+	`
+	var reserved bool
+	err = r.db.QueryRowContext(ctx, query, scope, key, fingerprint, lockFor.Seconds(), ttl.Seconds()).Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var stored IdempotencyRecord
+	var status sql.NullInt32
+	var contentType, etag sql.NullString
+	query = `SELECT fingerprint, status, content_type, etag, body FROM idempotency_keys WHERE scope = $1 AND key = $2`
+	err = r.db.QueryRowContext(ctx, query, scope, key).Scan(&stored.Fingerprint, &status, &contentType, &etag, &stored.Body)
+	if err != nil {
+		return nil, err
+	}
+	stored.Status = int(status.Int32)
+	stored.ContentType = contentType.String
+	stored.ETag = etag.String
+	return &stored, nil
+}
+
+// Complete menyimpan respons request yang mengklaim key, termasuk ETag-nya
+// supaya pengulangan bisa langsung dipakai untuk If-Match berikutnya.
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, status int, contentType, etag string, body []byte) (err error) {
+	ctx, span := startSpan(ctx, "IdempotencyRepository.Complete")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `UPDATE idempotency_keys SET status = $3, content_type = $4, etag = NULLIF($5, ''), body = $6 WHERE scope = $1 AND key = $2`
+	_, err = r.db.ExecContext(ctx, query, scope, key, status, contentType, etag, body)
+	return err
+}
+
+// Release melepas klaim tanpa menyimpan respons sehingga request yang sama
+// boleh dicoba lagi.
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) (err error) {
+	ctx, span := startSpan(ctx, "IdempotencyRepository.Release")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status IS NULL`
+	_, err = r.db.ExecContext(ctx, query, scope, key)
+	return err
+}
+
+// sweep menghapus key tenant ctx yang sudah kedaluwarsa, paling sering
+// sekali per menit per tenant per replika.
+func (r *IdempotencyRepository) sweep(ctx context.Context) {
+	tenant := scope.Tenant(ctx)
+	if tenant == "" {
+		return
+	}
+	v, _ := r.lastSweep.LoadOrStore(tenant, new(atomic.Int64))
+	lastSweep := v.(*atomic.Int64)
+
+	now := time.Now().UnixNano()
+	last := lastSweep.Load()
+	if now-last < int64(time.Minute) || !lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+	r.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < now()`)
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"maspos-be-go/internal/loyalty"
+)
+
+var (
+	ErrAlreadyReversed        = errors.New("ledger entry already reversed")
+	ErrReversalEntry          = errors.New("a reversal entry cannot be reversed")
+	ErrUnknownLoyaltyCategory = errors.New("category multiplier refers to an unknown category")
+)
+
+type LoyaltyEntry struct {
+	ID         string     `json:"id"`
+	CustomerID string     `json:"customer_id"`
+	Type       string     `json:"type"`
+	Points     int64      `json:"points"`
+	Reference  string     `json:"reference"`
+	ReversesID *string    `json:"reverses_id"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Note       string     `json:"note"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type LoyaltyRepository struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepository(db *sql.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db}
+}
+
+func (r *LoyaltyRepository) GetRules(ctx context.Context) (rules loyalty.Rules, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.GetRules")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	return loyaltyRules(ctx, r.db)
+}
+
+// loyaltyRules membaca pengaturan program poin beserta multiplier
+// kategorinya; db boleh berupa transaksi.
+func loyaltyRules(ctx context.Context, db interface {
+	queryer
+	rowsQueryer
+}) (rules loyalty.Rules, err error) {
+	rules.CategoryMultipliers = map[string]float64{}
+	query := `SELECT rupiah_per_point, point_value, expiry_days FROM loyalty_settings WHERE id = 1`
+	err = db.QueryRowContext(ctx, query).Scan(&rules.RupiahPerPoint, &rules.PointValue, &rules.ExpiryDays)
+	if err != nil {
+		return rules, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT category_id, multiplier FROM loyalty_category_multipliers`)
+	if err != nil {
+		return rules, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID string
+		var m float64
+		if err := rows.Scan(&categoryID, &m); err != nil {
+			return rules, err
+		}
+		rules.CategoryMultipliers[categoryID] = m
+	}
+	return rules, rows.Err()
+}
+
+// UpdateRules menyimpan pengaturan dan mengganti seluruh multiplier kategori.
+func (r *LoyaltyRepository) UpdateRules(ctx context.Context, rules loyalty.Rules) (err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.UpdateRules")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO loyalty_settings (id, rupiah_per_point, point_value, expiry_days)
+		VALUES (1, $1, $2, $3)
+		ON CONFLICT (tenant_id, id) DO UPDATE
+		SET rupiah_per_point = EXCLUDED.rupiah_per_point,
+		    point_value = EXCLUDED.point_value,
+		    expiry_days = EXCLUDED.expiry_days
+	`
+	if _, err := tx.ExecContext(ctx, query, rules.RupiahPerPoint, rules.PointValue, rules.ExpiryDays); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM loyalty_category_multipliers`); err != nil {
+		return err
+	}
+	for categoryID, m := range rules.CategoryMultipliers {
+		// foreign key tidak melihat RLS, jadi kategori tenant lain harus
+		// ditolak di sini
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`, categoryID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUnknownLoyaltyCategory
+		}
+		query := `INSERT INTO loyalty_category_multipliers (category_id, multiplier) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, categoryID, m); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *LoyaltyRepository) Balance(ctx context.Context, customerID string) (balance int64, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.Balance")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = $1`
+	err = r.db.QueryRowContext(ctx, query, customerID).Scan(&balance)
+	return balance, err
+}
+
+func (r *LoyaltyRepository) GetEntries(ctx context.Context, customerID string) (entries []LoyaltyEntry, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.GetEntries")
+	defer func() { endSpan(span, len(entries), err) }()
+
+	query := `
+		SELECT id, customer_id, type, points, reference, reverses_id, expires_at, note, created_at
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.QueryContext(ctx, query, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e LoyaltyEntry
+		var reversesID sql.NullString
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.CustomerID, &e.Type, &e.Points, &e.Reference, &reversesID, &expiresAt, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if reversesID.Valid {
+			e.ReversesID = &reversesID.String
+		}
+		if expiresAt.Valid {
+			e.ExpiresAt = &expiresAt.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// AddEntry mencatat entri apa adanya; dipakai untuk earn, yang hanya
+// menambah saldo.
+func (r *LoyaltyRepository) AddEntry(ctx context.Context, customerID, entryType string, points int64, reference string, expiresAt *time.Time, note string) (id string, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.AddEntry")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `
+		INSERT INTO loyalty_ledger (customer_id, type, points, reference, expires_at, note)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	err = r.db.QueryRowContext(ctx, query, customerID, entryType, points, reference, expiresAt, note).Scan(&id)
+	return id, err
+}
+
+// spendable mengunci baris customer lalu memastikan saldonya cukup untuk
+// dikurangi points, sehingga dua pengurangan bersamaan tidak bisa membuat
+// saldo negatif. Semua entri yang mengurangi saldo melewati pemeriksaan ini.
+func spendable(ctx context.Context, tx *sql.Tx, customerID string, points int64) error {
+	var locked string
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM customers WHERE id = $1 FOR UPDATE`, customerID).Scan(&locked); err != nil {
+		return err
+	}
+
+	var balance int64
+	query := `SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = $1`
+	if err := tx.QueryRowContext(ctx, query, customerID).Scan(&balance); err != nil {
+		return err
+	}
+	if balance < points {
+		return loyalty.ErrInsufficientPoints
+	}
+	return nil
+}
+
+// Adjust mencatat koreksi manual. Koreksi negatif tidak boleh melebihi
+// saldo, sama seperti penukaran.
+func (r *LoyaltyRepository) Adjust(ctx context.Context, customerID string, points int64, note string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.Adjust")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if points < 0 {
+		if err := spendable(ctx, tx, customerID, -points); err != nil {
+			return "", err
+		}
+	}
+
+	var id string
+	query := `INSERT INTO loyalty_ledger (customer_id, type, points, note) VALUES ($1, 'adjust', $2, $3) RETURNING id`
+	if err := tx.QueryRowContext(ctx, query, customerID, points, note).Scan(&id); err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+// Redeem mengurangi poin pelanggan sebesar points.
+func (r *LoyaltyRepository) Redeem(ctx context.Context, customerID string, points int64, reference string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.Redeem")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if err := spendable(ctx, tx, customerID, points); err != nil {
+		return "", err
+	}
+
+	var id string
+	query := `
+		INSERT INTO loyalty_ledger (customer_id, type, points, reference)
+		VALUES ($1, 'burn', $2, $3)
+		RETURNING id
+	`
+	if err := tx.QueryRowContext(ctx, query, customerID, -points, reference).Scan(&id); err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+// Reverse membatalkan sebuah entri dengan menulis entri baru bertipe sama
+// dan poin berlawanan, misalnya saat transaksi di-refund. Entri asli tetap
+// ada sehingga riwayat bisa diaudit.
+func (r *LoyaltyRepository) Reverse(ctx context.Context, entryID, note string) (_ string, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.Reverse")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var customerID, entryType, reference string
+	var points int64
+	var reversesID sql.NullString
+	var expiresAt sql.NullTime
+	query := `
+		SELECT customer_id, type, points, reference, reverses_id, expires_at
+		FROM loyalty_ledger WHERE id = $1 FOR UPDATE
+	`
+	err = tx.QueryRowContext(ctx, query, entryID).Scan(&customerID, &entryType, &points, &reference, &reversesID, &expiresAt)
+	if err != nil {
+		return "", err
+	}
+	if reversesID.Valid {
+		return "", ErrReversalEntry
+	}
+
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM loyalty_ledger WHERE reverses_id = $1)`
+	if err := tx.QueryRowContext(ctx, query, entryID).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return "", ErrAlreadyReversed
+	}
+	// membatalkan earn yang poinnya sudah ditukar tidak boleh membuat saldo
+	// negatif
+	if points > 0 {
+		if err := spendable(ctx, tx, customerID, points); err != nil {
+			return "", err
+		}
+	}
+
+	var id string
+	query = `
+		INSERT INTO loyalty_ledger (customer_id, type, points, reference, reverses_id, expires_at, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+	err = tx.QueryRowContext(ctx, query, customerID, entryType, -points, reference, entryID, expiresAt, note).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+// ExpireDue menulis entri expire untuk poin yang sudah lewat masa berlakunya
+// per now dan mengembalikan jumlah pelanggan yang terdampak.
+func (r *LoyaltyRepository) ExpireDue(ctx context.Context, now time.Time) (_ int, err error) {
+	ctx, span := startSpan(ctx, "LoyaltyRepository.ExpireDue")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT customer_id,
+		       COALESCE(SUM(points) FILTER (WHERE type = 'earn' AND expires_at <= $1), 0),
+		       COALESCE(-SUM(points) FILTER (WHERE type IN ('burn', 'expire')), 0),
+		       COALESCE(SUM(points), 0)
+		FROM loyalty_ledger
+		GROUP BY customer_id
+		HAVING COALESCE(SUM(points) FILTER (WHERE type = 'earn' AND expires_at <= $1), 0) > 0
+	`
+	rows, err := tx.QueryContext(ctx, query, now)
+	if err != nil {
+		return 0, err
+	}
+
+	due := map[string]int64{}
+	for rows.Next() {
+		var customerID string
+		var expiredEarned, consumed, balance int64
+		if err := rows.Scan(&customerID, &expiredEarned, &consumed, &balance); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if n := loyalty.Expirable(expiredEarned, consumed, balance); n > 0 {
+			due[customerID] = n
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for customerID, n := range due {
+		query := `INSERT INTO loyalty_ledger (customer_id, type, points, note) VALUES ($1, 'expire', $2, 'points expired')`
+		if _, err := tx.ExecContext(ctx, query, customerID, -n); err != nil {
+			return 0, err
+		}
+	}
+	return len(due), tx.Commit()
+}
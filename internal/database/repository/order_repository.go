@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+)
+
+var (
+	ErrProductUnavailable = errors.New("product not found or not sold at this outlet")
+	ErrCustomerNotFound   = errors.New("customer not found")
+	ErrLoyaltyNoCustomer  = errors.New("paying with loyalty points needs a customer")
+	ErrLoyaltyNoValue     = errors.New("loyalty points have no redemption value")
+)
+
+// Jenis mutasi stok.
+const (
+	StockSale   = "sale"
+	StockReturn = "return"
+)
+
+// Order adalah penjualan yang tersimpan. Lines, Taxes dan Tenders hanya
+// diisi oleh GetByID dan Create.
+type Order struct {
+	ID         string    `json:"id"`
+	OutletID   string    `json:"outlet_id"`
+	ShiftID    string    `json:"shift_id"`
+	Number     string    `json:"number"`
+	CustomerID *string   `json:"customer_id"`
+	Gross      float64   `json:"gross"`
+	Discount   float64   `json:"discount"`
+	Subtotal   float64   `json:"subtotal"`
+	Tax        float64   `json:"tax"`
+	Total      float64   `json:"total"`
+	Paid       float64   `json:"paid"`
+	Change     float64   `json:"change"`
+	Note       string    `json:"note"`
+	CreatedBy  *int      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Cashier adalah nama user yang membuat order; hanya diisi oleh GetByID.
+	Cashier string `json:"cashier,omitempty"`
+	// PointsEarned dan PointsRedeemed adalah poin loyalty yang didapat dan
+	// dipakai pelanggan pada order ini.
+	PointsEarned   int64 `json:"points_earned"`
+	PointsRedeemed int64 `json:"points_redeemed"`
+
+	Lines   []OrderLine    `json:"lines,omitempty"`
+	Taxes   []order.Tax    `json:"taxes,omitempty"`
+	Tenders []order.Tender `json:"tenders,omitempty"`
+}
+
+// OrderLine adalah baris order yang tersimpan; Total adalah jumlah baris
+// setelah diskon.
+type OrderLine struct {
+	ID string `json:"id"`
+	order.Line
+	Total float64 `json:"total"`
+}
+
+// NewOrder adalah penjualan yang akan disimpan. Harga, nama dan kategori
+// setiap baris diambil dari katalog outlet shift-nya.
+type NewOrder struct {
+	ShiftID    string
+	CustomerID string
+	Lines      []NewOrderLine
+	Taxes      []order.Tax
+	Tenders    []order.Tender
+	Note       string
+	CreatedBy  *int
+}
+
+type NewOrderLine struct {
+	ProductID string
+	Quantity  float64
+	Modifiers []order.Modifier
+	Discount  float64
+	Note      string
+}
+
+// OrderFilter narrows GetAll; empty fields do not filter.
+type OrderFilter struct {
+	CustomerID string
+	ShiftID    string
+	Number     string
+	Limit      int
+}
+
+type OrderRepository struct {
+	db *sql.DB
+}
+
+func NewOrderRepository(db *sql.DB) *OrderRepository {
+	return &OrderRepository{db}
+}
+
+const orderColumns = `o.id, o.outlet_id, o.shift_id, o.number, o.customer_id, o.gross, o.discount, o.subtotal,
+	o.tax, o.total, o.paid, o.change, o.note, o.created_by, o.created_at`
+
+// orderVisible membatasi orders o ke outlet di $n; kantor pusat melihat
+// order semua outlet.
+func orderVisible(n int) string {
+	return fmt.Sprintf(`($%[1]d::uuid IS NULL OR o.outlet_id = $%[1]d)`, n)
+}
+
+func scanOrder(row interface{ Scan(...any) error }, extra ...any) (*Order, error) {
+	var o Order
+	var customerID sql.NullString
+	var createdBy sql.NullInt64
+	dest := append([]any{&o.ID, &o.OutletID, &o.ShiftID, &o.Number, &customerID, &o.Gross, &o.Discount, &o.Subtotal,
+		&o.Tax, &o.Total, &o.Paid, &o.Change, &o.Note, &createdBy, &o.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	o.CustomerID, o.CreatedBy = nullableString(customerID), nullableInt(createdBy)
+	return &o, nil
+}
+
+// Create menyimpan penjualan di shift in.ShiftID yang masih terbuka dan
+// mencatat uang tunai yang diterima (dikurangi kembalian) sebagai penjualan
+// tunai shift tersebut. Setiap baris juga dicatat sebagai stok keluar dari
+// outlet.
+// sql.ErrNoRows dikembalikan bila shift tidak ada atau milik outlet lain,
+// ErrShiftClosed dan ErrDayClosed bila shift atau hari bisnisnya sudah
+// ditutup, ErrProductUnavailable bila ada produk yang tidak dijual di outlet
+// itu, ErrCustomerNotFound bila pelanggannya tidak ada, dan error dari
+// order.Validate bila pembayarannya tidak cocok. Pembayaran dengan poin
+// (order.TenderLoyalty) dinilai menurut program poin dan ditolak dengan
+// ErrLoyaltyNoCustomer tanpa pelanggan, ErrLoyaltyNoValue bila nilai poinnya
+// nol, atau loyalty.ErrInsufficientPoints bila saldonya kurang. Order dengan
+// pelanggan mendapat poin dari bagian yang tidak dibayar dengan poin.
+func (r *OrderRepository) Create(ctx context.Context, in NewOrder) (_ *Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// order di register yang sama diproses bergantian, dan shift tidak bisa
+	// ditutup di tengah penjualan
+	s, err := lockOpenShift(ctx, tx, in.ShiftID)
+	if err != nil {
+		return nil, err
+	}
+	if closed, err := dayClosed(ctx, tx, s.OutletID); err != nil {
+		return nil, err
+	} else if closed {
+		return nil, ErrDayClosed
+	}
+	if in.CustomerID != "" {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM customers WHERE id = $1)`, in.CustomerID).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrCustomerNotFound
+		}
+	}
+
+	calc := order.Order{Taxes: in.Taxes, Tenders: append([]order.Tender(nil), in.Tenders...)}
+	var rules loyalty.Rules
+	if in.CustomerID != "" {
+		if rules, err = loyaltyRules(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+	var redeemed int64
+	for i, t := range calc.Tenders {
+		if t.Method != order.TenderLoyalty {
+			continue
+		}
+		if in.CustomerID == "" {
+			return nil, ErrLoyaltyNoCustomer
+		}
+		calc.Tenders[i].Amount = roundCents(rules.RedemptionValue(t.Points))
+		if calc.Tenders[i].Amount <= 0 {
+			return nil, ErrLoyaltyNoValue
+		}
+		redeemed += t.Points
+	}
+	for _, l := range in.Lines {
+		line, err := priceLine(ctx, tx, s.OutletID, l)
+		if err != nil {
+			return nil, err
+		}
+		calc.Lines = append(calc.Lines, line)
+	}
+	if err := calc.Validate(); err != nil {
+		return nil, err
+	}
+	if redeemed > 0 {
+		if err := spendable(ctx, tx, in.CustomerID, redeemed); err != nil {
+			return nil, err
+		}
+	}
+
+	o := &Order{
+		OutletID: s.OutletID, ShiftID: s.ID,
+		Gross: calc.Gross(), Discount: calc.Discount(), Subtotal: calc.Subtotal(), Tax: calc.Tax(),
+		Total: calc.Total(), Paid: calc.Paid(), Change: calc.Change(), Note: in.Note, CreatedBy: in.CreatedBy,
+		Taxes: calc.Taxes, Tenders: calc.Tenders,
+	}
+	if in.CustomerID != "" {
+		o.CustomerID = &in.CustomerID
+	}
+	if o.Taxes == nil {
+		o.Taxes = []order.Tax{}
+	}
+	taxes, err := json.Marshal(o.Taxes)
+	if err != nil {
+		return nil, err
+	}
+	query := `INSERT INTO orders (outlet_id, shift_id, number, customer_id, gross, discount, subtotal, tax, total, paid, change, taxes, note, created_by)
+		VALUES ($1, $2, 'INV-' || to_char(now(), 'YYYYMMDD') || '-' || lpad(nextval('orders_number_seq')::text, 6, '0'),
+			$3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, number, created_at`
+	err = tx.QueryRowContext(ctx, query, o.OutletID, o.ShiftID, o.CustomerID, o.Gross, o.Discount, o.Subtotal, o.Tax,
+		o.Total, o.Paid, o.Change, taxes, o.Note, o.CreatedBy).Scan(&o.ID, &o.Number, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, l := range calc.Lines {
+		modifiers, err := json.Marshal(l.Modifiers)
+		if err != nil {
+			return nil, err
+		}
+		line := OrderLine{Line: l, Total: l.Total()}
+		query := `INSERT INTO order_lines (order_id, line_no, product_id, category_id, name, quantity, unit_price, modifiers, discount, total, note)
+			VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+		err = tx.QueryRowContext(ctx, query, o.ID, i+1, l.ProductID, l.CategoryID, l.Name, l.Quantity, l.UnitPrice,
+			modifiers, l.Discount, line.Total, l.Note).Scan(&line.ID)
+		if err != nil {
+			return nil, err
+		}
+		o.Lines = append(o.Lines, line)
+
+		query = `INSERT INTO stock_movements (outlet_id, product_id, type, quantity, reference) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.ExecContext(ctx, query, o.OutletID, l.ProductID, StockSale, -l.Quantity, o.Number); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range calc.Tenders {
+		query := `INSERT INTO order_tenders (order_id, method, amount, reference, points) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.ExecContext(ctx, query, o.ID, t.Method, t.Amount, t.Reference, t.Points); err != nil {
+			return nil, err
+		}
+	}
+	if in.CustomerID != "" {
+		if err := o.postLoyalty(ctx, tx, rules, calc, redeemed); err != nil {
+			return nil, err
+		}
+	}
+	// uang tunai yang tinggal di laci masuk ke kas shift, jadi kas yang
+	// seharusnya ada dihitung langsung dari pembayaran order
+	if cash := roundCents(calc.Cash() - calc.Change()); cash > 0 {
+		query := `INSERT INTO shift_cash_movements (shift_id, type, amount, reference, created_by) VALUES ($1, $2, $3, $4, $5)`
+		if _, err := tx.ExecContext(ctx, query, s.ID, CashSale, cash, o.Number, in.CreatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// postLoyalty mencatat poin yang ditukar dan poin yang didapat pelanggan
+// order di ledger, keduanya dengan nomor order sebagai referensi.
+func (o *Order) postLoyalty(ctx context.Context, tx *sql.Tx, rules loyalty.Rules, calc order.Order, redeemed int64) error {
+	query := `INSERT INTO loyalty_ledger (customer_id, type, points, reference, expires_at, order_id) VALUES ($1, $2, $3, $4, $5, $6)`
+	if redeemed > 0 {
+		if _, err := tx.ExecContext(ctx, query, *o.CustomerID, loyalty.TypeBurn, -redeemed, o.Number, nil, o.ID); err != nil {
+			return err
+		}
+		o.PointsRedeemed = redeemed
+	}
+	if earned := rules.PointsFor(earnLines(calc)); earned > 0 {
+		if _, err := tx.ExecContext(ctx, query, *o.CustomerID, loyalty.TypeEarn, earned, o.Number, rules.ExpiresAt(o.CreatedAt), o.ID); err != nil {
+			return err
+		}
+		o.PointsEarned = earned
+	}
+	return nil
+}
+
+// earnLines adalah jumlah per kategori yang mendapat poin. Bagian order yang
+// dibayar dengan poin tidak menghasilkan poin baru, jadi setiap baris
+// dikurangi secara proporsional.
+func earnLines(calc order.Order) []loyalty.Line {
+	share := 1.0
+	if total := calc.Total(); total > 0 {
+		share = max(total-calc.PaidWith(order.TenderLoyalty), 0) / total
+	}
+	lines := make([]loyalty.Line, len(calc.Lines))
+	for i, l := range calc.Lines {
+		lines[i] = loyalty.Line{CategoryID: l.CategoryID, Amount: l.Total() * share}
+	}
+	return lines
+}
+
+// priceLine melengkapi baris dengan nama, kategori dan harga produk di
+// outlet, termasuk harga khusus outlet. Produk nonaktif tidak bisa dijual.
+func priceLine(ctx context.Context, q queryer, outletID string, in NewOrderLine) (order.Line, error) {
+	l := order.Line{ProductID: in.ProductID, Quantity: in.Quantity, Modifiers: in.Modifiers, Discount: in.Discount, Note: in.Note}
+	if l.Modifiers == nil {
+		l.Modifiers = []order.Modifier{}
+	}
+	query := `SELECT p.category_id, p.name, COALESCE(op.price, p.price)` + productsFrom(2) + `
+		WHERE p.id = $1 AND p.active AND ` + readableBy("p", 2)
+	err := q.QueryRowContext(ctx, query, in.ProductID, outletID).Scan(&l.CategoryID, &l.Name, &l.UnitPrice)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrProductUnavailable
+	}
+	return l, err
+}
+
+// GetByID returns the order with its lines, taxes, tenders and the name of
+// its cashier. Inside an outlet only its own orders are found.
+func (r *OrderRepository) GetByID(ctx context.Context, id string) (_ *Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.GetByID")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	var taxes []byte
+	query := `SELECT ` + orderColumns + `, o.taxes,
+			COALESCE((SELECT SUM(points) FROM loyalty_ledger l WHERE l.order_id = o.id AND l.type = 'earn' AND l.points > 0), 0),
+			COALESCE((SELECT -SUM(points) FROM loyalty_ledger l WHERE l.order_id = o.id AND l.type = 'burn' AND l.points < 0), 0),
+			COALESCE((SELECT name FROM users u WHERE u.id = o.created_by), '')
+		FROM orders o WHERE o.id = $1 AND ` + orderVisible(2)
+	var earned, redeemed int64
+	var cashier string
+	o, err := scanOrder(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)), &taxes, &earned, &redeemed, &cashier)
+	if err != nil {
+		return nil, err
+	}
+	o.PointsEarned, o.PointsRedeemed, o.Cashier = earned, redeemed, cashier
+	if err := json.Unmarshal(taxes, &o.Taxes); err != nil {
+		return nil, err
+	}
+	if o.Lines, err = orderLines(ctx, r.db, o.ID); err != nil {
+		return nil, err
+	}
+	if o.Tenders, err = orderTenders(ctx, r.db, o.ID); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func orderLines(ctx context.Context, db rowsQueryer, orderID string) ([]OrderLine, error) {
+	query := `SELECT id, COALESCE(product_id::text, ''), COALESCE(category_id::text, ''), name, quantity, unit_price,
+			modifiers, discount, total, note
+		FROM order_lines WHERE order_id = $1 ORDER BY line_no`
+	rows, err := db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []OrderLine
+	for rows.Next() {
+		var l OrderLine
+		var modifiers []byte
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.CategoryID, &l.Name, &l.Quantity, &l.UnitPrice,
+			&modifiers, &l.Discount, &l.Total, &l.Note); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(modifiers, &l.Modifiers); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+func orderTenders(ctx context.Context, db rowsQueryer, orderID string) ([]order.Tender, error) {
+	rows, err := db.QueryContext(ctx, `SELECT method, amount, reference, points FROM order_tenders WHERE order_id = $1 ORDER BY id`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenders := []order.Tender{}
+	for rows.Next() {
+		var t order.Tender
+		if err := rows.Scan(&t.Method, &t.Amount, &t.Reference, &t.Points); err != nil {
+			return nil, err
+		}
+		tenders = append(tenders, t)
+	}
+	return tenders, rows.Err()
+}
+
+// GetAll returns orders without their lines, newest first. Inside an
+// outlet only its own orders are listed.
+func (r *OrderRepository) GetAll(ctx context.Context, f OrderFilter) (orders []Order, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.GetAll")
+	defer func() { endSpan(span, len(orders), err) }()
+
+	query := `SELECT ` + orderColumns + ` FROM orders o
+		WHERE ($1 = '' OR o.customer_id::text = $1) AND ($2 = '' OR o.shift_id::text = $2) AND ($3 = '' OR o.number = $3)
+			AND ` + orderVisible(4) + `
+		ORDER BY o.created_at DESC
+		LIMIT NULLIF($5, 0)`
+	rows, err := r.db.QueryContext(ctx, query, f.CustomerID, f.ShiftID, f.Number, outletParam(ctx), f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *o)
+	}
+	return orders, rows.Err()
+}
+
+// PurchaseHistory adalah ringkasan belanja seorang pelanggan. NetSpend
+// adalah TotalSpend dikurangi refund atas order-order tersebut.
+type PurchaseHistory struct {
+	CustomerID   string     `json:"customer_id"`
+	Orders       int        `json:"orders"`
+	TotalSpend   float64    `json:"total_spend"`
+	Refunded     float64    `json:"refunded"`
+	NetSpend     float64    `json:"net_spend"`
+	AverageOrder float64    `json:"average_order"`
+	FirstVisit   *time.Time `json:"first_visit"`
+	LastVisit    *time.Time `json:"last_visit"`
+	RecentOrders []Order    `json:"recent_orders"`
+}
+
+// CustomerHistory returns the purchase summary of a customer with their
+// latest orders, at most limit. Inside an outlet only its own orders count.
+func (r *OrderRepository) CustomerHistory(ctx context.Context, customerID string, limit int) (_ *PurchaseHistory, err error) {
+	ctx, span := startSpan(ctx, "OrderRepository.CustomerHistory")
+	defer func() { endSpan(span, -1, err) }()
+
+	h := &PurchaseHistory{CustomerID: customerID}
+	var first, last sql.NullTime
+	query := `SELECT COUNT(*), COALESCE(SUM(o.total), 0), MIN(o.created_at), MAX(o.created_at),
+			COALESCE((SELECT SUM(f.amount) FROM refunds f JOIN orders fo ON fo.id = f.order_id
+				WHERE fo.customer_id = $1 AND ($2::uuid IS NULL OR fo.outlet_id = $2)), 0)
+		FROM orders o WHERE o.customer_id = $1 AND ` + orderVisible(2)
+	err = r.db.QueryRowContext(ctx, query, customerID, outletParam(ctx)).Scan(&h.Orders, &h.TotalSpend, &first, &last, &h.Refunded)
+	if err != nil {
+		return nil, err
+	}
+	if first.Valid {
+		h.FirstVisit, h.LastVisit = &first.Time, &last.Time
+	}
+	h.NetSpend = roundCents(h.TotalSpend - h.Refunded)
+	if h.Orders > 0 {
+		h.AverageOrder = roundCents(h.TotalSpend / float64(h.Orders))
+	}
+	if h.RecentOrders, err = r.GetAll(ctx, OrderFilter{CustomerID: customerID, Limit: limit}); err != nil {
+		return nil, err
+	}
+	if h.RecentOrders == nil {
+		h.RecentOrders = []Order{}
+	}
+	return h, nil
+}
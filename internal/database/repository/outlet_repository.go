@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrHeadOfficeOnly = errors.New("only the head office can manage outlets")
+	ErrOutletInUse    = errors.New("outlet still has its own categories, products, shifts or Z-reports")
+)
+
+type Outlet struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// UseCentralCatalog false berarti outlet hanya melihat kategori dan
+	// produknya sendiri.
+	UseCentralCatalog bool      `json:"use_central_catalog"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type OutletRepository struct {
+	db *sql.DB
+}
+
+func NewOutletRepository(db *sql.DB) *OutletRepository {
+	return &OutletRepository{db}
+}
+
+const outletColumns = `id, name, address, use_central_catalog, created_at`
+
+func scanOutlet(row interface{ Scan(...any) error }) (*Outlet, error) {
+	var o Outlet
+	if err := row.Scan(&o.ID, &o.Name, &o.Address, &o.UseCentralCatalog, &o.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// headOffice memastikan ctx tidak membawa outlet; outlet tidak boleh
+// mengelola outlet lain maupun dirinya sendiri.
+func headOffice(ctx context.Context) error {
+	if outletParam(ctx).Valid {
+		return ErrHeadOfficeOnly
+	}
+	return nil
+}
+
+func (r *OutletRepository) Create(ctx context.Context, o Outlet) (id string, err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
+
+	if err := headOffice(ctx); err != nil {
+		return "", err
+	}
+	query := `INSERT INTO outlets (name, address, use_central_catalog) VALUES ($1, $2, $3) RETURNING id`
+	err = r.db.QueryRowContext(ctx, query, o.Name, o.Address, o.UseCentralCatalog).Scan(&id)
+	return id, err
+}
+
+// GetAll returns the outlets ordered by name. Inside an outlet scope only
+// that outlet is returned.
+func (r *OutletRepository) GetAll(ctx context.Context) (outlets []Outlet, err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.GetAll")
+	defer func() { endSpan(span, len(outlets), err) }()
+
+	query := `SELECT ` + outletColumns + ` FROM outlets WHERE $1::uuid IS NULL OR id = $1 ORDER BY name`
+	rows, err := r.db.QueryContext(ctx, query, outletParam(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := scanOutlet(rows)
+		if err != nil {
+			return nil, err
+		}
+		outlets = append(outlets, *o)
+	}
+	return outlets, rows.Err()
+}
+
+func (r *OutletRepository) GetByID(ctx context.Context, id string) (_ *Outlet, err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.GetByID")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + outletColumns + ` FROM outlets WHERE id = $1 AND ($2::uuid IS NULL OR id = $2)`
+	return scanOutlet(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)))
+}
+
+// Update replaces the name, address and catalog setting of an outlet.
+func (r *OutletRepository) Update(ctx context.Context, o Outlet) (err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.Update")
+	defer func() { endSpan(span, -1, err) }()
+
+	if err := headOffice(ctx); err != nil {
+		return err
+	}
+	query := `UPDATE outlets SET name = $2, address = $3, use_central_catalog = $4 WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, o.ID, o.Name, o.Address, o.UseCentralCatalog)
+	return affectedOne(res, err)
+}
+
+// Delete menghapus outlet beserta penugasan user dan harga khususnya.
+// ErrOutletInUse dikembalikan bila outlet masih punya kategori atau produk
+// sendiri, atau riwayat shift kasir dan laporan tutup hari.
+func (r *OutletRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.Delete")
+	defer func() { endSpan(span, -1, err) }()
+
+	if err := headOffice(ctx); err != nil {
+		return err
+	}
+	var inUse bool
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE outlet_id = $1)
+		OR EXISTS (SELECT 1 FROM products WHERE outlet_id = $1)
+		OR EXISTS (SELECT 1 FROM shifts WHERE outlet_id = $1)
+		OR EXISTS (SELECT 1 FROM z_reports WHERE outlet_id = $1)`
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&inUse); err != nil {
+		return err
+	}
+	if inUse {
+		return ErrOutletInUse
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM outlets WHERE id = $1`, id)
+	return affectedOne(res, err)
+}
+
+// AssignUser memberi user akses ke outlet. Menugaskan ulang user yang sama
+// tidak mengubah apa pun. sql.ErrNoRows dikembalikan bila user atau
+// outletnya tidak ada.
+func (r *OutletRepository) AssignUser(ctx context.Context, outletID string, userID int) (err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.AssignUser")
+	defer func() { endSpan(span, -1, err) }()
+
+	if err := headOffice(ctx); err != nil {
+		return err
+	}
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM outlets WHERE id = $1) AND EXISTS (SELECT 1 FROM users WHERE id = $2)`
+	if err := r.db.QueryRowContext(ctx, query, outletID, userID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return sql.ErrNoRows
+	}
+	query = `INSERT INTO user_outlets (user_id, outlet_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err = r.db.ExecContext(ctx, query, userID, outletID)
+	return err
+}
+
+func (r *OutletRepository) UnassignUser(ctx context.Context, outletID string, userID int) (err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.UnassignUser")
+	defer func() { endSpan(span, -1, err) }()
+
+	if err := headOffice(ctx); err != nil {
+		return err
+	}
+	res, err := r.db.ExecContext(ctx, `DELETE FROM user_outlets WHERE user_id = $1 AND outlet_id = $2`, userID, outletID)
+	return affectedOne(res, err)
+}
+
+// IDsForUser mengembalikan outlet tempat user ditugaskan.
+func (r *OutletRepository) IDsForUser(ctx context.Context, userID int) (ids []string, err error) {
+	ctx, span := startSpan(ctx, "OutletRepository.IDsForUser")
+	defer func() { endSpan(span, len(ids), err) }()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT outlet_id FROM user_outlets WHERE user_id = $1 ORDER BY outlet_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// affectedOne mengubah hasil Exec yang tidak mengenai baris apa pun menjadi
+// sql.ErrNoRows.
+func affectedOne(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
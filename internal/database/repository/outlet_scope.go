@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"maspos-be-go/internal/scope"
+)
+
+// ErrSharedCatalog is returned when an outlet tries to change a category or
+// product of the central catalog, which only the head office may edit.
+var ErrSharedCatalog = errors.New("item belongs to the central catalog and cannot be changed by an outlet")
+
+// outletParam mengembalikan outlet dari ctx sebagai parameter query; NULL
+// untuk kantor pusat.
+func outletParam(ctx context.Context) sql.NullString {
+	id := scope.Outlet(ctx)
+	return sql.NullString{String: id, Valid: id != ""}
+}
+
+// readableBy adalah kondisi untuk baris alias yang terlihat oleh outlet di
+// parameter $n: baris milik outlet itu sendiri, ditambah katalog pusat bila
+// outlet memakai katalog pusat. Kantor pusat (NULL) hanya melihat katalog
+// pusat.
+func readableBy(alias string, n int) string {
+	return fmt.Sprintf(`(%[1]s.outlet_id IS NOT DISTINCT FROM $%[2]d::uuid
+		OR (%[1]s.outlet_id IS NULL AND EXISTS (SELECT 1 FROM outlets o WHERE o.id = $%[2]d::uuid AND o.use_central_catalog)))`, alias, n)
+}
+
+// ownedBy adalah kondisi untuk baris alias yang boleh diubah outlet di
+// parameter $n, yaitu hanya miliknya sendiri.
+func ownedBy(alias string, n int) string {
+	return fmt.Sprintf(`%s.outlet_id IS NOT DISTINCT FROM $%d::uuid`, alias, n)
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Position is the display position of one category or product.
+type Position struct {
+	ID       string `json:"id" binding:"required,uuid"`
+	Position int    `json:"position" binding:"gte=0"`
+}
+
+// reorder mengubah posisi banyak baris sekaligus dalam satu statement dan
+// menaikkan versinya. Bila ada ID yang tidak ditemukan, tidak ada yang
+// diubah dan sql.ErrNoRows dikembalikan. Hanya baris milik outlet di ctx
+// yang dihitung ada.
+func reorder(ctx context.Context, db *sql.DB, table string, positions []Position) error {
+	ids := make([]string, len(positions))
+	values := make([]int32, len(positions))
+	for i, p := range positions {
+		ids[i], values[i] = p.ID, int32(p.Position)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		UPDATE %s t SET position = v.position, version = t.version + 1
+		FROM unnest($1::uuid[], $2::int[]) AS v(id, position)
+		WHERE t.id = v.id AND %s`, table, ownedBy("t", 3))
+	res, err := tx.ExecContext(ctx, query, ids, values, outletParam(ctx))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if int(n) != len(positions) {
+		return fmt.Errorf("%s: some IDs do not exist: %w", table, sql.ErrNoRows)
+	}
+	return tx.Commit()
+}
@@ -3,6 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrSKUTaken         = errors.New("SKU is already used by another product")
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrOutletRequired   = errors.New("this operation needs an outlet")
 )
 
 type ProductRepository struct {
@@ -10,59 +18,381 @@ type ProductRepository struct {
 }
 type Product struct {
 	ID         string  `json:"id"`
+	SKU        string  `json:"sku"`
 	CategoryID string  `json:"category_id"`
 	Name       string  `json:"name"`
 	Price      float64 `json:"price"`
 	Picture    string  `json:"picture"`
+	Position   int     `json:"position"`
+	Color      string  `json:"color"`
+	Icon       string  `json:"icon"`
+	Active     bool    `json:"active"`
+	Version    int     `json:"version"`
+	// OutletID kosong (null) untuk produk katalog pusat.
+	OutletID *string `json:"outlet_id"`
 }
 
 func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db}
 }
 
-func (r *ProductRepository) Create(ctx context.Context, categoryID, name string, price float64, picture string) (string, error) {
-	var id string
-	query := `INSERT INTO products (category_id, name, price, picture) VALUES ($1, $2, $3, $4) RETURNING id`
-	err := r.db.QueryRowContext(ctx, query, categoryID, name, price, picture).Scan(&id)
+// SKU kosong disimpan sebagai NULL supaya tidak bentrok di indeks unik.
+// Harga memakai harga khusus outlet bila ada (lihat productsFrom).
+const productColumns = `p.id, COALESCE(p.sku, ''), p.category_id, p.name, COALESCE(op.price, p.price), p.picture, p.position, p.color, p.icon, p.active, p.version, p.outlet_id`
+
+// productsFrom adalah klausa FROM untuk productColumns; $n berisi outlet
+// yang harga khususnya dipakai.
+func productsFrom(n int) string {
+	return fmt.Sprintf(` FROM products p LEFT JOIN product_outlet_prices op ON op.product_id = p.id AND op.outlet_id = $%d::uuid`, n)
+}
+
+func scanProduct(row interface{ Scan(...any) error }, extra ...any) (*Product, error) {
+	var p Product
+	var outletID sql.NullString
+	dest := append([]any{&p.ID, &p.SKU, &p.CategoryID, &p.Name, &p.Price, &p.Picture, &p.Position, &p.Color, &p.Icon, &p.Active, &p.Version, &outletID}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	p.OutletID = nullableString(outletID)
+	return &p, nil
+}
+
+// checkCategory memastikan kategori ada dan terlihat oleh outlet di ctx.
+func checkCategory(ctx context.Context, db queryer, categoryID string) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1 AND ` + readableBy("categories", 2) + `)`
+	if err := db.QueryRowContext(ctx, query, categoryID, outletParam(ctx)).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// Create menyimpan produk baru milik outlet di ctx. ErrCategoryNotFound
+// dikembalikan bila kategorinya tidak terlihat oleh outlet tersebut, dan
+// ErrSKUTaken bila p.SKU sudah dipakai produk lain di outlet yang sama.
+func (r *ProductRepository) Create(ctx context.Context, p Product) (id string, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
+
+	if err := checkCategory(ctx, r.db, p.CategoryID); err != nil {
+		return "", err
+	}
+	query := `INSERT INTO products (sku, category_id, name, price, picture, position, color, icon, active, outlet_id)
+		SELECT NULLIF($1, ''), $2, $3, $4, $5, $6, $7, $8, $9, $10
+		WHERE $1 = '' OR NOT EXISTS (SELECT 1 FROM products p WHERE p.sku = $1 AND ` + ownedBy("p", 10) + `)
+		RETURNING id`
+	err = r.db.QueryRowContext(ctx, query, p.SKU, p.CategoryID, p.Name, p.Price, p.Picture, p.Position, p.Color, p.Icon, p.Active, outletParam(ctx)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrSKUTaken
+	}
 	return id, err
 }
-func (r *ProductRepository) GetAll(ctx context.Context) ([]Product, error) {
-	query := `SELECT id, category_id, name, price, picture FROM products`
-	rows, err := r.db.QueryContext(ctx, query)
+
+// ProductFilter narrows GetAll. An empty CategoryID lists every category;
+// Descendants also includes subcategories of CategoryID. ActiveOnly keeps
+// only products that are active and whose category is visible (see
+// visibleCategories).
+type ProductFilter struct {
+	CategoryID  string
+	Descendants bool
+	ActiveOnly  bool
+}
+
+// productFilterCTEs dan productFilterWhere menerapkan ProductFilter pada
+// products p dengan parameter $1 CategoryID, $2 Descendants, $3 ActiveOnly,
+// dan membatasinya ke produk yang terlihat oleh outlet di $4.
+const productFilterCTEs = visibleCategories + `,
+		tree AS (
+			SELECT id FROM categories WHERE id = NULLIF($1, '')::uuid
+			UNION ALL
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE $2
+		)`
+
+var productFilterWhere = `
+		WHERE ($1 = '' OR p.category_id IN (SELECT id FROM tree))
+		  AND (NOT $3 OR (p.active AND p.category_id IN (SELECT id FROM visible)))
+		  AND ` + readableBy("p", 4)
+
+// GetAll returns products ordered for the POS grid: by position, then name.
+func (r *ProductRepository) GetAll(ctx context.Context, f ProductFilter) (products []Product, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.GetAll")
+	defer func() { endSpan(span, len(products), err) }()
+
+	query := `WITH RECURSIVE ` + productFilterCTEs + `
+		SELECT ` + productColumns + productsFrom(4) + productFilterWhere + `
+		ORDER BY p.position, p.name`
+	rows, err := r.db.QueryContext(ctx, query, f.CategoryID, f.Descendants, f.ActiveOnly, outletParam(ctx))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var products []Product
 	for rows.Next() {
-		var p Product
-		if err := rows.Scan(&p.ID, &p.CategoryID, &p.Name, &p.Price, &p.Picture); err != nil {
+		p, err := scanProduct(rows)
+		if err != nil {
 			return nil, err
 		}
-		products = append(products, p)
+		products = append(products, *p)
 	}
-	return products, nil
+	return products, rows.Err()
 }
 
-func (r *ProductRepository) GetByID(ctx context.Context, id string) (*Product, error) {
-	var p Product
-	query := `SELECT id, category_id, name, price, picture FROM products WHERE id = $1`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&p.ID, &p.CategoryID, &p.Name, &p.Price, &p.Picture)
+func (r *ProductRepository) GetByID(ctx context.Context, id string) (_ *Product, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.GetByID")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + productColumns + productsFrom(2) + ` WHERE p.id = $1 AND ` + readableBy("p", 2)
+	return scanProduct(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)))
+}
+
+// ProductExport is a product with the full path of its category, such as
+// "Minuman > Kopi".
+type ProductExport struct {
+	Product
+	CategoryPath string
+}
+
+// Export memanggil fn untuk setiap produk yang cocok dengan f, diurutkan per
+// kategori lalu posisi. Baris dibaca satu per satu dari database sehingga
+// katalog sebesar apa pun tidak dimuat sekaligus ke memori. Error dari fn
+// menghentikan iterasi dan dikembalikan apa adanya.
+func (r *ProductRepository) Export(ctx context.Context, f ProductFilter, fn func(ProductExport) error) (err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.Export")
+	n := 0
+	defer func() { endSpan(span, n, err) }()
+
+	query := `WITH RECURSIVE ` + productFilterCTEs + `, ` + categoryPaths + `
+		SELECT ` + productColumns + `, cp.path` + productsFrom(4) + `
+		JOIN paths cp ON cp.id = p.category_id` + productFilterWhere + `
+		ORDER BY cp.path, p.position, p.name`
+	rows, err := r.db.QueryContext(ctx, query, f.CategoryID, f.Descendants, f.ActiveOnly, outletParam(ctx))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &p, nil
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		p, err := scanProduct(rows, &path)
+		if err != nil {
+			return err
+		}
+		if err := fn(ProductExport{Product: *p, CategoryPath: path}); err != nil {
+			return err
+		}
+		n++
+	}
+	return rows.Err()
+}
+
+// ProductChanges lists the fields of a partial update; nil fields are left
+// unchanged.
+type ProductChanges struct {
+	SKU        *string
+	CategoryID *string
+	Name       *string
+	Price      *float64
+	Picture    *string
+	Position   *int
+	Color      *string
+	Icon       *string
+	Active     *bool
+}
+
+// Update mengubah field produk yang diisi di changes dan mengembalikan versi
+// barunya. version 0 berarti tanpa pemeriksaan versi; selain itu
+// ErrVersionConflict dikembalikan bila produk sudah diubah orang lain,
+// ErrSKUTaken bila SKU barunya sudah dipakai produk lain di outlet yang sama,
+// dan ErrSharedCatalog bila outlet mencoba mengubah produk katalog pusat.
+func (r *ProductRepository) Update(ctx context.Context, id string, changes ProductChanges, version int) (newVersion int, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.Update")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	var b updateBuilder
+	if changes.SKU != nil {
+		if *changes.SKU != "" {
+			var taken bool
+			query := `SELECT EXISTS (SELECT 1 FROM products p WHERE p.sku = $1 AND p.id <> $2 AND ` + ownedBy("p", 3) + `)`
+			if err := r.db.QueryRowContext(ctx, query, *changes.SKU, id, outletParam(ctx)).Scan(&taken); err != nil {
+				return 0, err
+			}
+			if taken {
+				return 0, ErrSKUTaken
+			}
+		}
+		b.set("sku", sql.NullString{String: *changes.SKU, Valid: *changes.SKU != ""})
+	}
+	if changes.CategoryID != nil {
+		if err := checkCategory(ctx, r.db, *changes.CategoryID); err != nil {
+			return 0, err
+		}
+		b.set("category_id", *changes.CategoryID)
+	}
+	if changes.Name != nil {
+		b.set("name", *changes.Name)
+	}
+	if changes.Price != nil {
+		b.set("price", *changes.Price)
+	}
+	if changes.Picture != nil {
+		b.set("picture", *changes.Picture)
+	}
+	if changes.Position != nil {
+		b.set("position", *changes.Position)
+	}
+	if changes.Color != nil {
+		b.set("color", *changes.Color)
+	}
+	if changes.Icon != nil {
+		b.set("icon", *changes.Icon)
+	}
+	if changes.Active != nil {
+		b.set("active", *changes.Active)
+	}
+	return updateVersioned(ctx, r.db, "products", id, version, b)
+}
+
+// Delete menghapus produk dengan aturan versi yang sama seperti Update.
+func (r *ProductRepository) Delete(ctx context.Context, id string, version int) (err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.Delete")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `DELETE FROM products WHERE id = $1 AND ($2 = 0 OR version = $2) AND ` + ownedBy("products", 3)
+	res, err := r.db.ExecContext(ctx, query, id, version, outletParam(ctx))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return versionMismatch(ctx, r.db, "products", id)
+	}
+	return nil
+}
+
+// Reorder sets the position of several products at once.
+func (r *ProductRepository) Reorder(ctx context.Context, positions []Position) (err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.Reorder")
+	defer func() { endSpan(span, len(positions), err) }()
+
+	return reorder(ctx, r.db, "products", positions)
+}
+
+// ProductImport is one row of a catalog import, matched to an existing
+// product of the same outlet by SKU. Nil optional fields keep the current value of an existing
+// product and take the column default for a new one.
+type ProductImport struct {
+	SKU        string
+	CategoryID string
+	Name       string
+	Price      float64
+	Position   *int
+	Color      *string
+	Icon       *string
+	Active     *bool
 }
 
-func (r *ProductRepository) Update(ctx context.Context, id string, categoryID string, name string, price float64, picture string) error {
-	query := `UPDATE products SET category_id=$1, name=$2, price=$3, picture=$4 WHERE id=$5`
-	_, err := r.db.ExecContext(ctx, query, categoryID, name, price, picture, id)
-	return err
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
 }
 
-func (r *ProductRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM products WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
-}
\ No newline at end of file
+// Import menyimpan semua item dalam satu transaksi: produk dengan SKU yang
+// sudah ada diperbarui, sisanya dibuat baru. Bila satu baris gagal tidak ada
+// yang tersimpan. dryRun menjalankan semuanya lalu me-rollback, sehingga
+// hasilnya menunjukkan apa yang akan terjadi.
+func (r *ProductRepository) Import(ctx context.Context, items []ProductImport, dryRun bool) (res ImportResult, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.Import")
+	defer func() { endSpan(span, len(items), err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO products (sku, category_id, name, price, picture, position, color, icon, active, outlet_id)
+		VALUES ($1, $2, $3, $4, '', COALESCE($5, 0), COALESCE($6, ''), COALESCE($7, ''), COALESCE($8, true), $9)
+		ON CONFLICT (tenant_id, (COALESCE(outlet_id, '00000000-0000-0000-0000-000000000000'::uuid)), sku) DO UPDATE SET
+			category_id = EXCLUDED.category_id,
+			name = EXCLUDED.name,
+			price = EXCLUDED.price,
+			position = COALESCE($5, products.position),
+			color = COALESCE($6, products.color),
+			icon = COALESCE($7, products.icon),
+			active = COALESCE($8, products.active),
+			version = products.version + 1
+		RETURNING xmax = 0`)
+	if err != nil {
+		return res, err
+	}
+	defer stmt.Close()
+
+	outlet := outletParam(ctx)
+	for _, it := range items {
+		var inserted bool
+		err := stmt.QueryRowContext(ctx, it.SKU, it.CategoryID, it.Name, it.Price, it.Position, it.Color, it.Icon, it.Active, outlet).Scan(&inserted)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("import SKU %s: %w", it.SKU, err)
+		}
+		if inserted {
+			res.Created++
+		} else {
+			res.Updated++
+		}
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	return res, tx.Commit()
+}
+
+// SetOutletPrice menyimpan harga khusus outlet di ctx untuk produk id, yang
+// menggantikan harga produk di setiap query outlet tersebut. Versi produk
+// ikut naik supaya ETag-nya berubah. sql.ErrNoRows dikembalikan bila
+// produknya tidak terlihat oleh outlet, ErrOutletRequired bila ctx tidak
+// membawa outlet.
+func (r *ProductRepository) SetOutletPrice(ctx context.Context, id string, price float64) (newVersion int, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.SetOutletPrice")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	return r.changeOutletPrice(ctx, id, `
+		INSERT INTO product_outlet_prices (product_id, outlet_id, price) VALUES ($1, $2, $3)
+		ON CONFLICT (product_id, outlet_id) DO UPDATE SET price = EXCLUDED.price`, price)
+}
+
+// DeleteOutletPrice menghapus harga khusus outlet di ctx sehingga produk
+// kembali memakai harganya sendiri.
+func (r *ProductRepository) DeleteOutletPrice(ctx context.Context, id string) (newVersion int, err error) {
+	ctx, span := startSpan(ctx, "ProductRepository.DeleteOutletPrice")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	return r.changeOutletPrice(ctx, id, `DELETE FROM product_outlet_prices WHERE product_id = $1 AND outlet_id = $2`)
+}
+
+func (r *ProductRepository) changeOutletPrice(ctx context.Context, id, stmt string, args ...any) (newVersion int, err error) {
+	outlet := outletParam(ctx)
+	if !outlet.Valid {
+		return 0, ErrOutletRequired
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE products p SET version = p.version + 1 WHERE p.id = $1 AND ` + readableBy("p", 2) + ` RETURNING p.version`
+	if err := tx.QueryRowContext(ctx, query, id, outlet).Scan(&newVersion); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, stmt, append([]any{id, outlet}, args...)...); err != nil {
+		return 0, err
+	}
+	return newVersion, tx.Commit()
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"maspos-be-go/internal/ratelimit"
+)
+
+// rateLimitSweepInterval adalah jarak minimal antar penghapusan baris yang
+// sudah kedaluwarsa oleh satu replika.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitRepository adalah ratelimit.Store di PostgreSQL sehingga semua
+// replika API berbagi bucket dan penguncian login yang sama. Waktu diambil
+// dari now() database agar jam replika yang berbeda tidak berpengaruh.
+type RateLimitRepository struct {
+	db        *sql.DB
+	lastSweep atomic.Int64
+}
+
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+func (r *RateLimitRepository) Allow(ctx context.Context, key string, l ratelimit.Limit) (d ratelimit.Decision, err error) {
+	ctx, span := startSpan(ctx, "RateLimitRepository.Allow")
+	defer func() { endSpan(span, -1, err) }()
+
+	r.sweep(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return d, err
+	}
+	defer tx.Rollback()
+
+	// baris baru dibuat penuh supaya SELECT ... FOR UPDATE selalu punya baris
+	// untuk dikunci, juga saat dua request pertama datang bersamaan
+	query := `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at, expires_at)
+		VALUES ($1, $2, now(), now())
+		ON CONFLICT (key) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, key, float64(l.Burst)); err != nil {
+		return d, err
+	}
+
+	var b ratelimit.Bucket
+	var now time.Time
+	query = `SELECT tokens, updated_at, now() FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, query, key).Scan(&b.Tokens, &b.Updated, &now); err != nil {
+		return d, err
+	}
+
+	d = b.Take(l, now)
+
+	query = `UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3, expires_at = $4 WHERE key = $1`
+	if _, err := tx.ExecContext(ctx, query, key, b.Tokens, b.Updated, b.FullAt(l)); err != nil {
+		return d, err
+	}
+	return d, tx.Commit()
+}
+
+func (r *RateLimitRepository) LockedFor(ctx context.Context, key string) (locked time.Duration, err error) {
+	ctx, span := startSpan(ctx, "RateLimitRepository.LockedFor")
+	defer func() { endSpan(span, -1, err) }()
+
+	var f ratelimit.Failures
+	var lockedUntil sql.NullTime
+	var now time.Time
+	query := `SELECT locked_until, now() FROM login_failures WHERE key = $1`
+	err = r.db.QueryRowContext(ctx, query, key).Scan(&lockedUntil, &now)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if lockedUntil.Valid {
+		f.LockedUntil = lockedUntil.Time
+	}
+	return f.LockedFor(now), nil
+}
+
+func (r *RateLimitRepository) RecordFailure(ctx context.Context, key string, p ratelimit.Lockout) (locked time.Duration, err error) {
+	ctx, span := startSpan(ctx, "RateLimitRepository.RecordFailure")
+	defer func() { endSpan(span, -1, err) }()
+
+	r.sweep(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO login_failures (key, failures, last_failure_at, expires_at)
+		VALUES ($1, 0, now(), now())
+		ON CONFLICT (key) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, query, key); err != nil {
+		return 0, err
+	}
+
+	var f ratelimit.Failures
+	var lockedUntil sql.NullTime
+	var now time.Time
+	query = `SELECT failures, last_failure_at, locked_until, now() FROM login_failures WHERE key = $1 FOR UPDATE`
+	if err := tx.QueryRowContext(ctx, query, key).Scan(&f.Count, &f.Last, &lockedUntil, &now); err != nil {
+		return 0, err
+	}
+	if lockedUntil.Valid {
+		f.LockedUntil = lockedUntil.Time
+	}
+
+	locked = f.Record(p, now)
+
+	var until *time.Time
+	if !f.LockedUntil.IsZero() {
+		until = &f.LockedUntil
+	}
+	query = `
+		UPDATE login_failures
+		SET failures = $2, last_failure_at = $3, locked_until = $4, expires_at = $5
+		WHERE key = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, key, f.Count, f.Last, until, f.ExpiresAt(p)); err != nil {
+		return 0, err
+	}
+	return locked, tx.Commit()
+}
+
+func (r *RateLimitRepository) ResetFailures(ctx context.Context, key string) (err error) {
+	ctx, span := startSpan(ctx, "RateLimitRepository.ResetFailures")
+	defer func() { endSpan(span, -1, err) }()
+
+	_, err = r.db.ExecContext(ctx, `DELETE FROM login_failures WHERE key = $1`, key)
+	return err
+}
+
+// sweep menghapus baris yang sudah kedaluwarsa, paling sering sekali per
+// rateLimitSweepInterval. Kegagalannya diabaikan; baris yang tertinggal
+// tetap benar karena hanya berisi bucket penuh atau kegagalan yang usang.
+func (r *RateLimitRepository) sweep(ctx context.Context) {
+	now := time.Now().UnixNano()
+	last := r.lastSweep.Load()
+	if now-last < int64(rateLimitSweepInterval) || !r.lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+	r.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE expires_at < now()`)
+	r.db.ExecContext(ctx, `DELETE FROM login_failures WHERE expires_at < now()`)
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"maspos-be-go/internal/receipt"
+)
+
+// Status pengiriman struk email.
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailFailed  = "failed"
+)
+
+// ReceiptEmail adalah satu permintaan kirim struk ke satu alamat email.
+type ReceiptEmail struct {
+	ID       string  `json:"id"`
+	OutletID string  `json:"outlet_id"`
+	OrderID  *string `json:"order_id"`
+	Number   string  `json:"number"`
+	Email    string  `json:"email"`
+	// Receipt hanya diisi oleh Claim; daftar pengiriman tidak memuatnya.
+	Receipt       *receipt.Receipt `json:"-"`
+	Status        string           `json:"status"`
+	Attempts      int              `json:"attempts"`
+	NextAttemptAt time.Time        `json:"next_attempt_at"`
+	LastError     string           `json:"last_error,omitempty"`
+	SentAt        *time.Time       `json:"sent_at,omitempty"`
+	CreatedBy     *int             `json:"created_by,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+type ReceiptEmailRepository struct {
+	db *sql.DB
+}
+
+func NewReceiptEmailRepository(db *sql.DB) *ReceiptEmailRepository {
+	return &ReceiptEmailRepository{db}
+}
+
+// ReceiptEmailFilter narrows GetAll; empty fields do not filter.
+type ReceiptEmailFilter struct {
+	Number  string
+	OrderID string
+}
+
+const receiptEmailColumns = `id, outlet_id, order_id, number, email, status, attempts, next_attempt_at, last_error, sent_at, created_by, created_at`
+
+func scanReceiptEmail(row interface{ Scan(...any) error }, extra ...any) (*ReceiptEmail, error) {
+	var (
+		e         ReceiptEmail
+		orderID   sql.NullString
+		sentAt    sql.NullTime
+		createdBy sql.NullInt64
+	)
+	dest := append([]any{&e.ID, &e.OutletID, &orderID, &e.Number, &e.Email, &e.Status, &e.Attempts,
+		&e.NextAttemptAt, &e.LastError, &sentAt, &createdBy, &e.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if sentAt.Valid {
+		e.SentAt = &sentAt.Time
+	}
+	e.OrderID, e.CreatedBy = nullableString(orderID), nullableInt(createdBy)
+	return &e, nil
+}
+
+// Enqueue mengantrekan struk rc dari order o untuk dikirim ke email.
+// Pengiriman dicatat di outlet order tersebut.
+func (r *ReceiptEmailRepository) Enqueue(ctx context.Context, o *Order, email string, rc receipt.Receipt, userID *int) (id string, err error) {
+	ctx, span := startSpan(ctx, "ReceiptEmailRepository.Enqueue")
+	defer func() { endSpan(span, -1, err) }()
+
+	payload, err := json.Marshal(rc)
+	if err != nil {
+		return "", err
+	}
+	query := `INSERT INTO receipt_emails (outlet_id, order_id, number, email, receipt, created_by)
+		VALUES ($1, $2, $3, $4, $5::jsonb, $6) RETURNING id`
+	err = r.db.QueryRowContext(ctx, query, o.OutletID, o.ID, rc.Number, email, string(payload), userID).Scan(&id)
+	return id, err
+}
+
+// GetAll returns the deliveries visible from ctx, newest first.
+func (r *ReceiptEmailRepository) GetAll(ctx context.Context, f ReceiptEmailFilter) (emails []ReceiptEmail, err error) {
+	ctx, span := startSpan(ctx, "ReceiptEmailRepository.GetAll")
+	defer func() { endSpan(span, len(emails), err) }()
+
+	query := `SELECT ` + receiptEmailColumns + ` FROM receipt_emails
+		WHERE ($1::uuid IS NULL OR outlet_id = $1) AND ($2 = '' OR number = $2) AND ($3 = '' OR order_id::text = $3)
+		ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, outletParam(ctx), f.Number, f.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanReceiptEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, *e)
+	}
+	return emails, rows.Err()
+}
+
+// Resend mengantrekan ulang struk dari pengiriman id, ke alamat email atau ke
+// alamat semula bila email kosong. Pengiriman lama tetap tersimpan sebagai
+// riwayat.
+func (r *ReceiptEmailRepository) Resend(ctx context.Context, id, email string, userID *int) (newID string, err error) {
+	ctx, span := startSpan(ctx, "ReceiptEmailRepository.Resend")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `INSERT INTO receipt_emails (outlet_id, order_id, number, email, receipt, created_by)
+		SELECT outlet_id, order_id, number, COALESCE(NULLIF($2, ''), email), receipt, $3
+		FROM receipt_emails WHERE id = $1 AND ($4::uuid IS NULL OR outlet_id = $4)
+		RETURNING id`
+	err = r.db.QueryRowContext(ctx, query, id, email, userID, outletParam(ctx)).Scan(&newID)
+	return newID, err
+}
+
+// Claim mengambil sampai limit pengiriman yang sudah jatuh tempo dan
+// menandainya sebagai satu percobaan. next_attempt_at dimajukan sebesar
+// lease, jadi worker lain (SKIP LOCKED) tidak mengambil baris yang sama dan
+// baris milik worker yang mati akan diambil lagi setelah lease habis.
+func (r *ReceiptEmailRepository) Claim(ctx context.Context, limit int, lease time.Duration) (emails []ReceiptEmail, err error) {
+	ctx, span := startSpan(ctx, "ReceiptEmailRepository.Claim")
+	defer func() { endSpan(span, len(emails), err) }()
+
+	query := `UPDATE receipt_emails SET attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $2)
+		WHERE id IN (SELECT id FROM receipt_emails
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING ` + receiptEmailColumns + `, receipt`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var payload []byte
+		e, err := scanReceiptEmail(rows, &payload)
+		if err != nil {
+			return nil, err
+		}
+		e.Receipt = new(receipt.Receipt)
+		if err := json.Unmarshal(payload, e.Receipt); err != nil {
+			return nil, err
+		}
+		emails = append(emails, *e)
+	}
+	return emails, rows.Err()
+}
+
+func (r *ReceiptEmailRepository) MarkSent(ctx context.Context, id string) (err error) {
+	ctx, span := startSpan(ctx, "ReceiptEmailRepository.MarkSent")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `UPDATE receipt_emails SET status = 'sent', sent_at = now(), last_error = '' WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, id)
+	return affectedOne(res, err)
+}
+
+// MarkFailed mencatat percobaan yang gagal. retryAt nil berarti tidak
+// dicoba lagi dan pengiriman menjadi failed.
+func (r *ReceiptEmailRepository) MarkFailed(ctx context.Context, id, reason string, retryAt *time.Time) (err error) {
+	ctx, span := startSpan(ctx, "ReceiptEmailRepository.MarkFailed")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `UPDATE receipt_emails
+		SET last_error = $2,
+			status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($3, next_attempt_at)
+		WHERE id = $1`
+	res, err := r.db.ExecContext(ctx, query, id, reason, retryAt)
+	return affectedOne(res, err)
+}
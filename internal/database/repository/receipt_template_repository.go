@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"maspos-be-go/internal/receipt"
+)
+
+// defaultFooter dicetak bila outlet belum mengatur template struk.
+const defaultFooter = "Terima kasih"
+
+type ReceiptTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewReceiptTemplateRepository(db *sql.DB) *ReceiptTemplateRepository {
+	return &ReceiptTemplateRepository{db}
+}
+
+// Get mengembalikan template struk outlet. Outlet yang belum mengatur
+// template mendapat template bawaan: nama dan alamat outlet sebagai kop,
+// "Terima kasih" sebagai penutup, kertas 80 mm. sql.ErrNoRows dikembalikan
+// bila outlet tidak ada atau tidak terlihat dari ctx.
+func (r *ReceiptTemplateRepository) Get(ctx context.Context, outletID string) (_ *receipt.Template, err error) {
+	ctx, span := startSpan(ctx, "ReceiptTemplateRepository.Get")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	var (
+		name, address  string
+		header, footer sql.NullString
+		paper          sql.NullInt64
+	)
+	query := `SELECT o.name, o.address, t.header, t.footer, t.paper
+		FROM outlets o LEFT JOIN receipt_templates t ON t.outlet_id = o.id
+		WHERE o.id = $1 AND ($2::uuid IS NULL OR o.id = $2)`
+	err = r.db.QueryRowContext(ctx, query, outletID, outletParam(ctx)).Scan(&name, &address, &header, &footer, &paper)
+	if err != nil {
+		return nil, err
+	}
+	if !paper.Valid {
+		t := &receipt.Template{Header: []string{name}, Footer: []string{defaultFooter}, Paper: receipt.Paper80}
+		if address != "" {
+			t.Header = append(t.Header, address)
+		}
+		return t, nil
+	}
+	return &receipt.Template{
+		Header: splitLines(header.String),
+		Footer: splitLines(footer.String),
+		Paper:  int(paper.Int64),
+	}, nil
+}
+
+// Put menyimpan template struk outlet. Outlet boleh mengatur template
+// miliknya sendiri; kantor pusat boleh mengatur semua outlet.
+func (r *ReceiptTemplateRepository) Put(ctx context.Context, outletID string, t receipt.Template) (err error) {
+	ctx, span := startSpan(ctx, "ReceiptTemplateRepository.Put")
+	defer func() { endSpan(span, -1, err) }()
+
+	query := `INSERT INTO receipt_templates (outlet_id, header, footer, paper)
+		SELECT o.id, $2, $3, $4 FROM outlets o WHERE o.id = $1 AND ($5::uuid IS NULL OR o.id = $5)
+		ON CONFLICT (outlet_id) DO UPDATE
+		SET header = EXCLUDED.header, footer = EXCLUDED.footer, paper = EXCLUDED.paper, updated_at = now()`
+	res, err := r.db.ExecContext(ctx, query, outletID,
+		strings.Join(t.Header, "\n"), strings.Join(t.Footer, "\n"), t.Paper, outletParam(ctx))
+	return affectedOne(res, err)
+}
+
+// splitLines kebalikan dari strings.Join(lines, "\n"); teks kosong berarti
+// tidak ada baris sama sekali.
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+)
+
+var (
+	ErrRefundLine   = errors.New("order line not found in this order")
+	ErrRefundShift  = errors.New("refund shift not found or belongs to another outlet")
+	ErrRefundTender = errors.New("refund exceeds what the order paid with this method")
+)
+
+// Refund adalah dokumen pengembalian sebagian atau seluruh baris sebuah
+// order. Lines hanya diisi oleh GetByID dan Create.
+type Refund struct {
+	ID          string  `json:"id"`
+	OrderID     string  `json:"order_id"`
+	OrderNumber string  `json:"order_number"`
+	OutletID    string  `json:"outlet_id"`
+	ShiftID     string  `json:"shift_id"`
+	Number      string  `json:"number"`
+	Reason      string  `json:"reason"`
+	Method      string  `json:"method"`
+	Subtotal    float64 `json:"subtotal"`
+	Tax         float64 `json:"tax"`
+	Amount      float64 `json:"amount"`
+	// Points adalah poin yang dikembalikan ke pelanggan pada refund
+	// loyalty; PointsReversed adalah poin hasil order yang ditarik kembali.
+	Points         int64        `json:"points"`
+	PointsReversed int64        `json:"points_reversed"`
+	Reference      string       `json:"reference"`
+	Note           string       `json:"note"`
+	ApprovedBy     *int         `json:"approved_by"`
+	CreatedBy      *int         `json:"created_by"`
+	CreatedAt      time.Time    `json:"created_at"`
+	Lines          []RefundLine `json:"lines,omitempty"`
+}
+
+type RefundLine struct {
+	ID          string  `json:"id"`
+	OrderLineID string  `json:"order_line_id"`
+	ProductID   string  `json:"product_id"`
+	Name        string  `json:"name"`
+	Quantity    float64 `json:"quantity"`
+	Amount      float64 `json:"amount"`
+	Restock     bool    `json:"restock"`
+}
+
+// NewRefund adalah refund yang akan disimpan. ApprovedBy adalah supervisor
+// yang menyetujuinya; pemeriksaan haknya dilakukan sebelum Create.
+type NewRefund struct {
+	OrderID    string
+	ShiftID    string
+	Reason     string
+	Method     string
+	Reference  string
+	Note       string
+	Lines      []NewRefundLine
+	ApprovedBy *int
+	CreatedBy  *int
+}
+
+// NewRefundLine mengembalikan Quantity unit dari satu baris order. Restock
+// nil berarti mengikuti alasan refund (lihat order.Restocks).
+type NewRefundLine struct {
+	OrderLineID string
+	Quantity    float64
+	Restock     *bool
+}
+
+type RefundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) *RefundRepository {
+	return &RefundRepository{db}
+}
+
+const refundColumns = `f.id, f.order_id, o.number, f.outlet_id, f.shift_id, f.number, f.reason, f.method, f.subtotal, f.tax,
+	f.amount, f.points, f.points_reversed, f.reference, f.note, f.approved_by, f.created_by, f.created_at`
+
+// refundVisible membatasi refunds f ke outlet di $n; kantor pusat melihat
+// refund semua outlet.
+func refundVisible(n int) string {
+	return fmt.Sprintf(`($%[1]d::uuid IS NULL OR f.outlet_id = $%[1]d)`, n)
+}
+
+func scanRefund(row interface{ Scan(...any) error }) (*Refund, error) {
+	var f Refund
+	var approvedBy, createdBy sql.NullInt64
+	err := row.Scan(&f.ID, &f.OrderID, &f.OrderNumber, &f.OutletID, &f.ShiftID, &f.Number, &f.Reason, &f.Method,
+		&f.Subtotal, &f.Tax, &f.Amount, &f.Points, &f.PointsReversed, &f.Reference, &f.Note, &approvedBy, &createdBy, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	f.ApprovedBy, f.CreatedBy = nullableInt(approvedBy), nullableInt(createdBy)
+	return &f, nil
+}
+
+// refunded adalah yang sudah dikembalikan dari sebuah order oleh refund
+// sebelumnya.
+type refunded struct {
+	quantity map[string]float64 // per baris order
+	subtotal float64
+	amount   float64
+	byMethod map[string]float64
+}
+
+func refundedSoFar(ctx context.Context, tx *sql.Tx, orderID string) (*refunded, error) {
+	done := &refunded{quantity: map[string]float64{}, byMethod: map[string]float64{}}
+	rows, err := tx.QueryContext(ctx, `SELECT method, subtotal, amount FROM refunds WHERE order_id = $1`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var method string
+		var subtotal, amount float64
+		if err := rows.Scan(&method, &subtotal, &amount); err != nil {
+			return nil, err
+		}
+		done.subtotal += subtotal
+		done.amount += amount
+		done.byMethod[method] += amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT l.order_line_id, SUM(l.quantity) FROM refund_lines l JOIN refunds f ON f.id = l.refund_id
+		WHERE f.order_id = $1 GROUP BY l.order_line_id`
+	lines, err := tx.QueryContext(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer lines.Close()
+	for lines.Next() {
+		var id string
+		var quantity float64
+		if err := lines.Scan(&id, &quantity); err != nil {
+			return nil, err
+		}
+		done.quantity[id] = quantity
+	}
+	return done, lines.Err()
+}
+
+// Create menyimpan refund atas baris-baris order in.OrderID. Nilai setiap
+// baris dihitung proporsional dari total baris order (setelah diskon), dan
+// pajak order ikut dikembalikan sebanding dengan subtotal yang direfund.
+// Refund dibayarkan dengan in.Method, yang tidak boleh melebihi yang
+// dibayar order dengan metode itu dikurangi refund sebelumnya. Refund tunai
+// diambil dari laci in.ShiftID; refund loyalty mengembalikan poin yang
+// dipakai secara proporsional. Poin yang didapat dari order ditarik kembali
+// sebanding dengan nilai refund, paling banyak sebesar saldo pelanggan.
+// Barang yang layak jual dicatat masuk kembali ke stok outlet.
+//
+// sql.ErrNoRows dikembalikan bila order tidak ada, ErrRefundShift bila
+// shift tidak ada atau milik outlet lain, ErrShiftClosed dan ErrDayClosed
+// bila shift atau hari bisnisnya sudah ditutup, ErrRefundLine bila baris
+// bukan milik order, order.ErrRefundQuantity bila jumlahnya melebihi sisa
+// yang belum direfund, ErrRefundTender bila nilainya melebihi pembayaran
+// metode itu, dan ErrInsufficientCash bila kas di laci tidak cukup.
+func (r *RefundRepository) Create(ctx context.Context, in NewRefund) (_ *Refund, err error) {
+	ctx, span := startSpan(ctx, "RefundRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// refund atas order yang sama diproses bergantian supaya sisa jumlah per
+	// baris tidak terlewati
+	query := `SELECT ` + orderColumns + ` FROM orders o WHERE o.id = $1 AND ` + orderVisible(2) + ` FOR UPDATE`
+	o, err := scanOrder(tx.QueryRowContext(ctx, query, in.OrderID, outletParam(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	s, err := lockOpenShift(ctx, tx, in.ShiftID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && s.OutletID != o.OutletID {
+		return nil, ErrRefundShift
+	}
+	if err != nil {
+		return nil, err
+	}
+	if closed, err := dayClosed(ctx, tx, s.OutletID); err != nil {
+		return nil, err
+	} else if closed {
+		return nil, ErrDayClosed
+	}
+
+	lines, err := orderLines(ctx, tx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	tenders, err := orderTenders(ctx, tx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	done, err := refundedSoFar(ctx, tx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Refund{
+		OrderID: o.ID, OrderNumber: o.Number, OutletID: o.OutletID, ShiftID: s.ID, Reason: in.Reason, Method: in.Method,
+		Reference: in.Reference, Note: in.Note, ApprovedBy: in.ApprovedBy, CreatedBy: in.CreatedBy,
+	}
+	byID := make(map[string]OrderLine, len(lines))
+	for _, l := range lines {
+		byID[l.ID] = l
+	}
+	for _, nl := range in.Lines {
+		l, ok := byID[nl.OrderLineID]
+		if !ok {
+			return nil, ErrRefundLine
+		}
+		before := done.quantity[l.ID]
+		if before+nl.Quantity > l.Quantity {
+			return nil, order.ErrRefundQuantity
+		}
+		done.quantity[l.ID] = before + nl.Quantity
+		restock := order.Restocks(in.Reason)
+		if nl.Restock != nil {
+			restock = *nl.Restock
+		}
+		amount := order.Share(l.Total, l.Quantity, before, nl.Quantity)
+		f.Lines = append(f.Lines, RefundLine{
+			OrderLineID: l.ID, ProductID: l.ProductID, Name: l.Name, Quantity: nl.Quantity, Amount: amount, Restock: restock,
+		})
+		f.Subtotal += amount
+	}
+	f.Subtotal = roundCents(f.Subtotal)
+	f.Tax = order.Share(o.Tax, o.Subtotal, done.subtotal, f.Subtotal)
+	f.Amount = roundCents(f.Subtotal + f.Tax)
+
+	calc := order.Order{Tenders: tenders}
+	paid := calc.PaidWith(in.Method)
+	if in.Method == order.TenderCash {
+		paid = roundCents(paid - o.Change)
+	}
+	if f.Amount > roundCents(paid-done.byMethod[in.Method]) {
+		return nil, ErrRefundTender
+	}
+	if in.Method == order.TenderCash && f.Amount > 0 {
+		rep, err := shiftReport(ctx, tx, s)
+		if err != nil {
+			return nil, err
+		}
+		if f.Amount > rep.Expected() {
+			return nil, ErrInsufficientCash
+		}
+	}
+
+	query = `INSERT INTO refunds (order_id, outlet_id, shift_id, number, reason, method, subtotal, tax, amount, reference, note, approved_by, created_by)
+		VALUES ($1, $2, $3, 'RF-' || to_char(now(), 'YYYYMMDD') || '-' || lpad(nextval('refunds_number_seq')::text, 6, '0'),
+			$4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, number, created_at`
+	err = tx.QueryRowContext(ctx, query, f.OrderID, f.OutletID, f.ShiftID, f.Reason, f.Method, f.Subtotal, f.Tax, f.Amount,
+		f.Reference, f.Note, f.ApprovedBy, f.CreatedBy).Scan(&f.ID, &f.Number, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range f.Lines {
+		query := `INSERT INTO refund_lines (refund_id, order_line_id, quantity, amount, restock) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+		if err := tx.QueryRowContext(ctx, query, f.ID, l.OrderLineID, l.Quantity, l.Amount, l.Restock).Scan(&f.Lines[i].ID); err != nil {
+			return nil, err
+		}
+		if l.Restock && l.ProductID != "" {
+			query := `INSERT INTO stock_movements (outlet_id, product_id, type, quantity, reference) VALUES ($1, $2, $3, $4, $5)`
+			if _, err := tx.ExecContext(ctx, query, f.OutletID, l.ProductID, StockReturn, l.Quantity, f.Number); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if o.CustomerID != nil {
+		if err := f.postLoyalty(ctx, tx, o, calc, done); err != nil {
+			return nil, err
+		}
+	}
+	if in.Method == order.TenderCash && f.Amount > 0 {
+		query := `INSERT INTO shift_cash_movements (shift_id, type, amount, reference, note, created_by) VALUES ($1, $2, $3, $4, $5, $6)`
+		if _, err := tx.ExecContext(ctx, query, s.ID, CashRefund, f.Amount, f.Number, o.Number, in.CreatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// postLoyalty mengembalikan poin yang dipakai membayar (refund loyalty) dan
+// menarik poin yang didapat dari order sebanding dengan nilai refund.
+// Poin yang sudah terpakai tidak bisa ditarik, jadi penarikan dibatasi
+// saldo pelanggan. Penarikan ditulis sebagai earn negatif dengan masa
+// berlaku earn asalnya, seperti Reverse, agar ExpireDue tidak lagi
+// menghanguskan poin yang sudah ditarik.
+func (f *Refund) postLoyalty(ctx context.Context, tx *sql.Tx, o *Order, calc order.Order, done *refunded) error {
+	var redeemed, earned int64
+	var expiresAt sql.NullTime
+	query := `SELECT
+			COALESCE(-SUM(points) FILTER (WHERE type = 'burn' AND points < 0), 0),
+			COALESCE(SUM(points) FILTER (WHERE type = 'earn' AND points > 0), 0),
+			MIN(expires_at) FILTER (WHERE type = 'earn' AND points > 0)
+		FROM loyalty_ledger WHERE order_id = $1`
+	if err := tx.QueryRowContext(ctx, query, o.ID).Scan(&redeemed, &earned, &expiresAt); err != nil {
+		return err
+	}
+
+	insert := `INSERT INTO loyalty_ledger (customer_id, type, points, reference, note, order_id) VALUES ($1, $2, $3, $4, $5, $6)`
+	if f.Method == order.TenderLoyalty && redeemed > 0 {
+		paid := calc.PaidWith(order.TenderLoyalty)
+		f.Points = int64(math.Round(order.Share(float64(redeemed), paid, done.byMethod[order.TenderLoyalty], f.Amount)))
+		if f.Points > 0 {
+			if _, err := tx.ExecContext(ctx, insert, *o.CustomerID, loyalty.TypeBurn, f.Points, f.Number, "refund", o.ID); err != nil {
+				return err
+			}
+		}
+	}
+	if earned > 0 {
+		reversed := int64(math.Round(order.Share(float64(earned), o.Total, done.amount, f.Amount)))
+		var balance int64
+		if err := tx.QueryRowContext(ctx, `SELECT id FROM customers WHERE id = $1 FOR UPDATE`, *o.CustomerID).Scan(new(string)); err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = $1`, *o.CustomerID).Scan(&balance); err != nil {
+			return err
+		}
+		f.PointsReversed = min(reversed, max(balance, 0))
+		if f.PointsReversed > 0 {
+			query := `INSERT INTO loyalty_ledger (customer_id, type, points, reference, expires_at, note, order_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+			if _, err := tx.ExecContext(ctx, query, *o.CustomerID, loyalty.TypeEarn, -f.PointsReversed, f.Number, expiresAt, "refund", o.ID); err != nil {
+				return err
+			}
+		}
+	}
+	if f.Points > 0 || f.PointsReversed > 0 {
+		_, err := tx.ExecContext(ctx, `UPDATE refunds SET points = $2, points_reversed = $3 WHERE id = $1`, f.ID, f.Points, f.PointsReversed)
+		return err
+	}
+	return nil
+}
+
+// GetByID returns the refund with its lines. Inside an outlet only its own
+// refunds are found.
+func (r *RefundRepository) GetByID(ctx context.Context, id string) (_ *Refund, err error) {
+	ctx, span := startSpan(ctx, "RefundRepository.GetByID")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + refundColumns + ` FROM refunds f JOIN orders o ON o.id = f.order_id
+		WHERE f.id = $1 AND ` + refundVisible(2)
+	f, err := scanRefund(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	query = `SELECT l.id, l.order_line_id, COALESCE(ol.product_id::text, ''), ol.name, l.quantity, l.amount, l.restock
+		FROM refund_lines l JOIN order_lines ol ON ol.id = l.order_line_id
+		WHERE l.refund_id = $1 ORDER BY ol.line_no`
+	rows, err := r.db.QueryContext(ctx, query, f.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l RefundLine
+		if err := rows.Scan(&l.ID, &l.OrderLineID, &l.ProductID, &l.Name, &l.Quantity, &l.Amount, &l.Restock); err != nil {
+			return nil, err
+		}
+		f.Lines = append(f.Lines, l)
+	}
+	return f, rows.Err()
+}
+
+// GetByOrder returns the refunds of an order without their lines, oldest
+// first.
+func (r *RefundRepository) GetByOrder(ctx context.Context, orderID string) (refunds []Refund, err error) {
+	ctx, span := startSpan(ctx, "RefundRepository.GetByOrder")
+	defer func() { endSpan(span, len(refunds), err) }()
+
+	query := `SELECT ` + refundColumns + ` FROM refunds f JOIN orders o ON o.id = f.order_id
+		WHERE f.order_id = $1 AND ` + refundVisible(2) + ` ORDER BY f.created_at`
+	rows, err := r.db.QueryContext(ctx, query, orderID, outletParam(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		f, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, *f)
+	}
+	return refunds, rows.Err()
+}
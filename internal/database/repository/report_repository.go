@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ReportFilter membatasi laporan ke transaksi dengan From <= created_at < To.
+// TZ adalah zona waktu IANA untuk menentukan tanggal dan jam transaksi.
+type ReportFilter struct {
+	From time.Time
+	To   time.Time
+	TZ   string
+}
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db}
+}
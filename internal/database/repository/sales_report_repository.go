@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sales adalah penjualan dari order yang selesai. Order dihitung menurut
+// waktu order dan refund menurut waktu refund, keduanya di outlet tempat
+// dicatat. Refunds tidak termasuk pajak; pajak yang dikembalikan sudah
+// dikurangkan dari Tax.
+//
+//	Net           = Gross - Discounts - Refunds
+//	Total         = Net + Tax
+//	AverageTicket = (Gross - Discounts) / Orders
+type Sales struct {
+	Orders        int     `json:"orders"`
+	Gross         float64 `json:"gross"`
+	Discounts     float64 `json:"discounts"`
+	RefundCount   int     `json:"refund_count"`
+	Refunds       float64 `json:"refunds"`
+	Net           float64 `json:"net"`
+	Tax           float64 `json:"tax"`
+	Total         float64 `json:"total"`
+	AverageTicket float64 `json:"average_ticket"`
+}
+
+type DailySales struct {
+	Date string `json:"date"`
+	Sales
+}
+
+type HourlySales struct {
+	Hour int `json:"hour"`
+	Sales
+}
+
+// CashierSales dikelompokkan per user yang membuat order atau refund.
+// UserID nil berarti user-nya sudah dihapus.
+type CashierSales struct {
+	UserID *int   `json:"user_id"`
+	Name   string `json:"name"`
+	Sales
+}
+
+// ItemSales adalah penjualan satu produk atau kategori dari baris order.
+// Gross sebelum diskon baris; diskon tingkat order tidak dibagi ke baris.
+// ID kosong berarti produk atau kategorinya sudah dihapus, atau baris itu
+// tidak berkategori. Net = Gross - Discounts - Refunds.
+type ItemSales struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Quantity         float64 `json:"quantity"`
+	RefundedQuantity float64 `json:"refunded_quantity"`
+	Gross            float64 `json:"gross"`
+	Discounts        float64 `json:"discounts"`
+	Refunds          float64 `json:"refunds"`
+	Net              float64 `json:"net"`
+}
+
+// salesBy menjalankan agregasi order dan refund yang dikelompokkan per key,
+// lalu memanggil fn untuk setiap kelompok. key dan label adalah ekspresi
+// SQL atas x.at dan x.user_id; $4 di dalamnya adalah zona waktu.
+func salesBy(ctx context.Context, q rowsQueryer, f ReportFilter, key, label string, fn func(key, label string, s Sales)) error {
+	query := `WITH x AS (
+			SELECT o.created_at AS at, o.created_by AS user_id, 1 AS orders, o.gross, o.discount, o.tax,
+				0 AS refund_count, 0::numeric AS refunds, 0::numeric AS refund_tax
+			FROM orders o
+			WHERE o.created_at >= $1 AND o.created_at < $2 AND ($3::uuid IS NULL OR o.outlet_id = $3)
+			UNION ALL
+			SELECT f.created_at, f.created_by, 0, 0, 0, 0, 1, f.subtotal, f.tax
+			FROM refunds f
+			WHERE f.created_at >= $1 AND f.created_at < $2 AND ($3::uuid IS NULL OR f.outlet_id = $3)
+		)
+		SELECT COALESCE(` + key + `, ''), ` + label + `,
+			SUM(x.orders), SUM(x.gross), SUM(x.discount), SUM(x.tax),
+			SUM(x.refund_count), SUM(x.refunds), SUM(x.refund_tax)
+		FROM x
+		LEFT JOIN users u ON u.id = x.user_id
+		GROUP BY 1
+		ORDER BY 1`
+	args := []any{f.From, f.To, outletParam(ctx)}
+	if strings.Contains(key, "$4") {
+		args = append(args, f.TZ)
+	}
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			k, l      string
+			s         Sales
+			refundTax float64
+		)
+		if err := rows.Scan(&k, &l, &s.Orders, &s.Gross, &s.Discounts, &s.Tax, &s.RefundCount, &s.Refunds, &refundTax); err != nil {
+			return err
+		}
+		s.Tax -= refundTax
+		fn(k, l, s.withTotals())
+	}
+	return rows.Err()
+}
+
+func (s Sales) withTotals() Sales {
+	s.Tax = roundCents(s.Tax)
+	s.Net = roundCents(s.Gross - s.Discounts - s.Refunds)
+	s.Total = roundCents(s.Net + s.Tax)
+	if s.Orders > 0 {
+		s.AverageTicket = roundCents((s.Gross - s.Discounts) / float64(s.Orders))
+	}
+	return s
+}
+
+// SalesTotal returns the order sales of the whole period. Inside an outlet
+// only that outlet is counted.
+func (r *ReportRepository) SalesTotal(ctx context.Context, f ReportFilter) (total Sales, err error) {
+	ctx, span := startSpan(ctx, "ReportRepository.SalesTotal")
+	defer func() { endSpan(span, 1, err) }()
+
+	err = salesBy(ctx, r.db, f, `NULL::text`, `''`, func(_, _ string, s Sales) { total = s })
+	return total, err
+}
+
+// SalesByDay returns one entry per day with orders or refunds, in date
+// order.
+func (r *ReportRepository) SalesByDay(ctx context.Context, f ReportFilter) (days []DailySales, err error) {
+	ctx, span := startSpan(ctx, "ReportRepository.SalesByDay")
+	defer func() { endSpan(span, len(days), err) }()
+
+	key := `to_char(x.at AT TIME ZONE $4, 'YYYY-MM-DD')`
+	err = salesBy(ctx, r.db, f, key, `''`, func(date, _ string, s Sales) {
+		days = append(days, DailySales{Date: date, Sales: s})
+	})
+	return days, err
+}
+
+// SalesByHour returns all 24 hours of the day, summed over the period.
+func (r *ReportRepository) SalesByHour(ctx context.Context, f ReportFilter) (hours []HourlySales, err error) {
+	ctx, span := startSpan(ctx, "ReportRepository.SalesByHour")
+	defer func() { endSpan(span, 24, err) }()
+
+	hours = make([]HourlySales, 24)
+	for h := range hours {
+		hours[h].Hour = h
+	}
+	key := `lpad(EXTRACT(HOUR FROM x.at AT TIME ZONE $4)::text, 2, '0')`
+	err = salesBy(ctx, r.db, f, key, `''`, func(hour, _ string, s Sales) {
+		if h, err := strconv.Atoi(hour); err == nil && h >= 0 && h < 24 {
+			hours[h].Sales = s
+		}
+	})
+	return hours, err
+}
+
+// SalesByCashier returns the totals per cashier, highest net first.
+func (r *ReportRepository) SalesByCashier(ctx context.Context, f ReportFilter) (cashiers []CashierSales, err error) {
+	ctx, span := startSpan(ctx, "ReportRepository.SalesByCashier")
+	defer func() { endSpan(span, len(cashiers), err) }()
+
+	err = salesBy(ctx, r.db, f, `x.user_id::text`, `COALESCE(MAX(u.name), '')`, func(id, name string, s Sales) {
+		c := CashierSales{Name: name, Sales: s}
+		if n, err := strconv.Atoi(id); err == nil {
+			c.UserID = &n
+		}
+		cashiers = append(cashiers, c)
+	})
+	slices.SortStableFunc(cashiers, func(a, b CashierSales) int {
+		return cmp.Compare(b.Net, a.Net)
+	})
+	return cashiers, err
+}
+
+// itemSalesBy mengagregasi baris order dan baris refund per key, urut dari
+// net terbesar. key dan label adalah ekspresi SQL atas x dan c (kategori).
+// limit 0 berarti semua kelompok.
+func (r *ReportRepository) itemSalesBy(ctx context.Context, f ReportFilter, key, label string, limit int) (items []ItemSales, err error) {
+	query := `WITH x AS (
+			SELECT l.product_id, l.category_id, l.name, l.quantity, 0::numeric AS refunded,
+				l.total + l.discount AS gross, l.discount, 0::numeric AS refunds
+			FROM order_lines l
+			JOIN orders o ON o.id = l.order_id
+			WHERE o.created_at >= $1 AND o.created_at < $2 AND ($3::uuid IS NULL OR o.outlet_id = $3)
+			UNION ALL
+			SELECT l.product_id, l.category_id, l.name, 0, rl.quantity, 0, 0, rl.amount
+			FROM refund_lines rl
+			JOIN refunds f ON f.id = rl.refund_id
+			JOIN order_lines l ON l.id = rl.order_line_id
+			WHERE f.created_at >= $1 AND f.created_at < $2 AND ($3::uuid IS NULL OR f.outlet_id = $3)
+		)
+		SELECT COALESCE(` + key + `::text, ''), ` + label + `,
+			SUM(x.quantity), SUM(x.refunded), SUM(x.gross), SUM(x.discount), SUM(x.refunds)
+		FROM x
+		LEFT JOIN categories c ON c.id = x.category_id
+		GROUP BY 1
+		ORDER BY SUM(x.gross) - SUM(x.discount) - SUM(x.refunds) DESC, 1
+		LIMIT NULLIF($4, 0)`
+	rows, err := r.db.QueryContext(ctx, query, f.From, f.To, outletParam(ctx), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var it ItemSales
+		if err := rows.Scan(&it.ID, &it.Name, &it.Quantity, &it.RefundedQuantity, &it.Gross, &it.Discounts, &it.Refunds); err != nil {
+			return nil, err
+		}
+		it.Net = roundCents(it.Gross - it.Discounts - it.Refunds)
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// SalesByProduct returns the best selling products by net, at most limit
+// of them (0 for all). Lines of a deleted product are grouped together.
+func (r *ReportRepository) SalesByProduct(ctx context.Context, f ReportFilter, limit int) (products []ItemSales, err error) {
+	ctx, span := startSpan(ctx, "ReportRepository.SalesByProduct")
+	defer func() { endSpan(span, len(products), err) }()
+
+	return r.itemSalesBy(ctx, f, `x.product_id`, `COALESCE(MAX(x.name), '')`, limit)
+}
+
+// SalesByCategory returns the sales per category, highest net first.
+func (r *ReportRepository) SalesByCategory(ctx context.Context, f ReportFilter) (categories []ItemSales, err error) {
+	ctx, span := startSpan(ctx, "ReportRepository.SalesByCategory")
+	defer func() { endSpan(span, len(categories), err) }()
+
+	return r.itemSalesBy(ctx, f, `x.category_id`, `COALESCE(MAX(c.name), '')`, 0)
+}
@@ -0,0 +1,451 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrRollupTimezone = errors.New("sales rollups were built in another time zone; rebuild them first")
+
+// OutletDailySales adalah satu baris rollup: penjualan satu outlet pada
+// satu tanggal, dihitung seperti SalesByDay.
+type OutletDailySales struct {
+	OutletID string `json:"outlet_id"`
+	Date     string `json:"date"`
+	Sales
+}
+
+// SalesRollupCheckpoint menyatakan sampai mana rollup sebuah tenant sudah
+// dihitung: semua order dan refund dengan created_at <= Position. Position
+// nil berarti belum ada yang dihitung.
+type SalesRollupCheckpoint struct {
+	Timezone string     `json:"timezone"`
+	Position *time.Time `json:"position"`
+}
+
+// RollupMismatch adalah tanggal outlet yang rollup-nya berbeda dari hasil
+// hitung ulang order dan refund. Rollup atau Source nil berarti barisnya
+// tidak ada.
+type RollupMismatch struct {
+	OutletID string `json:"outlet_id"`
+	Date     string `json:"date"`
+	Rollup   *Sales `json:"rollup"`
+	Source   *Sales `json:"source"`
+}
+
+// ProductRollupMismatch adalah produk pada tanggal outlet yang rollup-nya
+// berbeda dari hasil hitung ulang baris order dan refund. Rollup atau Source
+// nil berarti barisnya tidak ada.
+type ProductRollupMismatch struct {
+	OutletID  string     `json:"outlet_id"`
+	Date      string     `json:"date"`
+	ProductID string     `json:"product_id"`
+	Rollup    *ItemSales `json:"rollup"`
+	Source    *ItemSales `json:"source"`
+}
+
+// deletedProduct mengumpulkan baris order yang produknya sudah dihapus di
+// product_daily_rollups.
+const deletedProduct = "00000000-0000-0000-0000-000000000000"
+
+// SumSales menjumlahkan baris rollup.
+func SumSales(days []OutletDailySales) Sales {
+	var t Sales
+	for _, d := range days {
+		t.Orders += d.Orders
+		t.Gross += d.Gross
+		t.Discounts += d.Discounts
+		t.Tax += d.Tax
+		t.RefundCount += d.RefundCount
+		t.Refunds += d.Refunds
+	}
+	t.Gross, t.Discounts, t.Refunds = roundCents(t.Gross), roundCents(t.Discounts), roundCents(t.Refunds)
+	return t.withTotals()
+}
+
+type SalesRollupRepository struct {
+	db *sql.DB
+}
+
+func NewSalesRollupRepository(db *sql.DB) *SalesRollupRepository {
+	return &SalesRollupRepository{db}
+}
+
+// rollupSource mengelompokkan order dan refund dengan $1 < created_at <= $2
+// ($1 NULL berarti sejak awal) per outlet dan tanggal di zona waktu $3,
+// dengan angka yang sama seperti salesBy. tax sudah dikurangi pajak refund.
+const rollupSource = `SELECT outlet_id, business_date,
+		SUM(orders)::int AS orders, SUM(gross) AS gross, SUM(discount) AS discount, SUM(tax) AS tax,
+		SUM(refund_count)::int AS refund_count, SUM(refunds) AS refunds
+	FROM (
+		SELECT o.outlet_id, (o.created_at AT TIME ZONE $3)::date AS business_date, 1 AS orders,
+			o.gross, o.discount, o.tax, 0 AS refund_count, 0::numeric AS refunds
+		FROM orders o
+		WHERE ($1::timestamptz IS NULL OR o.created_at > $1) AND o.created_at <= $2
+		UNION ALL
+		SELECT f.outlet_id, (f.created_at AT TIME ZONE $3)::date, 0, 0, 0, -f.tax, 1, f.subtotal
+		FROM refunds f
+		WHERE ($1::timestamptz IS NULL OR f.created_at > $1) AND f.created_at <= $2
+	) x
+	GROUP BY 1, 2`
+
+// rollupAdd menambahkan hasil rollupSource ke baris rollup yang sudah ada.
+const rollupAdd = `INSERT INTO sales_daily_rollups (outlet_id, business_date, orders, gross, discount, tax, refund_count, refunds)
+	SELECT * FROM (` + rollupSource + `) src
+	ON CONFLICT (tenant_id, outlet_id, business_date) DO UPDATE SET
+		orders = sales_daily_rollups.orders + EXCLUDED.orders,
+		gross = sales_daily_rollups.gross + EXCLUDED.gross,
+		discount = sales_daily_rollups.discount + EXCLUDED.discount,
+		tax = sales_daily_rollups.tax + EXCLUDED.tax,
+		refund_count = sales_daily_rollups.refund_count + EXCLUDED.refund_count,
+		refunds = sales_daily_rollups.refunds + EXCLUDED.refunds,
+		updated_at = now()`
+
+// productRollupSource mengelompokkan baris order dan baris refund dengan
+// $1 < created_at <= $2 per outlet, tanggal di zona waktu $3 dan produk.
+const productRollupSource = `SELECT outlet_id, business_date, product_id,
+		SUM(quantity) AS quantity, SUM(refunded_quantity) AS refunded_quantity,
+		SUM(gross) AS gross, SUM(discount) AS discount, SUM(refunds) AS refunds
+	FROM (
+		SELECT o.outlet_id, (o.created_at AT TIME ZONE $3)::date AS business_date,
+			COALESCE(l.product_id, '` + deletedProduct + `') AS product_id, l.quantity, 0 AS refunded_quantity,
+			l.total + l.discount AS gross, l.discount, 0 AS refunds
+		FROM order_lines l
+		JOIN orders o ON o.id = l.order_id
+		WHERE ($1::timestamptz IS NULL OR o.created_at > $1) AND o.created_at <= $2
+		UNION ALL
+		SELECT f.outlet_id, (f.created_at AT TIME ZONE $3)::date,
+			COALESCE(l.product_id, '` + deletedProduct + `'), 0, rl.quantity, 0, 0, rl.amount
+		FROM refund_lines rl
+		JOIN refunds f ON f.id = rl.refund_id
+		JOIN order_lines l ON l.id = rl.order_line_id
+		WHERE ($1::timestamptz IS NULL OR f.created_at > $1) AND f.created_at <= $2
+	) x
+	GROUP BY 1, 2, 3`
+
+// productRollupAdd menambahkan hasil productRollupSource ke baris rollup
+// produk yang sudah ada.
+const productRollupAdd = `INSERT INTO product_daily_rollups (outlet_id, business_date, product_id, quantity, refunded_quantity, gross, discount, refunds)
+	SELECT * FROM (` + productRollupSource + `) src
+	ON CONFLICT (tenant_id, outlet_id, business_date, product_id) DO UPDATE SET
+		quantity = product_daily_rollups.quantity + EXCLUDED.quantity,
+		refunded_quantity = product_daily_rollups.refunded_quantity + EXCLUDED.refunded_quantity,
+		gross = product_daily_rollups.gross + EXCLUDED.gross,
+		discount = product_daily_rollups.discount + EXCLUDED.discount,
+		refunds = product_daily_rollups.refunds + EXCLUDED.refunds,
+		updated_at = now()`
+
+// lockCheckpoint membaca checkpoint tenant di ctx dengan FOR UPDATE,
+// membuatnya dengan zona waktu tz bila belum ada.
+func lockCheckpoint(ctx context.Context, tx *sql.Tx, tz string) (cp SalesRollupCheckpoint, err error) {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sales_rollup_checkpoints (timezone) VALUES ($1) ON CONFLICT DO NOTHING`, tz); err != nil {
+		return cp, err
+	}
+	var position sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT timezone, position FROM sales_rollup_checkpoints FOR UPDATE`).Scan(&cp.Timezone, &position)
+	if position.Valid {
+		cp.Position = &position.Time
+	}
+	return cp, err
+}
+
+// horizon adalah batas atas mutasi yang dihitung. Mutasi dicatat dengan
+// waktu mulai transaksinya, jadi mutasi yang baru di-commit bisa bertanggal
+// sedikit lebih awal; lag memberi waktu transaksi itu selesai.
+func horizon(ctx context.Context, tx *sql.Tx, lag time.Duration) (t time.Time, err error) {
+	err = tx.QueryRowContext(ctx, `SELECT now() - make_interval(secs => $1)`, lag.Seconds()).Scan(&t)
+	return t, err
+}
+
+// Advance menambahkan order dan refund sejak checkpoint sampai sekarang
+// dikurangi lag ke rollup tenant di ctx, lalu memajukan checkpoint, dalam
+// satu transaksi. days adalah jumlah baris rollup harian outlet yang
+// berubah. ErrRollupTimezone dikembalikan bila rollup dihitung dengan zona
+// waktu selain tz.
+func (r *SalesRollupRepository) Advance(ctx context.Context, tz string, lag time.Duration) (cp SalesRollupCheckpoint, days int, err error) {
+	ctx, span := startSpan(ctx, "SalesRollupRepository.Advance")
+	defer func() { endSpan(span, days, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return cp, 0, err
+	}
+	defer tx.Rollback()
+
+	if cp, err = lockCheckpoint(ctx, tx, tz); err != nil {
+		return cp, 0, err
+	}
+	if cp.Timezone != tz {
+		return cp, 0, ErrRollupTimezone
+	}
+	end, err := horizon(ctx, tx, lag)
+	if err != nil {
+		return cp, 0, err
+	}
+	if cp.Position != nil && !end.After(*cp.Position) {
+		return cp, 0, nil
+	}
+	if days, err = r.apply(ctx, tx, cp.Position, end, tz); err != nil {
+		return cp, 0, err
+	}
+	cp.Position = &end
+	return cp, days, tx.Commit()
+}
+
+// Rebuild menghapus rollup tenant di ctx lalu menghitungnya ulang dari semua
+// order dan refund dengan zona waktu tz. Checkpoint dikunci selama rebuild,
+// jadi worker yang berjalan bersamaan menunggu.
+func (r *SalesRollupRepository) Rebuild(ctx context.Context, tz string, lag time.Duration) (cp SalesRollupCheckpoint, days int, err error) {
+	ctx, span := startSpan(ctx, "SalesRollupRepository.Rebuild")
+	defer func() { endSpan(span, days, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return cp, 0, err
+	}
+	defer tx.Rollback()
+
+	if cp, err = lockCheckpoint(ctx, tx, tz); err != nil {
+		return cp, 0, err
+	}
+	for _, table := range []string{"sales_daily_rollups", "product_daily_rollups"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+			return cp, 0, err
+		}
+	}
+	end, err := horizon(ctx, tx, lag)
+	if err != nil {
+		return cp, 0, err
+	}
+	if days, err = r.apply(ctx, tx, nil, end, tz); err != nil {
+		return cp, 0, err
+	}
+	cp.Timezone, cp.Position = tz, &end
+	return cp, days, tx.Commit()
+}
+
+// apply menambahkan order dan refund start < created_at <= end ke rollup
+// dan mencatat end sebagai checkpoint.
+func (r *SalesRollupRepository) apply(ctx context.Context, tx *sql.Tx, start *time.Time, end time.Time, tz string) (int, error) {
+	res, err := tx.ExecContext(ctx, rollupAdd, start, end, tz)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, productRollupAdd, start, end, tz); err != nil {
+		return 0, err
+	}
+	query := `UPDATE sales_rollup_checkpoints SET timezone = $1, position = $2, updated_at = now()`
+	if _, err := tx.ExecContext(ctx, query, tz, end); err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// Check menghitung ulang order dan refund sampai checkpoint dan
+// membandingkannya dengan rollup harian dan rollup produk tenant di ctx.
+// Semuanya dibaca dari snapshot yang sama, jadi worker yang berjalan
+// bersamaan tidak menimbulkan selisih palsu.
+func (r *SalesRollupRepository) Check(ctx context.Context) (cp SalesRollupCheckpoint, mismatches []RollupMismatch, products []ProductRollupMismatch, err error) {
+	ctx, span := startSpan(ctx, "SalesRollupRepository.Check")
+	defer func() { endSpan(span, len(mismatches)+len(products), err) }()
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return cp, nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var position sql.NullTime
+	err = tx.QueryRowContext(ctx, `SELECT timezone, position FROM sales_rollup_checkpoints`).Scan(&cp.Timezone, &position)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return cp, nil, nil, err
+	}
+	if position.Valid {
+		cp.Position = &position.Time
+	}
+	// tanpa checkpoint sumbernya kosong; zona waktu hanya perlu sah
+	tz := cp.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+
+	query := `WITH src AS (` + rollupSource + `)
+		SELECT COALESCE(r.outlet_id, src.outlet_id), COALESCE(r.business_date, src.business_date)::text,
+			r.outlet_id IS NOT NULL, COALESCE(r.orders, 0), COALESCE(r.gross, 0), COALESCE(r.discount, 0),
+			COALESCE(r.tax, 0), COALESCE(r.refund_count, 0), COALESCE(r.refunds, 0),
+			src.outlet_id IS NOT NULL, COALESCE(src.orders, 0), COALESCE(src.gross, 0), COALESCE(src.discount, 0),
+			COALESCE(src.tax, 0), COALESCE(src.refund_count, 0), COALESCE(src.refunds, 0)
+		FROM sales_daily_rollups r
+		FULL JOIN src ON src.outlet_id = r.outlet_id AND src.business_date = r.business_date
+		WHERE (r.orders, r.gross, r.discount, r.tax, r.refund_count, r.refunds)
+			IS DISTINCT FROM (src.orders, src.gross, src.discount, src.tax, src.refund_count, src.refunds)
+		ORDER BY 2, 1`
+	rows, err := tx.QueryContext(ctx, query, nil, position, tz)
+	if err != nil {
+		return cp, nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			m               RollupMismatch
+			inRollup, inSrc bool
+			rollup, source  Sales
+		)
+		err := rows.Scan(&m.OutletID, &m.Date,
+			&inRollup, &rollup.Orders, &rollup.Gross, &rollup.Discounts, &rollup.Tax, &rollup.RefundCount, &rollup.Refunds,
+			&inSrc, &source.Orders, &source.Gross, &source.Discounts, &source.Tax, &source.RefundCount, &source.Refunds)
+		if err != nil {
+			return cp, nil, nil, err
+		}
+		if inRollup {
+			rollup = rollup.withTotals()
+			m.Rollup = &rollup
+		}
+		if inSrc {
+			source = source.withTotals()
+			m.Source = &source
+		}
+		mismatches = append(mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return cp, nil, nil, err
+	}
+	products, err = checkProducts(ctx, tx, position, tz)
+	return cp, mismatches, products, err
+}
+
+// checkProducts membandingkan rollup produk dengan hasil hitung ulang baris
+// order dan refund sampai position di dalam tx.
+func checkProducts(ctx context.Context, tx *sql.Tx, position sql.NullTime, tz string) (mismatches []ProductRollupMismatch, err error) {
+	query := `WITH src AS (` + productRollupSource + `)
+		SELECT COALESCE(r.outlet_id, src.outlet_id), COALESCE(r.business_date, src.business_date)::text,
+			COALESCE(r.product_id, src.product_id),
+			r.outlet_id IS NOT NULL, COALESCE(r.quantity, 0), COALESCE(r.refunded_quantity, 0),
+			COALESCE(r.gross, 0), COALESCE(r.discount, 0), COALESCE(r.refunds, 0),
+			src.outlet_id IS NOT NULL, COALESCE(src.quantity, 0), COALESCE(src.refunded_quantity, 0),
+			COALESCE(src.gross, 0), COALESCE(src.discount, 0), COALESCE(src.refunds, 0)
+		FROM product_daily_rollups r
+		FULL JOIN src ON src.outlet_id = r.outlet_id AND src.business_date = r.business_date AND src.product_id = r.product_id
+		WHERE (r.quantity, r.refunded_quantity, r.gross, r.discount, r.refunds)
+			IS DISTINCT FROM (src.quantity, src.refunded_quantity, src.gross, src.discount, src.refunds)
+		ORDER BY 2, 1, 3`
+	rows, err := tx.QueryContext(ctx, query, nil, position, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			m               ProductRollupMismatch
+			inRollup, inSrc bool
+			rollup, source  ItemSales
+		)
+		err := rows.Scan(&m.OutletID, &m.Date, &m.ProductID,
+			&inRollup, &rollup.Quantity, &rollup.RefundedQuantity, &rollup.Gross, &rollup.Discounts, &rollup.Refunds,
+			&inSrc, &source.Quantity, &source.RefundedQuantity, &source.Gross, &source.Discounts, &source.Refunds)
+		if err != nil {
+			return nil, err
+		}
+		if inRollup {
+			rollup.ID, rollup.Net = m.ProductID, roundCents(rollup.Gross-rollup.Discounts-rollup.Refunds)
+			m.Rollup = &rollup
+		}
+		if inSrc {
+			source.ID, source.Net = m.ProductID, roundCents(source.Gross-source.Discounts-source.Refunds)
+			m.Source = &source
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches, rows.Err()
+}
+
+// Checkpoint returns the checkpoint of the tenant in ctx, or sql.ErrNoRows
+// when its rollups were never built.
+func (r *SalesRollupRepository) Checkpoint(ctx context.Context) (_ *SalesRollupCheckpoint, err error) {
+	ctx, span := startSpan(ctx, "SalesRollupRepository.Checkpoint")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	var (
+		cp       SalesRollupCheckpoint
+		position sql.NullTime
+	)
+	if err := r.db.QueryRowContext(ctx, `SELECT timezone, position FROM sales_rollup_checkpoints`).Scan(&cp.Timezone, &position); err != nil {
+		return nil, err
+	}
+	if position.Valid {
+		cp.Position = &position.Time
+	}
+	return &cp, nil
+}
+
+// Daily returns the rollups from date from to date to (both included, as
+// YYYY-MM-DD) visible from ctx, by date and outlet.
+func (r *SalesRollupRepository) Daily(ctx context.Context, from, to string) (days []OutletDailySales, err error) {
+	ctx, span := startSpan(ctx, "SalesRollupRepository.Daily")
+	defer func() { endSpan(span, len(days), err) }()
+
+	query := `SELECT outlet_id, business_date::text, orders, gross, discount, tax, refund_count, refunds
+		FROM sales_daily_rollups
+		WHERE business_date BETWEEN $1::date AND $2::date AND ($3::uuid IS NULL OR outlet_id = $3)
+		ORDER BY business_date, outlet_id`
+	rows, err := r.db.QueryContext(ctx, query, from, to, outletParam(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d OutletDailySales
+		if err := rows.Scan(&d.OutletID, &d.Date, &d.Orders, &d.Gross, &d.Discounts, &d.Tax, &d.RefundCount, &d.Refunds); err != nil {
+			return nil, err
+		}
+		d.Sales = d.withTotals()
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// Products returns the products sold from date from to date to (both
+// included, as YYYY-MM-DD) visible from ctx, summed over the days of the
+// product rollups, highest net first and at most limit of them. Products
+// that were deleted are listed together with an empty id and name.
+func (r *SalesRollupRepository) Products(ctx context.Context, from, to string, limit int) (products []ItemSales, err error) {
+	ctx, span := startSpan(ctx, "SalesRollupRepository.Products")
+	defer func() { endSpan(span, len(products), err) }()
+
+	query := `SELECT NULLIF(r.product_id::text, '` + deletedProduct + `'), p.name,
+			SUM(r.quantity), SUM(r.refunded_quantity), SUM(r.gross), SUM(r.discount), SUM(r.refunds)
+		FROM product_daily_rollups r
+		LEFT JOIN products p ON p.id = r.product_id
+		WHERE r.business_date BETWEEN $1::date AND $2::date AND ($3::uuid IS NULL OR r.outlet_id = $3)
+		GROUP BY r.product_id, p.name
+		ORDER BY SUM(r.gross) - SUM(r.discount) - SUM(r.refunds) DESC, r.product_id
+		LIMIT $4`
+	rows, err := r.db.QueryContext(ctx, query, from, to, outletParam(ctx), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			it       ItemSales
+			id, name sql.NullString
+		)
+		if err := rows.Scan(&id, &name, &it.Quantity, &it.RefundedQuantity, &it.Gross, &it.Discounts, &it.Refunds); err != nil {
+			return nil, err
+		}
+		it.ID, it.Name = id.String, name.String
+		it.Net = roundCents(it.Gross - it.Discounts - it.Refunds)
+		products = append(products, it)
+	}
+	return products, rows.Err()
+}
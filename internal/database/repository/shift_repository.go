@@ -0,0 +1,339 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrShiftOpen        = errors.New("register already has an open shift")
+	ErrShiftClosed      = errors.New("shift is already closed")
+	ErrInsufficientCash = errors.New("amount exceeds the cash expected in the drawer")
+)
+
+// Jenis mutasi kas laci selama shift.
+const (
+	CashSale   = "sale"
+	CashRefund = "refund"
+	CashPayIn  = "pay_in"
+	CashPayOut = "pay_out"
+)
+
+// Shift adalah satu sesi laci kas di sebuah register. ExpectedCash,
+// CountedCash dan Variance disimpan saat shift ditutup; selama shift masih
+// terbuka ExpectedCash dihitung dari mutasi kas saat dibaca (lihat Report).
+type Shift struct {
+	ID           string     `json:"id"`
+	OutletID     string     `json:"outlet_id"`
+	Register     string     `json:"register"`
+	OpeningFloat float64    `json:"opening_float"`
+	OpenedBy     *int       `json:"opened_by"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	OpenNote     string     `json:"open_note"`
+	ClosedBy     *int       `json:"closed_by"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	ExpectedCash *float64   `json:"expected_cash"`
+	CountedCash  *float64   `json:"counted_cash"`
+	Variance     *float64   `json:"variance"`
+	CloseNote    string     `json:"close_note"`
+}
+
+type CashMovement struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Reference string    `json:"reference"`
+	Note      string    `json:"note"`
+	CreatedBy *int      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShiftReport adalah rekap kas sebuah shift: modal awal ditambah penjualan
+// tunai dan pay-in, dikurangi refund tunai dan pay-out, menghasilkan kas
+// yang seharusnya ada di laci.
+type ShiftReport struct {
+	Shift
+	CashSales float64        `json:"cash_sales"`
+	Refunds   float64        `json:"refunds"`
+	PayIns    float64        `json:"pay_ins"`
+	PayOuts   float64        `json:"pay_outs"`
+	Movements []CashMovement `json:"movements"`
+}
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{db}
+}
+
+const shiftColumns = `s.id, s.outlet_id, s.register, s.opening_float, s.opened_by, s.opened_at, s.open_note,
+	s.closed_by, s.closed_at, s.expected_cash, s.counted_cash, s.variance, s.close_note`
+
+// shiftVisible membatasi shifts s ke outlet di $n; kantor pusat melihat
+// shift semua outlet.
+func shiftVisible(n int) string {
+	return fmt.Sprintf(`($%[1]d::uuid IS NULL OR s.outlet_id = $%[1]d)`, n)
+}
+
+// rowsQueryer dipenuhi *sql.DB dan *sql.Tx.
+type rowsQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func nullableInt(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+func nullableFloat(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
+
+func scanShift(row interface{ Scan(...any) error }) (*Shift, error) {
+	var s Shift
+	var openedBy, closedBy sql.NullInt64
+	var closedAt sql.NullTime
+	var expected, counted, variance sql.NullFloat64
+	err := row.Scan(&s.ID, &s.OutletID, &s.Register, &s.OpeningFloat, &openedBy, &s.OpenedAt, &s.OpenNote,
+		&closedBy, &closedAt, &expected, &counted, &variance, &s.CloseNote)
+	if err != nil {
+		return nil, err
+	}
+	s.OpenedBy, s.ClosedBy = nullableInt(openedBy), nullableInt(closedBy)
+	if closedAt.Valid {
+		s.ClosedAt = &closedAt.Time
+	}
+	s.ExpectedCash, s.CountedCash, s.Variance = nullableFloat(expected), nullableFloat(counted), nullableFloat(variance)
+	return &s, nil
+}
+
+// Open membuka shift baru di register milik outlet di ctx. ErrShiftOpen
+// dikembalikan bila register itu masih punya shift yang belum ditutup, dan
+// ErrDayClosed bila hari bisnis outlet sudah ditutup.
+func (r *ShiftRepository) Open(ctx context.Context, register string, openingFloat float64, note string, userID *int) (id string, err error) {
+	ctx, span := startSpan(ctx, "ShiftRepository.Open")
+	defer func() { endSpan(span, -1, err) }()
+
+	outlet := outletParam(ctx)
+	if !outlet.Valid {
+		return "", ErrOutletRequired
+	}
+	if closed, err := dayClosed(ctx, r.db, outlet); err != nil {
+		return "", err
+	} else if closed {
+		return "", ErrDayClosed
+	}
+	query := `INSERT INTO shifts (outlet_id, register, opening_float, open_note, opened_by)
+		SELECT $1, $2, $3, $4, $5
+		WHERE NOT EXISTS (SELECT 1 FROM shifts WHERE outlet_id = $1 AND register = $2 AND closed_at IS NULL)
+		RETURNING id`
+	err = r.db.QueryRowContext(ctx, query, outlet, register, openingFloat, note, userID).Scan(&id)
+	// dua request bersamaan bisa sama-sama lolos NOT EXISTS; yang kalah
+	// ditolak oleh unique index shift terbuka
+	if errors.Is(err, sql.ErrNoRows) || uniqueViolation(err, "shifts_open_register_key") {
+		err = ErrShiftOpen
+	}
+	return id, err
+}
+
+// ShiftFilter narrows GetAll. An empty Register lists every register;
+// OpenOnly keeps only shifts that are not closed yet.
+type ShiftFilter struct {
+	Register string
+	OpenOnly bool
+}
+
+// GetAll returns shifts of the outlet in ctx, newest first.
+func (r *ShiftRepository) GetAll(ctx context.Context, f ShiftFilter) (shifts []Shift, err error) {
+	ctx, span := startSpan(ctx, "ShiftRepository.GetAll")
+	defer func() { endSpan(span, len(shifts), err) }()
+
+	query := `SELECT ` + shiftColumns + ` FROM shifts s
+		WHERE ($1 = '' OR s.register = $1) AND (NOT $2 OR s.closed_at IS NULL) AND ` + shiftVisible(3) + `
+		ORDER BY s.opened_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, f.Register, f.OpenOnly, outletParam(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, *s)
+	}
+	return shifts, rows.Err()
+}
+
+// Report returns the shift with its cash totals and movements. For an open
+// shift ExpectedCash is the cash the drawer should hold right now.
+func (r *ShiftRepository) Report(ctx context.Context, id string) (_ *ShiftReport, err error) {
+	ctx, span := startSpan(ctx, "ShiftRepository.Report")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + shiftColumns + ` FROM shifts s WHERE s.id = $1 AND ` + shiftVisible(2)
+	s, err := scanShift(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	return shiftReport(ctx, r.db, s)
+}
+
+// shiftReport melengkapi shift dengan total dan daftar mutasi kasnya.
+func shiftReport(ctx context.Context, db rowsQueryer, s *Shift) (*ShiftReport, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, type, amount, reference, note, created_by, created_at
+		FROM shift_cash_movements WHERE shift_id = $1 ORDER BY created_at, id`, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rep := &ShiftReport{Shift: *s, Movements: []CashMovement{}}
+	for rows.Next() {
+		var m CashMovement
+		var createdBy sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.Type, &m.Amount, &m.Reference, &m.Note, &createdBy, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		m.CreatedBy = nullableInt(createdBy)
+		switch m.Type {
+		case CashSale:
+			rep.CashSales += m.Amount
+		case CashRefund:
+			rep.Refunds += m.Amount
+		case CashPayIn:
+			rep.PayIns += m.Amount
+		case CashPayOut:
+			rep.PayOuts += m.Amount
+		}
+		rep.Movements = append(rep.Movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if rep.ExpectedCash == nil {
+		expected := rep.Expected()
+		rep.ExpectedCash = &expected
+	}
+	return rep, nil
+}
+
+// Expected menghitung kas yang seharusnya ada di laci dari modal awal dan
+// mutasi kasnya. Pembulatan ke sen mengikuti kolom NUMERIC(14, 2).
+func (r ShiftReport) Expected() float64 {
+	return roundCents(r.OpeningFloat + r.CashSales - r.Refunds + r.PayIns - r.PayOuts)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// AddMovement mencatat mutasi kas pada shift yang masih terbuka.
+// sql.ErrNoRows dikembalikan bila shift tidak ada atau milik outlet lain,
+// ErrShiftClosed bila shift sudah ditutup, ErrDayClosed bila hari bisnis
+// outlet sudah ditutup, dan ErrInsufficientCash bila refund atau pay-out
+// melebihi kas yang seharusnya ada di laci.
+func (r *ShiftRepository) AddMovement(ctx context.Context, shiftID string, m CashMovement) (id string, err error) {
+	ctx, span := startSpan(ctx, "ShiftRepository.AddMovement")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// kunci baris shift supaya penutupan dan mutasi keluar lain menunggu
+	s, err := lockOpenShift(ctx, tx, shiftID)
+	if err != nil {
+		return "", err
+	}
+	if closed, err := dayClosed(ctx, tx, s.OutletID); err != nil {
+		return "", err
+	} else if closed {
+		return "", ErrDayClosed
+	}
+	if m.Type == CashRefund || m.Type == CashPayOut {
+		rep, err := shiftReport(ctx, tx, s)
+		if err != nil {
+			return "", err
+		}
+		if m.Amount > rep.Expected() {
+			return "", ErrInsufficientCash
+		}
+	}
+
+	query := `INSERT INTO shift_cash_movements (shift_id, type, amount, reference, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := tx.QueryRowContext(ctx, query, shiftID, m.Type, m.Amount, m.Reference, m.Note, m.CreatedBy).Scan(&id); err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+// lockOpenShift membaca shift yang terlihat oleh outlet di ctx dengan FOR
+// UPDATE dan memastikan shift itu belum ditutup.
+func lockOpenShift(ctx context.Context, tx *sql.Tx, id string) (*Shift, error) {
+	query := `SELECT ` + shiftColumns + ` FROM shifts s WHERE s.id = $1 AND ` + shiftVisible(2) + ` FOR UPDATE`
+	s, err := scanShift(tx.QueryRowContext(ctx, query, id, outletParam(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	if s.ClosedAt != nil {
+		return nil, ErrShiftClosed
+	}
+	return s, nil
+}
+
+// Close menutup shift dengan jumlah kas hasil hitungan kasir, lalu
+// menyimpan kas yang seharusnya ada beserta selisihnya. Laporan yang
+// dikembalikan sama dengan Report setelah shift ditutup.
+func (r *ShiftRepository) Close(ctx context.Context, id string, counted float64, note string, userID *int) (_ *ShiftReport, err error) {
+	ctx, span := startSpan(ctx, "ShiftRepository.Close")
+	defer func() { endSpan(span, -1, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	s, err := lockOpenShift(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	rep, err := shiftReport(ctx, tx, s)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := rep.Expected()
+	variance := roundCents(counted - expected)
+	query := `UPDATE shifts SET closed_at = now(), closed_by = $2, expected_cash = $3, counted_cash = $4, variance = $5, close_note = $6
+		WHERE id = $1 RETURNING closed_at`
+	var closedAt time.Time
+	if err := tx.QueryRowContext(ctx, query, id, userID, expected, counted, variance, note).Scan(&closedAt); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	rep.ClosedBy, rep.ClosedAt, rep.CloseNote = userID, &closedAt, note
+	rep.ExpectedCash, rep.CountedCash, rep.Variance = &expected, &counted, &variance
+	return rep, nil
+}
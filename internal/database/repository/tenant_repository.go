@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"maspos-be-go/internal/scope"
+)
+
+var ErrTenantSlugTaken = errors.New("tenant slug is already taken")
+
+// Tenant adalah satu merchant. Tabel tenants sendiri tidak dibatasi RLS
+// karena dibaca sebelum tenant sebuah request diketahui.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TenantRepository struct {
+	db *sql.DB
+}
+
+func NewTenantRepository(db *sql.DB) *TenantRepository {
+	return &TenantRepository{db}
+}
+
+const tenantColumns = `id, slug, name, created_at`
+
+func scanTenant(row interface{ Scan(...any) error }) (*Tenant, error) {
+	var t Tenant
+	if err := row.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Create mendaftarkan tenant baru beserta pengaturan poin bawaannya dalam
+// satu transaksi. ID-nya dibuat lebih dulu supaya transaksi bisa langsung
+// berjalan sebagai tenant tersebut.
+func (r *TenantRepository) Create(ctx context.Context, slug, name string) (id string, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
+
+	var taken bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tenants WHERE slug = $1)`, slug).Scan(&taken); err != nil {
+		return "", err
+	}
+	if taken {
+		return "", ErrTenantSlugTaken
+	}
+
+	id = uuid.NewString()
+	tx, err := r.db.BeginTx(scope.WithTenant(ctx, id), nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO tenants (id, slug, name) VALUES ($1, $2, $3)`, id, slug, name); err != nil {
+		return "", err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO loyalty_settings (id) VALUES (1)`); err != nil {
+		return "", err
+	}
+	return id, tx.Commit()
+}
+
+func (r *TenantRepository) GetAll(ctx context.Context) (tenants []Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.GetAll")
+	defer func() { endSpan(span, len(tenants), err) }()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+tenantColumns+` FROM tenants ORDER BY slug`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, *t)
+	}
+	return tenants, rows.Err()
+}
+
+func (r *TenantRepository) GetBySlug(ctx context.Context, slug string) (_ *Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.GetBySlug")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + tenantColumns + ` FROM tenants WHERE slug = $1`
+	return scanTenant(r.db.QueryRowContext(ctx, query, slug))
+}
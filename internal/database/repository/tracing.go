@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("maspos-be-go/internal/database/repository")
+
+// startSpan membuka span untuk satu pemanggilan repository. name adalah nama
+// statement, misalnya "ProductRepository.GetAll"; durasinya adalah durasi span.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.statement.name", name),
+		),
+	)
+}
+
+// endSpan mencatat jumlah baris yang dikembalikan (abaikan bila rows < 0)
+// dan error selain sql.ErrNoRows, lalu menutup span.
+func endSpan(span trace.Span, rows int, err error) {
+	if rows >= 0 {
+		span.SetAttributes(attribute.Int("db.rows", rows))
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// rowCount untuk query satu baris: 1 bila berhasil, 0 bila tidak.
+func rowCount(err error) int {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// updateBuilder menyusun klausa SET untuk UPDATE parsial. Nama kolom selalu
+// berasal dari kode, bukan dari input, jadi aman disisipkan ke query.
+type updateBuilder struct {
+	sets []string
+	args []any
+}
+
+func (b *updateBuilder) set(column string, value any) {
+	b.args = append(b.args, value)
+	b.sets = append(b.sets, fmt.Sprintf("%s = $%d", column, len(b.args)))
+}
+
+// queryer dipenuhi oleh *sql.DB maupun *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// updateVersioned menjalankan UPDATE parsial pada tabel katalog berversi dan
+// mengembalikan versi barunya. version 0 berarti tanpa pemeriksaan versi.
+// Hanya baris milik outlet di ctx yang bisa diubah (lihat versionMismatch).
+func updateVersioned(ctx context.Context, db queryer, table, id string, version int, b updateBuilder) (newVersion int, err error) {
+	sets := append(b.sets, "version = version + 1")
+	args := append(b.args, id, version, outletParam(ctx))
+	n := len(args)
+	query := fmt.Sprintf(`UPDATE %s t SET %s WHERE t.id = $%d AND ($%d = 0 OR t.version = $%d) AND %s RETURNING t.version`,
+		table, strings.Join(sets, ", "), n-2, n-1, n-1, ownedBy("t", n))
+
+	err = db.QueryRowContext(ctx, query, args...).Scan(&newVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = versionMismatch(ctx, db, table, id)
+	}
+	return newVersion, err
+}
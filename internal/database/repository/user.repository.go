@@ -14,6 +14,10 @@ type User struct {
     Name      string
     Email     string
     Password  string
+    // HeadOffice memberi akses ke semua outlet dan ke katalog pusat
+    HeadOffice bool
+    // Supervisor boleh menyetujui refund
+    Supervisor bool
     CreatedAt time.Time
 }
 
@@ -24,14 +28,16 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 func (r *UserRepository) Create(
 	ctx context.Context,
 	name, email, password string,
-) error {
+) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.Create")
+	defer func() { endSpan(span, -1, err) }()
 
 	query := `
 		INSERT INTO users (name, email, password, created_at)
 		VALUES ($1, $2, $3, $4)
 	`
 
-	_, err := r.db.ExecContext(
+	_, err = r.db.ExecContext(
 		ctx,
 		query,
 		name,
@@ -43,19 +49,24 @@ func (r *UserRepository) Create(
 	return err
 }
 
-func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (_ *User, err error) {
+    ctx, span := startSpan(ctx, "UserRepository.GetByEmail")
+    defer func() { endSpan(span, rowCount(err), err) }()
+
     query := `
-        SELECT id, name, email, password, created_at 
+        SELECT id, name, email, password, head_office, supervisor, created_at
         FROM users 
         WHERE email = $1
     `
 
     var user User
-    err := r.db.QueryRowContext(ctx, query, email).Scan(
+    err = r.db.QueryRowContext(ctx, query, email).Scan(
         &user.ID,
         &user.Name,
         &user.Email,
         &user.Password,
+        &user.HeadOffice,
+        &user.Supervisor,
         &user.CreatedAt,
     )
 
@@ -68,11 +79,32 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*User, e
 func (r *UserRepository) ExistsByEmail(
 	ctx context.Context,
 	email string,
-) (bool, error) {
+) (exists bool, err error) {
+	ctx, span := startSpan(ctx, "UserRepository.ExistsByEmail")
+	defer func() { endSpan(span, rowCount(err), err) }()
 
-	var exists bool
 	query := `SELECT EXISTS (SELECT 1 FROM users WHERE email=$1)`
 
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
+	err = r.db.QueryRowContext(ctx, query, email).Scan(&exists)
 	return exists, err
 }
+
+// SetHeadOffice memberi atau mencabut hak kantor pusat user dengan email
+// tersebut di tenant ctx. Perubahan berlaku saat user login berikutnya.
+func (r *UserRepository) SetHeadOffice(ctx context.Context, email string, headOffice bool) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.SetHeadOffice")
+	defer func() { endSpan(span, -1, err) }()
+
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET head_office = $2 WHERE email = $1`, email, headOffice)
+	return affectedOne(res, err)
+}
+
+// SetSupervisor memberi atau mencabut hak menyetujui refund user dengan
+// email tersebut di tenant ctx.
+func (r *UserRepository) SetSupervisor(ctx context.Context, email string, supervisor bool) (err error) {
+	ctx, span := startSpan(ctx, "UserRepository.SetSupervisor")
+	defer func() { endSpan(span, -1, err) }()
+
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET supervisor = $2 WHERE email = $1`, email, supervisor)
+	return affectedOne(res, err)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrVersionConflict is returned by updates and deletes whose expected
+// version no longer matches the stored row.
+var ErrVersionConflict = errors.New("row was modified by another request")
+
+// versionMismatch dipanggil setelah UPDATE/DELETE bersyarat versi dan outlet
+// tidak mengenai baris apa pun: sql.ErrNoRows bila barisnya tidak ada atau
+// tidak terlihat oleh outlet di ctx, ErrSharedCatalog bila barisnya milik
+// katalog pusat, dan ErrVersionConflict bila versinya sudah berubah.
+func versionMismatch(ctx context.Context, db queryer, table, id string) error {
+	var readable, owned bool
+	query := `SELECT ` + readableBy("t", 2) + `, ` + ownedBy("t", 2) + ` FROM ` + table + ` t WHERE t.id = $1`
+	err := db.QueryRowContext(ctx, query, id, outletParam(ctx)).Scan(&readable, &owned)
+	switch {
+	case err != nil:
+		return err
+	case !readable:
+		return sql.ErrNoRows
+	case !owned:
+		return ErrSharedCatalog
+	}
+	return ErrVersionConflict
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDayClosed  = errors.New("the business day is already closed")
+	ErrShiftsOpen = errors.New("close every shift of the day before closing the day")
+)
+
+// ZReport adalah laporan tutup hari sebuah outlet. Nomornya berurutan per
+// outlet dan isinya tidak berubah setelah dibuat. Laporan mencakup order,
+// refund dan mutasi kas dengan PeriodStart <= created_at < PeriodEnd;
+// PeriodStart adalah PeriodEnd laporan sebelumnya, jadi hari yang terlewat
+// ikut dihitung di laporan berikutnya dan setiap transaksi masuk tepat satu
+// laporan.
+type ZReport struct {
+	ID           string     `json:"id"`
+	OutletID     string     `json:"outlet_id"`
+	Number       int        `json:"number"`
+	BusinessDate string     `json:"business_date"`
+	Timezone     string     `json:"timezone"`
+	PeriodStart  *time.Time `json:"period_start"`
+	PeriodEnd    time.Time  `json:"period_end"`
+	ZReportTotals
+	ClosedBy *int      `json:"closed_by"`
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+// ZReportTotals adalah isi laporan yang dibekukan saat hari ditutup.
+// Penjualan dihitung dari order dan refund seperti laporan penjualan,
+// Tenders adalah uang yang diterima per metode pembayaran (tunai sudah
+// dikurangi kembalian) dan RefundTenders uang yang dikembalikan per
+// metode. Kas yang seharusnya ada, hasil hitungan dan selisihnya
+// dijumlahkan dari shift yang ditutup dalam periode laporan.
+type ZReportTotals struct {
+	Sales
+	Tenders       []TenderTotal  `json:"tenders"`
+	RefundTenders []TenderTotal  `json:"refund_tenders"`
+	PayIns        float64        `json:"pay_ins"`
+	PayOuts       float64        `json:"pay_outs"`
+	ExpectedCash  float64        `json:"expected_cash"`
+	CountedCash   float64        `json:"counted_cash"`
+	Variance      float64        `json:"variance"`
+	Shifts        []ZReportShift `json:"shifts"`
+}
+
+// TenderTotal adalah jumlah order atau refund dan nilainya untuk satu
+// metode pembayaran.
+type TenderTotal struct {
+	Method string  `json:"method"`
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+type ZReportShift struct {
+	ID           string    `json:"id"`
+	Register     string    `json:"register"`
+	OpenedAt     time.Time `json:"opened_at"`
+	ClosedAt     time.Time `json:"closed_at"`
+	OpeningFloat float64   `json:"opening_float"`
+	ExpectedCash float64   `json:"expected_cash"`
+	CountedCash  float64   `json:"counted_cash"`
+	Variance     float64   `json:"variance"`
+}
+
+type ZReportRepository struct {
+	db *sql.DB
+}
+
+func NewZReportRepository(db *sql.DB) *ZReportRepository {
+	return &ZReportRepository{db}
+}
+
+const zReportColumns = `id, outlet_id, number, business_date::text, timezone, period_start, period_end, totals, closed_by, closed_at`
+
+func scanZReport(row interface{ Scan(...any) error }) (*ZReport, error) {
+	var (
+		z           ZReport
+		periodStart sql.NullTime
+		totals      []byte
+		closedBy    sql.NullInt64
+	)
+	err := row.Scan(&z.ID, &z.OutletID, &z.Number, &z.BusinessDate, &z.Timezone, &periodStart, &z.PeriodEnd,
+		&totals, &closedBy, &z.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	if periodStart.Valid {
+		z.PeriodStart = &periodStart.Time
+	}
+	z.ClosedBy = nullableInt(closedBy)
+	if err := json.Unmarshal(totals, &z.ZReportTotals); err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+// dayClosed melaporkan apakah saat ini sudah masuk periode Z-report outlet,
+// yaitu hari bisnisnya sudah ditutup.
+func dayClosed(ctx context.Context, q queryer, outletID any) (closed bool, err error) {
+	query := `SELECT EXISTS (SELECT 1 FROM z_reports WHERE outlet_id = $1 AND period_end > now())`
+	err = q.QueryRowContext(ctx, query, outletID).Scan(&closed)
+	return closed, err
+}
+
+// Close menutup hari bisnis date (tengah malam di zona waktu laporan) untuk
+// outlet di ctx dan membuat Z-report bernomor berikutnya. ErrDayClosed
+// dikembalikan bila date atau hari setelahnya sudah ditutup, dan
+// ErrShiftsOpen bila masih ada shift yang dibuka sebelum akhir hari itu.
+func (r *ZReportRepository) Close(ctx context.Context, date time.Time, userID *int) (_ *ZReport, err error) {
+	ctx, span := startSpan(ctx, "ZReportRepository.Close")
+	defer func() { endSpan(span, -1, err) }()
+
+	outlet := outletParam(ctx)
+	if !outlet.Valid {
+		return nil, ErrOutletRequired
+	}
+	z := &ZReport{
+		OutletID:     outlet.String,
+		Number:       1,
+		BusinessDate: date.Format(time.DateOnly),
+		Timezone:     date.Location().String(),
+		PeriodEnd:    date.AddDate(0, 0, 1),
+		ClosedBy:     userID,
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// penutupan hari di outlet yang sama berjalan satu per satu
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM outlets WHERE id = $1 FOR UPDATE`, outlet); err != nil {
+		return nil, err
+	}
+
+	var (
+		lastDate string
+		lastEnd  time.Time
+	)
+	query := `SELECT number, business_date::text, period_end FROM z_reports WHERE outlet_id = $1 ORDER BY number DESC LIMIT 1`
+	err = tx.QueryRowContext(ctx, query, outlet).Scan(&z.Number, &lastDate, &lastEnd)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	case z.BusinessDate <= lastDate || !z.PeriodEnd.After(lastEnd):
+		return nil, ErrDayClosed
+	default:
+		z.Number++
+		z.PeriodStart = &lastEnd
+	}
+
+	var open bool
+	query = `SELECT EXISTS (SELECT 1 FROM shifts WHERE outlet_id = $1 AND closed_at IS NULL AND opened_at < $2)`
+	if err := tx.QueryRowContext(ctx, query, outlet, z.PeriodEnd).Scan(&open); err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, ErrShiftsOpen
+	}
+
+	if z.ZReportTotals, err = zReportTotals(ctx, tx, outlet, z.PeriodStart, z.PeriodEnd); err != nil {
+		return nil, err
+	}
+	totals, err := json.Marshal(z.ZReportTotals)
+	if err != nil {
+		return nil, err
+	}
+	query = `INSERT INTO z_reports (outlet_id, number, business_date, timezone, period_start, period_end, totals, closed_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::jsonb, $8) RETURNING id, closed_at`
+	err = tx.QueryRowContext(ctx, query, outlet, z.Number, z.BusinessDate, z.Timezone, z.PeriodStart, z.PeriodEnd,
+		string(totals), userID).Scan(&z.ID, &z.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	return z, tx.Commit()
+}
+
+// zReportTotals menjumlahkan order, refund, mutasi kas dan shift yang
+// ditutup dalam periode start (nil berarti sejak awal) sampai end.
+func zReportTotals(ctx context.Context, tx *sql.Tx, outlet sql.NullString, start *time.Time, end time.Time) (t ZReportTotals, err error) {
+	f := ReportFilter{To: end}
+	if start != nil {
+		f.From = *start
+	}
+	err = salesBy(ctx, tx, f, `NULL::text`, `''`, func(_, _ string, s Sales) { t.Sales = s })
+	if err != nil {
+		return t, err
+	}
+
+	query := `SELECT method, COUNT(DISTINCT order_id), SUM(amount)
+		FROM (
+			SELECT t.method, t.order_id, t.amount
+			FROM order_tenders t
+			JOIN orders o ON o.id = t.order_id
+			WHERE o.outlet_id = $1 AND ($2::timestamptz IS NULL OR o.created_at >= $2) AND o.created_at < $3
+			UNION ALL
+			SELECT 'cash', o.id, -o.change
+			FROM orders o
+			WHERE o.outlet_id = $1 AND ($2::timestamptz IS NULL OR o.created_at >= $2) AND o.created_at < $3 AND o.change > 0
+		) x
+		GROUP BY method
+		ORDER BY method`
+	if t.Tenders, err = tenderTotals(ctx, tx, query, outlet, start, end); err != nil {
+		return t, err
+	}
+	query = `SELECT method, COUNT(*), SUM(amount)
+		FROM refunds
+		WHERE outlet_id = $1 AND ($2::timestamptz IS NULL OR created_at >= $2) AND created_at < $3
+		GROUP BY method
+		ORDER BY method`
+	if t.RefundTenders, err = tenderTotals(ctx, tx, query, outlet, start, end); err != nil {
+		return t, err
+	}
+
+	query = `SELECT COALESCE(SUM(m.amount) FILTER (WHERE m.type = 'pay_in'), 0),
+			COALESCE(SUM(m.amount) FILTER (WHERE m.type = 'pay_out'), 0)
+		FROM shift_cash_movements m
+		JOIN shifts s ON s.id = m.shift_id
+		WHERE s.outlet_id = $1 AND ($2::timestamptz IS NULL OR m.created_at >= $2) AND m.created_at < $3`
+	if err := tx.QueryRowContext(ctx, query, outlet, start, end).Scan(&t.PayIns, &t.PayOuts); err != nil {
+		return t, err
+	}
+
+	query = `SELECT id, register, opened_at, closed_at, opening_float, expected_cash, counted_cash, variance
+		FROM shifts
+		WHERE outlet_id = $1 AND closed_at IS NOT NULL AND ($2::timestamptz IS NULL OR closed_at >= $2) AND closed_at < $3
+		ORDER BY closed_at, id`
+	rows, err := tx.QueryContext(ctx, query, outlet, start, end)
+	if err != nil {
+		return t, err
+	}
+	defer rows.Close()
+
+	t.Shifts = []ZReportShift{}
+	for rows.Next() {
+		var s ZReportShift
+		if err := rows.Scan(&s.ID, &s.Register, &s.OpenedAt, &s.ClosedAt, &s.OpeningFloat,
+			&s.ExpectedCash, &s.CountedCash, &s.Variance); err != nil {
+			return t, err
+		}
+		t.ExpectedCash += s.ExpectedCash
+		t.CountedCash += s.CountedCash
+		t.Variance += s.Variance
+		t.Shifts = append(t.Shifts, s)
+	}
+	t.ExpectedCash, t.CountedCash, t.Variance = roundCents(t.ExpectedCash), roundCents(t.CountedCash), roundCents(t.Variance)
+	return t, rows.Err()
+}
+
+// GetAll returns the Z-reports visible from ctx, newest first.
+func (r *ZReportRepository) GetAll(ctx context.Context) (reports []ZReport, err error) {
+	ctx, span := startSpan(ctx, "ZReportRepository.GetAll")
+	defer func() { endSpan(span, len(reports), err) }()
+
+	query := `SELECT ` + zReportColumns + ` FROM z_reports
+		WHERE ($1::uuid IS NULL OR outlet_id = $1)
+		ORDER BY business_date DESC, outlet_id`
+	rows, err := r.db.QueryContext(ctx, query, outletParam(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		z, err := scanZReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *z)
+	}
+	return reports, rows.Err()
+}
+
+func (r *ZReportRepository) GetByID(ctx context.Context, id string) (_ *ZReport, err error) {
+	ctx, span := startSpan(ctx, "ZReportRepository.GetByID")
+	defer func() { endSpan(span, rowCount(err), err) }()
+
+	query := `SELECT ` + zReportColumns + ` FROM z_reports WHERE id = $1 AND ($2::uuid IS NULL OR outlet_id = $2)`
+	return scanZReport(r.db.QueryRowContext(ctx, query, id, outletParam(ctx)))
+}
+
+// tenderTotals menjalankan query yang mengembalikan method, jumlah dan nilai
+// per metode pembayaran.
+func tenderTotals(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]TenderTotal, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []TenderTotal{}
+	for rows.Next() {
+		var t TenderTotal
+		if err := rows.Scan(&t.Method, &t.Count, &t.Amount); err != nil {
+			return nil, err
+		}
+		t.Amount = roundCents(t.Amount)
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+)
+
+func TestRollupsMatchSalesReport(t *testing.T) {
+	db := migratedAsApp(t)
+	fx := newSalesFixture(t, db, "kopi-rollup", loyalty.Rules{})
+	orders := repository.NewOrderRepository(db)
+
+	var first *repository.Order
+	for _, qty := range []float64{2, 1} {
+		o, err := orders.Create(fx.ctx, repository.NewOrder{
+			ShiftID: fx.shift,
+			Lines:   []repository.NewOrderLine{{ProductID: fx.product, Quantity: qty}},
+			Taxes:   []order.Tax{{Name: "PB1", Amount: 1800 * qty}},
+			Tenders: []order.Tender{{Method: order.TenderCash, Amount: 19800 * qty}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = o
+		}
+	}
+	if _, err := repository.NewRefundRepository(db).Create(fx.ctx, repository.NewRefund{
+		OrderID: first.ID, ShiftID: fx.shift, Reason: "changed_mind", Method: order.TenderCash,
+		Lines: []repository.NewRefundLine{{OrderLineID: first.Lines[0].ID, Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	const tz = "Asia/Jakarta"
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	today := from.Format(time.DateOnly)
+
+	rollups := repository.NewSalesRollupRepository(db)
+	if _, _, err := rollups.Rebuild(fx.ctx, tz, 0); err != nil {
+		t.Fatal(err)
+	}
+	days, err := rollups.Daily(fx.ctx, today, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := repository.NewReportRepository(db).SalesTotal(fx.ctx, repository.ReportFilter{From: from, To: from.AddDate(0, 0, 1), TZ: tz})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := repository.SumSales(days); got != report {
+		t.Errorf("rollups %+v do not match the sales report %+v", got, report)
+	}
+	if report.Orders != 2 || report.RefundCount != 1 || report.Net != 36000 || report.Tax != 3600 {
+		t.Errorf("unexpected sales report %+v", report)
+	}
+
+	_, mismatches, products, err := rollups.Check(fx.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 || len(products) != 0 {
+		t.Errorf("expected no mismatches, got %v and %v", mismatches, products)
+	}
+}
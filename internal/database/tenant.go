@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"maspos-be-go/internal/scope"
+)
+
+// setTenant mengisi variabel yang dibaca policy RLS (lihat migrasi
+// 000010_add_tenants). is_local = true membuat nilainya hilang saat
+// transaksi selesai, jadi koneksi yang kembali ke pool tidak membawa tenant
+// request sebelumnya.
+const setTenant = `SELECT set_config('app.tenant_id', $1, true)`
+
+// tenantConnector membungkus connector pgx supaya setiap transaksi yang
+// dibuka dengan context ber-tenant (scope.WithTenant) langsung menjalankan
+// setTenant. Statement di luar transaksi dibungkus dalam transaksinya
+// sendiri dengan cara yang sama, sehingga repository cukup meneruskan ctx.
+// Tanpa tenant di ctx, statement berjalan apa adanya dan policy RLS tidak
+// menampilkan satu baris pun dari tabel tenant.
+type tenantConnector struct {
+	driver.Connector
+}
+
+// pgConn adalah interface yang diimplementasikan *stdlib.Conn dan diteruskan
+// oleh tenantConn, termasuk CheckNamedValue agar argumen array tetap bisa
+// dikirim.
+type pgConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.NamedValueChecker
+	driver.Pinger
+	driver.SessionResetter
+}
+
+func (t tenantConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := t.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tenantConn{pgConn: conn.(pgConn)}, nil
+}
+
+// tenantConn tidak dipakai bersamaan oleh dua goroutine; database/sql
+// menjamin itu, jadi inTx tidak perlu dikunci.
+type tenantConn struct {
+	pgConn
+	inTx bool
+}
+
+func (c *tenantConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	tx, err := c.pgConn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if tenant := scope.Tenant(ctx); tenant != "" {
+		if _, err := c.pgConn.ExecContext(ctx, setTenant, []driver.NamedValue{{Ordinal: 1, Value: tenant}}); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	c.inTx = true
+	return &tenantTx{Tx: tx, conn: c}, nil
+}
+
+func (c *tenantConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.inTx || scope.Tenant(ctx) == "" {
+		return c.pgConn.ExecContext(ctx, query, args)
+	}
+	tx, err := c.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.pgConn.ExecContext(ctx, query, args)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return res, tx.Commit()
+}
+
+func (c *tenantConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.inTx || scope.Tenant(ctx) == "" {
+		return c.pgConn.QueryContext(ctx, query, args)
+	}
+	tx, err := c.BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.pgConn.QueryContext(ctx, query, args)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	// transaksi baru di-commit setelah semua baris dibaca
+	return &tenantRows{Rows: rows, tx: tx}, nil
+}
+
+type tenantTx struct {
+	driver.Tx
+	conn *tenantConn
+}
+
+func (t *tenantTx) Commit() error {
+	t.conn.inTx = false
+	return t.Tx.Commit()
+}
+
+func (t *tenantTx) Rollback() error {
+	t.conn.inTx = false
+	return t.Tx.Rollback()
+}
+
+type tenantRows struct {
+	driver.Rows
+	tx driver.Tx
+}
+
+func (r *tenantRows) Close() error {
+	if err := r.Rows.Close(); err != nil {
+		r.tx.Rollback()
+		return err
+	}
+	return r.tx.Commit()
+}
@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/scope"
+)
+
+// baseSchema adalah tabel yang sudah ada sebelum migrasi pertama.
+const baseSchema = `
+CREATE TABLE users (
+    id         SERIAL PRIMARY KEY,
+    name       TEXT NOT NULL,
+    email      TEXT NOT NULL UNIQUE,
+    password   TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE categories (
+    id   UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL
+);
+CREATE TABLE products (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category_id UUID NOT NULL REFERENCES categories(id),
+    name        TEXT NOT NULL,
+    price       NUMERIC(14,2) NOT NULL,
+    picture     TEXT NOT NULL DEFAULT ''
+);`
+
+// appRole tidak superuser dan tidak BYPASSRLS, sama seperti role aplikasi
+// di produksi; user bawaan container adalah superuser yang melewati RLS.
+const appRole = `
+CREATE ROLE maspos_app LOGIN PASSWORD 'app-password' NOSUPERUSER NOBYPASSRLS;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO maspos_app;
+GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO maspos_app;`
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// migrate menjalankan semua migrasi sekali sebagai superuser; setiap test
+// memakai tenant-nya sendiri, jadi database bisa dipakai bersama.
+func migrate() error {
+	admin, err := sql.Open("pgx", testConfig.DSN())
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	files, err := filepath.Glob("migrations/*.up.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	stmts := []string{baseSchema}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		stmts = append(stmts, string(b))
+	}
+	stmts = append(stmts, appRole)
+	for i, stmt := range stmts {
+		if _, err := admin.Exec(stmt); err != nil {
+			return fmt.Errorf("migration step %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// migratedAsApp memastikan database sudah dimigrasi lalu membuka Service
+// sebagai role aplikasi.
+func migratedAsApp(t *testing.T) *sql.DB {
+	t.Helper()
+	migrateOnce.Do(func() { migrateErr = migrate() })
+	if migrateErr != nil {
+		t.Fatal(migrateErr)
+	}
+
+	cfg := testConfig
+	cfg.Username = "maspos_app"
+	cfg.Password = "app-password"
+	srv, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv.DB()
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db := migratedAsApp(t)
+	tenants := repository.NewTenantRepository(db)
+	categories := repository.NewCategoryRepository(db)
+	products := repository.NewProductRepository(db)
+
+	senja, err := tenants.Create(context.Background(), "kopi-senja", "Kopi Senja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagi, err := tenants.Create(context.Background(), "kopi-pagi", "Kopi Pagi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tenants.Create(context.Background(), "kopi-pagi", "Kopi Pagi 2"); !errors.Is(err, repository.ErrTenantSlugTaken) {
+		t.Fatalf("expected ErrTenantSlugTaken, got %v", err)
+	}
+	ctxA := scope.WithTenant(context.Background(), senja)
+	ctxB := scope.WithTenant(context.Background(), pagi)
+
+	catA, err := categories.Create(ctxA, repository.Category{Name: "Kopi", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prodA, err := products.Create(ctxA, repository.Product{SKU: "K-1", CategoryID: catA, Name: "Kopi Susu", Price: 18000, Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("other tenant cannot list", func(t *testing.T) {
+		cats, err := categories.GetAll(ctxB, repository.CategoryFilter{})
+		if err != nil || len(cats) != 0 {
+			t.Errorf("expected no categories, got %v, %v", cats, err)
+		}
+		prods, err := products.GetAll(ctxB, repository.ProductFilter{})
+		if err != nil || len(prods) != 0 {
+			t.Errorf("expected no products, got %v, %v", prods, err)
+		}
+		n := 0
+		err = products.Export(ctxB, repository.ProductFilter{}, func(repository.ProductExport) error { n++; return nil })
+		if err != nil || n != 0 {
+			t.Errorf("expected an empty export, got %d rows, %v", n, err)
+		}
+	})
+
+	t.Run("other tenant cannot read by id", func(t *testing.T) {
+		if _, err := categories.GetByID(ctxB, catA); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("category: expected sql.ErrNoRows, got %v", err)
+		}
+		if _, err := products.GetByID(ctxB, prodA); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("product: expected sql.ErrNoRows, got %v", err)
+		}
+	})
+
+	t.Run("other tenant cannot write", func(t *testing.T) {
+		name := "Diambil alih"
+		if _, err := categories.Update(ctxB, catA, repository.CategoryChanges{Name: &name}, 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("category update: expected sql.ErrNoRows, got %v", err)
+		}
+		if _, err := products.Update(ctxB, prodA, repository.ProductChanges{Name: &name}, 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("product update: expected sql.ErrNoRows, got %v", err)
+		}
+		if err := products.Delete(ctxB, prodA, 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("product delete: expected sql.ErrNoRows, got %v", err)
+		}
+		if err := categories.Delete(ctxB, catA, 0); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("category delete: expected sql.ErrNoRows, got %v", err)
+		}
+		// kategori tenant lain tidak bisa dipakai sebagai kategori produk
+		_, err := products.Create(ctxB, repository.Product{CategoryID: catA, Name: "Teh", Price: 8000})
+		if !errors.Is(err, repository.ErrCategoryNotFound) {
+			t.Errorf("product create: expected ErrCategoryNotFound, got %v", err)
+		}
+		// menulis tenant_id lain secara eksplisit ditolak policy
+		if _, err := db.ExecContext(ctxB, `INSERT INTO categories (name, tenant_id) VALUES ('Susupan', $1)`, senja); err == nil {
+			t.Error("expected the policy to reject a row of another tenant")
+		}
+
+		p, err := products.GetByID(ctxA, prodA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Name != "Kopi Susu" || p.Version != 1 {
+			t.Errorf("product of tenant A changed: %+v", p)
+		}
+	})
+
+	t.Run("SKU is unique per tenant", func(t *testing.T) {
+		catB, err := categories.Create(ctxB, repository.Category{Name: "Kopi", Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := products.Create(ctxB, repository.Product{SKU: "K-1", CategoryID: catB, Name: "Kopi Hitam", Price: 12000}); err != nil {
+			t.Errorf("expected the same SKU in another tenant to be accepted, got %v", err)
+		}
+		prods, err := products.GetAll(ctxA, repository.ProductFilter{})
+		if err != nil || len(prods) != 1 || prods[0].ID != prodA {
+			t.Errorf("tenant A should only see its own product, got %v, %v", prods, err)
+		}
+	})
+
+	t.Run("no tenant sees nothing", func(t *testing.T) {
+		ctx := context.Background()
+		var n int
+		if err := db.QueryRowContext(ctx, `SELECT count(*) FROM categories`).Scan(&n); err != nil || n != 0 {
+			t.Errorf("expected no visible categories, got %d, %v", n, err)
+		}
+		if _, err := categories.Create(ctx, repository.Category{Name: "Tanpa tenant"}); err == nil {
+			t.Error("expected an insert without tenant to fail")
+		}
+	})
+
+	t.Run("tenant is not kept on pooled connections", func(t *testing.T) {
+		// koneksi tunggal memastikan statement berikutnya memakai koneksi
+		// yang baru saja dipakai tenant A
+		db.SetMaxOpenConns(1)
+		defer db.SetMaxOpenConns(0)
+		if _, err := categories.GetAll(ctxA, repository.CategoryFilter{}); err != nil {
+			t.Fatal(err)
+		}
+		var tenant sql.NullString
+		if err := db.QueryRow(`SELECT NULLIF(current_setting('app.tenant_id', true), '')`).Scan(&tenant); err != nil {
+			t.Fatal(err)
+		}
+		if tenant.Valid {
+			t.Errorf("expected no tenant outside a transaction, got %q", tenant.String)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+)
+
+func TestZReportTotals(t *testing.T) {
+	db := migratedAsApp(t)
+	fx := newSalesFixture(t, db, "kopi-zreport", loyalty.Rules{})
+	orders := repository.NewOrderRepository(db)
+
+	cash, err := orders.Create(fx.ctx, repository.NewOrder{
+		ShiftID: fx.shift,
+		Lines:   []repository.NewOrderLine{{ProductID: fx.product, Quantity: 1}},
+		Taxes:   []order.Tax{{Name: "PB1", Amount: 1800}},
+		Tenders: []order.Tender{{Method: order.TenderCash, Amount: 20000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orders.Create(fx.ctx, repository.NewOrder{
+		ShiftID: fx.shift,
+		Lines:   []repository.NewOrderLine{{ProductID: fx.product, Quantity: 2}},
+		Taxes:   []order.Tax{{Name: "PB1", Amount: 3600}},
+		Tenders: []order.Tender{{Method: order.TenderQRIS, Amount: 39600}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.NewRefundRepository(db).Create(fx.ctx, repository.NewRefund{
+		OrderID: cash.ID, ShiftID: fx.shift, Reason: "changed_mind", Method: order.TenderCash,
+		Lines: []repository.NewRefundLine{{OrderLineID: cash.Lines[0].ID, Quantity: 1}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.NewShiftRepository(db).Close(fx.ctx, fx.shift, 100000, "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(loc)
+	z, err := repository.NewZReportRepository(db).Close(fx.ctx, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sales := repository.Sales{Orders: 2, Gross: 54000, RefundCount: 1, Refunds: 18000, Net: 36000, Tax: 3600, Total: 39600, AverageTicket: 27000}
+	if z.Sales != sales {
+		t.Errorf("expected sales %+v, got %+v", sales, z.Sales)
+	}
+	tenders := []repository.TenderTotal{{Method: "cash", Count: 1, Amount: 19800}, {Method: "qris", Count: 1, Amount: 39600}}
+	if !reflect.DeepEqual(z.Tenders, tenders) {
+		t.Errorf("expected tenders %+v, got %+v", tenders, z.Tenders)
+	}
+	refunds := []repository.TenderTotal{{Method: "cash", Count: 1, Amount: 19800}}
+	if !reflect.DeepEqual(z.RefundTenders, refunds) {
+		t.Errorf("expected refund tenders %+v, got %+v", refunds, z.RefundTenders)
+	}
+	if z.ExpectedCash != 100000 || z.Variance != 0 {
+		t.Errorf("expected 100000 cash without variance, got %v and %v", z.ExpectedCash, z.Variance)
+	}
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context) (map[string]string, error)
+}
+
+func (c checkerFunc) Name() string { return c.name }
+
+func (c checkerFunc) Check(ctx context.Context) (map[string]string, error) { return c.fn(ctx) }
+
+// CheckerFunc turns a function into a named Checker.
+func CheckerFunc(name string, fn func(ctx context.Context) (map[string]string, error)) Checker {
+	return checkerFunc{name: name, fn: fn}
+}
+
+// Pinger is satisfied by *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// Database pings the database and reports connection pool statistics.
+func Database(db Pinger) Checker {
+	return CheckerFunc("database", func(ctx context.Context) (map[string]string, error) {
+		stats := db.Stats()
+		details := map[string]string{
+			"open_connections": strconv.Itoa(stats.OpenConnections),
+			"in_use":           strconv.Itoa(stats.InUse),
+			"idle":             strconv.Itoa(stats.Idle),
+			"wait_count":       strconv.FormatInt(stats.WaitCount, 10),
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return details, fmt.Errorf("db down: %w", err)
+		}
+		return details, nil
+	})
+}
+
+// Storage verifies that dir exists and a file can be written into it.
+func Storage(dir string) Checker {
+	return CheckerFunc("storage", func(ctx context.Context) (map[string]string, error) {
+		details := map[string]string{"path": dir}
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return details, fmt.Errorf("storage not writable: %w", err)
+		}
+		name := f.Name()
+		f.Close()
+		return details, os.Remove(name)
+	})
+}
+
+var errDiskUnsupported = errors.New("disk space check is not supported on this platform")
+
+// DiskSpace fails when the filesystem holding dir has less than minFree
+// bytes available.
+func DiskSpace(dir string, minFree uint64) Checker {
+	return CheckerFunc("disk_space", func(ctx context.Context) (map[string]string, error) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		free, err := freeBytes(abs)
+		if errors.Is(err, errDiskUnsupported) {
+			return map[string]string{"path": abs, "note": err.Error()}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		details := map[string]string{
+			"path":       abs,
+			"free_bytes": strconv.FormatUint(free, 10),
+			"min_bytes":  strconv.FormatUint(minFree, 10),
+		}
+		if free < minFree {
+			return details, fmt.Errorf("low disk space: %d bytes free, need %d", free, minFree)
+		}
+		return details, nil
+	})
+}
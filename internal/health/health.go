@@ -0,0 +1,114 @@
+// Package health menjalankan pemeriksaan kesiapan (readiness) API terhadap
+// dependensinya dan menyimpan hasilnya sebentar agar probe load balancer
+// tidak membebani PostgreSQL.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker memeriksa satu dependensi. Details boleh dikembalikan walaupun
+// pemeriksaan gagal.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) (details map[string]string, err error)
+}
+
+type CheckResult struct {
+	Status   string            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+	Duration string            `json:"duration"`
+}
+
+type Report struct {
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// Up reports whether every check passed.
+func (r Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+// Health runs a set of checkers and caches the combined report for ttl.
+type Health struct {
+	checkers []Checker
+	ttl      time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	report *Report
+}
+
+func New(ttl time.Duration, checkers ...Checker) *Health {
+	return &Health{
+		checkers: checkers,
+		ttl:      ttl,
+		timeout:  2 * time.Second,
+		now:      time.Now,
+	}
+}
+
+// Check returns the cached report when it is younger than ttl, otherwise
+// runs all checkers concurrently. Callers arriving while a check is running
+// wait for it instead of starting their own.
+func (h *Health) Check(ctx context.Context) Report {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.report != nil && h.now().Sub(h.report.CheckedAt) < h.ttl {
+		return *h.report
+	}
+
+	// hasil dipakai bersama oleh banyak request, jadi jangan ikut batal
+	// bila request yang memicu pemeriksaan terputus
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.timeout)
+	defer cancel()
+
+	report := Report{
+		Status:    StatusUp,
+		CheckedAt: h.now(),
+		Checks:    make(map[string]CheckResult, len(h.checkers)),
+	}
+
+	var wg sync.WaitGroup
+	var resMu sync.Mutex
+	for _, c := range h.checkers {
+		wg.Add(1)
+		go func(c Checker) {
+			defer wg.Done()
+			start := time.Now()
+			details, err := c.Check(ctx)
+			res := CheckResult{
+				Status:   StatusUp,
+				Details:  details,
+				Duration: time.Since(start).String(),
+			}
+			if err != nil {
+				res.Status = StatusDown
+				res.Error = err.Error()
+			}
+
+			resMu.Lock()
+			report.Checks[c.Name()] = res
+			if err != nil {
+				report.Status = StatusDown
+			}
+			resMu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	h.report = &report
+	return report
+}
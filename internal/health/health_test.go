@@ -0,0 +1,55 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckAggregatesAndCaches(t *testing.T) {
+	calls := 0
+	failing := CheckerFunc("database", func(ctx context.Context) (map[string]string, error) {
+		calls++
+		return map[string]string{"open_connections": "0"}, errors.New("db down")
+	})
+	ok := CheckerFunc("storage", func(ctx context.Context) (map[string]string, error) {
+		return nil, nil
+	})
+
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	h := New(5*time.Second, failing, ok)
+	h.now = func() time.Time { return now }
+
+	report := h.Check(context.Background())
+	if report.Up() {
+		t.Fatal("expected report to be down when a checker fails")
+	}
+	if got := report.Checks["database"]; got.Status != StatusDown || got.Error != "db down" {
+		t.Fatalf("unexpected database result: %+v", got)
+	}
+	if report.Checks["storage"].Status != StatusUp {
+		t.Fatalf("expected storage to be up")
+	}
+
+	now = now.Add(2 * time.Second)
+	h.Check(context.Background())
+	if calls != 1 {
+		t.Fatalf("expected cached result within ttl, checker called %d times", calls)
+	}
+
+	now = now.Add(5 * time.Second)
+	h.Check(context.Background())
+	if calls != 2 {
+		t.Fatalf("expected checks to run again after ttl, checker called %d times", calls)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	if _, err := Storage(t.TempDir()).Check(context.Background()); err != nil {
+		t.Fatalf("expected temp dir to be writable: %v", err)
+	}
+	if _, err := Storage("/nonexistent/uploads").Check(context.Background()); err == nil {
+		t.Fatal("expected missing directory to fail")
+	}
+}
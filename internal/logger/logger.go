@@ -0,0 +1,66 @@
+// Package logger menyiapkan slog logger berformat JSON untuk seluruh aplikasi
+// dan menyediakan logger per request melalui context.
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values never reach the log output.
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// New returns a logger writing to w. level is one of debug, info, warn or
+// error; format is json or text.
+func New(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redact,
+	}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+// ParseLevel maps a level name to slog.Level, defaulting to info.
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return a
+}
+
+type ctxKey struct{}
+
+// WithContext stores l in ctx.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the request logger stored in ctx, or slog.Default.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNewRedactsSensitiveAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "info", "json")
+
+	l.Info("login",
+		slog.String("email", "kasir@example.com"),
+		slog.String("password", "rahasia"),
+		slog.Group("headers", slog.String("Authorization", "Bearer abc"), slog.String("User-Agent", "pos")),
+	)
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected JSON output: %v", err)
+	}
+	if line["password"] != redacted {
+		t.Errorf("password not redacted: %v", line["password"])
+	}
+	headers := line["headers"].(map[string]any)
+	if headers["Authorization"] != redacted {
+		t.Errorf("authorization header not redacted: %v", headers["Authorization"])
+	}
+	if headers["User-Agent"] != "pos" || line["email"] != "kasir@example.com" {
+		t.Errorf("non-sensitive attributes must be kept: %v", line)
+	}
+}
+
+func TestNewRespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "warn", "json")
+	l.Info("ignored")
+	if buf.Len() != 0 {
+		t.Fatalf("expected info to be filtered at warn level, got %s", buf.String())
+	}
+}
@@ -0,0 +1,86 @@
+// Package loyalty berisi aturan perhitungan poin pelanggan. Saldo poin
+// sendiri tidak disimpan di sini; ia diturunkan dari ledger di database.
+package loyalty
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Jenis entri ledger.
+const (
+	TypeEarn   = "earn"
+	TypeBurn   = "burn"
+	TypeExpire = "expire"
+	TypeAdjust = "adjust"
+)
+
+var ErrInsufficientPoints = errors.New("insufficient loyalty points")
+
+// Rules describes how points are earned, what they are worth when redeemed
+// and how long they stay valid.
+type Rules struct {
+	RupiahPerPoint      float64            `json:"rupiah_per_point"`
+	PointValue          float64            `json:"point_value"`
+	ExpiryDays          int                `json:"expiry_days"`
+	CategoryMultipliers map[string]float64 `json:"category_multipliers"`
+}
+
+// Line is one purchased amount used to compute earned points.
+type Line struct {
+	CategoryID string  `json:"category_id"`
+	Amount     float64 `json:"amount"`
+}
+
+// PointsFor returns the points earned for the given lines. Each line amount
+// is weighted by its category multiplier (1 when not configured) and the
+// total is rounded down to whole points.
+func (r Rules) PointsFor(lines []Line) int64 {
+	if r.RupiahPerPoint <= 0 {
+		return 0
+	}
+
+	var weighted float64
+	for _, l := range lines {
+		m, ok := r.CategoryMultipliers[l.CategoryID]
+		if !ok {
+			m = 1
+		}
+		weighted += l.Amount * m
+	}
+	if weighted <= 0 {
+		return 0
+	}
+	return int64(math.Floor(weighted / r.RupiahPerPoint))
+}
+
+// RedemptionValue returns the Rupiah value of the given points.
+func (r Rules) RedemptionValue(points int64) float64 {
+	return float64(points) * r.PointValue
+}
+
+// ExpiresAt returns when points earned at t expire, or nil when points
+// never expire.
+func (r Rules) ExpiresAt(t time.Time) *time.Time {
+	if r.ExpiryDays <= 0 {
+		return nil
+	}
+	exp := t.AddDate(0, 0, r.ExpiryDays)
+	return &exp
+}
+
+// Expirable returns how many points must be expired for a customer.
+// Redemptions and earlier expiries are assumed to consume the oldest points
+// first, so only earned points past their expiry that have not been used
+// yet are expired, never more than the current balance.
+func Expirable(expiredEarned, consumed, balance int64) int64 {
+	n := expiredEarned - consumed
+	if n > balance {
+		n = balance
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package loyalty
+
+import "testing"
+
+func TestPointsFor(t *testing.T) {
+	rules := Rules{
+		RupiahPerPoint:      10000,
+		CategoryMultipliers: map[string]float64{"kopi": 2},
+	}
+
+	got := rules.PointsFor([]Line{
+		{CategoryID: "kopi", Amount: 25000},
+		{CategoryID: "makanan", Amount: 19000},
+	})
+	// 25000*2 + 19000 = 69000 -> 6 poin
+	if got != 6 {
+		t.Fatalf("expected 6 points, got %d", got)
+	}
+
+	if (Rules{}).PointsFor([]Line{{Amount: 50000}}) != 0 {
+		t.Fatal("expected no points when rate is not configured")
+	}
+}
+
+func TestExpirable(t *testing.T) {
+	cases := []struct {
+		expiredEarned, consumed, balance, want int64
+	}{
+		{100, 0, 150, 100},
+		{100, 30, 150, 70},
+		{100, 120, 80, 0},
+		{100, 0, 40, 40},
+	}
+	for _, c := range cases {
+		if got := Expirable(c.expiredEarned, c.consumed, c.balance); got != c.want {
+			t.Errorf("Expirable(%d, %d, %d) = %d, want %d", c.expiredEarned, c.consumed, c.balance, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Package mailer mengirim email. Pengirim disembunyikan di balik interface
+// Sender supaya pengiriman struk bisa diuji tanpa server SMTP sungguhan dan
+// penyedia lain (API HTTP) bisa dipasang nanti.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message adalah satu email dengan isi teks dan HTML. Klien email
+// menampilkan HTML bila bisa dan teks bila tidak.
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Sender mengirim satu pesan. Error yang membuat Permanent bernilai true
+// tidak perlu dicoba lagi.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// PermanentError membungkus error yang tidak akan berhasil bila dicoba
+// lagi, misalnya alamat tujuan ditolak server.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// Permanent reports whether retrying err is pointless: the error is a
+// PermanentError or an SMTP reply with a 5xx code.
+func Permanent(err error) bool {
+	var pe *PermanentError
+	if errors.As(err, &pe) {
+		return true
+	}
+	var tp *textproto.Error
+	return errors.As(err, &tp) && tp.Code >= 500
+}
+
+// encode menyusun m sebagai pesan MIME (RFC 5322) dari from.
+func encode(from string, m Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+	// boundary bagian alternative harus diketahui sebelum part-nya dibuat
+	altBoundary := multipart.NewWriter(nil).Boundary()
+
+	id := make([]byte, 12)
+	rand.Read(id)
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(addr.Address, "@"); ok {
+			domain = d
+		}
+	}
+
+	header := []string{
+		"From: " + from,
+		"To: " + m.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"Message-ID: <" + hex.EncodeToString(id) + "@" + domain + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + mixed.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	body, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + altBoundary}})
+	if err != nil {
+		return nil, err
+	}
+	alt := multipart.NewWriter(body)
+	if err := alt.SetBoundary(altBoundary); err != nil {
+		return nil, err
+	}
+	if err := writeAlternatives(alt, m); err != nil {
+		return nil, err
+	}
+
+	for _, a := range m.Attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(a.ContentType, map[string]string{"name": a.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeAlternatives(alt *multipart.Writer, m Message) error {
+	for _, p := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if p.body == "" {
+			continue
+		}
+		part, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+	}
+	return alt.Close()
+}
+
+// writeBase64 menulis data dalam baris 76 karakter sesuai RFC 2045.
+func writeBase64(w io.Writer, data []byte) error {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 0 {
+		n := min(76, len(enc))
+		if _, err := fmt.Fprintf(w, "%s\r\n", enc[:n]); err != nil {
+			return err
+		}
+		enc = enc[n:]
+	}
+	return nil
+}
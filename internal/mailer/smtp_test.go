@@ -0,0 +1,211 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTP adalah server SMTP minimal di 127.0.0.1 yang menyimpan setiap
+// pesan yang diterima. Penerima di reject ditolak permanen (550), di busy
+// ditolak sementara (451).
+type fakeSMTP struct {
+	addr   string
+	reject string
+	busy   string
+
+	mu       sync.Mutex
+	auth     []string
+	messages []string
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeSMTP{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fake\r\n250-AUTH PLAIN\r\n250 8BITMIME")
+		case "AUTH":
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			f.mu.Lock()
+			f.auth = append(f.auth, string(creds))
+			f.mu.Unlock()
+			reply("235 authenticated")
+		case "RCPT":
+			switch {
+			case f.reject != "" && strings.Contains(arg, f.reject):
+				reply("550 5.1.1 no such user")
+			case f.busy != "" && strings.Contains(arg, f.busy):
+				reply("451 4.3.0 try again later")
+			default:
+				reply("250 ok")
+			}
+		case "DATA":
+			reply("354 go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(strings.TrimPrefix(l, "."))
+			}
+			f.mu.Lock()
+			f.messages = append(f.messages, msg.String())
+			f.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// received mengembalikan salinan kredensial AUTH dan pesan yang diterima.
+func (f *fakeSMTP) received() (auth, messages []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.auth), slices.Clone(f.messages)
+}
+
+func (f *fakeSMTP) sender() *SMTP {
+	host, port, _ := net.SplitHostPort(f.addr)
+	p, _ := strconv.Atoi(port)
+	return &SMTP{Host: host, Port: p, Username: "maspos", Password: "rahasia", From: "MasPOS <struk@maspos.id>"}
+}
+
+func TestSMTPSend(t *testing.T) {
+	f := startFakeSMTP(t)
+	pdf := []byte("%PDF-1.4 struk")
+	err := f.sender().Send(context.Background(), Message{
+		To:          "budi@example.com",
+		Subject:     "Struk INV-1 – Kopi Senja",
+		Text:        "Total 50.600",
+		HTML:        "<p>Total <b>50.600</b></p>",
+		Attachments: []Attachment{{Filename: "INV-1.pdf", ContentType: "application/pdf", Data: pdf}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, messages := f.received()
+	if len(auth) != 1 || auth[0] != "\x00maspos\x00rahasia" {
+		t.Errorf("unexpected AUTH PLAIN credentials %q", auth)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected one message, got %d", len(messages))
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Struk INV-1 – Kopi Senja" || msg.Header.Get("To") != "budi@example.com" {
+		t.Errorf("unexpected headers: %v", msg.Header)
+	}
+
+	parts := map[string]string{}
+	var walk func(r io.Reader, contentType string)
+	walk = func(r io.Reader, contentType string) {
+		media, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(media, "multipart/") {
+			b, _ := io.ReadAll(r)
+			parts[media] = string(b)
+			return
+		}
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body io.Reader = p
+			if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+				body = base64.NewDecoder(base64.StdEncoding, p)
+			}
+			walk(body, p.Header.Get("Content-Type"))
+		}
+	}
+	walk(msg.Body, msg.Header.Get("Content-Type"))
+
+	if parts["text/plain"] != "Total 50.600" || parts["text/html"] != "<p>Total <b>50.600</b></p>" {
+		t.Errorf("unexpected bodies: %q", parts)
+	}
+	if !bytes.Equal([]byte(parts["application/pdf"]), pdf) {
+		t.Errorf("attachment = %q, want %q", parts["application/pdf"], pdf)
+	}
+}
+
+func TestSMTPRejections(t *testing.T) {
+	f := startFakeSMTP(t)
+	f.reject, f.busy = "nobody@", "busy@"
+	s := f.sender()
+
+	err := s.Send(context.Background(), Message{To: "nobody@example.com", Subject: "x", Text: "x"})
+	if err == nil || !Permanent(err) {
+		t.Errorf("expected a permanent error for 550, got %v", err)
+	}
+	err = s.Send(context.Background(), Message{To: "busy@example.com", Subject: "x", Text: "x"})
+	if err == nil || Permanent(err) {
+		t.Errorf("expected a temporary error for 451, got %v", err)
+	}
+	err = s.Send(context.Background(), Message{To: "bukan alamat", Subject: "x", Text: "x"})
+	if err == nil || !Permanent(err) {
+		t.Errorf("expected a permanent error for an invalid address, got %v", err)
+	}
+	if _, messages := f.received(); len(messages) != 0 {
+		t.Errorf("expected nothing delivered, got %d messages", len(messages))
+	}
+}
@@ -0,0 +1,268 @@
+// Package metrics mengumpulkan metrik Prometheus untuk HTTP, pool koneksi
+// database dan kejadian bisnis. Semua metrik didaftarkan ke registry milik
+// Metrics sendiri, bukan registry global, sehingga bisa dites tanpa server
+// Prometheus.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "maspos"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	logins           *prometheus.CounterVec
+	customersCreated prometheus.Counter
+	loyaltyPoints    *prometheus.CounterVec
+
+	rateLimited   *prometheus.CounterVec
+	loginLockouts prometheus.Counter
+
+	ordersCompleted prometheus.Counter
+	orderRevenue    prometheus.Counter
+	refunds         *prometheus.CounterVec
+	cash            *prometheus.CounterVec
+	shifts          *prometheus.CounterVec
+	zReports        prometheus.Counter
+	receiptEmails   *prometheus.CounterVec
+}
+
+// New creates the collectors. When db is not nil its pool statistics are
+// exported as go_sql_* metrics labelled with dbName.
+func New(db *sql.DB, dbName string) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result (success or failed).",
+		}, []string{"result"}),
+		customersCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "customers_created_total",
+			Help:      "Customers registered in the directory.",
+		}),
+		loyaltyPoints: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "loyalty_points_total",
+			Help:      "Loyalty points earned or redeemed.",
+		}, []string{"type"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_requests_total",
+			Help:      "Requests rejected with 429 by method and route template.",
+		}, []string{"method", "route"}),
+		loginLockouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_lockouts_total",
+			Help:      "Accounts locked after repeated failed logins.",
+		}),
+		ordersCompleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "orders_completed_total",
+			Help:      "Orders completed at the point of sale.",
+		}),
+		orderRevenue: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "order_revenue_total",
+			Help:      "Sum of completed order totals, tax included, before refunds.",
+		}),
+		refunds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "refunds_total",
+			Help:      "Refunds given by tender method.",
+		}, []string{"method"}),
+		cash: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cash_amount_total",
+			Help:      "Cash taken into (sale, net of change) or paid out of (refund) shift drawers.",
+		}, []string{"type"}),
+		shifts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "shifts_total",
+			Help:      "Cashier shifts by event (opened or closed).",
+		}, []string{"event"}),
+		zReports: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "z_reports_total",
+			Help:      "Business days closed with a Z-report.",
+		}),
+		receiptEmails: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "receipt_emails_total",
+			Help:      "Receipt email deliveries by result (sent or failed).",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.logins,
+		m.customersCreated,
+		m.loyaltyPoints,
+		m.rateLimited,
+		m.loginLockouts,
+		m.ordersCompleted,
+		m.orderRevenue,
+		m.refunds,
+		m.cash,
+		m.shifts,
+		m.zReports,
+		m.receiptEmails,
+	)
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+	}
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Semua method di bawah aman dipanggil pada *Metrics nil supaya handler
+// yang dites tanpa metrik tidak perlu menyiapkannya.
+
+// ObserveRequest records one finished HTTP request. route must be the
+// route template (e.g. /products/:id), never the raw path, to keep label
+// cardinality bounded.
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+func (m *Metrics) LoginSucceeded() {
+	if m == nil {
+		return
+	}
+	m.logins.WithLabelValues("success").Inc()
+}
+
+func (m *Metrics) LoginFailed() {
+	if m == nil {
+		return
+	}
+	m.logins.WithLabelValues("failed").Inc()
+}
+
+func (m *Metrics) CustomerCreated() {
+	if m == nil {
+		return
+	}
+	m.customersCreated.Inc()
+}
+
+// LoyaltyPoints records earned ("earn") or redeemed ("burn") points.
+func (m *Metrics) LoyaltyPoints(entryType string, points int64) {
+	if m == nil || points <= 0 {
+		return
+	}
+	m.loyaltyPoints.WithLabelValues(entryType).Add(float64(points))
+}
+
+// RateLimited records one request rejected by the rate limiter.
+func (m *Metrics) RateLimited(method, route string) {
+	if m == nil {
+		return
+	}
+	m.rateLimited.WithLabelValues(method, route).Inc()
+}
+
+// LoginLockedOut records an account becoming locked after failed logins.
+func (m *Metrics) LoginLockedOut() {
+	if m == nil {
+		return
+	}
+	m.loginLockouts.Inc()
+}
+
+// OrderCompleted records one completed order with its total, and the cash
+// it left in the drawer after change.
+func (m *Metrics) OrderCompleted(total, cash float64) {
+	if m == nil {
+		return
+	}
+	m.ordersCompleted.Inc()
+	m.orderRevenue.Add(total)
+	if cash > 0 {
+		m.cash.WithLabelValues("sale").Add(cash)
+	}
+}
+
+// Refunded records one refund paid back with method, and the cash it took
+// out of the drawer.
+func (m *Metrics) Refunded(method string, cash float64) {
+	if m == nil {
+		return
+	}
+	m.refunds.WithLabelValues(method).Inc()
+	if cash > 0 {
+		m.cash.WithLabelValues("refund").Add(cash)
+	}
+}
+
+func (m *Metrics) ShiftOpened() {
+	if m == nil {
+		return
+	}
+	m.shifts.WithLabelValues("opened").Inc()
+}
+
+func (m *Metrics) ShiftClosed() {
+	if m == nil {
+		return
+	}
+	m.shifts.WithLabelValues("closed").Inc()
+}
+
+func (m *Metrics) ZReportClosed() {
+	if m == nil {
+		return
+	}
+	m.zReports.Inc()
+}
+
+func (m *Metrics) ReceiptEmailSent() {
+	if m == nil {
+		return
+	}
+	m.receiptEmails.WithLabelValues("sent").Inc()
+}
+
+// ReceiptEmailFailed records one failed delivery attempt, whether or not it
+// is retried.
+func (m *Metrics) ReceiptEmailFailed() {
+	if m == nil {
+		return
+	}
+	m.receiptEmails.WithLabelValues("failed").Inc()
+}
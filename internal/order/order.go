@@ -0,0 +1,174 @@
+// Package order menghitung isi sebuah penjualan: harga baris beserta
+// modifier dan diskonnya, pajak, pembayaran dan kembalian. Penyimpanan
+// order ada di repository; package ini tidak menyentuh database.
+package order
+
+import (
+	"errors"
+	"math"
+)
+
+// Metode pembayaran.
+const (
+	TenderCash     = "cash"
+	TenderCard     = "card"
+	TenderQRIS     = "qris"
+	TenderTransfer = "transfer"
+	// TenderLoyalty membayar dengan poin pelanggan; Amount dihitung dari
+	// Points menurut nilai tukar program poin.
+	TenderLoyalty = "loyalty"
+)
+
+var (
+	ErrUnderpaid         = errors.New("tenders do not cover the order total")
+	ErrChangeWithoutCash = errors.New("only cash tenders may exceed the order total")
+	ErrDiscountTooHigh   = errors.New("line discount exceeds the line amount")
+)
+
+// Modifier adalah tambahan pada satu item, misalnya "Extra shot". Price
+// berlaku per unit item.
+type Modifier struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// Line adalah satu produk yang dijual. Name, CategoryID dan UnitPrice
+// disalin dari katalog saat order dibuat, jadi perubahan katalog berikutnya
+// tidak mengubah penjualan yang sudah terjadi. Discount berlaku untuk
+// seluruh baris, bukan per unit.
+type Line struct {
+	ProductID  string     `json:"product_id"`
+	CategoryID string     `json:"category_id"`
+	Name       string     `json:"name"`
+	Quantity   float64    `json:"quantity"`
+	UnitPrice  float64    `json:"unit_price"`
+	Modifiers  []Modifier `json:"modifiers"`
+	Discount   float64    `json:"discount"`
+	Note       string     `json:"note"`
+}
+
+// Tax adalah pajak atau biaya layanan yang sudah dihitung kasir dan
+// ditambahkan ke subtotal.
+type Tax struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+type Tender struct {
+	Method    string  `json:"method"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+	Points    int64   `json:"points,omitempty"`
+}
+
+// Gross returns the price of the line including its modifiers, before the
+// discount.
+func (l Line) Gross() float64 {
+	unit := l.UnitPrice
+	for _, m := range l.Modifiers {
+		unit += m.Price
+	}
+	return round(unit * l.Quantity)
+}
+
+// Total returns the line amount after its discount.
+func (l Line) Total() float64 {
+	return round(l.Gross() - l.Discount)
+}
+
+// Order adalah penjualan lengkap sebagaimana dihitung sebelum disimpan.
+type Order struct {
+	Lines   []Line
+	Taxes   []Tax
+	Tenders []Tender
+}
+
+// Gross returns the sum of the lines before discounts.
+func (o Order) Gross() float64 {
+	var sum float64
+	for _, l := range o.Lines {
+		sum += l.Gross()
+	}
+	return round(sum)
+}
+
+func (o Order) Discount() float64 {
+	var sum float64
+	for _, l := range o.Lines {
+		sum += l.Discount
+	}
+	return round(sum)
+}
+
+// Subtotal returns the sum of the lines after discounts, before tax.
+func (o Order) Subtotal() float64 {
+	var sum float64
+	for _, l := range o.Lines {
+		sum += l.Total()
+	}
+	return round(sum)
+}
+
+func (o Order) Tax() float64 {
+	var sum float64
+	for _, t := range o.Taxes {
+		sum += t.Amount
+	}
+	return round(sum)
+}
+
+func (o Order) Total() float64 {
+	return round(o.Subtotal() + o.Tax())
+}
+
+func (o Order) Paid() float64 {
+	var sum float64
+	for _, t := range o.Tenders {
+		sum += t.Amount
+	}
+	return round(sum)
+}
+
+// Change returns the change given back, or 0 when the tenders do not cover
+// the total.
+func (o Order) Change() float64 {
+	return max(round(o.Paid()-o.Total()), 0)
+}
+
+// PaidWith returns the amount tendered with method.
+func (o Order) PaidWith(method string) float64 {
+	var sum float64
+	for _, t := range o.Tenders {
+		if t.Method == method {
+			sum += t.Amount
+		}
+	}
+	return round(sum)
+}
+
+// Cash returns the cash tendered, before the change is given back.
+func (o Order) Cash() float64 {
+	return o.PaidWith(TenderCash)
+}
+
+// Validate checks that the discounts fit their lines and that the tenders
+// pay the total. Only cash may be overpaid, because change is given back in
+// cash.
+func (o Order) Validate() error {
+	for _, l := range o.Lines {
+		if l.Discount > l.Gross() {
+			return ErrDiscountTooHigh
+		}
+	}
+	if o.Paid() < o.Total() {
+		return ErrUnderpaid
+	}
+	if o.Change() > o.Cash() {
+		return ErrChangeWithoutCash
+	}
+	return nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
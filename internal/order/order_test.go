@@ -0,0 +1,71 @@
+package order
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTotals(t *testing.T) {
+	o := Order{
+		Lines: []Line{
+			{Name: "Kopi Susu", Quantity: 2, UnitPrice: 18000, Modifiers: []Modifier{{Name: "Extra shot", Price: 5000}}, Discount: 6000},
+			{Name: "Croissant", Quantity: 1, UnitPrice: 22000},
+		},
+		Taxes:   []Tax{{Name: "PB1 10%", Amount: 6200}},
+		Tenders: []Tender{{Method: TenderCard, Amount: 30000}, {Method: TenderCash, Amount: 50000}},
+	}
+	// (18000+5000)*2 = 46000, dikurangi diskon 6000 = 40000, ditambah 22000
+	if got := o.Gross(); got != 68000 {
+		t.Errorf("gross: expected 68000, got %v", got)
+	}
+	if got := o.Subtotal(); got != 62000 {
+		t.Errorf("subtotal: expected 62000, got %v", got)
+	}
+	if got := o.Total(); got != 68200 {
+		t.Errorf("total: expected 68200, got %v", got)
+	}
+	if got := o.Change(); got != 11800 {
+		t.Errorf("change: expected 11800, got %v", got)
+	}
+	if err := o.Validate(); err != nil {
+		t.Errorf("expected a valid order, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	line := Line{Name: "Kopi Susu", Quantity: 1, UnitPrice: 18000}
+	cases := []struct {
+		name string
+		o    Order
+		want error
+	}{
+		{"exact card payment", Order{Lines: []Line{line}, Tenders: []Tender{{Method: TenderCard, Amount: 18000}}}, nil},
+		{"underpaid", Order{Lines: []Line{line}, Tenders: []Tender{{Method: TenderCash, Amount: 10000}}}, ErrUnderpaid},
+		{"card overpaid", Order{Lines: []Line{line}, Tenders: []Tender{{Method: TenderCard, Amount: 20000}}}, ErrChangeWithoutCash},
+		{"change larger than cash", Order{Lines: []Line{line}, Tenders: []Tender{{Method: TenderQRIS, Amount: 17000}, {Method: TenderCash, Amount: 2000}}}, nil},
+		{"change beyond cash", Order{Lines: []Line{line}, Tenders: []Tender{{Method: TenderQRIS, Amount: 19000}, {Method: TenderCash, Amount: 500}}}, ErrChangeWithoutCash},
+		{"discount above line", Order{Lines: []Line{{Name: "Teh", Quantity: 1, UnitPrice: 8000, Discount: 9000}}}, ErrDiscountTooHigh},
+	}
+	for _, c := range cases {
+		if err := c.o.Validate(); !errors.Is(err, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, err)
+		}
+	}
+}
+
+func TestShare(t *testing.T) {
+	// tiga refund satu unit dari baris 3 x 10.000 dengan diskon 1.000
+	var sum float64
+	for done := 0.0; done < 3; done++ {
+		sum += Share(29000, 3, done, 1)
+	}
+	if sum != 29000 {
+		t.Errorf("expected the shares to add up to 29000, got %v", sum)
+	}
+	if got := Share(29000, 3, 0, 1); got != 9666.67 {
+		t.Errorf("expected 9666.67 for the first unit, got %v", got)
+	}
+	if got := Share(100, 0, 0, 1); got != 0 {
+		t.Errorf("expected 0 for an empty whole, got %v", got)
+	}
+}
@@ -0,0 +1,33 @@
+package order
+
+import "errors"
+
+// Alasan refund. Barang yang dikembalikan karena salah item atau pelanggan
+// berubah pikiran masih layak jual, jadi bawaannya masuk kembali ke stok;
+// barang rusak atau basi tidak.
+const (
+	ReasonWrongItem   = "wrong_item"
+	ReasonChangedMind = "changed_mind"
+	ReasonDamaged     = "damaged"
+	ReasonExpired     = "expired"
+	ReasonOther       = "other"
+)
+
+var ErrRefundQuantity = errors.New("refund quantity exceeds the quantity not yet refunded")
+
+// Restocks reports whether goods returned for reason go back to stock
+// unless the refund says otherwise.
+func Restocks(reason string) bool {
+	return reason == ReasonWrongItem || reason == ReasonChangedMind
+}
+
+// Share returns the part of amount that belongs to part units out of whole,
+// given that done units were already refunded. It is computed on the
+// running total, so the shares of all refunds of a line add up to exactly
+// amount even when each one is rounded to cents.
+func Share(amount, whole, done, part float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return round(amount*(done+part)/whole) - round(amount*done/whole)
+}
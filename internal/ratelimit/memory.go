@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval adalah jarak minimal antar pembersihan entri yang sudah
+// kedaluwarsa.
+const sweepInterval = time.Minute
+
+type memoryBucket struct {
+	Bucket
+	expires time.Time
+}
+
+type memoryFailures struct {
+	Failures
+	expires time.Time
+}
+
+// Memory menyimpan state di memori proses. Cocok untuk satu replika; dengan
+// beberapa replika setiap proses punya bucket sendiri.
+type Memory struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	buckets   map[string]*memoryBucket
+	failures  map[string]*memoryFailures
+	lastSweep time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		now:      time.Now,
+		buckets:  map[string]*memoryBucket{},
+		failures: map[string]*memoryFailures{},
+	}
+}
+
+func (m *Memory) Allow(ctx context.Context, key string, l Limit) (Decision, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{}
+		m.buckets[key] = b
+	}
+	d := b.Take(l, now)
+	b.expires = b.FullAt(l)
+	return d, nil
+}
+
+func (m *Memory) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.failures[key]
+	if !ok {
+		return 0, nil
+	}
+	return f.LockedFor(m.now()), nil
+}
+
+func (m *Memory) RecordFailure(ctx context.Context, key string, p Lockout) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	f, ok := m.failures[key]
+	if !ok {
+		f = &memoryFailures{}
+		m.failures[key] = f
+	}
+	locked := f.Record(p, now)
+	f.expires = f.ExpiresAt(p)
+	return locked, nil
+}
+
+func (m *Memory) ResetFailures(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.failures, key)
+	return nil
+}
+
+// sweep membuang bucket yang sudah penuh kembali dan kegagalan login yang
+// sudah tidak berlaku, paling sering sekali per sweepInterval.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for k, b := range m.buckets {
+		if now.After(b.expires) {
+			delete(m.buckets, k)
+		}
+	}
+	for k, f := range m.failures {
+		if now.After(f.expires) {
+			delete(m.failures, k)
+		}
+	}
+}
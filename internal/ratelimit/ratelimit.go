@@ -0,0 +1,142 @@
+// Package ratelimit berisi token bucket untuk membatasi request per klien dan
+// penguncian akun setelah login gagal berulang kali. Perhitungannya ada di
+// Bucket dan Failures; Store hanya menentukan di mana state itu disimpan,
+// sehingga backend memori dan PostgreSQL berperilaku sama.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket: Burst requests may be made at once and tokens
+// come back at Rate per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// NewLimit returns a limit allowing requests per period on average, with
+// bursts of up to burst requests.
+func NewLimit(requests int, per time.Duration, burst int) Limit {
+	if requests <= 0 || per <= 0 {
+		return Limit{}
+	}
+	return Limit{Rate: float64(requests) / per.Seconds(), Burst: burst}
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+type Decision struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token is available. It is zero
+	// when the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Bucket is the stored state of one token bucket. The zero value is a full
+// bucket.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Take refills the bucket for the time elapsed since its last update and
+// takes one token if available.
+func (b *Bucket) Take(l Limit, now time.Time) Decision {
+	burst := float64(l.Burst)
+	if b.Updated.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+elapsed*l.Rate)
+	}
+	b.Updated = now
+
+	if b.Tokens < 1 {
+		wait := time.Duration((1 - b.Tokens) / l.Rate * float64(time.Second))
+		return Decision{RetryAfter: wait}
+	}
+	b.Tokens--
+	return Decision{Allowed: true, Remaining: int(b.Tokens)}
+}
+
+// FullAt returns when the bucket will be full again. After that moment the
+// stored state is equivalent to a missing one and may be discarded.
+func (b Bucket) FullAt(l Limit) time.Time {
+	missing := float64(l.Burst) - b.Tokens
+	if missing <= 0 || l.Rate <= 0 {
+		return b.Updated
+	}
+	return b.Updated.Add(time.Duration(missing / l.Rate * float64(time.Second)))
+}
+
+// Lockout describes how an account is locked after failed logins.
+// MaxFailures failures, each less than Window apart, lock the account for
+// BaseDelay; every further failure doubles the delay up to MaxDelay.
+type Lockout struct {
+	MaxFailures int
+	Window      time.Duration
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func (p Lockout) Enabled() bool {
+	return p.MaxFailures > 0
+}
+
+// Failures is the stored failed-login state of one account.
+type Failures struct {
+	Count       int
+	Last        time.Time
+	LockedUntil time.Time
+}
+
+// Record counts one failed login and returns how long the account is now
+// locked (zero when it is not).
+func (f *Failures) Record(p Lockout, now time.Time) time.Duration {
+	if now.Sub(f.Last) > p.Window {
+		f.Count = 0
+	}
+	f.Count++
+	f.Last = now
+	if f.Count < p.MaxFailures {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.MaxFailures; i < f.Count && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	f.LockedUntil = now.Add(delay)
+	return delay
+}
+
+// LockedFor returns the remaining lock time at now.
+func (f Failures) LockedFor(now time.Time) time.Duration {
+	return max(f.LockedUntil.Sub(now), 0)
+}
+
+// ExpiresAt returns when the state stops mattering: the lock is over and
+// the next failure would start a new count.
+func (f Failures) ExpiresAt(p Lockout) time.Time {
+	end := f.Last.Add(p.Window)
+	if f.LockedUntil.After(end) {
+		return f.LockedUntil
+	}
+	return end
+}
+
+// Store menyimpan state bucket dan kegagalan login. Key sudah berisi ruang
+// lingkupnya (route, klien, email), jadi Store tidak perlu menafsirkannya.
+type Store interface {
+	Allow(ctx context.Context, key string, l Limit) (Decision, error)
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	RecordFailure(ctx context.Context, key string, p Lockout) (time.Duration, error)
+	ResetFailures(ctx context.Context, key string) error
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucketTake(t *testing.T) {
+	l := NewLimit(1, time.Second, 2)
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	var b Bucket
+
+	for i := 0; i < 2; i++ {
+		if d := b.Take(l, start); !d.Allowed {
+			t.Fatalf("request %d within burst was denied", i+1)
+		}
+	}
+
+	d := b.Take(l, start)
+	if d.Allowed {
+		t.Fatal("expected request beyond burst to be denied")
+	}
+	if d.RetryAfter != time.Second {
+		t.Errorf("expected retry after 1s, got %s", d.RetryAfter)
+	}
+
+	d = b.Take(l, start.Add(500*time.Millisecond))
+	if d.Allowed || d.RetryAfter != 500*time.Millisecond {
+		t.Errorf("expected denial with 500ms left, got %+v", d)
+	}
+
+	if d := b.Take(l, start.Add(time.Second)); !d.Allowed {
+		t.Error("expected a token to be refilled after 1s")
+	}
+	if got := b.FullAt(l); !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("expected bucket to be full at +3s, got %s", got.Sub(start))
+	}
+}
+
+func TestFailuresRecord(t *testing.T) {
+	p := Lockout{MaxFailures: 3, Window: 10 * time.Minute, BaseDelay: time.Minute, MaxDelay: 3 * time.Minute}
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	var f Failures
+
+	for i := 0; i < 2; i++ {
+		if locked := f.Record(p, now); locked != 0 {
+			t.Fatalf("failure %d should not lock yet, got %s", i+1, locked)
+		}
+	}
+
+	want := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	for i, w := range want {
+		if got := f.Record(p, now); got != w {
+			t.Errorf("failure %d: expected lock of %s, got %s", i+3, w, got)
+		}
+	}
+	if got := f.LockedFor(now.Add(time.Minute)); got != 2*time.Minute {
+		t.Errorf("expected 2m of lock remaining, got %s", got)
+	}
+
+	// kegagalan yang berjarak lebih dari Window memulai hitungan baru
+	later := now.Add(time.Hour)
+	if locked := f.Record(p, later); locked != 0 || f.Count != 1 {
+		t.Errorf("expected count to restart after the window, got count %d lock %s", f.Count, locked)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	l := NewLimit(1, time.Minute, 1)
+	if d, _ := m.Allow(ctx, "a", l); !d.Allowed {
+		t.Fatal("expected first request to be allowed")
+	}
+	if d, _ := m.Allow(ctx, "a", l); d.Allowed {
+		t.Fatal("expected second request to be denied")
+	}
+	if d, _ := m.Allow(ctx, "b", l); !d.Allowed {
+		t.Fatal("expected other keys to have their own bucket")
+	}
+
+	p := Lockout{MaxFailures: 2, Window: time.Minute, BaseDelay: 30 * time.Second, MaxDelay: time.Minute}
+	m.RecordFailure(ctx, "login:kasir@example.com", p)
+	if locked, _ := m.RecordFailure(ctx, "login:kasir@example.com", p); locked != 30*time.Second {
+		t.Fatalf("expected 30s lock, got %s", locked)
+	}
+	if locked, _ := m.LockedFor(ctx, "login:kasir@example.com"); locked != 30*time.Second {
+		t.Errorf("expected LockedFor to report 30s, got %s", locked)
+	}
+	m.ResetFailures(ctx, "login:kasir@example.com")
+	if locked, _ := m.LockedFor(ctx, "login:kasir@example.com"); locked != 0 {
+		t.Errorf("expected reset to unlock, got %s", locked)
+	}
+
+	now = now.Add(2 * time.Minute)
+	m.Allow(ctx, "c", l)
+	if _, ok := m.buckets["a"]; ok {
+		t.Error("expected full buckets to be swept")
+	}
+}
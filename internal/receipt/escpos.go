@@ -0,0 +1,54 @@
+package receipt
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Perintah ESC/POS yang dipakai; semuanya didukung printer thermal umum
+// (Epson TM, Xprinter, dan klonnya).
+var (
+	escInit       = []byte{0x1b, '@'}        // ESC @: reset printer
+	escBoldOn     = []byte{0x1b, 'E', 1}     // ESC E 1
+	escBoldOff    = []byte{0x1b, 'E', 0}     // ESC E 0
+	escDoubleOn   = []byte{0x1d, '!', 0x01}  // GS ! 1: tinggi ganda, lebar tetap
+	escDoubleOff  = []byte{0x1d, '!', 0x00}  // GS ! 0
+	escFeedAndCut = []byte{0x1d, 'V', 66, 3} // GS V 66 n: maju n baris lalu potong
+)
+
+// ESCPOS menulis baris struk sebagai perintah ESC/POS, diakhiri dengan
+// memotong kertas. Lebar baris sudah disesuaikan dengan kertas oleh layout.
+func ESCPOS(w io.Writer, lines []line) error {
+	bw := bufio.NewWriter(w)
+	bw.Write(escInit)
+	for _, l := range lines {
+		if l.bold {
+			bw.Write(escBoldOn)
+		}
+		if l.large {
+			bw.Write(escDoubleOn)
+		}
+		bw.WriteString(ascii(l.text))
+		bw.WriteByte('\n')
+		if l.large {
+			bw.Write(escDoubleOff)
+		}
+		if l.bold {
+			bw.Write(escBoldOff)
+		}
+	}
+	bw.Write(escFeedAndCut)
+	return bw.Flush()
+}
+
+// ascii mengganti karakter di luar ASCII dengan '?', karena code page
+// bawaan printer berbeda-beda.
+func ascii(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0x7e || (r < 0x20 && r != '\n') {
+			return '?'
+		}
+		return r
+	}, s)
+}
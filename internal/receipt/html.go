@@ -0,0 +1,71 @@
+package receipt
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlTemplate memakai style inline karena banyak klien email membuang
+// tag <style>.
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"money": Money,
+	"qty":   quantity,
+	"mul":   func(a, b float64) float64 { return a * b },
+	"neg":   func(a float64) float64 { return -a },
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head><meta charset="utf-8"><title>Struk {{.R.Number}}</title></head>
+<body style="margin:0;padding:16px;background:#f4f4f4;font-family:Helvetica,Arial,sans-serif;color:#222">
+<table role="presentation" cellpadding="0" cellspacing="0" style="max-width:400px;margin:0 auto;background:#fff;padding:16px;width:100%">
+{{- range $i, $h := .T.Header}}
+<tr><td colspan="2" style="text-align:center;{{if eq $i 0}}font-weight:bold;font-size:18px{{else}}font-size:13px{{end}}">{{$h}}</td></tr>
+{{- end}}
+<tr><td style="padding-top:12px;font-size:13px">{{.R.Number}}</td><td style="padding-top:12px;font-size:13px;text-align:right">{{.R.Time.Format "02/01/2006 15:04"}}</td></tr>
+{{- if .R.Cashier}}
+<tr><td colspan="2" style="font-size:13px">Kasir: {{.R.Cashier}}</td></tr>
+{{- end}}
+<tr><td colspan="2" style="border-bottom:1px dashed #999;padding-top:8px"></td></tr>
+{{- range .R.Lines}}
+<tr><td style="padding-top:8px">{{.Name}}<br><span style="font-size:12px;color:#666">{{qty .Quantity}} x {{money .UnitPrice}}</span></td><td style="padding-top:8px;text-align:right;vertical-align:bottom">{{money (mul .UnitPrice .Quantity)}}</td></tr>
+{{- $q := .Quantity}}
+{{- range .Modifiers}}
+<tr><td style="font-size:12px;color:#666;padding-left:12px">+ {{.Name}}</td><td style="font-size:12px;color:#666;text-align:right">{{if .Price}}{{money (mul .Price $q)}}{{end}}</td></tr>
+{{- end}}
+{{- if gt .Discount 0.0}}
+<tr><td style="font-size:12px;color:#666;padding-left:12px">Diskon</td><td style="font-size:12px;color:#666;text-align:right">{{money (neg .Discount)}}</td></tr>
+{{- end}}
+{{- if .Note}}
+<tr><td colspan="2" style="font-size:12px;color:#666;padding-left:12px;font-style:italic">{{.Note}}</td></tr>
+{{- end}}
+{{- end}}
+<tr><td colspan="2" style="border-bottom:1px dashed #999;padding-top:8px"></td></tr>
+<tr><td style="padding-top:8px">Subtotal</td><td style="padding-top:8px;text-align:right">{{money .R.Subtotal}}</td></tr>
+{{- range .R.Taxes}}
+<tr><td>{{.Name}}</td><td style="text-align:right">{{money .Amount}}</td></tr>
+{{- end}}
+<tr><td style="font-weight:bold;font-size:18px;padding-top:4px">TOTAL</td><td style="font-weight:bold;font-size:18px;padding-top:4px;text-align:right">{{money .R.Total}}</td></tr>
+{{- range .R.Tenders}}
+<tr><td>{{.Method}}</td><td style="text-align:right">{{money .Amount}}</td></tr>
+{{- end}}
+{{- if gt .R.Change 0.0}}
+<tr><td>Kembali</td><td style="text-align:right">{{money .R.Change}}</td></tr>
+{{- end}}
+{{- if .T.Footer}}
+<tr><td colspan="2" style="border-bottom:1px dashed #999;padding-top:8px"></td></tr>
+{{- end}}
+{{- range .T.Footer}}
+<tr><td colspan="2" style="text-align:center;font-size:13px;padding-top:4px">{{.}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// HTML menulis struk sebagai halaman HTML untuk badan email. Isinya sama
+// dengan struk cetak, tetapi tidak terikat lebar kertas.
+func HTML(w io.Writer, r Receipt, t Template) error {
+	return htmlTemplate.Execute(w, struct {
+		R Receipt
+		T Template
+	}{r, t})
+}
@@ -0,0 +1,180 @@
+package receipt
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// line adalah satu baris struk yang sudah dipotong dan diratakan sesuai
+// lebar kertas. ESC/POS, teks dan PDF hanya berbeda cara mencetaknya.
+type line struct {
+	text  string
+	bold  bool
+	large bool // tinggi ganda di ESC/POS
+}
+
+// Label struk; pelanggan membaca struk dalam bahasa Indonesia.
+const (
+	labelCashier  = "Kasir"
+	labelSubtotal = "Subtotal"
+	labelDiscount = "Diskon"
+	labelTotal    = "TOTAL"
+	labelChange   = "Kembali"
+	timeLayout    = "02/01/2006 15:04"
+)
+
+// layout menyusun struk menjadi baris-baris selebar cols karakter.
+func layout(r Receipt, t Template, cols int) []line {
+	b := &builder{cols: cols}
+
+	for i, h := range t.Header {
+		b.center(h, i == 0)
+	}
+	if len(t.Header) > 0 {
+		b.rule()
+	}
+
+	b.pair(r.Number, r.Time.Format(timeLayout))
+	if r.Cashier != "" {
+		b.text(labelCashier + ": " + r.Cashier)
+	}
+	b.rule()
+
+	for _, l := range r.Lines {
+		b.text(l.Name)
+		b.pair("  "+quantity(l.Quantity)+" x "+Money(l.UnitPrice), Money(l.UnitPrice*l.Quantity))
+		for _, m := range l.Modifiers {
+			amount := ""
+			if m.Price != 0 {
+				amount = Money(m.Price * l.Quantity)
+			}
+			b.pair("  + "+m.Name, amount)
+		}
+		if l.Discount > 0 {
+			b.pair("  "+labelDiscount, Money(-l.Discount))
+		}
+		if l.Note != "" {
+			b.text("  * " + l.Note)
+		}
+	}
+	b.rule()
+
+	b.pair(labelSubtotal, Money(r.Subtotal()))
+	for _, tax := range r.Taxes {
+		b.pair(tax.Name, Money(tax.Amount))
+	}
+	b.lines = append(b.lines, withStyle(b.wrapPair(labelTotal, Money(r.Total())), true, true)...)
+	for _, tender := range r.Tenders {
+		b.pair(tender.Method, Money(tender.Amount))
+	}
+	if change := r.Change(); change > 0 {
+		b.pair(labelChange, Money(change))
+	}
+
+	if len(t.Footer) > 0 {
+		b.rule()
+	}
+	for _, f := range t.Footer {
+		b.center(f, false)
+	}
+	return b.lines
+}
+
+type builder struct {
+	cols  int
+	lines []line
+}
+
+func withStyle(texts []string, bold, large bool) []line {
+	res := make([]line, len(texts))
+	for i, s := range texts {
+		res[i] = line{text: s, bold: bold, large: large}
+	}
+	return res
+}
+
+func (b *builder) rule() {
+	b.lines = append(b.lines, line{text: strings.Repeat("-", b.cols)})
+}
+
+func (b *builder) text(s string) {
+	b.lines = append(b.lines, withStyle(wrap(s, b.cols), false, false)...)
+}
+
+func (b *builder) center(s string, bold bool) {
+	texts := wrap(s, b.cols)
+	for i, t := range texts {
+		texts[i] = strings.Repeat(" ", (b.cols-width(t))/2) + t
+	}
+	b.lines = append(b.lines, withStyle(texts, bold, false)...)
+}
+
+// pair mencetak left rata kiri dan right rata kanan pada baris yang sama.
+func (b *builder) pair(left, right string) {
+	b.lines = append(b.lines, withStyle(b.wrapPair(left, right), false, false)...)
+}
+
+// wrapPair memotong left bila terlalu panjang; right ditaruh di baris
+// terakhir left bila muat, selain itu di baris sendiri.
+func (b *builder) wrapPair(left, right string) []string {
+	texts := wrap(left, b.cols)
+	if right == "" {
+		return texts
+	}
+	last := texts[len(texts)-1]
+	if width(last)+1+width(right) <= b.cols {
+		texts[len(texts)-1] = last + strings.Repeat(" ", b.cols-width(last)-width(right)) + right
+		return texts
+	}
+	return append(texts, strings.Repeat(" ", max(b.cols-width(right), 0))+right)
+}
+
+// wrap memotong s per kata menjadi baris selebar paling banyak cols. Kata
+// yang lebih panjang dari satu baris dipotong paksa; spasi di awal s
+// (indentasi) dipertahankan pada baris pertama.
+func wrap(s string, cols int) []string {
+	indent := s[:len(s)-len(strings.TrimLeft(s, " "))]
+	var res []string
+	cur := indent
+	for _, word := range strings.Fields(s) {
+		for width(word) > cols {
+			if cur != "" && cur != indent {
+				res = append(res, cur)
+			}
+			head, tail := splitAt(word, cols)
+			res = append(res, head)
+			word, cur = tail, ""
+		}
+		switch {
+		case cur == "" || cur == indent:
+			if width(cur)+width(word) > cols {
+				cur = ""
+			}
+			cur += word
+		case width(cur)+1+width(word) <= cols:
+			cur += " " + word
+		default:
+			res = append(res, cur)
+			cur = word
+		}
+	}
+	if cur != "" || len(res) == 0 {
+		res = append(res, cur)
+	}
+	return res
+}
+
+func splitAt(s string, n int) (string, string) {
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos], s[pos:]
+		}
+		i++
+	}
+	return s, ""
+}
+
+func width(s string) int {
+	return utf8.RuneCountInString(s)
+}
@@ -0,0 +1,73 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Ukuran halaman PDF dalam point. Teks memakai Courier supaya tata letak
+// kolomnya sama persis dengan struk thermal.
+const (
+	pdfFontSize = 9
+	pdfLeading  = 11
+	pdfCharW    = 0.6 * pdfFontSize // lebar satu karakter Courier
+	pdfMargin   = 14
+)
+
+// PDF menulis baris struk sebagai dokumen PDF satu halaman selebar struk,
+// untuk dilampirkan di email. Hasilnya deterministik (tanpa tanggal
+// pembuatan), jadi struk yang sama selalu menghasilkan file yang sama.
+func PDF(w io.Writer, lines []line, cols int) error {
+	width := float64(cols)*pdfCharW + 2*pdfMargin
+	height := float64(len(lines)*pdfLeading + 2*pdfMargin)
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n%d TL\n%.2f %.2f Td\n", pdfLeading, float64(pdfMargin), height-pdfMargin-pdfFontSize)
+	font := ""
+	for _, l := range lines {
+		f := "/F1"
+		if l.bold {
+			f = "/F2"
+		}
+		if f != font {
+			fmt.Fprintf(&content, "%s %d Tf\n", f, pdfFontSize)
+			font = f
+		}
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(ascii(l.text)))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", width, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var pdfEscaper = strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+
+func pdfEscape(s string) string {
+	return pdfEscaper.Replace(s)
+}
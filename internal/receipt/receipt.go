@@ -0,0 +1,202 @@
+// Package receipt mencetak struk penjualan sebagai perintah ESC/POS untuk
+// printer thermal 58 mm dan 80 mm, teks biasa, HTML untuk email, dan PDF.
+// Semua format disusun dari tata letak yang sama (lihat layout), jadi isi
+// struk selalu sama di setiap format.
+package receipt
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format keluaran yang didukung.
+const (
+	FormatESCPOS = "escpos"
+	FormatText   = "text"
+	FormatHTML   = "html"
+	FormatPDF    = "pdf"
+)
+
+// Lebar kertas printer thermal dalam milimeter.
+const (
+	Paper58 = 58
+	Paper80 = 80
+)
+
+var (
+	ErrUnknownFormat = errors.New("unsupported receipt format; use escpos, text, html or pdf")
+	ErrUnknownPaper  = errors.New("unsupported paper width; use 58 or 80")
+)
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) (string, error) {
+	switch format {
+	case FormatESCPOS:
+		return "application/vnd.escpos", nil
+	case FormatText:
+		return "text/plain; charset=utf-8", nil
+	case FormatHTML:
+		return "text/html; charset=utf-8", nil
+	case FormatPDF:
+		return "application/pdf", nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Columns returns the characters per line of the paper width in Font A.
+func Columns(paper int) (int, error) {
+	switch paper {
+	case Paper58:
+		return 32, nil
+	case Paper80:
+		return 48, nil
+	}
+	return 0, ErrUnknownPaper
+}
+
+// Receipt adalah penjualan yang sudah selesai, sebagaimana dicetak.
+type Receipt struct {
+	Number  string    `json:"number"`
+	Time    time.Time `json:"time"`
+	Cashier string    `json:"cashier"`
+	Lines   []Line    `json:"lines"`
+	Taxes   []Tax     `json:"taxes"`
+	Tenders []Tender  `json:"tenders"`
+}
+
+type Line struct {
+	Name      string     `json:"name" binding:"required"`
+	Quantity  float64    `json:"quantity" binding:"gt=0"`
+	UnitPrice float64    `json:"unit_price" binding:"gte=0"`
+	Modifiers []Modifier `json:"modifiers" binding:"dive"`
+	// Discount berlaku untuk seluruh baris, bukan per unit.
+	Discount float64 `json:"discount" binding:"gte=0"`
+	Note     string  `json:"note"`
+}
+
+// Modifier adalah tambahan pada satu item, misalnya "Extra shot". Price
+// berlaku per unit item.
+type Modifier struct {
+	Name  string  `json:"name" binding:"required"`
+	Price float64 `json:"price" binding:"gte=0"`
+}
+
+// Tax adalah pajak atau biaya layanan yang sudah dihitung oleh order dan
+// ditambahkan ke subtotal.
+type Tax struct {
+	Name   string  `json:"name" binding:"required"`
+	Amount float64 `json:"amount" binding:"gte=0"`
+}
+
+type Tender struct {
+	Method string  `json:"method" binding:"required"`
+	Amount float64 `json:"amount" binding:"gt=0"`
+}
+
+// Total returns the price of the line including its modifiers, after its
+// discount.
+func (l Line) Total() float64 {
+	unit := l.UnitPrice
+	for _, m := range l.Modifiers {
+		unit += m.Price
+	}
+	return round(unit*l.Quantity - l.Discount)
+}
+
+func (r Receipt) Subtotal() float64 {
+	var sum float64
+	for _, l := range r.Lines {
+		sum += l.Total()
+	}
+	return round(sum)
+}
+
+func (r Receipt) Total() float64 {
+	total := r.Subtotal()
+	for _, t := range r.Taxes {
+		total += t.Amount
+	}
+	return round(total)
+}
+
+func (r Receipt) Paid() float64 {
+	var sum float64
+	for _, t := range r.Tenders {
+		sum += t.Amount
+	}
+	return round(sum)
+}
+
+// Change returns the change given back, or 0 when the tenders do not cover
+// the total.
+func (r Receipt) Change() float64 {
+	return max(round(r.Paid()-r.Total()), 0)
+}
+
+// Template adalah bagian struk yang diatur per outlet. Header biasanya
+// berisi nama toko, alamat dan NPWP; baris pertamanya dicetak tebal.
+type Template struct {
+	Header []string `json:"header"`
+	Footer []string `json:"footer"`
+	// Paper adalah lebar kertas printer outlet (58 atau 80).
+	Paper int `json:"paper"`
+}
+
+// Render writes r in format. paper selects the line width for escpos, text
+// and pdf; HTML ignores it.
+func Render(w io.Writer, format string, paper int, r Receipt, t Template) error {
+	if _, err := ContentType(format); err != nil {
+		return err
+	}
+	cols, err := Columns(paper)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatESCPOS:
+		return ESCPOS(w, layout(r, t, cols))
+	case FormatText:
+		return Text(w, layout(r, t, cols))
+	case FormatHTML:
+		return HTML(w, r, t)
+	default:
+		return PDF(w, layout(r, t, cols), cols)
+	}
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Money memformat Rupiah dengan pemisah ribuan titik, misalnya 18.000 atau
+// 1.250,50 bila ada sen.
+func Money(v float64) string {
+	neg := v < 0
+	cents := int64(math.Round(math.Abs(v) * 100))
+	digits := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	if neg {
+		b.WriteByte('-')
+	}
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	if frac := cents % 100; frac != 0 {
+		b.WriteString("," + strconv.FormatInt(frac/10, 10) + strconv.FormatInt(frac%10, 10))
+	}
+	return b.String()
+}
+
+// quantity memformat jumlah tanpa desimal yang tidak perlu dan dengan koma
+// desimal seperti Money: 2, 0,5, 1,25.
+func quantity(q float64) string {
+	return strings.Replace(strconv.FormatFloat(q, 'f', -1, 64), ".", ",", 1)
+}
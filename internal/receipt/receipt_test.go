@@ -0,0 +1,185 @@
+package receipt
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func sampleReceipt() (Receipt, Template) {
+	r := Receipt{
+		Number:  "INV-20260115-0042",
+		Time:    time.Date(2026, 1, 15, 19, 42, 0, 0, time.FixedZone("WIB", 7*3600)),
+		Cashier: "Sari",
+		Lines: []Line{
+			{Name: "Es Kopi Susu Gula Aren", Quantity: 2, UnitPrice: 18000, Modifiers: []Modifier{
+				{Name: "Extra shot", Price: 5000},
+				{Name: "Less ice"},
+			}},
+			{Name: "Croissant Almond Panggang dengan Taburan Gula Halus", Quantity: 1, UnitPrice: 27500, Note: "Dipanaskan"},
+			{Name: "Air Mineral", Quantity: 0.5, UnitPrice: 7000},
+		},
+		Taxes:   []Tax{{Name: "Layanan 5%", Amount: 3850}, {Name: "PPN 11%", Amount: 8893.50}},
+		Tenders: []Tender{{Method: "Voucher", Amount: 20000}, {Method: "Tunai", Amount: 100000}},
+	}
+	t := Template{
+		Header: []string{"Kopi Senja Kemang", "Jl. Kemang Raya No. 8, Jakarta Selatan", "NPWP 01.234.567.8-901.000"},
+		Footer: []string{"Terima kasih atas kunjungan Anda", "Wi-Fi: senja / kopisenja"},
+	}
+	return r, t
+}
+
+func TestRenderGolden(t *testing.T) {
+	r, tpl := sampleReceipt()
+	cases := []struct {
+		file   string
+		format string
+		paper  int
+	}{
+		{"receipt_58.escpos", FormatESCPOS, Paper58},
+		{"receipt_80.escpos", FormatESCPOS, Paper80},
+		{"receipt_58.txt", FormatText, Paper58},
+		{"receipt_80.txt", FormatText, Paper80},
+		{"receipt.html", FormatHTML, Paper80},
+		{"receipt_80.pdf", FormatPDF, Paper80},
+	}
+	for _, tc := range cases {
+		t.Run(tc.file, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, tc.format, tc.paper, r, tpl); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tc.file)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("output differs from %s (run go test -update after checking the change):\n%s", golden, buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestTotals(t *testing.T) {
+	r, _ := sampleReceipt()
+	// 2 x (18.000 + 5.000) + 27.500 + 0,5 x 7.000
+	if got := r.Subtotal(); got != 77000 {
+		t.Errorf("Subtotal() = %v, want 77000", got)
+	}
+	if got := r.Total(); got != 89743.50 {
+		t.Errorf("Total() = %v, want 89743.50", got)
+	}
+	if got := r.Change(); got != 30256.50 {
+		t.Errorf("Change() = %v, want 30256.50", got)
+	}
+	r.Tenders = r.Tenders[:1]
+	if got := r.Change(); got != 0 {
+		t.Errorf("Change() with a short payment = %v, want 0", got)
+	}
+}
+
+func TestLineDiscount(t *testing.T) {
+	r, tpl := sampleReceipt()
+	r.Lines[0].Discount = 6000
+	if got := r.Subtotal(); got != 71000 {
+		t.Errorf("Subtotal() with a discount = %v, want 71000", got)
+	}
+	for _, format := range []string{FormatText, FormatHTML} {
+		var buf bytes.Buffer
+		if err := Render(&buf, format, Paper58, r, tpl); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(buf.Bytes(), []byte("Diskon")) || !bytes.Contains(buf.Bytes(), []byte("-6.000")) {
+			t.Errorf("%s: expected the line discount on the receipt:\n%s", format, buf.Bytes())
+		}
+	}
+}
+
+func TestMoney(t *testing.T) {
+	for v, want := range map[float64]string{
+		0: "0", 500: "500", 18000: "18.000", 1250000.5: "1.250.000,50", 11319.05: "11.319,05", -2500: "-2.500",
+	} {
+		if got := Money(v); got != want {
+			t.Errorf("Money(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		in   string
+		cols int
+		want []string
+	}{
+		{"Es Kopi Susu", 32, []string{"Es Kopi Susu"}},
+		{"Croissant Almond Panggang", 12, []string{"Croissant", "Almond", "Panggang"}},
+		{"  * Dipanaskan sebentar", 12, []string{"  *", "Dipanaskan", "sebentar"}},
+		{"Supercalifragilistic", 8, []string{"Supercal", "ifragili", "stic"}},
+		{"", 10, []string{""}},
+	}
+	for _, tc := range cases {
+		if got := wrap(tc.in, tc.cols); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tc.in, tc.cols, got, tc.want)
+		}
+	}
+}
+
+func TestRenderRejectsUnknownOptions(t *testing.T) {
+	r, tpl := sampleReceipt()
+	if err := Render(&bytes.Buffer{}, "docx", Paper80, r, tpl); err != ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+	if err := Render(&bytes.Buffer{}, FormatText, 76, r, tpl); err != ErrUnknownPaper {
+		t.Errorf("expected ErrUnknownPaper, got %v", err)
+	}
+}
+
+func TestRenderSummary(t *testing.T) {
+	_, tpl := sampleReceipt()
+	s := Summary{
+		Title:    "LAPORAN TUTUP HARI",
+		Subtitle: []string{"Z-0007", "15/01/2026"},
+		Rows: []SummaryRow{
+			{Label: "Penjualan tunai (12)", Value: Money(250000)},
+			{Label: "Refund tunai (1)", Value: Money(18000)},
+			{},
+			{Label: "Bersih", Value: Money(232000), Bold: true},
+		},
+	}
+	var buf bytes.Buffer
+	if err := RenderSummary(&buf, FormatText, Paper58, s, tpl); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "summary_58.txt")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("output differs from %s (run go test -update after checking the change):\n%s", golden, buf.Bytes())
+	}
+
+	if err := RenderSummary(&buf, FormatHTML, Paper80, s, tpl); err != nil {
+		t.Fatal(err)
+	}
+	if err := RenderSummary(&buf, "docx", Paper80, s, tpl); err != ErrUnknownFormat {
+		t.Errorf("unknown format: err = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,77 @@
+package receipt
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+// Summary adalah laporan ringkas yang dicetak di printer struk dengan kop
+// outlet, misalnya laporan tutup hari (Z-report).
+type Summary struct {
+	Title string
+	// Subtitle dicetak di tengah di bawah judul, misalnya nomor dan tanggal.
+	Subtitle []string
+	Rows     []SummaryRow
+}
+
+// SummaryRow adalah satu baris label dan nilai. Baris dengan Label dan Value
+// kosong mencetak garis pemisah.
+type SummaryRow struct {
+	Label string
+	Value string
+	Bold  bool
+}
+
+func summaryLayout(s Summary, t Template, cols int) []line {
+	b := &builder{cols: cols}
+
+	for i, h := range t.Header {
+		b.center(h, i == 0)
+	}
+	if len(t.Header) > 0 {
+		b.rule()
+	}
+	b.center(s.Title, true)
+	for _, st := range s.Subtitle {
+		b.center(st, false)
+	}
+	b.rule()
+
+	for _, r := range s.Rows {
+		if r.Label == "" && r.Value == "" {
+			b.rule()
+			continue
+		}
+		b.lines = append(b.lines, withStyle(b.wrapPair(r.Label, r.Value), r.Bold, false)...)
+	}
+	return b.lines
+}
+
+// RenderSummary writes s in format with the header of t. HTML prints the
+// text layout in a <pre> block, so a reprint looks the same everywhere.
+func RenderSummary(w io.Writer, format string, paper int, s Summary, t Template) error {
+	if _, err := ContentType(format); err != nil {
+		return err
+	}
+	cols, err := Columns(paper)
+	if err != nil {
+		return err
+	}
+	lines := summaryLayout(s, t, cols)
+	switch format {
+	case FormatESCPOS:
+		return ESCPOS(w, lines)
+	case FormatText:
+		return Text(w, lines)
+	case FormatHTML:
+		var text strings.Builder
+		if err := Text(&text, lines); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "<!DOCTYPE html>\n<html><body><pre>"+html.EscapeString(text.String())+"</pre></body></html>\n")
+		return err
+	default:
+		return PDF(w, lines, cols)
+	}
+}
@@ -0,0 +1,18 @@
+package receipt
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Text menulis baris struk sebagai teks biasa, satu baris per baris
+// struk.
+func Text(w io.Writer, lines []line) error {
+	bw := bufio.NewWriter(w)
+	for _, l := range lines {
+		bw.WriteString(strings.TrimRight(l.text, " "))
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,170 @@
+// Package receiptmail mengirim struk lewat email dari antrean
+// receipt_emails. Worker mengambil pengiriman yang jatuh tempo untuk setiap
+// tenant, merender struk dengan template outletnya, lalu mengirimnya lewat
+// mailer.Sender. Kegagalan sementara dicoba lagi dengan jeda yang berlipat
+// dua sampai MaxAttempts.
+package receiptmail
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/mailer"
+	"maspos-be-go/internal/metrics"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/scope"
+)
+
+const (
+	// batchSize adalah jumlah pengiriman yang diambil per tenant per putaran.
+	batchSize = 20
+	// lease harus lebih lama dari satu pengiriman SMTP; setelah itu baris
+	// milik worker yang mati diambil worker lain.
+	lease = 5 * time.Minute
+)
+
+type Queue interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]repository.ReceiptEmail, error)
+	MarkSent(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id, reason string, retryAt *time.Time) error
+}
+
+type Templates interface {
+	Get(ctx context.Context, outletID string) (*receipt.Template, error)
+}
+
+type Tenants interface {
+	GetAll(ctx context.Context) ([]repository.Tenant, error)
+}
+
+type Worker struct {
+	Queue     Queue
+	Templates Templates
+	Tenants   Tenants
+	Sender    mailer.Sender
+	Logger    *slog.Logger
+	Metrics   *metrics.Metrics // boleh nil
+
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Interval    time.Duration
+
+	now func() time.Time
+}
+
+// Run memproses antrean setiap Interval sampai ctx selesai.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			w.Logger.Error("receipt email run failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends the receipt emails that are due for every tenant and
+// returns how many were sent. A failed delivery does not stop the run.
+func (w *Worker) RunOnce(ctx context.Context) (sent int, err error) {
+	tenants, err := w.Tenants.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range tenants {
+		tctx := scope.WithTenant(ctx, t.ID)
+		emails, err := w.Queue.Claim(tctx, batchSize, lease)
+		if err != nil {
+			return sent, err
+		}
+		for _, e := range emails {
+			if w.deliver(tctx, e) {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// deliver mengirim satu struk dan mencatat hasilnya.
+func (w *Worker) deliver(ctx context.Context, e repository.ReceiptEmail) bool {
+	log := w.Logger.With("receipt_email_id", e.ID, "number", e.Number, "attempt", e.Attempts)
+
+	err := w.send(ctx, e)
+	if err == nil {
+		w.Metrics.ReceiptEmailSent()
+		if err := w.Queue.MarkSent(ctx, e.ID); err != nil {
+			log.Error("failed to mark receipt email as sent", "error", err)
+		}
+		return true
+	}
+
+	var retryAt *time.Time
+	if !mailer.Permanent(err) && e.Attempts < w.MaxAttempts {
+		t := w.clock().Add(w.RetryDelay << (e.Attempts - 1))
+		retryAt = &t
+	}
+	w.Metrics.ReceiptEmailFailed()
+	log.Warn("receipt email not sent", "error", err, "retry_at", retryAt)
+	if err := w.Queue.MarkFailed(ctx, e.ID, err.Error(), retryAt); err != nil {
+		log.Error("failed to record receipt email failure", "error", err)
+	}
+	return false
+}
+
+func (w *Worker) send(ctx context.Context, e repository.ReceiptEmail) error {
+	t, err := w.Templates.Get(ctx, e.OutletID)
+	if err != nil {
+		return err
+	}
+	m, err := Message(*e.Receipt, *t)
+	if err != nil {
+		return err
+	}
+	m.To = e.Email
+	return w.Sender.Send(ctx, m)
+}
+
+func (w *Worker) clock() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+// Message menyusun email struk: HTML untuk dibaca, teks 48 kolom untuk
+// klien tanpa HTML, dan PDF selebar kertas outlet sebagai lampiran.
+func Message(r receipt.Receipt, t receipt.Template) (mailer.Message, error) {
+	var html, text, pdf bytes.Buffer
+	if err := receipt.HTML(&html, r, t); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := receipt.Render(&text, receipt.FormatText, receipt.Paper80, r, t); err != nil {
+		return mailer.Message{}, err
+	}
+	if err := receipt.Render(&pdf, receipt.FormatPDF, t.Paper, r, t); err != nil {
+		return mailer.Message{}, err
+	}
+
+	subject := "Struk " + r.Number
+	if len(t.Header) > 0 {
+		subject += " - " + t.Header[0]
+	}
+	return mailer.Message{
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Attachments: []mailer.Attachment{{
+			Filename:    "struk-" + r.Number + ".pdf",
+			ContentType: "application/pdf",
+			Data:        pdf.Bytes(),
+		}},
+	}, nil
+}
@@ -0,0 +1,178 @@
+package receiptmail
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/mailer"
+	"maspos-be-go/internal/metrics"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/scope"
+)
+
+// fakeQueue menyimpan pengiriman per tenant dan meniru Claim: hanya baris
+// pending yang jatuh tempo yang diambil, dan attempts bertambah.
+type fakeQueue struct {
+	now    time.Time
+	emails map[string][]*repository.ReceiptEmail
+}
+
+func (q *fakeQueue) Claim(ctx context.Context, limit int, lease time.Duration) ([]repository.ReceiptEmail, error) {
+	var res []repository.ReceiptEmail
+	for _, e := range q.emails[scope.Tenant(ctx)] {
+		if e.Status == repository.EmailPending && !e.NextAttemptAt.After(q.now) && len(res) < limit {
+			e.Attempts++
+			e.NextAttemptAt = q.now.Add(lease)
+			res = append(res, *e)
+		}
+	}
+	return res, nil
+}
+
+func (q *fakeQueue) find(ctx context.Context, id string) *repository.ReceiptEmail {
+	for _, e := range q.emails[scope.Tenant(ctx)] {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (q *fakeQueue) MarkSent(ctx context.Context, id string) error {
+	e := q.find(ctx, id)
+	e.Status, e.LastError = repository.EmailSent, ""
+	return nil
+}
+
+func (q *fakeQueue) MarkFailed(ctx context.Context, id, reason string, retryAt *time.Time) error {
+	e := q.find(ctx, id)
+	e.LastError = reason
+	if retryAt == nil {
+		e.Status = repository.EmailFailed
+	} else {
+		e.NextAttemptAt = *retryAt
+	}
+	return nil
+}
+
+type fakeTemplates struct{}
+
+func (fakeTemplates) Get(ctx context.Context, outletID string) (*receipt.Template, error) {
+	return &receipt.Template{Header: []string{"Kopi Senja"}, Footer: []string{"Terima kasih"}, Paper: receipt.Paper58}, nil
+}
+
+type fakeTenants []string
+
+func (f fakeTenants) GetAll(ctx context.Context) ([]repository.Tenant, error) {
+	var res []repository.Tenant
+	for _, id := range f {
+		res = append(res, repository.Tenant{ID: id})
+	}
+	return res, nil
+}
+
+// fakeSender gagal untuk alamat di errs dan mencatat pesan lainnya.
+type fakeSender struct {
+	errs map[string]error
+	sent []mailer.Message
+}
+
+func (f *fakeSender) Send(ctx context.Context, m mailer.Message) error {
+	if err := f.errs[m.To]; err != nil {
+		return err
+	}
+	f.sent = append(f.sent, m)
+	return nil
+}
+
+func TestWorkerRunOnce(t *testing.T) {
+	start := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	sale := &receipt.Receipt{
+		Number: "INV-1",
+		Time:   start,
+		Lines:  []receipt.Line{{Name: "Es Kopi Susu", Quantity: 2, UnitPrice: 18000}},
+	}
+	email := func(id, to string) *repository.ReceiptEmail {
+		return &repository.ReceiptEmail{ID: id, Number: "INV-1", Email: to, Receipt: sale, Status: repository.EmailPending, NextAttemptAt: start}
+	}
+	queue := &fakeQueue{now: start, emails: map[string][]*repository.ReceiptEmail{
+		"tenant-a": {email("ok", "budi@example.com"), email("busy", "busy@example.com"), email("bounce", "nobody@example.com")},
+		"tenant-b": {email("other", "sari@example.com")},
+	}}
+	sender := &fakeSender{errs: map[string]error{
+		"busy@example.com":   &textproto.Error{Code: 451, Msg: "try again later"},
+		"nobody@example.com": &textproto.Error{Code: 550, Msg: "no such user"},
+	}}
+	w := &Worker{
+		Queue: queue, Templates: fakeTemplates{}, Tenants: fakeTenants{"tenant-a", "tenant-b"}, Sender: sender,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Metrics:     metrics.New(nil, ""),
+		MaxAttempts: 3, RetryDelay: time.Minute,
+		now: func() time.Time { return queue.now },
+	}
+	ctx := context.Background()
+	tenantA := scope.WithTenant(ctx, "tenant-a")
+
+	sent, err := w.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 || len(sender.sent) != 2 {
+		t.Fatalf("expected the emails of both tenants to be sent, got %d", sent)
+	}
+	m := sender.sent[0]
+	if m.To != "budi@example.com" || m.Subject != "Struk INV-1 - Kopi Senja" || !strings.Contains(m.HTML, "Es Kopi Susu") ||
+		!strings.Contains(m.Text, "36.000") || !strings.HasPrefix(string(m.Attachments[0].Data), "%PDF-") {
+		t.Errorf("unexpected message: %+v", m)
+	}
+	if e := queue.find(tenantA, "bounce"); e.Status != repository.EmailFailed || !strings.Contains(e.LastError, "no such user") {
+		t.Errorf("expected a 5xx reply to fail the delivery at once, got %+v", e)
+	}
+	busy := queue.find(tenantA, "busy")
+	if busy.Status != repository.EmailPending || !busy.NextAttemptAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("expected a retry after 1m, got %+v", busy)
+	}
+
+	// belum jatuh tempo: tidak ada yang dicoba
+	queue.now = start.Add(30 * time.Second)
+	w.RunOnce(ctx)
+	if busy.Attempts != 1 {
+		t.Fatalf("expected no attempt before the retry time, got %d attempts", busy.Attempts)
+	}
+
+	// percobaan kedua menunggu 2m, yang ketiga adalah yang terakhir
+	queue.now = start.Add(time.Minute)
+	w.RunOnce(ctx)
+	if want := queue.now.Add(2 * time.Minute); busy.Status != repository.EmailPending || !busy.NextAttemptAt.Equal(want) {
+		t.Errorf("expected the second retry at %s, got %+v", want, busy)
+	}
+	queue.now = busy.NextAttemptAt
+	w.RunOnce(ctx)
+	if busy.Attempts != 3 || busy.Status != repository.EmailFailed {
+		t.Errorf("expected the delivery to fail after 3 attempts, got %+v", busy)
+	}
+
+	// server pulih: kiriman yang sudah failed tidak dicoba lagi
+	delete(sender.errs, "busy@example.com")
+	queue.now = queue.now.Add(time.Hour)
+	if sent, _ := w.RunOnce(ctx); sent != 0 {
+		t.Errorf("expected nothing to send, got %d", sent)
+	}
+
+	// setiap percobaan yang gagal dihitung, juga yang akan dicoba lagi
+	rr := httptest.NewRecorder()
+	w.Metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{`maspos_receipt_emails_total{result="sent"} 2`, `maspos_receipt_emails_total{result="failed"} 4`} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("expected metrics output to contain %q", want)
+		}
+	}
+}
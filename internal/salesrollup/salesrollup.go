@@ -0,0 +1,72 @@
+// Package salesrollup menjaga tabel sales_daily_rollups tetap mengikuti
+// order dan refund, dan product_daily_rollups mengikuti baris keduanya.
+// Worker memajukan checkpoint setiap tenant secara bertahap; menghitung ulang
+// dan memeriksa rollup dilakukan lewat perintah rollup (cmd/rollup).
+package salesrollup
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/scope"
+)
+
+type Rollups interface {
+	Advance(ctx context.Context, tz string, lag time.Duration) (repository.SalesRollupCheckpoint, int, error)
+}
+
+type Tenants interface {
+	GetAll(ctx context.Context) ([]repository.Tenant, error)
+}
+
+type Worker struct {
+	Rollups Rollups
+	Tenants Tenants
+	Logger  *slog.Logger
+
+	Timezone string
+	Lag      time.Duration
+	Interval time.Duration
+}
+
+// Run memajukan rollup setiap Interval sampai ctx selesai.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			w.Logger.Error("sales rollup run failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce adds the new orders and refunds of every tenant
+// to its rollups and returns how many daily outlet rollup rows changed. A
+// tenant whose rollups were built in another time zone is skipped with an
+// error log until it is rebuilt; other errors stop the run.
+func (w *Worker) RunOnce(ctx context.Context) (days int, err error) {
+	tenants, err := w.Tenants.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range tenants {
+		cp, n, err := w.Rollups.Advance(scope.WithTenant(ctx, t.ID), w.Timezone, w.Lag)
+		if errors.Is(err, repository.ErrRollupTimezone) {
+			w.Logger.Error("sales rollups need a rebuild", "tenant", t.Slug, "built_in", cp.Timezone, "timezone", w.Timezone)
+			continue
+		}
+		if err != nil {
+			return days, err
+		}
+		days += n
+	}
+	return days, nil
+}
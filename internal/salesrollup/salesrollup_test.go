@@ -0,0 +1,57 @@
+package salesrollup
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/scope"
+)
+
+// fakeRollups mencatat zona waktu rollup per tenant dan mengembalikan
+// jumlah baris tetap untuk setiap Advance.
+type fakeRollups struct {
+	timezones map[string]string
+	advanced  []string
+}
+
+func (f *fakeRollups) Advance(ctx context.Context, tz string, lag time.Duration) (repository.SalesRollupCheckpoint, int, error) {
+	tenant := scope.Tenant(ctx)
+	cp := repository.SalesRollupCheckpoint{Timezone: f.timezones[tenant]}
+	if cp.Timezone != tz {
+		return cp, 0, repository.ErrRollupTimezone
+	}
+	f.advanced = append(f.advanced, tenant)
+	return cp, 2, nil
+}
+
+type fakeTenants []repository.Tenant
+
+func (f fakeTenants) GetAll(ctx context.Context) ([]repository.Tenant, error) {
+	return f, nil
+}
+
+func TestWorkerRunOnce(t *testing.T) {
+	rollups := &fakeRollups{timezones: map[string]string{"t1": "Asia/Jakarta", "t2": "UTC", "t3": "Asia/Jakarta"}}
+	w := &Worker{
+		Rollups:  rollups,
+		Tenants:  fakeTenants{{ID: "t1", Slug: "kopi-senja"}, {ID: "t2", Slug: "kopi-pagi"}, {ID: "t3", Slug: "roti-sore"}},
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Timezone: "Asia/Jakarta",
+		Lag:      time.Minute,
+	}
+	days, err := w.RunOnce(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days != 4 {
+		t.Errorf("days = %d, want 4", days)
+	}
+	// tenant dengan zona waktu lain dilewati tanpa menghentikan tenant berikutnya
+	if len(rollups.advanced) != 2 || rollups.advanced[0] != "t1" || rollups.advanced[1] != "t3" {
+		t.Errorf("advanced tenants = %v, want [t1 t3]", rollups.advanced)
+	}
+}
@@ -0,0 +1,37 @@
+// Package scope membawa tenant (merchant) dan outlet aktif sebuah request di
+// context, dari middleware HTTP sampai ke lapisan database yang membatasi
+// setiap query dengannya.
+package scope
+
+import "context"
+
+type (
+	tenantKey struct{}
+	outletKey struct{}
+)
+
+// WithTenant returns a context scoped to the given tenant. The database
+// layer sets it as app.tenant_id in every transaction, where the row-level
+// security policies read it.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// Tenant returns the tenant of ctx, or "" when there is none. Queries on
+// tenant tables without a tenant see no rows.
+func Tenant(ctx context.Context) string {
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id
+}
+
+// WithOutlet returns a context scoped to the given outlet.
+func WithOutlet(ctx context.Context, outletID string) context.Context {
+	return context.WithValue(ctx, outletKey{}, outletID)
+}
+
+// Outlet returns the outlet of ctx, or "" for the central (head office)
+// scope that only sees the shared catalog.
+func Outlet(ctx context.Context) string {
+	id, _ := ctx.Value(outletKey{}).(string)
+	return id
+}
@@ -2,10 +2,13 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
-	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/ratelimit"
+	"maspos-be-go/internal/scope"
 	"maspos-be-go/internal/server/dto"
 	"maspos-be-go/internal/utils"
 )
@@ -32,11 +35,10 @@ func (s *Server) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	repo := repository.NewUserRepository(s.db.DB())
 	ctx := c.Request.Context()
 
 	// cek email
-	exists, err := repo.ExistsByEmail(ctx, req.Email)
+	exists, err := s.users.ExistsByEmail(ctx, req.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
@@ -64,7 +66,7 @@ func (s *Server) RegisterHandler(c *gin.Context) {
 	}
 
 	// insert user
-	if err := repo.Create(ctx, req.Name, req.Email, hashedPassword); err != nil {
+	if err := s.users.Create(ctx, req.Name, req.Email, hashedPassword); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": err.Error(),
@@ -91,6 +93,7 @@ func (s *Server) RegisterHandler(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]interface{}
 // @Failure 401 {object} map[string]interface{}
+// @Failure 429 {object} map[string]interface{}
 // @Router /auth/login [post]
 func (s *Server) LoginHandler(c *gin.Context) {
     var req dto.LoginRequest
@@ -104,12 +107,19 @@ func (s *Server) LoginHandler(c *gin.Context) {
         return
     }
 
-    // Panggil repository untuk mencari user berdasarkan email
-    repo := repository.NewUserRepository(s.db.DB())
+    // Tolak lebih dulu bila email ini sedang dikunci karena terlalu sering gagal
     ctx := c.Request.Context()
-    
-    user, err := repo.GetByEmail(ctx, req.Email) // Pastikan method GetByEmail ada di repository
+    tenant := scope.Tenant(ctx)
+    lockKey := loginLockKey(tenant, req.Email)
+    if s.loginLocked(c, lockKey) {
+        return
+    }
+
+    // Panggil repository untuk mencari user berdasarkan email
+
+    user, err := s.users.GetByEmail(ctx, req.Email) // Pastikan method GetByEmail ada di repository
     if err != nil {
+        s.loginFailed(c, lockKey)
         c.JSON(http.StatusUnauthorized, gin.H{
             "status":  "error",
             "message": "invalid email or password",
@@ -119,6 +129,7 @@ func (s *Server) LoginHandler(c *gin.Context) {
 
     // Verifikasi password (membandingkan input plain text dengan hash di DB)
     if err := utils.CheckPassword(req.Password, user.Password); err != nil {
+        s.loginFailed(c, lockKey)
         c.JSON(http.StatusUnauthorized, gin.H{
             "status":  "error",
             "message": "invalid email or password",
@@ -126,7 +137,18 @@ func (s *Server) LoginHandler(c *gin.Context) {
         return
     }
 
-   token, err := utils.GenerateToken(user.Email)
+    // outlet user ikut disimpan di token; middleware outletScope memakainya
+    // untuk memeriksa header X-Outlet-ID tanpa query tambahan
+    outlets, err := s.outlets.IDsForUser(ctx, user.ID)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{
+            "status":  "error",
+            "message": "failed to load user outlets",
+        })
+        return
+    }
+
+    token, err := utils.GenerateToken(user.ID, user.Email, tenant, outlets, user.HeadOffice, []byte(s.cfg.JWT.Secret), s.cfg.JWT.TTL)
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{
             "status":  "error",
@@ -135,12 +157,73 @@ func (s *Server) LoginHandler(c *gin.Context) {
         return
     }
 
+    s.metrics.LoginSucceeded()
+    s.loginReset(c, lockKey)
     c.JSON(http.StatusOK, gin.H{
         "status":  "success",
         "message": "Login successful",
         "data": dto.LoginResponse{
             Token: token, // Sekarang berisi token JWT asli
             Email: user.Email,
+            Outlets: outlets,
+            HeadOffice: user.HeadOffice,
         },
     })
 }
+
+// loginLockKey adalah kunci penguncian login untuk satu alamat email di satu
+// tenant. Email yang sama boleh terdaftar di beberapa merchant, dan gagal
+// login di satu merchant tidak boleh mengunci user merchant lain.
+func loginLockKey(tenantID, email string) string {
+	return "login:" + tenantID + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginLocked membalas 429 dan mengembalikan true bila email sedang dikunci.
+// Gangguan pada backend rate limit tidak menghalangi login.
+func (s *Server) loginLocked(c *gin.Context, key string) bool {
+	if s.limiter == nil {
+		return false
+	}
+	locked, err := s.limiter.LockedFor(c.Request.Context(), key)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("login lockout check failed", "error", err)
+		return false
+	}
+	if locked <= 0 {
+		return false
+	}
+	tooManyRequests(c, locked)
+	return true
+}
+
+// loginFailed mencatat login yang gagal dan mengunci email bila batasnya
+// tercapai. Request yang gagal ini sendiri tetap dibalas 401.
+func (s *Server) loginFailed(c *gin.Context, key string) {
+	s.metrics.LoginFailed()
+	if s.limiter == nil {
+		return
+	}
+	l := s.cfg.RateLimit.Login
+	policy := ratelimit.Lockout{MaxFailures: l.MaxFailures, Window: l.Window, BaseDelay: l.BaseDelay, MaxDelay: l.MaxDelay}
+	if !policy.Enabled() {
+		return
+	}
+	locked, err := s.limiter.RecordFailure(c.Request.Context(), key, policy)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Warn("failed to record login failure", "error", err)
+		return
+	}
+	if locked > 0 {
+		s.metrics.LoginLockedOut()
+		logger.FromContext(c.Request.Context()).Warn("login locked after repeated failures", "locked_for", locked)
+	}
+}
+
+func (s *Server) loginReset(c *gin.Context, key string) {
+	if s.limiter == nil {
+		return
+	}
+	if err := s.limiter.ResetFailures(c.Request.Context(), key); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("failed to reset login failures", "error", err)
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/ratelimit"
+	"maspos-be-go/internal/scope"
+	"maspos-be-go/internal/utils"
+)
+
+type fakeUserStore struct {
+	users map[string]repository.User
+}
+
+func (f *fakeUserStore) Create(ctx context.Context, name, email, password string) error {
+	f.users[email] = repository.User{ID: len(f.users) + 1, Name: name, Email: email, Password: password}
+	return nil
+}
+
+func (f *fakeUserStore) GetByEmail(ctx context.Context, email string) (*repository.User, error) {
+	u, ok := f.users[email]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (f *fakeUserStore) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, ok := f.users[email]
+	return ok, nil
+}
+
+func TestLoginLockout(t *testing.T) {
+	hash, err := utils.HashPassword("rahasia123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		JWT: config.JWT{Secret: "test-secret-0123456789", TTL: time.Hour},
+		RateLimit: config.RateLimit{Login: config.Lockout{
+			MaxFailures: 2, Window: time.Minute, BaseDelay: time.Minute, MaxDelay: time.Hour,
+		}},
+	}
+	s := &Server{
+		cfg:     cfg,
+		limiter: ratelimit.NewMemory(),
+		users: &fakeUserStore{users: map[string]repository.User{
+			"kasir@example.com": {ID: 1, Email: "kasir@example.com", Password: hash},
+		}},
+		outlets: &fakeOutletStore{},
+	}
+	r := gin.New()
+	r.POST("/auth/login", s.LoginHandler)
+
+	const tenantSenja, tenantPagi = "tenant-senja", "tenant-pagi"
+	loginAt := func(tenant, email, password string) *httptest.ResponseRecorder {
+		body := `{"email":"` + email + `","password":"` + password + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		req = req.WithContext(scope.WithTenant(req.Context(), tenant))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	login := func(email, password string) *httptest.ResponseRecorder {
+		return loginAt(tenantSenja, email, password)
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := login("kasir@example.com", "salah"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("failed login %d: expected 401, got %d", i+1, rr.Code)
+		}
+	}
+
+	// password benar pun ditolak selama email dikunci
+	rr := login("Kasir@Example.com", "rahasia123")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 while locked, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+
+	if rr := login("pemilik@example.com", "salah"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected other accounts to be unaffected, got %d", rr.Code)
+	}
+	// email yang sama di merchant lain adalah akun lain
+	if rr := loginAt(tenantPagi, "kasir@example.com", "rahasia123"); rr.Code != http.StatusOK {
+		t.Errorf("expected the same email in another tenant to be unaffected, got %d: %s", rr.Code, rr.Body)
+	}
+}
@@ -1,8 +1,12 @@
 package server
 
 import (
+    "database/sql"
+    "errors"
     "net/http"
+    "strconv"
     "github.com/gin-gonic/gin"
+    "maspos-be-go/internal/catalogexport"
     "maspos-be-go/internal/database/repository"
     "maspos-be-go/internal/server/dto"
 )
@@ -13,6 +17,7 @@ import (
 // @Produce json
 // @Param body body dto.CategoryRequest true "Category payload"
 // @Success 201 {object} dto.CategoryResponse
+// @Failure 400 {object} map[string]string
 // @Router /categories [post]
 func (s *Server) CreateCategoryHandler(c *gin.Context) {
     var req dto.CategoryRequest
@@ -21,9 +26,19 @@ func (s *Server) CreateCategoryHandler(c *gin.Context) {
         return
     }
     
-    repo := repository.NewCategoryRepository(s.db.DB())
-    id, err := repo.Create(c.Request.Context(), req.Name)
-    
+    active := req.Active == nil || *req.Active
+    id, err := s.categories.Create(c.Request.Context(), repository.Category{
+        Name:     req.Name,
+        ParentID: req.ParentID,
+        Position: req.Position,
+        Color:    req.Color,
+        Icon:     req.Icon,
+        Active:   active,
+    })
+    if errors.Is(err, repository.ErrParentNotFound) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
     if err != nil {
         // PERHATIKAN DI SINI: Kita kirim err.Error() asli dari database
         c.JSON(http.StatusInternalServerError, gin.H{
@@ -32,72 +47,304 @@ func (s *Server) CreateCategoryHandler(c *gin.Context) {
         return
     }
     
-    c.JSON(http.StatusCreated, dto.CategoryResponse{ID: id, Name: req.Name})
+    c.Header("ETag", versionETag(1))
+    c.JSON(http.StatusCreated, dto.CategoryResponse{
+        ID:       id,
+        ParentID: req.ParentID,
+        Name:     req.Name,
+        Position: req.Position,
+        Color:    req.Color,
+        Icon:     req.Icon,
+        Active:   active,
+        Version:  1,
+    })
 }
 
 // @Summary Get all categories
 // @Tags Category
 // @Produce json
+// @Param active query bool false "Only categories shown on the POS grid"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {array} repository.Category
+// @Success 304 "Not modified"
 // @Router /categories [get]
 func (s *Server) GetAllCategoriesHandler(c *gin.Context) {
-    repo := repository.NewCategoryRepository(s.db.DB())
-    res, err := repo.GetAll(c.Request.Context())
+    res, err := s.categories.GetAll(c.Request.Context(), repository.CategoryFilter{ActiveOnly: activeOnly(c)})
     if err != nil {
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
+    if notModified(c, categoriesETag(res)) {
+        return
+    }
     c.JSON(http.StatusOK, res)
 }
 
 // @Summary Get category by ID
 // @Tags Category
 // @Param id path string true "Category ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} repository.Category
+// @Success 304 "Not modified"
 // @Router /categories/{id} [get]
 func (s *Server) GetCategoryByIDHandler(c *gin.Context) {
     id := c.Param("id")
-    repo := repository.NewCategoryRepository(s.db.DB())
-    res, err := repo.GetByID(c.Request.Context(), id)
+    res, err := s.categories.GetByID(c.Request.Context(), id)
     if err != nil {
         c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
         return
     }
+    if notModified(c, versionETag(res.Version)) {
+        return
+    }
     c.JSON(http.StatusOK, res)
 }
 
+// @Summary Get category tree
+// @Description All categories nested under their parents, each level ordered by position.
+// @Tags Category
+// @Produce json
+// @Param active query bool false "Only categories shown on the POS grid"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {array} dto.CategoryNode
+// @Success 304 "Not modified"
+// @Router /categories/tree [get]
+func (s *Server) GetCategoryTreeHandler(c *gin.Context) {
+    res, err := s.categories.GetAll(c.Request.Context(), repository.CategoryFilter{ActiveOnly: activeOnly(c)})
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+    if notModified(c, categoriesETag(res)) {
+        return
+    }
+    c.JSON(http.StatusOK, categoryTree(res))
+}
+
+// @Summary Get category subtree
+// @Description The category with all of its descendants nested under it.
+// @Tags Category
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} dto.CategoryNode
+// @Success 304 "Not modified"
+// @Failure 404 {object} map[string]string
+// @Router /categories/{id}/tree [get]
+func (s *Server) GetCategorySubtreeHandler(c *gin.Context) {
+    res, err := s.categories.GetSubtree(c.Request.Context(), c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+        return
+    }
+    if notModified(c, categoriesETag(res)) {
+        return
+    }
+    c.JSON(http.StatusOK, categoryTree(res)[0])
+}
+
 // @Summary Update category
+// @Description Partial update with JSON Merge Patch; only the fields sent are changed. Changing parent_id moves the whole subtree; null moves it to the top level.
 // @Tags Category
+// @Accept json
 // @Param id path string true "Category ID"
-// @Param body body dto.CategoryRequest true "Category Name"
+// @Param If-Match header string false "ETag of the version being edited"
+// @Param body body dto.CategoryPatch true "Fields to change"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /categories/{id} [patch]
 func (s *Server) UpdateCategoryHandler(c *gin.Context) {
     id := c.Param("id")
-    var req dto.CategoryRequest
-    if err := c.ShouldBindJSON(&req); err != nil {
+    var req dto.CategoryPatch
+    nulls, err := bindMergePatch(c, &req, "parent_id")
+    if err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
-    repo := repository.NewCategoryRepository(s.db.DB())
-    if err := repo.Update(c.Request.Context(), id, req.Name); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    changes := repository.CategoryChanges{
+        Name:     req.Name,
+        Position: req.Position,
+        Color:    req.Color,
+        Icon:     req.Icon,
+        Active:   req.Active,
+    }
+    switch {
+    case nulls["parent_id"]:
+        changes.ParentID = &sql.NullString{}
+    case req.ParentID != nil:
+        changes.ParentID = &sql.NullString{String: *req.ParentID, Valid: true}
+    }
+    if changes == (repository.CategoryChanges{}) {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
+        return
+    }
+    version, ok := s.ifMatchVersion(c)
+    if !ok {
+        return
+    }
+    newVersion, err := s.categories.Update(c.Request.Context(), id, changes, version)
+    if err != nil {
+        catalogWriteError(c, err, "Category not found")
         return
     }
+    c.Header("ETag", versionETag(newVersion))
     c.JSON(http.StatusOK, gin.H{"message": "Category updated"})
 }
 
 // @Summary Delete category
 // @Tags Category
 // @Param id path string true "Category ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /categories/{id} [delete]
 func (s *Server) DeleteCategoryHandler(c *gin.Context) {
     id := c.Param("id")
-    repo := repository.NewCategoryRepository(s.db.DB())
-    if err := repo.Delete(c.Request.Context(), id); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    version, ok := s.ifMatchVersion(c)
+    if !ok {
+        return
+    }
+    if err := s.categories.Delete(c.Request.Context(), id, version); err != nil {
+        catalogWriteError(c, err, "Category not found")
         return
     }
     c.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
-}
\ No newline at end of file
+}
+
+// @Summary Reorder categories
+// @Description Sets the positions of several categories in one transaction. Nothing is changed when any ID does not exist or appears more than once.
+// @Tags Category
+// @Accept json
+// @Param body body dto.ReorderRequest true "New positions"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /categories/reorder [post]
+func (s *Server) ReorderCategoriesHandler(c *gin.Context) {
+    var req dto.ReorderRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    if id, ok := duplicatePosition(req.Items); ok {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "ID " + id + " appears more than once"})
+        return
+    }
+    if err := s.categories.Reorder(c.Request.Context(), req.Items); err != nil {
+        catalogWriteError(c, err, "One or more categories not found")
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{"message": "Categories reordered"})
+}
+
+// @Summary Export categories
+// @Description Streams every category with its full path as CSV, NDJSON or XLSX.
+// @Tags Category
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param active query bool false "Only categories shown on the POS grid"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /categories/export [get]
+func (s *Server) ExportCategoriesHandler(c *gin.Context) {
+    filter := repository.CategoryFilter{ActiveOnly: activeOnly(c)}
+    streamExport(c, "categories", catalogexport.CategoryColumns, func(write func([]any) error) error {
+        return s.categories.Export(c.Request.Context(), filter, func(cat repository.CategoryExport) error {
+            return write(catalogexport.CategoryRow(cat))
+        })
+    })
+}
+
+// activeOnly membaca query ?active=true yang dipakai layar POS untuk
+// menyembunyikan item nonaktif; tanpa parameter semua item dikembalikan.
+func activeOnly(c *gin.Context) bool {
+    active, _ := strconv.ParseBool(c.Query("active"))
+    return active
+}
+
+// catalogWriteError memetakan error update/delete kategori dan produk ke
+// status HTTP: 412 untuk konflik versi, 409 untuk perubahan yang merusak
+// hierarki atau SKU yang sudah dipakai, 404 bila barisnya tidak ada.
+// duplicatePosition mencari ID yang muncul lebih dari sekali; database
+// hanya mengubah barisnya sekali sehingga jumlahnya tidak akan cocok.
+func duplicatePosition(items []repository.Position) (string, bool) {
+    seen := make(map[string]bool, len(items))
+    for _, p := range items {
+        if seen[p.ID] {
+            return p.ID, true
+        }
+        seen[p.ID] = true
+    }
+    return "", false
+}
+
+func catalogWriteError(c *gin.Context, err error, notFound string) {
+    switch {
+    case errors.Is(err, repository.ErrVersionConflict):
+        c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+    case errors.Is(err, repository.ErrCategoryCycle), errors.Is(err, repository.ErrCategoryHasChildren),
+        errors.Is(err, repository.ErrSKUTaken), errors.Is(err, repository.ErrOutletInUse):
+        c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+    case errors.Is(err, repository.ErrSharedCatalog), errors.Is(err, repository.ErrHeadOfficeOnly):
+        c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+    case errors.Is(err, repository.ErrParentNotFound), errors.Is(err, repository.ErrCategoryNotFound),
+        errors.Is(err, repository.ErrOutletRequired):
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    case errors.Is(err, sql.ErrNoRows):
+        c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+    default:
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    }
+}
+
+func categoriesETag(categories []repository.Category) string {
+    ids := make([]string, len(categories))
+    versions := make([]int, len(categories))
+    for i, cat := range categories {
+        ids[i], versions[i] = cat.ID, cat.Version
+    }
+    return listETag(ids, versions)
+}
+
+// categoryTree menyusun daftar kategori menjadi pohon. Urutan masukan
+// dipertahankan di setiap tingkat; kategori yang parent-nya tidak ada di
+// daftar menjadi akar.
+func categoryTree(categories []repository.Category) []*dto.CategoryNode {
+    nodes := make(map[string]*dto.CategoryNode, len(categories))
+    for _, cat := range categories {
+        nodes[cat.ID] = &dto.CategoryNode{
+            ID:       cat.ID,
+            ParentID: cat.ParentID,
+            Name:     cat.Name,
+            Position: cat.Position,
+            Color:    cat.Color,
+            Icon:     cat.Icon,
+            Active:   cat.Active,
+            Version:  cat.Version,
+            OutletID: cat.OutletID,
+            Children: []*dto.CategoryNode{},
+        }
+    }
+
+    roots := []*dto.CategoryNode{}
+    for _, cat := range categories {
+        node := nodes[cat.ID]
+        if cat.ParentID != nil {
+            if parent, ok := nodes[*cat.ParentID]; ok {
+                parent.Children = append(parent.Children, node)
+                continue
+            }
+        }
+        roots = append(roots, node)
+    }
+    return roots
+}
@@ -0,0 +1,446 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/server/dto"
+)
+
+type fakeCategoryStore struct {
+	categories map[string]repository.Category
+}
+
+func (f *fakeCategoryStore) Create(ctx context.Context, c repository.Category) (string, error) {
+	if c.ParentID != nil {
+		if _, ok := f.categories[*c.ParentID]; !ok {
+			return "", repository.ErrParentNotFound
+		}
+	}
+	c.ID, c.Version = "cat-new", 1
+	f.categories[c.ID] = c
+	return c.ID, nil
+}
+
+func (f *fakeCategoryStore) GetAll(ctx context.Context, filter repository.CategoryFilter) ([]repository.Category, error) {
+	var res []repository.Category
+	for _, c := range f.categories {
+		if !filter.ActiveOnly || f.visible(c.ID) {
+			res = append(res, c)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Position != res[j].Position {
+			return res[i].Position < res[j].Position
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// visible meniru CTE visibleCategories: kategori dan semua leluhurnya aktif.
+func (f *fakeCategoryStore) visible(id string) bool {
+	for {
+		c, ok := f.categories[id]
+		if !ok || !c.Active {
+			return false
+		}
+		if c.ParentID == nil {
+			return true
+		}
+		id = *c.ParentID
+	}
+}
+
+func (f *fakeCategoryStore) GetSubtree(ctx context.Context, rootID string) ([]repository.Category, error) {
+	all, _ := f.GetAll(ctx, repository.CategoryFilter{})
+	in := map[string]bool{rootID: true}
+	res := []repository.Category{}
+	for changed := true; changed; {
+		changed = false
+		for _, c := range all {
+			if !in[c.ID] && c.ParentID != nil && in[*c.ParentID] {
+				in[c.ID], changed = true, true
+			}
+		}
+	}
+	for _, c := range all {
+		if in[c.ID] {
+			res = append(res, c)
+		}
+	}
+	if len(res) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return res, nil
+}
+
+func (f *fakeCategoryStore) GetByID(ctx context.Context, id string) (*repository.Category, error) {
+	c, ok := f.categories[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &c, nil
+}
+
+func (f *fakeCategoryStore) Update(ctx context.Context, id string, changes repository.CategoryChanges, version int) (int, error) {
+	c, ok := f.categories[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	if version != 0 && c.Version != version {
+		return 0, repository.ErrVersionConflict
+	}
+	if changes.Name != nil {
+		c.Name = *changes.Name
+	}
+	if changes.Active != nil {
+		c.Active = *changes.Active
+	}
+	if changes.Color != nil {
+		c.Color = *changes.Color
+	}
+	if changes.ParentID != nil {
+		c.ParentID = nil
+		if changes.ParentID.Valid {
+			for p := changes.ParentID.String; ; {
+				if p == id {
+					return 0, repository.ErrCategoryCycle
+				}
+				parent, ok := f.categories[p]
+				if !ok || parent.ParentID == nil {
+					break
+				}
+				p = *parent.ParentID
+			}
+			c.ParentID = &changes.ParentID.String
+		}
+	}
+	c.Version++
+	f.categories[id] = c
+	return c.Version, nil
+}
+
+func (f *fakeCategoryStore) Delete(ctx context.Context, id string, version int) error {
+	c, ok := f.categories[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if version != 0 && c.Version != version {
+		return repository.ErrVersionConflict
+	}
+	delete(f.categories, id)
+	return nil
+}
+
+func (f *fakeCategoryStore) Reorder(ctx context.Context, positions []repository.Position) error {
+	for _, p := range positions {
+		if _, ok := f.categories[p.ID]; !ok {
+			return sql.ErrNoRows
+		}
+	}
+	for _, p := range positions {
+		c := f.categories[p.ID]
+		c.Position = p.Position
+		c.Version++
+		f.categories[p.ID] = c
+	}
+	return nil
+}
+
+func (f *fakeCategoryStore) Export(ctx context.Context, filter repository.CategoryFilter, fn func(repository.CategoryExport) error) error {
+	categories, _ := f.GetAll(ctx, filter)
+	for _, c := range categories {
+		if err := fn(repository.CategoryExport{Category: c, Path: c.Name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newCategoryTestRouter() (*gin.Engine, *fakeCategoryStore) {
+	store := &fakeCategoryStore{categories: map[string]repository.Category{
+		"cat-1": {ID: "cat-1", Name: "Minuman", Version: 1},
+	}}
+	s := &Server{
+		cfg:        &config.Config{Concurrency: config.Concurrency{RequireIfMatch: true}},
+		categories: store,
+	}
+
+	r := gin.New()
+	r.POST("/categories", s.CreateCategoryHandler)
+	r.GET("/categories", s.GetAllCategoriesHandler)
+	r.GET("/categories/:id", s.GetCategoryByIDHandler)
+	r.PATCH("/categories/:id", s.UpdateCategoryHandler)
+	r.DELETE("/categories/:id", s.DeleteCategoryHandler)
+	return r, store
+}
+
+func TestGetCategoryByIDHandler(t *testing.T) {
+	r, _ := newCategoryTestRouter()
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categories/cat-1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var got repository.Category
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "Minuman" {
+		t.Errorf("expected Minuman, got %s", got.Name)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categories/unknown", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rr.Code)
+	}
+}
+
+func TestCreateCategoryHandler(t *testing.T) {
+	r, store := newCategoryTestRouter()
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(`{"name":"Makanan"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if store.categories["cat-new"].Name != "Makanan" {
+		t.Errorf("category was not stored through the injected store")
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for missing name, got %d", rr.Code)
+	}
+}
+
+func TestCategoryConditionalRequests(t *testing.T) {
+	r, store := newCategoryTestRouter()
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categories/cat-1", nil))
+	etag := rr.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("expected ETag \"1\", got %q", etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/categories/cat-1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected empty 304 for a matching If-None-Match, got %d %q", rr.Code, rr.Body)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categories", nil))
+	listTag := rr.Header().Get("ETag")
+
+	patch := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/categories/cat-1", strings.NewReader(`{"name":"Minuman Dingin"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := patch(""); rr.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected 428 without If-Match, got %d", rr.Code)
+	}
+	rr = patch(etag)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expected update with ETag \"2\", got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	// supervisor kedua masih memegang versi lama
+	if rr := patch(etag); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a stale ETag, got %d", rr.Code)
+	}
+	if store.categories["cat-1"].Name != "Minuman Dingin" {
+		t.Error("stale update must not overwrite the newer one")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/categories", nil)
+	req.Header.Set("If-None-Match", listTag)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected list ETag to change after an update, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/categories/cat-1", nil)
+	req.Header.Set("If-Match", `"2"`)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected delete with current ETag to succeed, got %d", rr.Code)
+	}
+}
+
+func TestCategoryTree(t *testing.T) {
+	const (
+		minuman  = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a90"
+		kopi     = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a91"
+		espresso = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a92"
+		teh      = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a93"
+	)
+	parent := func(id string) *string { return &id }
+	store := &fakeCategoryStore{categories: map[string]repository.Category{
+		minuman:  {ID: minuman, Name: "Minuman", Version: 1},
+		teh:      {ID: teh, ParentID: parent(minuman), Name: "Teh", Position: 1, Version: 1},
+		kopi:     {ID: kopi, ParentID: parent(minuman), Name: "Kopi", Position: 0, Version: 1},
+		espresso: {ID: espresso, ParentID: parent(kopi), Name: "Espresso-based", Version: 1},
+	}}
+	s := &Server{categories: store}
+	r := gin.New()
+	r.GET("/categories/tree", s.GetCategoryTreeHandler)
+	r.GET("/categories/:id/tree", s.GetCategorySubtreeHandler)
+	r.PATCH("/categories/:id", s.UpdateCategoryHandler)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categories/tree", nil))
+	var tree []dto.CategoryNode
+	if err := json.Unmarshal(rr.Body.Bytes(), &tree); err != nil {
+		t.Fatal(err)
+	}
+	if len(tree) != 1 || len(tree[0].Children) != 2 {
+		t.Fatalf("expected Minuman with two children, got %s", rr.Body)
+	}
+	if tree[0].Children[0].Name != "Kopi" || tree[0].Children[0].Children[0].Name != "Espresso-based" {
+		t.Errorf("expected Kopi > Espresso-based first by position, got %s", rr.Body)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/categories/"+kopi+"/tree", nil))
+	var sub dto.CategoryNode
+	if err := json.Unmarshal(rr.Body.Bytes(), &sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Name != "Kopi" || len(sub.Children) != 1 {
+		t.Errorf("expected the Kopi subtree, got %s", rr.Body)
+	}
+
+	patch := func(id, body string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/categories/"+id, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := patch(minuman, `{"parent_id":"`+espresso+`"}`); code != http.StatusConflict {
+		t.Errorf("expected 409 when moving a category under its descendant, got %d", code)
+	}
+	if code := patch(kopi, `{"parent_id":null}`); code != http.StatusOK || store.categories[kopi].ParentID != nil {
+		t.Errorf("expected parent_id null to move Kopi to the top level, got %d", code)
+	}
+	if code := patch(kopi, `{"parent_id":"not-a-uuid"}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed parent_id, got %d", code)
+	}
+}
+
+func TestCategoryDisplaySettings(t *testing.T) {
+	const (
+		minuman = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a90"
+		kopi    = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a91"
+		teh     = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8a93"
+		unknown = "8a7f8b9e-0c1d-4e2f-8a3b-4c5d6e7f8aff"
+	)
+	parent := func(id string) *string { return &id }
+	store := &fakeCategoryStore{categories: map[string]repository.Category{
+		minuman: {ID: minuman, Name: "Minuman", Active: true, Version: 1},
+		kopi:    {ID: kopi, ParentID: parent(minuman), Name: "Kopi", Position: 0, Active: true, Version: 1},
+		teh:     {ID: teh, ParentID: parent(minuman), Name: "Teh", Position: 1, Active: true, Version: 1},
+	}}
+	s := &Server{categories: store}
+	r := gin.New()
+	r.POST("/categories", s.CreateCategoryHandler)
+	r.GET("/categories", s.GetAllCategoriesHandler)
+	r.POST("/categories/reorder", s.ReorderCategoriesHandler)
+	r.PATCH("/categories/:id", s.UpdateCategoryHandler)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	names := func(query string) []string {
+		rr := send(http.MethodGet, "/categories"+query, "")
+		var got []repository.Category
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		var res []string
+		for _, c := range got {
+			res = append(res, c.Name)
+		}
+		return res
+	}
+
+	rr := send(http.MethodPost, "/categories/reorder", `{"items":[{"id":"`+teh+`","position":0},{"id":"`+kopi+`","position":1}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected reorder to succeed, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := names(""); strings.Join(got, ",") != "Minuman,Teh,Kopi" {
+		t.Errorf("expected Teh before Kopi after reorder, got %v", got)
+	}
+
+	if rr := send(http.MethodPost, "/categories/reorder", `{"items":[{"id":"`+kopi+`","position":5},{"id":"`+unknown+`","position":0}]}`); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown ID, got %d", rr.Code)
+	}
+	if store.categories[kopi].Position != 1 {
+		t.Error("a failed reorder must not change any position")
+	}
+	for _, body := range []string{`{"items":[]}`, `{"items":[{"id":"` + kopi + `","position":-1}]}`} {
+		if rr := send(http.MethodPost, "/categories/reorder", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+	body := `{"items":[{"id":"` + kopi + `","position":0},{"id":"` + kopi + `","position":1}]}`
+	if rr := send(http.MethodPost, "/categories/reorder", body); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "more than once") {
+		t.Errorf("expected 400 for a repeated ID, got %d: %s", rr.Code, rr.Body)
+	}
+
+	// menyembunyikan induk ikut menyembunyikan subkategorinya dari POS
+	if rr := send(http.MethodPatch, "/categories/"+minuman, `{"active":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected hiding a category to succeed, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := names("?active=true"); len(got) != 0 {
+		t.Errorf("expected no visible categories, got %v", got)
+	}
+	if got := names(""); len(got) != 3 {
+		t.Errorf("expected hidden categories in the back-office list, got %v", got)
+	}
+
+	if rr := send(http.MethodPatch, "/categories/"+kopi, `{"color":"brown"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a non-hex color, got %d", rr.Code)
+	}
+	if rr := send(http.MethodPatch, "/categories/"+kopi, `{"color":""}`); rr.Code != http.StatusOK {
+		t.Errorf("expected an empty color to clear it, got %d: %s", rr.Code, rr.Body)
+	}
+	rr = send(http.MethodPost, "/categories", `{"name":"Makanan","color":"#ffcc00","icon":"utensils"}`)
+	if rr.Code != http.StatusCreated || !store.categories["cat-new"].Active || store.categories["cat-new"].Color != "#ffcc00" {
+		t.Errorf("expected new category to be active with its color, got %d %+v", rr.Code, store.categories["cat-new"])
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/server/dto"
+	"maspos-be-go/internal/utils"
+)
+
+func parseBirthday(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	// format sudah divalidasi oleh tag binding datetime
+	t, _ := time.Parse("2006-01-02", s)
+	return &t
+}
+
+// @Summary Create new customer
+// @Description Phone numbers are normalized (62xxx) and must be unique
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param body body dto.CustomerRequest true "Customer payload"
+// @Success 201 {object} dto.CustomerResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /customers [post]
+func (s *Server) CreateCustomerHandler(c *gin.Context) {
+	var req dto.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// duplikat ditolak oleh unique index nomor telepon, termasuk saat dua
+	// kasir mendaftarkan nomor yang sama bersamaan
+	id, err := s.customers.Create(c.Request.Context(), req.Name, phone, req.Email, parseBirthday(req.Birthday), req.Notes)
+	if errors.Is(err, repository.ErrPhoneTaken) {
+		s.phoneTaken(c, phone)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.metrics.CustomerCreated()
+	c.JSON(http.StatusCreated, dto.CustomerResponse{ID: id, Name: req.Name, Phone: phone})
+}
+
+// phoneTaken membalas 409 untuk nomor telepon yang sudah terdaftar, beserta
+// ID pelanggan pemiliknya supaya kasir bisa langsung memakainya.
+func (s *Server) phoneTaken(c *gin.Context, phone string) {
+	resp := gin.H{"error": repository.ErrPhoneTaken.Error()}
+	if existing, err := s.customers.GetByPhone(c.Request.Context(), phone); err == nil {
+		resp["customer_id"] = existing.ID
+	}
+	c.JSON(http.StatusConflict, resp)
+}
+
+// @Summary Get all customers
+// @Tags Customer
+// @Produce json
+// @Param q query string false "Search by name or phone"
+// @Success 200 {array} repository.Customer
+// @Router /customers [get]
+func (s *Server) GetAllCustomersHandler(c *gin.Context) {
+	customers, err := s.customers.GetAll(c.Request.Context(), c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, customers)
+}
+
+// @Summary Get customer by ID
+// @Tags Customer
+// @Param id path string true "Customer ID"
+// @Success 200 {object} repository.Customer
+// @Router /customers/{id} [get]
+func (s *Server) GetCustomerByIDHandler(c *gin.Context) {
+	id := c.Param("id")
+	customer, err := s.customers.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	c.JSON(http.StatusOK, customer)
+}
+
+// @Summary Update customer
+// @Tags Customer
+// @Accept json
+// @Param id path string true "Customer ID"
+// @Param body body dto.CustomerRequest true "Customer payload"
+// @Success 200 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /customers/{id} [patch]
+func (s *Server) UpdateCustomerHandler(c *gin.Context) {
+	id := c.Param("id")
+	var req dto.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	phone, err := utils.NormalizePhone(req.Phone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	if _, err := s.customers.GetByID(ctx, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+
+	err = s.customers.Update(ctx, id, req.Name, phone, req.Email, parseBirthday(req.Birthday), req.Notes)
+	if errors.Is(err, repository.ErrPhoneTaken) {
+		s.phoneTaken(c, phone)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Customer updated"})
+}
+
+// @Summary Delete customer
+// @Tags Customer
+// @Param id path string true "Customer ID"
+// @Success 200 {object} map[string]string
+// @Router /customers/{id} [delete]
+func (s *Server) DeleteCustomerHandler(c *gin.Context) {
+	id := c.Param("id")
+	if err := s.customers.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Customer deleted"})
+}
+
+// @Summary Get customer purchase history
+// @Description Number of orders, total and net spend (after refunds), average order, first and last visit, and the latest orders of the customer. Inside an outlet only its orders count.
+// @Tags Customer
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param limit query int false "Latest orders to include, default 20, max 500"
+// @Success 200 {object} repository.PurchaseHistory
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /customers/{id}/purchases [get]
+func (s *Server) GetCustomerPurchasesHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPurchaseLimit)))
+	if err != nil || limit < 1 || limit > maxOrderLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	if _, err := s.customers.GetByID(ctx, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+	h, err := s.orders.CustomerHistory(ctx, id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, h)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+)
+
+// fakeCustomerStore meniru CustomerRepository, termasuk unique index nomor
+// telepon yang menolak duplikat dengan ErrPhoneTaken.
+type fakeCustomerStore struct {
+	customers map[string]repository.Customer
+}
+
+func (f *fakeCustomerStore) phoneTaken(id, phone string) bool {
+	for _, c := range f.customers {
+		if c.Phone == phone && c.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeCustomerStore) Create(ctx context.Context, name, phone, email string, birthday *time.Time, notes string) (string, error) {
+	if f.phoneTaken("", phone) {
+		return "", repository.ErrPhoneTaken
+	}
+	id := "cust-" + strconv.Itoa(len(f.customers)+1)
+	f.customers[id] = repository.Customer{ID: id, Name: name, Phone: phone, Email: email, Birthday: birthday, Notes: notes}
+	return id, nil
+}
+
+func (f *fakeCustomerStore) GetAll(ctx context.Context, search string) ([]repository.Customer, error) {
+	var list []repository.Customer
+	for _, c := range f.customers {
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+func (f *fakeCustomerStore) GetByID(ctx context.Context, id string) (*repository.Customer, error) {
+	c, ok := f.customers[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &c, nil
+}
+
+func (f *fakeCustomerStore) GetByPhone(ctx context.Context, phone string) (*repository.Customer, error) {
+	for _, c := range f.customers {
+		if c.Phone == phone {
+			return &c, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCustomerStore) Update(ctx context.Context, id, name, phone, email string, birthday *time.Time, notes string) error {
+	if f.phoneTaken(id, phone) {
+		return repository.ErrPhoneTaken
+	}
+	c := f.customers[id]
+	c.Name, c.Phone, c.Email, c.Birthday, c.Notes = name, phone, email, birthday, notes
+	f.customers[id] = c
+	return nil
+}
+
+func (f *fakeCustomerStore) Delete(ctx context.Context, id string) error {
+	delete(f.customers, id)
+	return nil
+}
+
+func TestCustomerPhoneConflict(t *testing.T) {
+	s := &Server{customers: &fakeCustomerStore{customers: map[string]repository.Customer{}}}
+	r := gin.New()
+	r.POST("/customers", s.CreateCustomerHandler)
+	r.PATCH("/customers/:id", s.UpdateCustomerHandler)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := send(http.MethodPost, "/customers", `{"name":"Sari","phone":"0812-3456-7890"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr := send(http.MethodPost, "/customers", `{"name":"Budi","phone":"0813-1111-2222"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+
+	// nomor yang sama dalam format lain tetap duplikat
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/customers"},
+		{http.MethodPatch, "/customers/cust-2"},
+	} {
+		rr := send(tc.method, tc.path, `{"name":"Budi","phone":"+62 812 3456 7890"}`)
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("%s %s: expected 409, got %d: %s", tc.method, tc.path, rr.Code, rr.Body)
+		}
+		var resp struct {
+			CustomerID string `json:"customer_id"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.CustomerID != "cust-1" {
+			t.Errorf("%s %s: expected the conflicting customer cust-1, got %q", tc.method, tc.path, resp.CustomerID)
+		}
+	}
+
+	// pelanggan boleh menyimpan ulang nomornya sendiri
+	if rr := send(http.MethodPatch, "/customers/cust-1", `{"name":"Sari W","phone":"081234567890"}`); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 when keeping the own phone, got %d: %s", rr.Code, rr.Body)
+	}
+}
@@ -1,10 +1,54 @@
 package dto
 
+import "maspos-be-go/internal/database/repository"
+
 type CategoryRequest struct {
-    Name string `json:"name" example:"Makanan" binding:"required"`
+    Name     string  `json:"name" example:"Makanan" binding:"required"`
+    ParentID *string `json:"parent_id" example:"uuid-string-123" binding:"omitnil,uuid"`
+    Position int     `json:"position" example:"0" binding:"gte=0"`
+    Color    string  `json:"color" example:"#8d6e63" binding:"omitempty,hexcolor"`
+    Icon     string  `json:"icon" example:"coffee" binding:"max=64"`
+    Active   *bool   `json:"active" example:"true"`
+}
+
+// CategoryPatch is a JSON Merge Patch for a category; absent fields are left
+// unchanged. "parent_id": null moves the category (with its subtree) to the
+// top level.
+type CategoryPatch struct {
+    Name     *string `json:"name" example:"Makanan" binding:"omitnil,min=1"`
+    ParentID *string `json:"parent_id" example:"uuid-string-123" binding:"omitnil,uuid"`
+    Position *int    `json:"position" example:"0" binding:"omitnil,gte=0"`
+    Color    *string `json:"color" example:"#8d6e63" binding:"omitnil,eq=|hexcolor"`
+    Icon     *string `json:"icon" example:"coffee" binding:"omitnil,max=64"`
+    Active   *bool   `json:"active" example:"false"`
+}
+
+// ReorderRequest sets the positions of several items in one transaction.
+type ReorderRequest struct {
+    Items []repository.Position `json:"items" binding:"required,min=1,dive"`
 }
 
 type CategoryResponse struct {
-    ID   string `json:"id" example:"uuid-string-123"`
-    Name string `json:"name" example:"Makanan"`
-}
\ No newline at end of file
+    ID       string  `json:"id" example:"uuid-string-123"`
+    ParentID *string `json:"parent_id"`
+    Name     string  `json:"name" example:"Makanan"`
+    Position int     `json:"position" example:"0"`
+    Color    string  `json:"color" example:"#8d6e63"`
+    Icon     string  `json:"icon" example:"coffee"`
+    Active   bool    `json:"active" example:"true"`
+    Version  int     `json:"version" example:"1"`
+}
+
+// CategoryNode is a category with its subcategories, ordered by position.
+type CategoryNode struct {
+    ID       string          `json:"id" example:"uuid-string-123"`
+    ParentID *string         `json:"parent_id"`
+    Name     string          `json:"name" example:"Kopi"`
+    Position int             `json:"position" example:"0"`
+    Color    string          `json:"color" example:"#8d6e63"`
+    Icon     string          `json:"icon" example:"coffee"`
+    Active   bool            `json:"active" example:"true"`
+    Version  int             `json:"version" example:"1"`
+    OutletID *string         `json:"outlet_id"`
+    Children []*CategoryNode `json:"children"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+type CustomerRequest struct {
+	Name     string `json:"name" example:"Budi Santoso" binding:"required"`
+	Phone    string `json:"phone" example:"0812-3456-7890" binding:"required"`
+	Email    string `json:"email" example:"budi@example.com" binding:"omitempty,email"`
+	Birthday string `json:"birthday" example:"1990-08-17" binding:"omitempty,datetime=2006-01-02"`
+	Notes    string `json:"notes" example:"Suka kopi tanpa gula"`
+}
+
+type CustomerResponse struct {
+	ID    string `json:"id" example:"uuid-string-123"`
+	Name  string `json:"name" example:"Budi Santoso"`
+	Phone string `json:"phone" example:"6281234567890"`
+}
@@ -8,4 +8,7 @@ type LoginRequest struct {
 type LoginResponse struct {
     Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
     Email string `json:"email" example:"admin@example.com"`
+    Outlets []string `json:"outlets"`
+    // HeadOffice true untuk staf kantor pusat, yang boleh memilih outlet mana pun
+    HeadOffice bool `json:"head_office"`
 }
\ No newline at end of file
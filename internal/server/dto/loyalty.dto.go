@@ -0,0 +1,35 @@
+package dto
+
+type LoyaltyRedeemRequest struct {
+	Points    int64  `json:"points" example:"50" binding:"required,gt=0"`
+	Reference string `json:"reference" example:"INV-20260101-001" binding:"required"`
+}
+
+type LoyaltyAdjustRequest struct {
+	Points int64  `json:"points" example:"-20" binding:"required"`
+	Note   string `json:"note" example:"Koreksi input kasir" binding:"required"`
+}
+
+type LoyaltyReverseRequest struct {
+	Note string `json:"note" example:"Order di-refund"`
+}
+
+type LoyaltyRulesRequest struct {
+	RupiahPerPoint      float64            `json:"rupiah_per_point" example:"10000" binding:"gt=0"`
+	PointValue          float64            `json:"point_value" example:"100" binding:"gte=0"`
+	ExpiryDays          int                `json:"expiry_days" example:"365" binding:"gte=0"`
+	CategoryMultipliers map[string]float64 `json:"category_multipliers" binding:"dive,gte=0"`
+}
+
+type LoyaltyEntryResponse struct {
+	ID      string `json:"id"`
+	Points  int64  `json:"points"`
+	Balance int64  `json:"balance"`
+}
+
+type LoyaltyRedeemResponse struct {
+	ID      string  `json:"id"`
+	Points  int64   `json:"points"`
+	Value   float64 `json:"value"`
+	Balance int64   `json:"balance"`
+}
@@ -0,0 +1,72 @@
+package dto
+
+// OrderRequest adalah penjualan yang dibayar di register sebuah shift yang
+// masih terbuka. Harga, nama dan kategori baris diambil dari katalog
+// outlet; kasir hanya mengirim produk, jumlah, modifier dan diskonnya.
+// Pajak dikirim sebagai jumlah yang sudah dihitung, seperti pada struk.
+type OrderRequest struct {
+	ShiftID    string             `json:"shift_id" example:"uuid-string-123" binding:"required,uuid"`
+	CustomerID string             `json:"customer_id" example:"uuid-string-456" binding:"omitempty,uuid"`
+	Lines      []OrderLineRequest `json:"lines" binding:"required,min=1,max=200,dive"`
+	Taxes      []OrderTaxRequest  `json:"taxes" binding:"max=10,dive"`
+	Tenders    []TenderRequest    `json:"tenders" binding:"required,min=1,max=10,dive"`
+	Note       string             `json:"note" example:"Meja 4" binding:"max=500"`
+}
+
+type OrderLineRequest struct {
+	ProductID string                 `json:"product_id" example:"uuid-string-789" binding:"required,uuid"`
+	Quantity  float64                `json:"quantity" example:"2" binding:"gt=0"`
+	Modifiers []OrderModifierRequest `json:"modifiers" binding:"max=20,dive"`
+	// Discount berlaku untuk seluruh baris, bukan per unit.
+	Discount float64 `json:"discount" example:"5000" binding:"gte=0"`
+	Note     string  `json:"note" example:"Tanpa gula" binding:"max=200"`
+}
+
+// OrderModifierRequest adalah tambahan per unit item, misalnya "Extra shot".
+type OrderModifierRequest struct {
+	Name  string  `json:"name" example:"Extra shot" binding:"required,max=100"`
+	Price float64 `json:"price" example:"5000" binding:"gte=0"`
+}
+
+type OrderTaxRequest struct {
+	Name   string  `json:"name" example:"PB1 10%" binding:"required,max=50"`
+	Amount float64 `json:"amount" example:"4100" binding:"gte=0"`
+}
+
+// TenderRequest adalah satu pembayaran. Tunai boleh melebihi total; sisanya
+// menjadi kembalian. Pembayaran loyalty mengirim Points saja; nilainya
+// dihitung dari pengaturan program poin.
+type TenderRequest struct {
+	Method    string  `json:"method" example:"cash" binding:"required,oneof=cash card qris transfer loyalty"`
+	Amount    float64 `json:"amount" example:"50000" binding:"required_unless=Method loyalty,gte=0"`
+	Points    int64   `json:"points" example:"0" binding:"required_if=Method loyalty,gte=0"`
+	Reference string  `json:"reference" example:"APPR-123456" binding:"max=100"`
+}
+
+// RefundRequest mengembalikan baris-baris sebuah order. Refund dicatat di
+// shift yang sedang terbuka di outlet order tersebut dan harus disetujui
+// supervisor dengan email dan password-nya.
+type RefundRequest struct {
+	ShiftID   string              `json:"shift_id" example:"uuid-string-123" binding:"required,uuid"`
+	Reason    string              `json:"reason" example:"wrong_item" binding:"required,oneof=wrong_item changed_mind damaged expired other"`
+	Method    string              `json:"method" example:"cash" binding:"required,oneof=cash card qris transfer loyalty"`
+	Reference string              `json:"reference" example:"RRN-123456" binding:"max=100"`
+	Note      string              `json:"note" example:"Pesanan tertukar" binding:"max=500"`
+	Lines     []RefundLineRequest `json:"lines" binding:"required,min=1,max=200,dive"`
+	Approval  ApprovalRequest     `json:"approval" binding:"required"`
+}
+
+// RefundLineRequest mengembalikan sebagian unit satu baris order. Restock
+// kosong berarti mengikuti alasan refund: salah item dan berubah pikiran
+// masuk kembali ke stok, rusak, kedaluwarsa dan lainnya tidak.
+type RefundLineRequest struct {
+	OrderLineID string  `json:"order_line_id" example:"uuid-string-789" binding:"required,uuid"`
+	Quantity    float64 `json:"quantity" example:"1" binding:"gt=0"`
+	Restock     *bool   `json:"restock" example:"true"`
+}
+
+// ApprovalRequest adalah kredensial supervisor yang menyetujui refund.
+type ApprovalRequest struct {
+	Email    string `json:"email" example:"spv@kopisenja.id" binding:"required,email"`
+	Password string `json:"password" example:"rahasia123" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+type OutletRequest struct {
+	Name    string `json:"name" example:"MasPOS Kemang" binding:"required,max=100"`
+	Address string `json:"address" example:"Jl. Kemang Raya No. 8, Jakarta"`
+	// UseCentralCatalog defaults to true: the outlet sees the central catalog
+	// next to its own categories and products.
+	UseCentralCatalog *bool `json:"use_central_catalog" example:"true"`
+}
+
+type OutletResponse struct {
+	ID   string `json:"id" example:"uuid-string-123"`
+	Name string `json:"name" example:"MasPOS Kemang"`
+}
+
+type OutletPriceRequest struct {
+	Price float64 `json:"price" example:"20000" binding:"required,gt=0"`
+}
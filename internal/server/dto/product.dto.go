@@ -3,16 +3,42 @@ package dto
 import "mime/multipart"
 
 type ProductRequest struct {
+	SKU        string                `form:"sku" binding:"max=64"`
 	CategoryID string                `form:"category_id" binding:"required"`
 	Name       string                `form:"name" binding:"required"`
 	Price      float64               `form:"price" binding:"required"`
 	Picture    *multipart.FileHeader `form:"picture" binding:"required"`
+	Position   int                   `form:"position" binding:"gte=0"`
+	Color      string                `form:"color" binding:"omitempty,hexcolor"`
+	Icon       string                `form:"icon" binding:"max=64"`
+	Active     *bool                 `form:"active"`
+}
+
+// ProductPatch is a partial product update, sent either as JSON Merge Patch
+// or as multipart form fields. Absent fields are left unchanged; the picture
+// is uploaded as the multipart file "picture" or removed with
+// {"picture": null}.
+type ProductPatch struct {
+	SKU        *string  `json:"sku" form:"sku" binding:"omitnil,max=64"`
+	CategoryID *string  `json:"category_id" form:"category_id" binding:"omitnil,min=1"`
+	Name       *string  `json:"name" form:"name" binding:"omitnil,min=1"`
+	Price      *float64 `json:"price" form:"price" binding:"omitnil,gt=0"`
+	Position   *int     `json:"position" form:"position" binding:"omitnil,gte=0"`
+	Color      *string  `json:"color" form:"color" binding:"omitnil,eq=|hexcolor"`
+	Icon       *string  `json:"icon" form:"icon" binding:"omitnil,max=64"`
+	Active     *bool    `json:"active" form:"active"`
 }
 
 type ProductResponse struct {
 	ID         string  `json:"id"`
+	SKU        string  `json:"sku"`
 	CategoryID string  `json:"category_id"`
 	Name       string  `json:"name"`
 	Price      float64 `json:"price"`
 	Picture    string  `json:"picture"`
-}
\ No newline at end of file
+	Position   int     `json:"position"`
+	Color      string  `json:"color"`
+	Icon       string  `json:"icon"`
+	Active     bool    `json:"active"`
+	Version    int     `json:"version"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"maspos-be-go/internal/receipt"
+)
+
+// ReceiptTemplateRequest mengganti kop dan penutup struk outlet. Setiap
+// elemen adalah satu baris cetak; baris yang lebih panjang dari kertas
+// dipotong per kata. excludesall=\n menolak baris yang berisi newline.
+type ReceiptTemplateRequest struct {
+	Header []string `json:"header" example:"Kopi Senja,Jl. Kemang Raya No. 8" binding:"max=10,dive,max=100,excludesall=\n"`
+	Footer []string `json:"footer" example:"Terima kasih" binding:"max=10,dive,max=100,excludesall=\n"`
+	Paper  int      `json:"paper" example:"80" binding:"required,oneof=58 80"`
+}
+
+// ReceiptRequest adalah penjualan yang sudah selesai untuk dicetak. Time
+// kosong berarti waktu sekarang.
+type ReceiptRequest struct {
+	Number  string           `json:"number" example:"INV-20260101-001" binding:"required,max=50"`
+	Time    *time.Time       `json:"time" example:"2026-01-01T09:30:00+07:00"`
+	Cashier string           `json:"cashier" example:"Rina"`
+	Lines   []receipt.Line   `json:"lines" binding:"required,min=1,dive"`
+	Taxes   []receipt.Tax    `json:"taxes" binding:"dive"`
+	Tenders []receipt.Tender `json:"tenders" binding:"dive"`
+}
+
+// ReceiptEmailRequest mengantrekan struk sebuah order. Email kosong berarti
+// email pelanggan order tersebut.
+type ReceiptEmailRequest struct {
+	Email string `json:"email" example:"budi@example.com" binding:"omitempty,email"`
+}
+
+// ReceiptResendRequest mengirim ulang struk, ke alamat lain bila Email diisi.
+// Pada order, alamat semula adalah alamat pengiriman terakhirnya.
+type ReceiptResendRequest struct {
+	Email string `json:"email" example:"budi@example.com" binding:"omitempty,email"`
+}
+
+type ReceiptEmailResponse struct {
+	ID     string `json:"id" example:"uuid-string-123"`
+	Status string `json:"status" example:"pending"`
+}
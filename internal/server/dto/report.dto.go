@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+)
+
+// SalesSummaryResponse berisi penjualan order periode itu, total dan per
+// hari.
+type SalesSummaryResponse struct {
+	From     string                  `json:"from" example:"2026-01-01"`
+	To       string                  `json:"to" example:"2026-01-31"`
+	Timezone string                  `json:"timezone" example:"Asia/Jakarta"`
+	Totals   repository.Sales        `json:"totals"`
+	Days     []repository.DailySales `json:"days"`
+}
+
+// CloseDayRequest memilih hari bisnis yang ditutup. Body boleh kosong:
+// hari ini menurut zona waktu Asia/Jakarta.
+type CloseDayRequest struct {
+	Date     string `json:"date" binding:"omitempty,datetime=2006-01-02" example:"2026-01-31"`
+	Timezone string `json:"timezone" binding:"omitempty,timezone" example:"Asia/Jakarta"`
+}
+
+// SalesRollupResponse berisi rollup harian per outlet. AsOf adalah batas
+// order dan refund yang sudah masuk rollup; null bila rollup belum pernah
+// dibuat.
+type SalesRollupResponse struct {
+	From     string                        `json:"from" example:"2026-01-01"`
+	To       string                        `json:"to" example:"2026-01-31"`
+	Timezone string                        `json:"timezone" example:"Asia/Jakarta"`
+	AsOf     *time.Time                    `json:"as_of"`
+	Totals   repository.Sales              `json:"totals"`
+	Days     []repository.OutletDailySales `json:"days"`
+}
+
+// ProductRollupResponse berisi produk terlaris dari rollup produk. AsOf
+// adalah batas order dan refund yang sudah masuk rollup; null bila rollup
+// belum pernah dibuat.
+type ProductRollupResponse struct {
+	From     string                 `json:"from" example:"2026-01-01"`
+	To       string                 `json:"to" example:"2026-01-31"`
+	Timezone string                 `json:"timezone" example:"Asia/Jakarta"`
+	AsOf     *time.Time             `json:"as_of"`
+	Products []repository.ItemSales `json:"products"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+type ShiftOpenRequest struct {
+	Register     string  `json:"register" example:"kasir-1" binding:"required,max=50"`
+	OpeningFloat float64 `json:"opening_float" example:"500000" binding:"gte=0"`
+	Note         string  `json:"note" example:"Modal dari brankas"`
+}
+
+type ShiftResponse struct {
+	ID       string `json:"id" example:"uuid-string-123"`
+	Register string `json:"register" example:"kasir-1"`
+}
+
+// CashMovementRequest mencatat uang yang ditambahkan ke laci (pay_in) atau
+// diambil dari laci (pay_out) di luar penjualan.
+type CashMovementRequest struct {
+	Type   string  `json:"type" example:"pay_out" binding:"required,oneof=pay_in pay_out"`
+	Amount float64 `json:"amount" example:"25000" binding:"required,gt=0"`
+	Note   string  `json:"note" example:"Beli galon" binding:"required"`
+}
+
+type ShiftCloseRequest struct {
+	CountedCash *float64 `json:"counted_cash" example:"742000" binding:"required,gte=0"`
+	Note        string   `json:"note" example:"Selisih karena kembalian"`
+}
+
+type CashMovementResponse struct {
+	ID string `json:"id" example:"uuid-string-123"`
+	// ExpectedCash adalah kas yang seharusnya ada di laci setelah mutasi ini.
+	ExpectedCash float64 `json:"expected_cash" example:"538000"`
+}
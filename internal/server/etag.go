@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag adalah ETag kuat untuk satu resource berversi.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// listETag adalah ETag lemah untuk sebuah daftar, dihitung dari pasangan ID
+// dan versi setiap item sehingga berubah saat item ditambah, diubah atau
+// dihapus.
+func listETag(ids []string, versions []int) string {
+	h := sha256.New()
+	for i, id := range ids {
+		h.Write([]byte(id + ":" + strconv.Itoa(versions[i]) + "\n"))
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+}
+
+// notModified memasang header ETag lalu, bila If-None-Match cocok (dengan
+// perbandingan lemah), membalas 304 dan mengembalikan true.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion membaca header If-Match untuk PATCH dan DELETE dan
+// mengembalikan versi yang diharapkan klien; 0 berarti versi mana pun.
+// Bila header tidak bisa dipenuhi, respons error sudah ditulis dan ok false.
+func (s *Server) ifMatchVersion(c *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	switch {
+	case header == "":
+		if s.cfg != nil && s.cfg.Concurrency.RequireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the resource ETag is required"})
+			return 0, false
+		}
+		return 0, true
+	case header == "*":
+		return 0, true
+	case strings.Contains(header, ","):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must contain a single ETag"})
+		return 0, false
+	}
+
+	// ETag lemah atau yang bukan buatan server ini tidak akan pernah cocok
+	unquoted, quoted := strings.CutPrefix(header, `"`)
+	unquoted, closed := strings.CutSuffix(unquoted, `"`)
+	v, err := strconv.Atoi(unquoted)
+	if !quoted || !closed || err != nil || v < 1 {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "If-Match does not match the current version"})
+		return 0, false
+	}
+	return v, true
+}
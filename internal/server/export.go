@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/catalogexport"
+	"maspos-be-go/internal/logger"
+)
+
+// exportTimeout menggantikan WriteTimeout server untuk respons ekspor, yang
+// bisa jauh lebih lama dari request biasa.
+const exportTimeout = 10 * time.Minute
+
+// streamExport menulis hasil run sebagai file unduhan dalam format ?format=
+// (default csv). run memanggil write untuk setiap baris. Selama belum ada
+// baris yang terkirim, error dari run masih dibalas 500 JSON; setelah itu
+// respons hanya bisa diputus dan error-nya dicatat di log.
+func streamExport(c *gin.Context, name string, columns []string, run func(write func([]any) error) error) {
+	format := c.DefaultQuery("format", catalogexport.FormatCSV)
+	contentType, err := catalogexport.ContentType(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	out, _ := catalogexport.NewWriter(c.Writer, format, name, columns)
+
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(exportTimeout))
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+name+"-"+time.Now().Format("20060102")+"."+format+`"`)
+
+	err = run(out.Write)
+	if err == nil {
+		err = out.Close()
+	}
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		c.Header("Content-Disposition", "")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	logger.FromContext(c.Request.Context()).Error("export aborted", "export", name, "error", err)
+	panic(http.ErrAbortHandler)
+}
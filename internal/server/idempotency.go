@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/logger"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyMaxKeyLength  = 255
+	idempotencyLockTimeout   = time.Minute
+)
+
+// idempotent membuat request POST/PUT/PATCH/DELETE dengan header
+// Idempotency-Key aman diulang: request pertama dijalankan dan responsnya
+// disimpan, pengulangan dengan body yang sama mendapat respons itu lagi tanpa
+// menjalankan handler. Key dipisah per klien (lihat clientKey).
+func (s *Server) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" || s.idempotency == nil || s.cfg == nil {
+			c.Next()
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyMaxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "Idempotency-Key must be at most 255 characters",
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"status":  "error",
+				"message": "failed to read request body",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := clientKey(c)
+		fp := requestFingerprint(c.Request, body)
+
+		stored, err := s.idempotency.Reserve(ctx, scope, key, fp, idempotencyLockTimeout, s.cfg.Idempotency.TTL)
+		if err != nil {
+			c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"status":  "error",
+				"message": "failed to check Idempotency-Key",
+			})
+			return
+		}
+		if stored != nil {
+			switch {
+			case stored.Fingerprint != fp:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"status":  "error",
+					"message": "Idempotency-Key was already used for a different request",
+				})
+			case stored.Status == 0:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+					"status":  "error",
+					"message": "a request with this Idempotency-Key is still being processed",
+				})
+			default:
+				c.Header(idempotentReplayedHeader, "true")
+				if stored.ETag != "" {
+					c.Header("ETag", stored.ETag)
+				}
+				c.Data(stored.Status, stored.ContentType, stored.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		// simpan walaupun klien sudah memutus koneksi, justru saat itulah ia
+		// akan mengulang request
+		ctx = context.WithoutCancel(ctx)
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			// hasil 5xx tidak pasti; biarkan pengulangan menjalankan handler lagi
+			if err := s.idempotency.Release(ctx, scope, key); err != nil {
+				logger.FromContext(ctx).Error("failed to release Idempotency-Key", "error", err)
+			}
+			return
+		}
+		if err := s.idempotency.Complete(ctx, scope, key, status, c.Writer.Header().Get("Content-Type"), c.Writer.Header().Get("ETag"), rec.body.Bytes()); err != nil {
+			logger.FromContext(ctx).Error("failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// responseRecorder menyalin body respons sambil tetap menulisnya ke klien.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// requestFingerprint meringkas method, URL, header yang mengubah arti
+// request (outlet aktif dan If-Match) dan body request. Body multipart
+// diringkas per bagian karena boundary-nya berubah setiap kali klien
+// membangun ulang form yang sama.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	for _, name := range []string{outletHeader, "If-Match"} {
+		io.WriteString(h, name+": "+r.Header.Get(name)+"\n")
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if parts, err := multipartDigests(body, params["boundary"]); err == nil {
+			for _, p := range parts {
+				io.WriteString(h, p)
+			}
+			return hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func multipartDigests(body []byte, boundary string) ([]string, error) {
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	var digests []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		io.WriteString(h, p.FormName()+"\x00"+p.FileName()+"\x00")
+		if _, err := io.Copy(h, p); err != nil {
+			return nil, err
+		}
+		digests = append(digests, hex.EncodeToString(h.Sum(nil)))
+	}
+	sort.Strings(digests)
+	return digests, nil
+}
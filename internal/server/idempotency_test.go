@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+)
+
+type fakeIdempotencyStore struct {
+	records map[string]*repository.IdempotencyRecord
+}
+
+func (f *fakeIdempotencyStore) Reserve(ctx context.Context, scope, key, fingerprint string, lockFor, ttl time.Duration) (*repository.IdempotencyRecord, error) {
+	if rec, ok := f.records[scope+"|"+key]; ok {
+		return rec, nil
+	}
+	f.records[scope+"|"+key] = &repository.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (f *fakeIdempotencyStore) Complete(ctx context.Context, scope, key string, status int, contentType, etag string, body []byte) error {
+	rec := f.records[scope+"|"+key]
+	rec.Status, rec.ContentType, rec.ETag, rec.Body = status, contentType, etag, body
+	return nil
+}
+
+func (f *fakeIdempotencyStore) Release(ctx context.Context, scope, key string) error {
+	delete(f.records, scope+"|"+key)
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	store := &fakeIdempotencyStore{records: map[string]*repository.IdempotencyRecord{}}
+	s := &Server{cfg: &config.Config{Idempotency: config.Idempotency{TTL: time.Hour}}, idempotency: store}
+
+	calls := 0
+	r := gin.New()
+	g := r.Group("/products", s.idempotent())
+	g.POST("", func(c *gin.Context) {
+		calls++
+		c.Header("ETag", versionETag(calls))
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+	g.DELETE("/:id", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": "error"})
+	})
+
+	postWith := func(key, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		for name := range header {
+			req.Header.Set(name, header.Get(name))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	post := func(key, body string) *httptest.ResponseRecorder { return postWith(key, body, nil) }
+
+	first := post("k-1", `{"name":"Kopi"}`)
+	retry := post("k-1", `{"name":"Kopi"}`)
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected replayed 201 %s, got %d %s", first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected replayed response to be marked")
+	}
+	if got := retry.Header().Get("ETag"); got != versionETag(1) {
+		t.Errorf("expected replayed ETag %q, got %q", versionETag(1), got)
+	}
+
+	// outlet aktif dan If-Match ikut menentukan arti request
+	for name, v := range map[string]string{outletHeader: outletDepok, "If-Match": versionETag(3)} {
+		if rr := postWith("k-1", `{"name":"Kopi"}`, http.Header{http.CanonicalHeaderKey(name): {v}}); rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("expected 422 for key reuse with another %s, got %d", name, rr.Code)
+		}
+	}
+
+	if rr := post("k-1", `{"name":"Teh"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for key reuse with a different body, got %d", rr.Code)
+	}
+	if post("k-2", `{"name":"Kopi"}`); calls != 2 {
+		t.Errorf("expected a new key to run the handler, calls = %d", calls)
+	}
+
+	// respons 5xx tidak disimpan supaya pengulangan bisa berhasil
+	req := httptest.NewRequest(http.MethodDelete, "/products/p-1", nil)
+	req.Header.Set("Idempotency-Key", "k-3")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if len(store.records) != 2 {
+		t.Errorf("expected failed request to release its key, have %d keys", len(store.records))
+	}
+}
+
+func TestRequestFingerprintMultipart(t *testing.T) {
+	form := func() (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		w.WriteField("name", "Kopi")
+		fw, _ := w.CreateFormFile("picture", "kopi.jpg")
+		fw.Write([]byte("jpeg-bytes"))
+		w.Close()
+		return &buf, w.FormDataContentType()
+	}
+
+	fingerprint := func() string {
+		body, contentType := form()
+		req := httptest.NewRequest(http.MethodPost, "/products", nil)
+		req.Header.Set("Content-Type", contentType)
+		return requestFingerprint(req, body.Bytes())
+	}
+
+	// setiap form memakai boundary acak, tapi isinya sama
+	if a, b := fingerprint(), fingerprint(); a != b {
+		t.Errorf("expected identical forms to share a fingerprint, got %s and %s", a, b)
+	}
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/server/dto"
+)
+
+// @Summary Get customer loyalty balance and ledger
+// @Tags Loyalty
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /customers/{id}/loyalty [get]
+func (s *Server) GetCustomerLoyaltyHandler(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	if _, err := s.customers.GetByID(ctx, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+
+	balance, err := s.loyalty.Balance(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	entries, err := s.loyalty.GetEntries(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"customer_id": id,
+		"balance":     balance,
+		"entries":     entries,
+	})
+}
+
+// @Summary Redeem loyalty points
+// @Description Burns points and returns their Rupiah value to apply as a tender
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param body body dto.LoyaltyRedeemRequest true "Points to redeem"
+// @Success 201 {object} dto.LoyaltyRedeemResponse
+// @Failure 422 {object} map[string]string
+// @Router /customers/{id}/loyalty/redeem [post]
+func (s *Server) RedeemLoyaltyHandler(c *gin.Context) {
+	id := c.Param("id")
+	var req dto.LoyaltyRedeemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	rules, err := s.loyalty.GetRules(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entryID, err := s.loyalty.Redeem(ctx, id, req.Points, req.Reference)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	case errors.Is(err, loyalty.ErrInsufficientPoints):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.metrics.LoyaltyPoints(loyalty.TypeBurn, req.Points)
+
+	balance, err := s.loyalty.Balance(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, dto.LoyaltyRedeemResponse{
+		ID:      entryID,
+		Points:  req.Points,
+		Value:   rules.RedemptionValue(req.Points),
+		Balance: balance,
+	})
+}
+
+// @Summary Manually adjust loyalty points
+// @Description A negative adjustment cannot take more points than the balance
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path string true "Customer ID"
+// @Param body body dto.LoyaltyAdjustRequest true "Adjustment"
+// @Success 201 {object} dto.LoyaltyEntryResponse
+// @Failure 422 {object} map[string]string
+// @Router /customers/{id}/loyalty/adjust [post]
+func (s *Server) AdjustLoyaltyHandler(c *gin.Context) {
+	id := c.Param("id")
+	var req dto.LoyaltyAdjustRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := s.customers.GetByID(ctx, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	}
+
+	entryID, err := s.loyalty.Adjust(ctx, id, req.Points, req.Note)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+		return
+	case errors.Is(err, loyalty.ErrInsufficientPoints):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	balance, err := s.loyalty.Balance(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, dto.LoyaltyEntryResponse{ID: entryID, Points: req.Points, Balance: balance})
+}
+
+// @Summary Reverse a loyalty ledger entry
+// @Description Writes an opposite entry, e.g. when the originating order is refunded. Reversing points that were already spent returns 422.
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path string true "Ledger entry ID"
+// @Param body body dto.LoyaltyReverseRequest false "Reason"
+// @Success 201 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /loyalty/entries/{id}/reverse [post]
+func (s *Server) ReverseLoyaltyEntryHandler(c *gin.Context) {
+	var req dto.LoyaltyReverseRequest
+	// body opsional
+	_ = c.ShouldBindJSON(&req)
+
+	id, err := s.loyalty.Reverse(c.Request.Context(), c.Param("id"), req.Note)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ledger entry not found"})
+		return
+	case errors.Is(err, repository.ErrAlreadyReversed), errors.Is(err, repository.ErrReversalEntry):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, loyalty.ErrInsufficientPoints):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// @Summary Get loyalty program rules
+// @Tags Loyalty
+// @Produce json
+// @Success 200 {object} loyalty.Rules
+// @Router /loyalty/settings [get]
+func (s *Server) GetLoyaltySettingsHandler(c *gin.Context) {
+	rules, err := s.loyalty.GetRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// @Summary Update loyalty program rules
+// @Tags Loyalty
+// @Accept json
+// @Description Category multipliers must name categories of the tenant
+// @Param body body dto.LoyaltyRulesRequest true "Rules"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /loyalty/settings [put]
+func (s *Server) UpdateLoyaltySettingsHandler(c *gin.Context) {
+	var req dto.LoyaltyRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for categoryID := range req.CategoryMultipliers {
+		if err := uuid.Validate(categoryID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category_multipliers keys must be category IDs"})
+			return
+		}
+	}
+
+	err := s.loyalty.UpdateRules(c.Request.Context(), loyalty.Rules{
+		RupiahPerPoint:      req.RupiahPerPoint,
+		PointValue:          req.PointValue,
+		ExpiryDays:          req.ExpiryDays,
+		CategoryMultipliers: req.CategoryMultipliers,
+	})
+	if errors.Is(err, repository.ErrUnknownLoyaltyCategory) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Loyalty settings updated"})
+}
+
+// @Summary Expire loyalty points past their validity
+// @Tags Loyalty
+// @Produce json
+// @Success 200 {object} map[string]int
+// @Router /loyalty/expire [post]
+func (s *Server) ExpireLoyaltyPointsHandler(c *gin.Context) {
+	n, err := s.loyalty.ExpireDue(c.Request.Context(), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"customers": n})
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+)
+
+const categoryKopi = "7a2d3b64-1c5e-4f0a-8b9c-0d1e2f3a4b01"
+
+// fakeLoyaltyStore meniru LoyaltyRepository: saldo dihitung dari ledger dan
+// setiap entri yang mengurangi saldo ditolak bila saldonya tidak cukup.
+type fakeLoyaltyStore struct {
+	entries    []repository.LoyaltyEntry
+	categories map[string]bool
+	rules      loyalty.Rules
+}
+
+func (f *fakeLoyaltyStore) GetRules(ctx context.Context) (loyalty.Rules, error) { return f.rules, nil }
+
+func (f *fakeLoyaltyStore) UpdateRules(ctx context.Context, rules loyalty.Rules) error {
+	for id := range rules.CategoryMultipliers {
+		if !f.categories[id] {
+			return repository.ErrUnknownLoyaltyCategory
+		}
+	}
+	f.rules = rules
+	return nil
+}
+
+func (f *fakeLoyaltyStore) Balance(ctx context.Context, customerID string) (int64, error) {
+	var balance int64
+	for _, e := range f.entries {
+		if e.CustomerID == customerID {
+			balance += e.Points
+		}
+	}
+	return balance, nil
+}
+
+func (f *fakeLoyaltyStore) GetEntries(ctx context.Context, customerID string) ([]repository.LoyaltyEntry, error) {
+	return f.entries, nil
+}
+
+func (f *fakeLoyaltyStore) add(customerID, entryType string, points int64, reversesID *string) string {
+	id := "entry-" + strconv.Itoa(len(f.entries)+1)
+	f.entries = append(f.entries, repository.LoyaltyEntry{ID: id, CustomerID: customerID, Type: entryType, Points: points, ReversesID: reversesID})
+	return id
+}
+
+func (f *fakeLoyaltyStore) spendable(customerID string, points int64) error {
+	if balance, _ := f.Balance(context.Background(), customerID); balance < points {
+		return loyalty.ErrInsufficientPoints
+	}
+	return nil
+}
+
+func (f *fakeLoyaltyStore) Adjust(ctx context.Context, customerID string, points int64, note string) (string, error) {
+	if points < 0 {
+		if err := f.spendable(customerID, -points); err != nil {
+			return "", err
+		}
+	}
+	return f.add(customerID, loyalty.TypeAdjust, points, nil), nil
+}
+
+func (f *fakeLoyaltyStore) Redeem(ctx context.Context, customerID string, points int64, reference string) (string, error) {
+	if err := f.spendable(customerID, points); err != nil {
+		return "", err
+	}
+	return f.add(customerID, loyalty.TypeBurn, -points, nil), nil
+}
+
+func (f *fakeLoyaltyStore) Reverse(ctx context.Context, entryID, note string) (string, error) {
+	for _, e := range f.entries {
+		if e.ID != entryID {
+			continue
+		}
+		if e.Points > 0 {
+			if err := f.spendable(e.CustomerID, e.Points); err != nil {
+				return "", err
+			}
+		}
+		return f.add(e.CustomerID, e.Type, -e.Points, &entryID), nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func (f *fakeLoyaltyStore) ExpireDue(ctx context.Context, now time.Time) (int, error) { return 0, nil }
+
+func TestLoyaltyCannotGoNegative(t *testing.T) {
+	store := &fakeLoyaltyStore{}
+	s := &Server{
+		loyalty:   store,
+		customers: &fakeCustomerStore{customers: map[string]repository.Customer{"cust-1": {ID: "cust-1"}}},
+	}
+	r := gin.New()
+	r.POST("/customers/:id/loyalty/adjust", s.AdjustLoyaltyHandler)
+	r.POST("/customers/:id/loyalty/redeem", s.RedeemLoyaltyHandler)
+	r.POST("/loyalty/entries/:id/reverse", s.ReverseLoyaltyEntryHandler)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	earn := store.add("cust-1", loyalty.TypeEarn, 100, nil)
+
+	if rr := post("/customers/cust-1/loyalty/adjust", `{"points":-150,"note":"koreksi"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for an adjustment below zero, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr := post("/customers/cust-1/loyalty/adjust", `{"points":-40,"note":"koreksi"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr := post("/customers/cust-1/loyalty/redeem", `{"points":50,"reference":"INV-1"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+
+	// 100 poin earn tinggal 10 setelah koreksi dan penukaran
+	if rr := post("/loyalty/entries/"+earn+"/reverse", `{"note":"refund"}`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 when reversing spent points, got %d: %s", rr.Code, rr.Body)
+	}
+	if balance, _ := store.Balance(context.Background(), "cust-1"); balance != 10 {
+		t.Errorf("expected balance 10, got %d", balance)
+	}
+}
+
+func TestLoyaltySettingsCategories(t *testing.T) {
+	s := &Server{loyalty: &fakeLoyaltyStore{categories: map[string]bool{categoryKopi: true}}}
+	r := gin.New()
+	r.PUT("/loyalty/settings", s.UpdateLoyaltySettingsHandler)
+
+	put := func(multipliers string) int {
+		body := `{"rupiah_per_point":10000,"point_value":100,"expiry_days":365,"category_multipliers":` + multipliers + `}`
+		req := httptest.NewRequest(http.MethodPut, "/loyalty/settings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := put(`{"` + categoryKopi + `":2}`); code != http.StatusOK {
+		t.Errorf("expected 200 for a known category, got %d", code)
+	}
+	if code := put(`{"` + categoryKopi + `":-1}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative multiplier, got %d", code)
+	}
+	if code := put(`{"kopi":2}`); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed category ID, got %d", code)
+	}
+	if code := put(`{"7a2d3b64-1c5e-4f0a-8b9c-0d1e2f3a4b99":2}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a category of another tenant, got %d", code)
+	}
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/ratelimit"
+	"maspos-be-go/internal/scope"
+	"maspos-be-go/internal/utils"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	traceIDHeader   = "X-Trace-ID"
+	apiKeyHeader    = "X-API-Key"
+	outletHeader    = "X-Outlet-ID"
+
+	ctxRequestID  = "request_id"
+	ctxUserID     = "user_id"
+	ctxOutlets    = "outlets"
+	ctxHeadOffice = "head_office"
+	ctxTenant     = "tenant"
+)
+
+// tracing membuat span server untuk setiap request. Konteks trace dari header
+// traceparent (W3C) dipakai sebagai parent bila ada, dan trace ID dikirim
+// balik lewat header X-Trace-ID supaya error bisa dicocokkan dengan trace-nya.
+func (s *Server) tracing() gin.HandlerFunc {
+	tracer := otel.Tracer("maspos-be-go/internal/server")
+	return func(c *gin.Context) {
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			c.Header(traceIDHeader, sc.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
+
+// identify membaca bearer token bila ada dan menyimpan user ID-nya di context.
+// Middleware ini tidak menolak request tanpa token; ia hanya memberi identitas
+// untuk log dan middleware lain.
+func (s *Server) identify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(auth, "Bearer ")
+		if ok && s.cfg != nil {
+			if claims, err := utils.ParseToken(token, []byte(s.cfg.JWT.Secret)); err == nil {
+				if sub, err := claims.GetSubject(); err == nil {
+					c.Set(ctxUserID, sub)
+				}
+				c.Set(ctxTenant, utils.TokenTenant(claims))
+				c.Set(ctxOutlets, utils.TokenOutlets(claims))
+				c.Set(ctxHeadOffice, utils.TokenHeadOffice(claims))
+			}
+		}
+		c.Next()
+	}
+}
+
+// requireAuth menolak request tanpa token yang sah dengan 401. Dipasang di
+// depan semua route data; hanya /auth dan route dasar yang terbuka.
+func (s *Server) requireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ctxUserID) == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentUserID mengembalikan ID user dari token request, atau nil untuk
+// request tanpa token.
+func currentUserID(c *gin.Context) *int {
+	id, err := strconv.Atoi(c.GetString(ctxUserID))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// tenantScope menentukan tenant request dan menyimpannya di context request;
+// lapisan database membatasi setiap transaksi ke tenant tersebut. Urutannya:
+// subdomain di bawah TENANT_BASE_DOMAIN, lalu klaim tenant di token, lalu
+// DEFAULT_TENANT. Token milik tenant lain dari subdomain yang dituju ditolak,
+// sehingga token satu merchant tidak bisa dipakai di merchant lain. Di route
+// data middleware ini dipasang setelah requireAuth, jadi request tanpa token
+// tidak pernah mendapat tenant.
+func (s *Server) tenantScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		tenant := c.GetString(ctxTenant)
+
+		slug := s.tenantSlug(c.Request.Host)
+		if slug == "" && tenant == "" {
+			slug = s.cfg.Tenancy.DefaultTenant
+		}
+		if slug != "" {
+			id, err := s.tenantID(ctx, slug)
+			if errors.Is(err, sql.ErrNoRows) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "unknown tenant"})
+				return
+			} else if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if tenant != "" && tenant != id {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token belongs to another tenant"})
+				return
+			}
+			tenant = id
+		}
+
+		if tenant != "" {
+			ctx = scope.WithTenant(ctx, tenant)
+			ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(slog.String("tenant_id", tenant)))
+			c.Request = c.Request.WithContext(ctx)
+		}
+		c.Next()
+	}
+}
+
+// tenantSlug mengambil subdomain tenant dari host, misalnya "kopi-senja"
+// dari kopi-senja.maspos.id:8080.
+func (s *Server) tenantSlug(host string) string {
+	base := s.cfg.Tenancy.BaseDomain
+	if base == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	slug, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(base))
+	if !ok {
+		return ""
+	}
+	return slug
+}
+
+// tenantID mencari ID tenant dari slug-nya. Hasilnya disimpan selamanya
+// karena slug sebuah tenant tidak pernah berubah.
+func (s *Server) tenantID(ctx context.Context, slug string) (string, error) {
+	if id, ok := s.tenantIDs.Load(slug); ok {
+		return id.(string), nil
+	}
+	t, err := s.tenants.GetBySlug(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	s.tenantIDs.Store(slug, t.ID)
+	return t.ID, nil
+}
+
+// outletScope menentukan outlet aktif request dan menyimpannya di context
+// request, tempat repository membacanya untuk memfilter setiap query:
+//   - user yang ditugaskan ke outlet hanya boleh memilih salah satu outletnya
+//     lewat X-Outlet-ID; bila ia hanya punya satu outlet, header boleh kosong;
+//   - staf kantor pusat (klaim head_office di token) bekerja pada katalog
+//     pusat, atau pada outlet mana pun yang dipilih lewat X-Outlet-ID;
+//   - user lain yang tidak ditugaskan ke outlet mana pun ditolak.
+func (s *Server) outletScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader(outletHeader)
+		assigned := c.GetStringSlice(ctxOutlets)
+		headOffice := c.GetBool(ctxHeadOffice)
+		outlet := requested
+
+		switch {
+		case headOffice && requested != "":
+			if err := uuid.Validate(requested); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": outletHeader + " must be a UUID"})
+				return
+			}
+			if _, err := s.outlets.GetByID(c.Request.Context(), requested); errors.Is(err, sql.ErrNoRows) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unknown outlet"})
+				return
+			} else if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		case headOffice:
+		case len(assigned) == 0:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "you are not assigned to any outlet"})
+			return
+		case requested == "" && len(assigned) == 1:
+			outlet = assigned[0]
+		case requested == "":
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": outletHeader + " is required for users of several outlets"})
+			return
+		case !slices.Contains(assigned, requested):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "you are not assigned to this outlet"})
+			return
+		}
+
+		if outlet != "" {
+			ctx := scope.WithOutlet(c.Request.Context(), outlet)
+			ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(slog.String("outlet_id", outlet)))
+			c.Request = c.Request.WithContext(ctx)
+		}
+		c.Next()
+	}
+}
+
+// requestLogger memberi setiap request sebuah ID (diambil dari X-Request-ID
+// atau dibuat baru), menyimpan logger berisi request ID, user ID dan route di
+// context request, lalu menulis satu baris log setelah request selesai.
+func (s *Server) requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+		c.Set(ctxRequestID, requestID)
+		c.Header(requestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		l := s.logger.With(
+			slog.String("request_id", requestID),
+			slog.String("trace_id", traceID(c)),
+			slog.String("user_id", c.GetString(ctxUserID)),
+			slog.String("route", route),
+		)
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if l.Enabled(c.Request.Context(), slog.LevelDebug) {
+			attrs = append(attrs, headerGroup(c.Request.Header))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		l.LogAttrs(c.Request.Context(), level, "request completed", attrs...)
+	}
+}
+
+// instrument mencatat jumlah dan latensi request per route template.
+func (s *Server) instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		s.metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// rateLimit membatasi request dengan token bucket per klien. Route yang punya
+// batas sendiri di konfigurasi memakai bucket terpisah; route lain berbagi satu
+// bucket default per klien. Bila backend gagal, request tetap dilayani.
+func (s *Server) rateLimit() gin.HandlerFunc {
+	if s.limiter == nil || s.cfg == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	rl := s.cfg.RateLimit
+	def := ratelimit.NewLimit(rl.Default.Requests, rl.Default.Per, rl.Default.Burst)
+	routes := make(map[string]ratelimit.Limit, len(rl.Routes))
+	for route, l := range rl.Routes {
+		routes[route] = ratelimit.NewLimit(l.Requests, l.Per, l.Burst)
+	}
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		limit, ok := routes[route]
+		scope := route
+		if !ok {
+			limit, scope = def, "default"
+		}
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		d, err := s.limiter.Allow(ctx, "rl:"+scope+":"+clientKey(c), limit)
+		if err != nil {
+			logger.FromContext(ctx).Warn("rate limiter unavailable, allowing request", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+		if !d.Allowed {
+			s.metrics.RateLimited(c.Request.Method, c.FullPath())
+			tooManyRequests(c, d.RetryAfter)
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey mengidentifikasi klien untuk rate limit: API key bila dikirim
+// (disimpan sebagai hash), lalu user yang login, lalu alamat IP.
+func clientKey(c *gin.Context) string {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:])
+	}
+	if userID := c.GetString(ctxUserID); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// tooManyRequests menolak request dengan 429 dan Retry-After dalam detik,
+// dibulatkan ke atas supaya klien tidak mencoba lagi terlalu cepat.
+func tooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(seconds, 1)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"status":  "error",
+		"message": "too many requests, retry later",
+	})
+}
+
+// recovery menggantikan gin.Recovery agar panic juga tercatat sebagai JSON.
+func (s *Server) recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		// handler yang sengaja memutus respons setengah jalan (misalnya
+		// ekspor yang gagal di tengah) diteruskan ke net/http supaya
+		// koneksinya ditutup, bukan ditambahi body error
+		if err == http.ErrAbortHandler {
+			panic(err)
+		}
+		logger.FromContext(c.Request.Context()).Error("panic recovered",
+			slog.Any("panic", err),
+			slog.String("stack", string(debug.Stack())),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":    "internal server error",
+			"trace_id": traceID(c),
+		})
+	})
+}
+
+func headerGroup(h http.Header) slog.Attr {
+	args := make([]any, 0, len(h))
+	for k, v := range h {
+		args = append(args, slog.String(k, strings.Join(v, ",")))
+	}
+	return slog.Group("headers", args...)
+}
+
+// traceID mengembalikan trace ID request saat ini, atau string kosong.
+func traceID(c *gin.Context) string {
+	sc := trace.SpanContextFromContext(c.Request.Context())
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/ratelimit"
+	"maspos-be-go/internal/scope"
+	"maspos-be-go/internal/utils"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &config.Config{JWT: config.JWT{Secret: "test-secret-0123456789"}}
+	s := &Server{cfg: cfg, logger: logger.New(&buf, "info", "json")}
+
+	r := gin.New()
+	r.Use(s.identify(), s.requestLogger())
+	r.GET("/products/:id", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Info("inside handler")
+		c.Status(http.StatusOK)
+	})
+
+	token, err := utils.GenerateToken(42, "kasir@example.com", "", nil, false, []byte(cfg.JWT.Secret), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/products/abc", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("X-Request-ID"); got != "req-123" {
+		t.Fatalf("expected request ID to be echoed, got %q", got)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+	for _, raw := range lines {
+		var line map[string]any
+		if err := json.Unmarshal(raw, &line); err != nil {
+			t.Fatalf("log line is not JSON: %s", raw)
+		}
+		if line["request_id"] != "req-123" || line["user_id"] != "42" || line["route"] != "/products/:id" {
+			t.Errorf("missing request context in log line: %s", raw)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/products/abc", nil))
+	if rr.Header().Get("X-Request-ID") == "" {
+		t.Error("expected a request ID to be generated when none is sent")
+	}
+}
+
+func TestTracingPropagatesTraceparent(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer tp.Shutdown(context.Background())
+
+	var buf bytes.Buffer
+	s := &Server{logger: logger.New(&buf, "info", "json")}
+	r := gin.New()
+	r.Use(s.tracing(), s.requestLogger())
+	r.GET("/products/:id", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/products/abc", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("X-Trace-ID"); got != traceID {
+		t.Fatalf("expected X-Trace-ID %s, got %q", traceID, got)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "GET /products/:id" || spans[0].SpanContext.TraceID().String() != traceID {
+		t.Errorf("unexpected span %q in trace %s", spans[0].Name, spans[0].SpanContext.TraceID())
+	}
+	if spans[0].Status.Code.String() != "Error" {
+		t.Errorf("expected 5xx to mark the span as error, got %s", spans[0].Status.Code)
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line["trace_id"] != traceID {
+		t.Errorf("expected trace_id in log line, got %v", line["trace_id"])
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := &config.Config{RateLimit: config.RateLimit{
+		Default: config.Limit{Requests: 1, Per: time.Minute, Burst: 2},
+		Routes: map[string]config.Limit{
+			"POST /auth/login": {Requests: 1, Per: time.Minute, Burst: 1},
+		},
+	}}
+	s := &Server{cfg: cfg, limiter: ratelimit.NewMemory()}
+
+	r := gin.New()
+	r.Use(s.rateLimit())
+	r.GET("/products", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path, ip, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := do(http.MethodGet, "/products", "10.0.0.1", ""); rr.Code != http.StatusOK {
+			t.Fatalf("request %d within burst got %d", i+1, rr.Code)
+		}
+	}
+	rr := do(http.MethodGet, "/products", "10.0.0.1", "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after burst, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+
+	if rr := do(http.MethodGet, "/products", "10.0.0.2", ""); rr.Code != http.StatusOK {
+		t.Errorf("expected another IP to have its own bucket, got %d", rr.Code)
+	}
+	if rr := do(http.MethodGet, "/products", "10.0.0.1", "key-1"); rr.Code != http.StatusOK {
+		t.Errorf("expected an API key to have its own bucket, got %d", rr.Code)
+	}
+
+	// route dengan batas sendiri tidak memakai bucket default
+	if rr := do(http.MethodPost, "/auth/login", "10.0.0.1", ""); rr.Code != http.StatusOK {
+		t.Fatalf("expected login to use its own bucket, got %d", rr.Code)
+	}
+	if rr := do(http.MethodPost, "/auth/login", "10.0.0.1", ""); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected second login within the minute to be limited, got %d", rr.Code)
+	}
+}
+
+type fakeTenantStore struct {
+	tenants map[string]string
+	lookups int
+}
+
+func (f *fakeTenantStore) GetBySlug(ctx context.Context, slug string) (*repository.Tenant, error) {
+	f.lookups++
+	id, ok := f.tenants[slug]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &repository.Tenant{ID: id, Slug: slug}, nil
+}
+
+func TestTenantScope(t *testing.T) {
+	const (
+		tenantDefault = "00000000-0000-0000-0000-000000000001"
+		tenantSenja   = "7d0b5a8e-1111-4c1e-8a5e-4b7e0a2f3c01"
+		tenantPagi    = "7d0b5a8e-2222-4c1e-8a5e-4b7e0a2f3c02"
+	)
+	cfg := &config.Config{
+		JWT:     config.JWT{Secret: "test-secret-0123456789"},
+		Tenancy: config.Tenancy{BaseDomain: "maspos.id", DefaultTenant: "default"},
+	}
+	tenants := &fakeTenantStore{tenants: map[string]string{
+		"default": tenantDefault, "kopi-senja": tenantSenja, "kopi-pagi": tenantPagi,
+	}}
+	s := &Server{cfg: cfg, tenants: tenants}
+
+	r := gin.New()
+	r.Use(s.identify(), s.tenantScope())
+	r.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, scope.Tenant(c.Request.Context()))
+	})
+
+	token := func(tenant string) string {
+		tok, err := utils.GenerateToken(7, "kasir@example.com", tenant, nil, false, []byte(cfg.JWT.Secret), time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	tests := []struct {
+		name   string
+		host   string
+		token  string
+		status int
+		tenant string
+	}{
+		{"no subdomain uses the default tenant", "api.example.com", "", http.StatusOK, tenantDefault},
+		{"subdomain selects the tenant", "kopi-senja.maspos.id:8080", "", http.StatusOK, tenantSenja},
+		{"subdomain is case-insensitive", "Kopi-Pagi.Maspos.ID", "", http.StatusOK, tenantPagi},
+		{"unknown subdomain", "kopi-malam.maspos.id", "", http.StatusNotFound, ""},
+		{"token tenant without subdomain", "api.example.com", token(tenantPagi), http.StatusOK, tenantPagi},
+		{"token matches subdomain", "kopi-senja.maspos.id", token(tenantSenja), http.StatusOK, tenantSenja},
+		{"token of another tenant", "kopi-senja.maspos.id", token(tenantPagi), http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			req.Host = tt.host
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rr.Code, rr.Body)
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != tt.tenant {
+				t.Errorf("expected tenant %q, got %q", tt.tenant, rr.Body)
+			}
+		})
+	}
+
+	// route data menolak request tanpa token sebelum tenant-nya ditentukan
+	data := gin.New()
+	data.Use(s.identify())
+	data.Group("", s.requireAuth(), s.tenantScope()).GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, scope.Tenant(c.Request.Context()))
+	})
+	lookups := tenants.lookups
+	for _, host := range []string{"api.example.com", "kopi-malam.maspos.id"} {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Host = host
+		rr := httptest.NewRecorder()
+		data.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("anonymous request on %s: expected 401, got %d: %s", host, rr.Code, rr.Body)
+		}
+	}
+	if tenants.lookups != lookups {
+		t.Errorf("expected no tenant lookup for anonymous requests, got %d", tenants.lookups-lookups)
+	}
+
+	// slug yang sudah dikenal tidak dicari ulang ke database
+	before := tenants.lookups
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Host = "kopi-senja.maspos.id"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if tenants.lookups != before {
+		t.Errorf("expected a cached tenant lookup, got %d new lookups", tenants.lookups-before)
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+	"maspos-be-go/internal/server/dto"
+)
+
+const (
+	defaultOrderLimit    = 100
+	defaultPurchaseLimit = 20
+	maxOrderLimit        = 500
+)
+
+func orderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrShiftClosed), errors.Is(err, repository.ErrDayClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrProductUnavailable), errors.Is(err, repository.ErrCustomerNotFound),
+		errors.Is(err, repository.ErrLoyaltyNoCustomer), errors.Is(err, repository.ErrLoyaltyNoValue),
+		errors.Is(err, loyalty.ErrInsufficientPoints),
+		errors.Is(err, order.ErrUnderpaid), errors.Is(err, order.ErrChangeWithoutCash), errors.Is(err, order.ErrDiscountTooHigh):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func newOrderFromRequest(req dto.OrderRequest) repository.NewOrder {
+	in := repository.NewOrder{ShiftID: req.ShiftID, CustomerID: req.CustomerID, Note: req.Note}
+	for _, l := range req.Lines {
+		line := repository.NewOrderLine{ProductID: l.ProductID, Quantity: l.Quantity, Discount: l.Discount, Note: l.Note}
+		for _, m := range l.Modifiers {
+			line.Modifiers = append(line.Modifiers, order.Modifier{Name: m.Name, Price: m.Price})
+		}
+		in.Lines = append(in.Lines, line)
+	}
+	for _, t := range req.Taxes {
+		in.Taxes = append(in.Taxes, order.Tax{Name: t.Name, Amount: t.Amount})
+	}
+	for _, t := range req.Tenders {
+		in.Tenders = append(in.Tenders, order.Tender{Method: t.Method, Amount: t.Amount, Reference: t.Reference, Points: t.Points})
+	}
+	return in
+}
+
+// @Summary Complete order
+// @Description Stores a paid sale on an open shift of the active outlet. Prices, names and categories come from the outlet catalog. Tenders must cover the total; only cash may exceed it, the rest is change. A loyalty tender redeems the customer's points at the configured point value, and an order with a customer earns points on the part not paid with points.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param body body dto.OrderRequest true "Lines, taxes and tenders"
+// @Success 201 {object} repository.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /orders [post]
+func (s *Server) CreateOrderHandler(c *gin.Context) {
+	var req dto.OrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	in := newOrderFromRequest(req)
+	in.CreatedBy = currentUserID(c)
+
+	o, err := s.orders.Create(c.Request.Context(), in)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shift not found"})
+		return
+	}
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	s.metrics.OrderCompleted(o.Total, order.Order{Tenders: o.Tenders}.Cash()-o.Change)
+	s.metrics.LoyaltyPoints(loyalty.TypeEarn, o.PointsEarned)
+	s.metrics.LoyaltyPoints(loyalty.TypeBurn, o.PointsRedeemed)
+	c.JSON(http.StatusCreated, o)
+}
+
+// @Summary Get all orders
+// @Description Orders without their lines, newest first. Inside an outlet only its orders are listed.
+// @Tags Order
+// @Produce json
+// @Param customer_id query string false "Only orders of this customer"
+// @Param shift_id query string false "Only orders of this shift"
+// @Param number query string false "Only the order with this number"
+// @Param limit query int false "At most this many orders, default 100, max 500"
+// @Success 200 {array} repository.Order
+// @Failure 400 {object} map[string]string
+// @Router /orders [get]
+func (s *Server) GetAllOrdersHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultOrderLimit)))
+	if err != nil || limit < 1 || limit > maxOrderLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+	f := repository.OrderFilter{
+		CustomerID: c.Query("customer_id"),
+		ShiftID:    c.Query("shift_id"),
+		Number:     c.Query("number"),
+		Limit:      limit,
+	}
+	orders, err := s.orders.GetAll(c.Request.Context(), f)
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	if orders == nil {
+		orders = []repository.Order{}
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// @Summary Get order
+// @Description The order with its lines, taxes and tenders.
+// @Tags Order
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {object} repository.Order
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id} [get]
+func (s *Server) GetOrderByIDHandler(c *gin.Context) {
+	o, err := s.orders.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, o)
+}
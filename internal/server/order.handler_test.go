@@ -0,0 +1,385 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/order"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/scope"
+)
+
+const (
+	productKopiSusu  = "3f1c2a4e-5b6d-4e7f-8a9b-0c1d2e3f4a01"
+	productCroissant = "3f1c2a4e-5b6d-4e7f-8a9b-0c1d2e3f4a02"
+)
+
+// fakeOrderStore meniru OrderRepository: order hanya dibuat di shift yang
+// masih terbuka dan harganya diambil dari katalog, bukan dari request.
+type fakeOrderStore struct {
+	shifts   *fakeShiftStore
+	loyalty  *fakeLoyaltyStore
+	products map[string]repository.Product
+	orders   []*repository.Order
+}
+
+func newFakeOrderStore(shifts *fakeShiftStore) *fakeOrderStore {
+	return &fakeOrderStore{shifts: shifts, loyalty: &fakeLoyaltyStore{rules: loyalty.Rules{PointValue: 100}}, products: map[string]repository.Product{
+		productKopiSusu:  {ID: productKopiSusu, CategoryID: categoryKopi, Name: "Kopi Susu", Price: 18000, Active: true},
+		productCroissant: {ID: productCroissant, CategoryID: "cat-roti", Name: "Croissant", Price: 22000, Active: true},
+	}}
+}
+
+func (f *fakeOrderStore) Create(ctx context.Context, in repository.NewOrder) (*repository.Order, error) {
+	sh, err := f.shifts.visible(ctx, in.ShiftID)
+	if err != nil {
+		return nil, err
+	}
+	if sh.ClosedAt != nil {
+		return nil, repository.ErrShiftClosed
+	}
+	calc := order.Order{Taxes: in.Taxes, Tenders: in.Tenders}
+	var redeemed int64
+	for i, t := range calc.Tenders {
+		if t.Method != order.TenderLoyalty {
+			continue
+		}
+		if in.CustomerID == "" {
+			return nil, repository.ErrLoyaltyNoCustomer
+		}
+		calc.Tenders[i].Amount = f.loyalty.rules.RedemptionValue(t.Points)
+		if calc.Tenders[i].Amount <= 0 {
+			return nil, repository.ErrLoyaltyNoValue
+		}
+		redeemed += t.Points
+	}
+	for _, l := range in.Lines {
+		p, ok := f.products[l.ProductID]
+		if !ok || !p.Active {
+			return nil, repository.ErrProductUnavailable
+		}
+		calc.Lines = append(calc.Lines, order.Line{ProductID: p.ID, CategoryID: p.CategoryID, Name: p.Name,
+			Quantity: l.Quantity, UnitPrice: p.Price, Modifiers: l.Modifiers, Discount: l.Discount, Note: l.Note})
+	}
+	if err := calc.Validate(); err != nil {
+		return nil, err
+	}
+	if redeemed > 0 {
+		if err := f.loyalty.spendable(in.CustomerID, redeemed); err != nil {
+			return nil, err
+		}
+		f.loyalty.add(in.CustomerID, loyalty.TypeBurn, -redeemed, nil)
+	}
+	o := &repository.Order{
+		ID: fmt.Sprintf("order-%d", len(f.orders)+1), Number: fmt.Sprintf("INV-%06d", len(f.orders)+1),
+		OutletID: sh.OutletID, ShiftID: sh.ID, Gross: calc.Gross(), Discount: calc.Discount(), Subtotal: calc.Subtotal(),
+		Tax: calc.Tax(), Total: calc.Total(), Paid: calc.Paid(), Change: calc.Change(), CreatedBy: in.CreatedBy,
+		CreatedAt: time.Now(), Taxes: calc.Taxes, Tenders: calc.Tenders, PointsRedeemed: redeemed,
+	}
+	if in.CustomerID != "" {
+		o.CustomerID = &in.CustomerID
+	}
+	for i, l := range calc.Lines {
+		id := fmt.Sprintf("0e1d0000-0000-4000-8000-%012d", len(f.orders)*1000+i+1)
+		o.Lines = append(o.Lines, repository.OrderLine{ID: id, Line: l, Total: l.Total()})
+	}
+	if cash := calc.Cash() - calc.Change(); cash > 0 {
+		f.shifts.movements[sh.ID] = append(f.shifts.movements[sh.ID], repository.CashMovement{Type: repository.CashSale, Amount: cash, Reference: o.Number})
+	}
+	f.orders = append(f.orders, o)
+	return o, nil
+}
+
+func (f *fakeOrderStore) GetAll(ctx context.Context, filter repository.OrderFilter) ([]repository.Order, error) {
+	var res []repository.Order
+	for _, o := range f.orders {
+		if (scope.Outlet(ctx) != "" && o.OutletID != scope.Outlet(ctx)) || (filter.ShiftID != "" && o.ShiftID != filter.ShiftID) ||
+			(filter.CustomerID != "" && (o.CustomerID == nil || *o.CustomerID != filter.CustomerID)) {
+			continue
+		}
+		res = append(res, *o)
+	}
+	return res, nil
+}
+
+func (f *fakeOrderStore) GetByID(ctx context.Context, id string) (*repository.Order, error) {
+	for _, o := range f.orders {
+		if o.ID == id && (scope.Outlet(ctx) == "" || o.OutletID == scope.Outlet(ctx)) {
+			return o, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeOrderStore) CustomerHistory(ctx context.Context, customerID string, limit int) (*repository.PurchaseHistory, error) {
+	h := &repository.PurchaseHistory{CustomerID: customerID, RecentOrders: []repository.Order{}}
+	orders, _ := f.GetAll(ctx, repository.OrderFilter{CustomerID: customerID})
+	for i := len(orders) - 1; i >= 0; i-- {
+		o := orders[i]
+		h.Orders++
+		h.TotalSpend += o.Total
+		if h.FirstVisit == nil {
+			h.FirstVisit = &o.CreatedAt
+		}
+		h.LastVisit = &o.CreatedAt
+		if len(h.RecentOrders) < limit {
+			h.RecentOrders = append(h.RecentOrders, o)
+		}
+	}
+	h.NetSpend = h.TotalSpend
+	if h.Orders > 0 {
+		h.AverageOrder = h.TotalSpend / float64(h.Orders)
+	}
+	return h, nil
+}
+
+// orderTestServer menyiapkan server dengan satu shift terbuka di outlet
+// Kemang dan mengembalikan ID shift tersebut.
+func orderTestServer(t *testing.T) (*Server, *gin.Engine, string) {
+	t.Helper()
+	shifts := newFakeShiftStore()
+	s := &Server{
+		shifts:  shifts,
+		orders:  newFakeOrderStore(shifts),
+		outlets: &fakeOutletStore{outlets: map[string]repository.Outlet{outletKemang: {ID: outletKemang}, outletDepok: {ID: outletDepok}}},
+	}
+	shiftID, err := shifts.Open(scope.WithOutlet(context.Background(), outletKemang), "kasir-1", 200000, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.Use(asHeadOffice(), s.outletScope())
+	r.POST("/orders", s.CreateOrderHandler)
+	r.GET("/orders", s.GetAllOrdersHandler)
+	r.GET("/orders/:id", s.GetOrderByIDHandler)
+	r.GET("/shifts/:id", s.GetShiftReportHandler)
+	r.POST("/shifts/:id/close", s.CloseShiftHandler)
+	return s, r, shiftID
+}
+
+func sendJSON(r http.Handler, method, path, outlet, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if outlet != "" {
+		req.Header.Set(outletHeader, outlet)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCreateOrder(t *testing.T) {
+	_, r, shiftID := orderTestServer(t)
+
+	body := `{"shift_id":"` + shiftID + `","lines":[
+		{"product_id":"` + productKopiSusu + `","quantity":2,"modifiers":[{"name":"Extra shot","price":5000}],"discount":6000},
+		{"product_id":"` + productCroissant + `","quantity":1}],
+		"taxes":[{"name":"PB1 10%","amount":6200}],
+		"tenders":[{"method":"card","amount":30000},{"method":"cash","amount":50000}]}`
+	rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var o repository.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Total != 68200 || o.Change != 11800 || o.Discount != 6000 || len(o.Lines) != 2 {
+		t.Errorf("unexpected totals: total %v, change %v, discount %v, %d lines", o.Total, o.Change, o.Discount, len(o.Lines))
+	}
+	if o.Lines[0].Name != "Kopi Susu" || o.Lines[0].UnitPrice != 18000 {
+		t.Errorf("expected the catalog name and price, got %q at %v", o.Lines[0].Name, o.Lines[0].UnitPrice)
+	}
+
+	// kartu tidak masuk laci; tunai 50.000 dikurangi kembalian 11.800
+	rr = sendJSON(r, http.MethodGet, "/shifts/"+shiftID, outletKemang, "")
+	var rep repository.ShiftReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.CashSales != 38200 || rep.ExpectedCash == nil || *rep.ExpectedCash != 238200 {
+		t.Errorf("expected cash sales 38200 and expected cash 238200, got %v and %v", rep.CashSales, rep.ExpectedCash)
+	}
+
+	if rr := sendJSON(r, http.MethodGet, "/orders/"+o.ID, outletKemang, ""); rr.Code != http.StatusOK {
+		t.Errorf("expected the order to be readable, got %d", rr.Code)
+	}
+	if rr := sendJSON(r, http.MethodGet, "/orders/"+o.ID, outletDepok, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 from another outlet, got %d", rr.Code)
+	}
+}
+
+func TestCreateOrderRejected(t *testing.T) {
+	_, r, shiftID := orderTestServer(t)
+
+	line := `{"product_id":"` + productKopiSusu + `","quantity":1}`
+	cases := []struct {
+		name, body string
+		want       int
+	}{
+		{"no tenders", `{"shift_id":"` + shiftID + `","lines":[` + line + `]}`, http.StatusBadRequest},
+		{"unknown tender", `{"shift_id":"` + shiftID + `","lines":[` + line + `],"tenders":[{"method":"voucher","amount":18000}]}`, http.StatusBadRequest},
+		{"unknown product", `{"shift_id":"` + shiftID + `","lines":[{"product_id":"3f1c2a4e-5b6d-4e7f-8a9b-0c1d2e3f4aff","quantity":1}],"tenders":[{"method":"cash","amount":18000}]}`, http.StatusUnprocessableEntity},
+		{"underpaid", `{"shift_id":"` + shiftID + `","lines":[` + line + `],"tenders":[{"method":"cash","amount":10000}]}`, http.StatusUnprocessableEntity},
+		{"card overpaid", `{"shift_id":"` + shiftID + `","lines":[` + line + `],"tenders":[{"method":"card","amount":20000}]}`, http.StatusUnprocessableEntity},
+		{"unknown shift", `{"shift_id":"3f1c2a4e-5b6d-4e7f-8a9b-0c1d2e3f4aee","lines":[` + line + `],"tenders":[{"method":"cash","amount":18000}]}`, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		if rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, c.body); rr.Code != c.want {
+			t.Errorf("%s: expected %d, got %d: %s", c.name, c.want, rr.Code, rr.Body)
+		}
+	}
+
+	if rr := sendJSON(r, http.MethodPost, "/shifts/"+shiftID+"/close", outletKemang, `{"counted_cash":200000}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected the shift to close, got %d: %s", rr.Code, rr.Body)
+	}
+	body := `{"shift_id":"` + shiftID + `","lines":[` + line + `],"tenders":[{"method":"cash","amount":18000}]}`
+	if rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 on a closed shift, got %d: %s", rr.Code, rr.Body)
+	}
+}
+
+func TestOrderLoyaltyTender(t *testing.T) {
+	s, r, shiftID := orderTestServer(t)
+	points := s.orders.(*fakeOrderStore).loyalty
+
+	checkout := func(customer, tenders string) *httptest.ResponseRecorder {
+		body := `{"shift_id":"` + shiftID + `","customer_id":"` + customer + `",
+			"lines":[{"product_id":"` + productKopiSusu + `","quantity":1}],"tenders":` + tenders + `}`
+		return sendJSON(r, http.MethodPost, "/orders", outletKemang, body)
+	}
+	const customer = "7c0a1e2b-3d4f-4a5b-8c6d-7e8f9a0b1c01"
+	points.add(customer, loyalty.TypeEarn, 100, nil)
+
+	if rr := checkout(customer, `[{"method":"loyalty"},{"method":"cash","amount":18000}]`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a loyalty tender without points, got %d", rr.Code)
+	}
+	if rr := checkout(customer, `[{"method":"loyalty","points":150},{"method":"cash","amount":3000}]`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 when redeeming more than the balance, got %d: %s", rr.Code, rr.Body)
+	}
+	body := `{"shift_id":"` + shiftID + `","lines":[{"product_id":"` + productKopiSusu + `","quantity":1}],
+		"tenders":[{"method":"loyalty","points":50},{"method":"cash","amount":13000}]}`
+	if rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a loyalty tender without customer, got %d: %s", rr.Code, rr.Body)
+	}
+
+	// tanpa nilai poin, poin tidak bisa dipakai membayar
+	points.rules.PointValue = 0
+	if rr := checkout(customer, `[{"method":"loyalty","points":50},{"method":"cash","amount":18000}]`); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for points without a redemption value, got %d: %s", rr.Code, rr.Body)
+	}
+	points.rules.PointValue = 100
+
+	// 50 poin bernilai 5.000; sisanya dibayar tunai
+	rr := checkout(customer, `[{"method":"loyalty","points":50},{"method":"cash","amount":13000}]`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var o repository.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.PointsRedeemed != 50 || o.Tenders[0].Amount != 5000 || o.Change != 0 {
+		t.Errorf("expected 50 points worth 5000 without change, got %d points, %v, change %v", o.PointsRedeemed, o.Tenders[0].Amount, o.Change)
+	}
+	if balance, _ := points.Balance(context.Background(), customer); balance != 50 {
+		t.Errorf("expected balance 50, got %d", balance)
+	}
+}
+
+func TestCustomerPurchases(t *testing.T) {
+	s, r, shiftID := orderTestServer(t)
+	const customer = "7c0a1e2b-3d4f-4a5b-8c6d-7e8f9a0b1c03"
+	s.customers = &fakeCustomerStore{customers: map[string]repository.Customer{customer: {ID: customer, Name: "Budi"}}}
+	r.GET("/customers/:id/purchases", s.GetCustomerPurchasesHandler)
+
+	// 1 x 18.000 dan 3 x 18.000
+	for _, sale := range []struct{ quantity, amount string }{{"1", "18000"}, {"3", "54000"}} {
+		body := `{"shift_id":"` + shiftID + `","customer_id":"` + customer + `",
+			"lines":[{"product_id":"` + productKopiSusu + `","quantity":` + sale.quantity + `}],
+			"tenders":[{"method":"card","amount":` + sale.amount + `}]}`
+		if rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body); rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+		}
+	}
+
+	if rr := sendJSON(r, http.MethodGet, "/customers/7c0a1e2b-3d4f-4a5b-8c6d-7e8f9a0b1cff/purchases", outletKemang, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown customer, got %d", rr.Code)
+	}
+	if rr := sendJSON(r, http.MethodGet, "/customers/"+customer+"/purchases?limit=0", outletKemang, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for limit 0, got %d", rr.Code)
+	}
+	rr := sendJSON(r, http.MethodGet, "/customers/"+customer+"/purchases?limit=1", outletKemang, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var h repository.PurchaseHistory
+	if err := json.Unmarshal(rr.Body.Bytes(), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Orders != 2 || h.TotalSpend != 72000 || h.AverageOrder != 36000 || h.LastVisit == nil || len(h.RecentOrders) != 1 {
+		t.Errorf("unexpected history: %+v", h)
+	}
+
+	// Depok tidak melihat belanja di Kemang
+	rr = sendJSON(r, http.MethodGet, "/customers/"+customer+"/purchases", outletDepok, "")
+	h = repository.PurchaseHistory{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Orders != 0 || h.LastVisit != nil {
+		t.Errorf("expected no purchases in Depok, got %+v", h)
+	}
+}
+
+func TestOrderReceipt(t *testing.T) {
+	s, r, shiftID := orderTestServer(t)
+	outlets := map[string]repository.Outlet{outletKemang: {ID: outletKemang, Name: "Kopi Senja Kemang"}, outletDepok: {ID: outletDepok}}
+	s.receipts = &fakeReceiptTemplateStore{outlets: outlets, templates: map[string]receipt.Template{}}
+	r.GET("/orders/:id/receipt", s.GetOrderReceiptHandler)
+
+	body := `{"shift_id":"` + shiftID + `","lines":[
+		{"product_id":"` + productKopiSusu + `","quantity":2,"modifiers":[{"name":"Extra shot","price":5000}],"discount":6000},
+		{"product_id":"` + productCroissant + `","quantity":1}],
+		"taxes":[{"name":"PB1 10%","amount":6200}],
+		"tenders":[{"method":"card","amount":30000},{"method":"cash","amount":50000}]}`
+	rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var o repository.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &o); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = sendJSON(r, http.MethodGet, "/orders/"+o.ID+"/receipt?format=text", outletKemang, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	text := rr.Body.String()
+	for _, want := range []string{"Kopi Senja Kemang", o.Number, "Kopi Susu", "Diskon", "-6.000", "68.200", "11.800"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q on the receipt:\n%s", want, text)
+		}
+	}
+	// kantor pusat mencetak dengan template outlet order
+	if rr := sendJSON(r, http.MethodGet, "/orders/"+o.ID+"/receipt?format=html", "", ""); rr.Code != http.StatusOK ||
+		!strings.Contains(rr.Body.String(), "Kopi Senja Kemang") {
+		t.Errorf("expected the Kemang template for the head office, got %d", rr.Code)
+	}
+	if rr := sendJSON(r, http.MethodGet, "/orders/"+o.ID+"/receipt", outletDepok, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 from another outlet, got %d", rr.Code)
+	}
+}
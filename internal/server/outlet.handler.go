@@ -0,0 +1,159 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/server/dto"
+)
+
+// @Summary Create outlet
+// @Description Head office only.
+// @Tags Outlet
+// @Accept json
+// @Produce json
+// @Param body body dto.OutletRequest true "Outlet payload"
+// @Success 201 {object} dto.OutletResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /outlets [post]
+func (s *Server) CreateOutletHandler(c *gin.Context) {
+	var req dto.OutletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := s.outlets.Create(c.Request.Context(), outletFromRequest(req))
+	if err != nil {
+		catalogWriteError(c, err, "Outlet not found")
+		return
+	}
+	c.JSON(http.StatusCreated, dto.OutletResponse{ID: id, Name: req.Name})
+}
+
+func outletFromRequest(req dto.OutletRequest) repository.Outlet {
+	return repository.Outlet{
+		Name:              req.Name,
+		Address:           req.Address,
+		UseCentralCatalog: req.UseCentralCatalog == nil || *req.UseCentralCatalog,
+	}
+}
+
+// @Summary Get all outlets
+// @Description Inside an outlet only that outlet is listed.
+// @Tags Outlet
+// @Produce json
+// @Success 200 {array} repository.Outlet
+// @Router /outlets [get]
+func (s *Server) GetAllOutletsHandler(c *gin.Context) {
+	outlets, err := s.outlets.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, outlets)
+}
+
+// @Summary Get outlet by ID
+// @Tags Outlet
+// @Param id path string true "Outlet ID"
+// @Success 200 {object} repository.Outlet
+// @Failure 404 {object} map[string]string
+// @Router /outlets/{id} [get]
+func (s *Server) GetOutletByIDHandler(c *gin.Context) {
+	outlet, err := s.outlets.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Outlet not found"})
+		return
+	}
+	c.JSON(http.StatusOK, outlet)
+}
+
+// @Summary Update outlet
+// @Description Head office only. Replaces the name, address and catalog setting.
+// @Tags Outlet
+// @Accept json
+// @Param id path string true "Outlet ID"
+// @Param body body dto.OutletRequest true "Outlet payload"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /outlets/{id} [put]
+func (s *Server) UpdateOutletHandler(c *gin.Context) {
+	var req dto.OutletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	outlet := outletFromRequest(req)
+	outlet.ID = c.Param("id")
+	if err := s.outlets.Update(c.Request.Context(), outlet); err != nil {
+		catalogWriteError(c, err, "Outlet not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Outlet updated"})
+}
+
+// @Summary Delete outlet
+// @Description Head office only. An outlet that still has its own categories, products, shifts or Z-reports cannot be deleted.
+// @Tags Outlet
+// @Param id path string true "Outlet ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /outlets/{id} [delete]
+func (s *Server) DeleteOutletHandler(c *gin.Context) {
+	if err := s.outlets.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		catalogWriteError(c, err, "Outlet not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Outlet deleted"})
+}
+
+// @Summary Assign user to outlet
+// @Description Head office only. Takes effect at the user's next login.
+// @Tags Outlet
+// @Param id path string true "Outlet ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /outlets/{id}/users/{userID} [put]
+func (s *Server) AssignOutletUserHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if err := s.outlets.AssignUser(c.Request.Context(), c.Param("id"), userID); err != nil {
+		catalogWriteError(c, err, "Outlet or user not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User assigned to outlet"})
+}
+
+// @Summary Remove user from outlet
+// @Description Head office only. Takes effect at the user's next login.
+// @Tags Outlet
+// @Param id path string true "Outlet ID"
+// @Param userID path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /outlets/{id}/users/{userID} [delete]
+func (s *Server) UnassignOutletUserHandler(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if err := s.outlets.UnassignUser(c.Request.Context(), c.Param("id"), userID); err != nil {
+		catalogWriteError(c, err, "User is not assigned to this outlet")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User removed from outlet"})
+}
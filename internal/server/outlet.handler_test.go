@@ -0,0 +1,245 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/ratelimit"
+	"maspos-be-go/internal/scope"
+	"maspos-be-go/internal/utils"
+)
+
+const (
+	outletKemang = "6f1c1a52-5f0e-4a43-9a8e-0c9a4b1d2e01"
+	outletDepok  = "6f1c1a52-5f0e-4a43-9a8e-0c9a4b1d2e02"
+	outletBogor  = "6f1c1a52-5f0e-4a43-9a8e-0c9a4b1d2e03"
+)
+
+// asHeadOffice menggantikan identify di test handler: request dianggap
+// berasal dari staf kantor pusat yang sudah login.
+func asHeadOffice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(ctxUserID, "1")
+		c.Set(ctxHeadOffice, true)
+		c.Next()
+	}
+}
+
+type fakeOutletStore struct {
+	outlets map[string]repository.Outlet
+	users   map[int][]string
+}
+
+func (f *fakeOutletStore) Create(ctx context.Context, o repository.Outlet) (string, error) {
+	if scope.Outlet(ctx) != "" {
+		return "", repository.ErrHeadOfficeOnly
+	}
+	o.ID = "outlet-new"
+	f.outlets[o.ID] = o
+	return o.ID, nil
+}
+
+func (f *fakeOutletStore) GetAll(ctx context.Context) ([]repository.Outlet, error) {
+	var res []repository.Outlet
+	for _, o := range f.outlets {
+		if id := scope.Outlet(ctx); id == "" || id == o.ID {
+			res = append(res, o)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeOutletStore) GetByID(ctx context.Context, id string) (*repository.Outlet, error) {
+	o, ok := f.outlets[id]
+	if !ok || (scope.Outlet(ctx) != "" && scope.Outlet(ctx) != id) {
+		return nil, sql.ErrNoRows
+	}
+	return &o, nil
+}
+
+func (f *fakeOutletStore) Update(ctx context.Context, o repository.Outlet) error {
+	if _, ok := f.outlets[o.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	f.outlets[o.ID] = o
+	return nil
+}
+
+func (f *fakeOutletStore) Delete(ctx context.Context, id string) error {
+	delete(f.outlets, id)
+	return nil
+}
+
+func (f *fakeOutletStore) AssignUser(ctx context.Context, outletID string, userID int) error {
+	f.users[userID] = append(f.users[userID], outletID)
+	return nil
+}
+
+func (f *fakeOutletStore) UnassignUser(ctx context.Context, outletID string, userID int) error {
+	f.users[userID] = slices.DeleteFunc(f.users[userID], func(id string) bool { return id == outletID })
+	return nil
+}
+
+func (f *fakeOutletStore) IDsForUser(ctx context.Context, userID int) ([]string, error) {
+	return f.users[userID], nil
+}
+
+func TestOutletScope(t *testing.T) {
+	cfg := &config.Config{JWT: config.JWT{Secret: "test-secret-0123456789"}}
+	s := &Server{cfg: cfg, outlets: &fakeOutletStore{outlets: map[string]repository.Outlet{
+		outletKemang: {ID: outletKemang, Name: "Kemang"},
+		outletDepok:  {ID: outletDepok, Name: "Depok"},
+	}}}
+
+	r := gin.New()
+	r.Use(s.identify(), s.requireAuth(), s.outletScope())
+	r.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, scope.Outlet(c.Request.Context()))
+	})
+
+	issue := func(headOffice bool, outlets ...string) string {
+		tok, err := utils.GenerateToken(7, "kasir@example.com", "", outlets, headOffice, []byte(cfg.JWT.Secret), time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	token := func(outlets ...string) string { return issue(false, outlets...) }
+	headOffice := func() string { return issue(true) }
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+		outlet string
+	}{
+		{"anonymous is rejected", "", "", http.StatusUnauthorized, ""},
+		{"anonymous cannot pick an outlet", "", outletDepok, http.StatusUnauthorized, ""},
+		{"user without outlets is not head office", token(), "", http.StatusForbidden, ""},
+		{"user without outlets cannot pick an outlet", token(), outletDepok, http.StatusForbidden, ""},
+		{"head office without header", headOffice(), "", http.StatusOK, ""},
+		{"head office picks an outlet", headOffice(), outletDepok, http.StatusOK, outletDepok},
+		{"head office picks an unknown outlet", headOffice(), outletBogor, http.StatusBadRequest, ""},
+		{"head office sends a malformed outlet", headOffice(), "kemang", http.StatusBadRequest, ""},
+		{"single outlet defaults to it", token(outletKemang), "", http.StatusOK, outletKemang},
+		{"single outlet names it", token(outletKemang), outletKemang, http.StatusOK, outletKemang},
+		{"cashier tries another outlet", token(outletKemang), outletDepok, http.StatusForbidden, ""},
+		{"several outlets need the header", token(outletKemang, outletDepok), "", http.StatusBadRequest, ""},
+		{"several outlets pick one", token(outletKemang, outletDepok), outletDepok, http.StatusOK, outletDepok},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.header != "" {
+				req.Header.Set(outletHeader, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rr.Code, rr.Body)
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != tt.outlet {
+				t.Errorf("expected outlet %q, got %q", tt.outlet, rr.Body)
+			}
+		})
+	}
+}
+
+func TestLoginTokenCarriesOutlets(t *testing.T) {
+	hash, err := utils.HashPassword("rahasia123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{JWT: config.JWT{Secret: "test-secret-0123456789", TTL: time.Hour}}
+	s := &Server{
+		cfg:     cfg,
+		limiter: ratelimit.NewMemory(),
+		users: &fakeUserStore{users: map[string]repository.User{
+			"kasir@example.com": {ID: 3, Email: "kasir@example.com", Password: hash},
+		}},
+		outlets: &fakeOutletStore{users: map[int][]string{3: {outletKemang, outletDepok}}},
+	}
+	r := gin.New()
+	r.POST("/auth/login", s.LoginHandler)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(`{"email":"kasir@example.com","password":"rahasia123"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+
+	var resp struct {
+		Data struct {
+			Token   string   `json:"token"`
+			Outlets []string `json:"outlets"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseToken(resp.Data.Token, []byte(cfg.JWT.Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{outletKemang, outletDepok}
+	if got := utils.TokenOutlets(claims); !slices.Equal(got, want) {
+		t.Errorf("token outlets = %v, want %v", got, want)
+	}
+	if utils.TokenHeadOffice(claims) {
+		t.Error("a cashier token must not carry the head office claim")
+	}
+	if !slices.Equal(resp.Data.Outlets, want) {
+		t.Errorf("response outlets = %v, want %v", resp.Data.Outlets, want)
+	}
+}
+
+func TestProductOutletPriceNeedsOutlet(t *testing.T) {
+	store := &fakeProductStore{products: map[string]repository.Product{
+		"prod-1": {ID: "prod-1", Name: "Es Kopi Susu", Price: 18000, Version: 1},
+	}}
+	s := &Server{products: store, outlets: &fakeOutletStore{outlets: map[string]repository.Outlet{
+		outletKemang: {ID: outletKemang},
+	}}}
+	r := gin.New()
+	r.Use(asHeadOffice(), s.outletScope())
+	r.PUT("/products/:id/outlet-price", s.SetProductOutletPriceHandler)
+
+	put := func(outlet string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/products/prod-1/outlet-price", strings.NewReader(`{"price":20000}`))
+		req.Header.Set("Content-Type", "application/json")
+		if outlet != "" {
+			req.Header.Set(outletHeader, outlet)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := put(""); rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without an outlet, got %d: %s", rr.Code, rr.Body)
+	}
+	rr := put(outletKemang)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr.Header().Get("ETag") != versionETag(2) {
+		t.Errorf("expected ETag of version 2, got %q", rr.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindMergePatch membaca body JSON Merge Patch (RFC 7396) ke dst, yang
+// field-nya berupa pointer dan tetap nil bila tidak dikirim. Validasi binding
+// hanya berlaku untuk field yang ada. Member bernilai null dikembalikan di
+// nulls dan hanya boleh untuk field yang disebut di nullable.
+func bindMergePatch(c *gin.Context, dst any, nullable ...string) (nulls map[string]bool, err error) {
+	var members map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&members); err != nil {
+		return nil, fmt.Errorf("body must be a JSON object: %w", err)
+	}
+	if members == nil {
+		return nil, errors.New("body must be a JSON object")
+	}
+
+	nulls = map[string]bool{}
+	for name, raw := range members {
+		if string(raw) != "null" {
+			continue
+		}
+		if !slices.Contains(nullable, name) {
+			return nil, fmt.Errorf("%s cannot be null", name)
+		}
+		nulls[name] = true
+		delete(members, name)
+	}
+
+	b, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return nil, err
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return nil, err
+	}
+	return nulls, nil
+}
@@ -1,12 +1,17 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/catalogexport"
+	"maspos-be-go/internal/catalogimport"
 	"maspos-be-go/internal/database/repository"
 	"maspos-be-go/internal/server/dto"
 )
@@ -15,11 +20,18 @@ import (
 // @Tags Product
 // @Accept multipart/form-data
 // @Produce json
+// @Param sku formData string false "Stock keeping unit, unique per product"
 // @Param category_id formData string true "Category ID"
 // @Param name formData string true "Product Name"
 // @Param price formData number true "Price"
 // @Param picture formData file true "Product Picture"
+// @Param position formData int false "Position on the POS grid"
+// @Param color formData string false "Button color, e.g. #8d6e63"
+// @Param icon formData string false "Icon name"
+// @Param active formData bool false "Shown on the POS grid (default true)"
 // @Success 201 {object} dto.ProductResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /products [post]
 func (s *Server) CreateProductHandler(c *gin.Context) {
 	var req dto.ProductRequest
@@ -41,106 +53,349 @@ func (s *Server) CreateProductHandler(c *gin.Context) {
 	}
 
 	// 2. Simpan ke Database
-	repo := repository.NewProductRepository(s.db.DB())
-	id, err := repo.Create(c.Request.Context(), req.CategoryID, req.Name, req.Price, dst)
+	product := repository.Product{
+		SKU:        req.SKU,
+		CategoryID: req.CategoryID,
+		Name:       req.Name,
+		Price:      req.Price,
+		Picture:    dst,
+		Position:   req.Position,
+		Color:      req.Color,
+		Icon:       req.Icon,
+		Active:     req.Active == nil || *req.Active,
+	}
+	id, err := s.products.Create(c.Request.Context(), product)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		catalogWriteError(c, err, "Category not found")
 		return
 	}
 
+	c.Header("ETag", versionETag(1))
 	c.JSON(http.StatusCreated, dto.ProductResponse{
 		ID:         id,
+		SKU:        req.SKU,
 		CategoryID: req.CategoryID,
 		Name:       req.Name,
 		Price:      req.Price,
 		Picture:    dst,
+		Position:   product.Position,
+		Color:      product.Color,
+		Icon:       product.Icon,
+		Active:     product.Active,
+		Version:    1,
 	})
 }
 // @Summary Get all products
 // @Tags Product
 // @Produce json
+// @Param category_id query string false "Only products in this category"
+// @Param include_descendants query bool false "Include products of subcategories (default true)"
+// @Param active query bool false "Only active products in visible categories, as shown on the POS grid"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {array} repository.Product
+// @Success 304 "Not modified"
 // @Router /products [get]
 func (s *Server) GetAllProductsHandler(c *gin.Context) {
-	repo := repository.NewProductRepository(s.db.DB())
-	products, err := repo.GetAll(c.Request.Context())
+	products, err := s.products.GetAll(c.Request.Context(), productFilter(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	ids := make([]string, len(products))
+	versions := make([]int, len(products))
+	for i, p := range products {
+		ids[i], versions[i] = p.ID, p.Version
+	}
+	if notModified(c, listETag(ids, versions)) {
+		return
+	}
 	c.JSON(http.StatusOK, products)
 }
 
 // @Summary Get product by ID
 // @Tags Product
 // @Param id path string true "Product ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} repository.Product
+// @Success 304 "Not modified"
 // @Router /products/{id} [get]
 func (s *Server) GetProductByIDHandler(c *gin.Context) {
 	id := c.Param("id")
-	repo := repository.NewProductRepository(s.db.DB())
-	product, err := repo.GetByID(c.Request.Context(), id)
+	product, err := s.products.GetByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
 		return
 	}
+	if notModified(c, versionETag(product.Version)) {
+		return
+	}
 	c.JSON(http.StatusOK, product)
 }
 
 // @Summary Update product
+// @Description Partial update: send JSON Merge Patch or multipart form fields; only the fields sent are changed.
 // @Tags Product
+// @Accept json
 // @Accept multipart/form-data
 // @Param id path string true "Product ID"
-// @Param category_id formData string true "Category ID"
-// @Param name formData string true "Product Name"
-// @Param price formData number true "Price"
+// @Param If-Match header string false "ETag of the version being edited"
+// @Param sku formData string false "Stock keeping unit; empty removes it"
+// @Param category_id formData string false "Category ID"
+// @Param name formData string false "Product Name"
+// @Param price formData number false "Price"
 // @Param picture formData file false "Product Picture"
+// @Param position formData int false "Position on the POS grid"
+// @Param color formData string false "Button color, e.g. #8d6e63"
+// @Param icon formData string false "Icon name"
+// @Param active formData bool false "Shown on the POS grid"
 // @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /products/{id} [patch]
 func (s *Server) UpdateProductHandler(c *gin.Context) {
 	id := c.Param("id")
-	var req dto.ProductRequest
-	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var req dto.ProductPatch
+	var changes repository.ProductChanges
+	var file *multipart.FileHeader
+
+	if c.ContentType() == gin.MIMEMultipartPOSTForm || c.ContentType() == gin.MIMEPOSTForm {
+		if err := c.ShouldBind(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		file, _ = c.FormFile("picture")
+	} else {
+		nulls, err := bindMergePatch(c, &req, "picture")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if nulls["picture"] {
+			noPicture := ""
+			changes.Picture = &noPicture
+		}
+	}
+	changes.SKU, changes.CategoryID, changes.Name, changes.Price = req.SKU, req.CategoryID, req.Name, req.Price
+	changes.Position, changes.Color, changes.Icon, changes.Active = req.Position, req.Color, req.Icon, req.Active
+	if changes == (repository.ProductChanges{}) && file == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to update"})
 		return
 	}
 
-	repo := repository.NewProductRepository(s.db.DB())
-	
-	// Ambil data lama untuk mendapatkan path gambar lama jika tidak ada upload baru
-	oldProduct, err := repo.GetByID(c.Request.Context(), id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	version, ok := s.ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	picturePath := oldProduct.Picture
-	file, _ := c.FormFile("picture")
 	if file != nil {
+		// pastikan produk ada dan versinya cocok sebelum menyimpan gambar;
+		// Update tetap memeriksa ulang versinya
+		oldProduct, err := s.products.GetByID(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+			return
+		}
+		if version != 0 && oldProduct.Version != version {
+			catalogWriteError(c, repository.ErrVersionConflict, "Product not found")
+			return
+		}
+
 		filename := fmt.Sprintf("%d%s", time.Now().Unix(), filepath.Ext(file.Filename))
-		picturePath = filepath.Join("uploads", filename)
-		c.SaveUploadedFile(file, picturePath)
+		picturePath := filepath.Join("uploads", filename)
+		if err := c.SaveUploadedFile(file, picturePath); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+			return
+		}
+		changes.Picture = &picturePath
 	}
 
-	err = repo.Update(c.Request.Context(), id, req.CategoryID, req.Name, req.Price, picturePath)
+	newVersion, err := s.products.Update(c.Request.Context(), id, changes, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		catalogWriteError(c, err, "Product not found")
 		return
 	}
+	c.Header("ETag", versionETag(newVersion))
 	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
 }
 
 // @Summary Delete product
 // @Tags Product
 // @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag of the version being deleted"
 // @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 428 {object} map[string]string
 // @Router /products/{id} [delete]
 func (s *Server) DeleteProductHandler(c *gin.Context) {
 	id := c.Param("id")
-	repo := repository.NewProductRepository(s.db.DB())
-	if err := repo.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	version, ok := s.ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	if err := s.products.Delete(c.Request.Context(), id, version); err != nil {
+		catalogWriteError(c, err, "Product not found")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
-}
\ No newline at end of file
+}
+
+// @Summary Reorder products
+// @Description Sets the positions of several products in one transaction. Nothing is changed when any ID does not exist or appears more than once.
+// @Tags Product
+// @Accept json
+// @Param body body dto.ReorderRequest true "New positions"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/reorder [post]
+func (s *Server) ReorderProductsHandler(c *gin.Context) {
+	var req dto.ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if id, ok := duplicatePosition(req.Items); ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID " + id + " appears more than once"})
+		return
+	}
+	if err := s.products.Reorder(c.Request.Context(), req.Items); err != nil {
+		catalogWriteError(c, err, "One or more products not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Products reordered"})
+}
+
+// @Summary Import products
+// @Description Creates or updates products from a CSV or XLSX file, matched by SKU. Columns: sku, name, category, price (required) and position, color, icon, active. category is a category name or a path such as "Minuman > Kopi". Every row is validated first; if any row is invalid nothing is saved and 422 lists the errors. The whole import runs in one transaction.
+// @Tags Product
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Validate and report without saving"
+// @Success 200 {object} catalogimport.Report
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} catalogimport.Report
+// @Router /products/import [post]
+func (s *Server) ImportProductsHandler(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	format, err := catalogimport.FormatOf(file.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry_run must be true or false"})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+	table, err := catalogimport.ReadTable(f, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot read file: " + err.Error()})
+		return
+	}
+
+	report, err := catalogimport.Run(c.Request.Context(), s.categories, s.products, table, dryRun)
+	switch {
+	case errors.Is(err, catalogimport.ErrEmptyFile), errors.Is(err, catalogimport.ErrTooManyRows):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case len(report.Errors) > 0:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// @Summary Export products
+// @Description Streams every matching product with its category path as CSV, NDJSON or XLSX. Takes the same filters as GET /products. The CSV and XLSX files can be edited and imported again with POST /products/import.
+// @Tags Product
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param category_id query string false "Only products in this category"
+// @Param include_descendants query bool false "Include products of subcategories (default true)"
+// @Param active query bool false "Only active products in visible categories"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /products/export [get]
+func (s *Server) ExportProductsHandler(c *gin.Context) {
+	streamExport(c, "products", catalogexport.ProductColumns, func(write func([]any) error) error {
+		return s.products.Export(c.Request.Context(), productFilter(c), func(p repository.ProductExport) error {
+			return write(catalogexport.ProductRow(p))
+		})
+	})
+}
+
+// productFilter membaca filter daftar produk dari query string; dipakai
+// bersama oleh GET /products dan ekspor.
+func productFilter(c *gin.Context) repository.ProductFilter {
+	return repository.ProductFilter{
+		CategoryID:  c.Query("category_id"),
+		Descendants: c.DefaultQuery("include_descendants", "true") != "false",
+		ActiveOnly:  activeOnly(c),
+	}
+}
+
+// @Summary Set outlet price
+// @Description Overrides the price of a product for the outlet selected with X-Outlet-ID, typically for a product of the central catalog.
+// @Tags Product
+// @Accept json
+// @Param id path string true "Product ID"
+// @Param X-Outlet-ID header string true "Outlet ID"
+// @Param body body dto.OutletPriceRequest true "Outlet price"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/{id}/outlet-price [put]
+func (s *Server) SetProductOutletPriceHandler(c *gin.Context) {
+	var req dto.OutletPriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	newVersion, err := s.products.SetOutletPrice(c.Request.Context(), c.Param("id"), req.Price)
+	if err != nil {
+		catalogWriteError(c, err, "Product not found")
+		return
+	}
+	c.Header("ETag", versionETag(newVersion))
+	c.JSON(http.StatusOK, gin.H{"message": "Outlet price updated"})
+}
+
+// @Summary Remove outlet price
+// @Description The product goes back to its own price for the outlet selected with X-Outlet-ID.
+// @Tags Product
+// @Param id path string true "Product ID"
+// @Param X-Outlet-ID header string true "Outlet ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /products/{id}/outlet-price [delete]
+func (s *Server) DeleteProductOutletPriceHandler(c *gin.Context) {
+	newVersion, err := s.products.DeleteOutletPrice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		catalogWriteError(c, err, "Product not found")
+		return
+	}
+	c.Header("ETag", versionETag(newVersion))
+	c.JSON(http.StatusOK, gin.H{"message": "Outlet price removed"})
+}
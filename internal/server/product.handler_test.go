@@ -0,0 +1,358 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/scope"
+)
+
+type fakeProductStore struct {
+	products  map[string]repository.Product
+	exportErr error
+}
+
+func (f *fakeProductStore) Create(ctx context.Context, p repository.Product) (string, error) {
+	p.ID, p.Version = "prod-new", 1
+	f.products[p.ID] = p
+	return p.ID, nil
+}
+
+func (f *fakeProductStore) GetAll(ctx context.Context, filter repository.ProductFilter) ([]repository.Product, error) {
+	var res []repository.Product
+	for _, p := range f.products {
+		if filter.CategoryID != "" && p.CategoryID != filter.CategoryID {
+			continue
+		}
+		if filter.ActiveOnly && !p.Active {
+			continue
+		}
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Position != res[j].Position {
+			return res[i].Position < res[j].Position
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+func (f *fakeProductStore) GetByID(ctx context.Context, id string) (*repository.Product, error) {
+	p, ok := f.products[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (f *fakeProductStore) Update(ctx context.Context, id string, changes repository.ProductChanges, version int) (int, error) {
+	p, ok := f.products[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+	if version != 0 && p.Version != version {
+		return 0, repository.ErrVersionConflict
+	}
+	if changes.CategoryID != nil {
+		p.CategoryID = *changes.CategoryID
+	}
+	if changes.Name != nil {
+		p.Name = *changes.Name
+	}
+	if changes.Price != nil {
+		p.Price = *changes.Price
+	}
+	if changes.Picture != nil {
+		p.Picture = *changes.Picture
+	}
+	if changes.Active != nil {
+		p.Active = *changes.Active
+	}
+	p.Version++
+	f.products[id] = p
+	return p.Version, nil
+}
+
+func (f *fakeProductStore) Delete(ctx context.Context, id string, version int) error {
+	delete(f.products, id)
+	return nil
+}
+
+func (f *fakeProductStore) Reorder(ctx context.Context, positions []repository.Position) error {
+	for _, pos := range positions {
+		if _, ok := f.products[pos.ID]; !ok {
+			return sql.ErrNoRows
+		}
+	}
+	for _, pos := range positions {
+		p := f.products[pos.ID]
+		p.Position = pos.Position
+		p.Version++
+		f.products[pos.ID] = p
+	}
+	return nil
+}
+
+func (f *fakeProductStore) Import(ctx context.Context, items []repository.ProductImport, dryRun bool) (repository.ImportResult, error) {
+	var res repository.ImportResult
+	for _, it := range items {
+		id := "prod-" + it.SKU
+		p, exists := f.products[id]
+		if exists {
+			res.Updated++
+		} else {
+			res.Created++
+			p = repository.Product{ID: id, SKU: it.SKU, Active: true}
+		}
+		p.CategoryID, p.Name, p.Price = it.CategoryID, it.Name, it.Price
+		p.Version++
+		if !dryRun {
+			f.products[id] = p
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeProductStore) Export(ctx context.Context, filter repository.ProductFilter, fn func(repository.ProductExport) error) error {
+	products, _ := f.GetAll(ctx, filter)
+	for _, p := range products {
+		if err := fn(repository.ProductExport{Product: p, CategoryPath: "Minuman"}); err != nil {
+			return err
+		}
+	}
+	return f.exportErr
+}
+
+func (f *fakeProductStore) SetOutletPrice(ctx context.Context, id string, price float64) (int, error) {
+	if scope.Outlet(ctx) == "" {
+		return 0, repository.ErrOutletRequired
+	}
+	return f.Update(ctx, id, repository.ProductChanges{Price: &price}, 0)
+}
+
+func (f *fakeProductStore) DeleteOutletPrice(ctx context.Context, id string) (int, error) {
+	if scope.Outlet(ctx) == "" {
+		return 0, repository.ErrOutletRequired
+	}
+	return f.Update(ctx, id, repository.ProductChanges{}, 0)
+}
+
+func TestUpdateProductHandlerPartial(t *testing.T) {
+	store := &fakeProductStore{products: map[string]repository.Product{
+		"prod-1": {ID: "prod-1", CategoryID: "cat-1", Name: "Kopi Susu", Price: 18000, Picture: "uploads/kopi.jpg", Version: 1},
+	}}
+	s := &Server{products: store}
+	r := gin.New()
+	r.PATCH("/products/:id", s.UpdateProductHandler)
+
+	patchJSON := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/products/prod-1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := patchJSON(`{"price": 20000}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected price-only patch to succeed, got %d: %s", rr.Code, rr.Body)
+	}
+	got := store.products["prod-1"]
+	if got.Price != 20000 || got.Name != "Kopi Susu" || got.Picture != "uploads/kopi.jpg" {
+		t.Errorf("expected only the price to change, got %+v", got)
+	}
+
+	for _, body := range []string{`{}`, `{"name": null}`, `{"price": -1}`, `{"stock": 3}`, `[]`} {
+		if rr := patchJSON(body); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+
+	if rr := patchJSON(`{"picture": null}`); rr.Code != http.StatusOK || store.products["prod-1"].Picture != "" {
+		t.Errorf("expected picture to be removed, got %d %+v", rr.Code, store.products["prod-1"])
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.WriteField("name", "Kopi Susu Aren")
+	w.Close()
+	req := httptest.NewRequest(http.MethodPatch, "/products/prod-1", &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected multipart patch without picture to succeed, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := store.products["prod-1"]; got.Name != "Kopi Susu Aren" || got.Price != 20000 {
+		t.Errorf("expected only the name to change, got %+v", got)
+	}
+}
+
+func TestProductDisplaySettings(t *testing.T) {
+	const (
+		kopi = "5b1e2f3a-4c5d-4e6f-8a7b-9c0d1e2f3a41"
+		teh  = "5b1e2f3a-4c5d-4e6f-8a7b-9c0d1e2f3a42"
+	)
+	store := &fakeProductStore{products: map[string]repository.Product{
+		kopi: {ID: kopi, CategoryID: "cat-1", Name: "Kopi Susu", Position: 0, Active: true, Version: 1},
+		teh:  {ID: teh, CategoryID: "cat-1", Name: "Es Teh", Position: 1, Active: true, Version: 1},
+	}}
+	s := &Server{products: store}
+	r := gin.New()
+	r.GET("/products", s.GetAllProductsHandler)
+	r.POST("/products/reorder", s.ReorderProductsHandler)
+	r.PATCH("/products/:id", s.UpdateProductHandler)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	list := func(query string) []repository.Product {
+		var got []repository.Product
+		if err := json.Unmarshal(send(http.MethodGet, "/products"+query, "").Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if rr := send(http.MethodPost, "/products/reorder", `{"items":[{"id":"`+teh+`","position":0},{"id":"`+kopi+`","position":1}]}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected reorder to succeed, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := list(""); len(got) != 2 || got[0].ID != teh || got[0].Version != 2 {
+		t.Errorf("expected Es Teh first with a bumped version, got %+v", got)
+	}
+	if rr := send(http.MethodPost, "/products/reorder", `{"items":[{"id":"`+kopi+`","position":0},{"id":"`+kopi+`","position":1}]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a repeated ID, got %d: %s", rr.Code, rr.Body)
+	}
+
+	if rr := send(http.MethodPatch, "/products/"+kopi, `{"active":false}`); rr.Code != http.StatusOK {
+		t.Fatalf("expected hiding a product to succeed, got %d: %s", rr.Code, rr.Body)
+	}
+	if got := list("?active=true"); len(got) != 1 || got[0].ID != teh {
+		t.Errorf("expected only Es Teh on the POS grid, got %+v", got)
+	}
+	if got := list(""); len(got) != 2 {
+		t.Errorf("expected hidden products in the back-office list, got %+v", got)
+	}
+}
+
+func TestImportProductsHandler(t *testing.T) {
+	products := &fakeProductStore{products: map[string]repository.Product{
+		"prod-K-1": {ID: "prod-K-1", SKU: "K-1", CategoryID: "cat-1", Name: "Kopi", Price: 15000, Active: true, Version: 3},
+	}}
+	categories := &fakeCategoryStore{categories: map[string]repository.Category{
+		"cat-1": {ID: "cat-1", Name: "Minuman", Version: 1},
+	}}
+	s := &Server{products: products, categories: categories}
+	r := gin.New()
+	r.POST("/products/import", s.ImportProductsHandler)
+
+	upload := func(query, filename, content string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		fw, _ := w.CreateFormFile("file", filename)
+		fw.Write([]byte(content))
+		w.Close()
+		req := httptest.NewRequest(http.MethodPost, "/products/import"+query, &buf)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	const csv = "sku,name,category,price\nK-1,Kopi Susu,Minuman,18000\nT-1,Es Teh,minuman,8000\n"
+
+	rr := upload("?dry_run=true", "produk.csv", csv)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"created":1,"updated":1`) {
+		t.Fatalf("expected a dry-run report, got %d: %s", rr.Code, rr.Body)
+	}
+	if len(products.products) != 1 || products.products["prod-K-1"].Price != 15000 {
+		t.Fatal("dry run must not change the catalog")
+	}
+
+	rr = upload("", "produk.csv", csv+"T-2,Teh Tarik,Makanan,-5\n")
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), `"row":4,"column":"category"`) {
+		t.Errorf("expected row errors, got %d: %s", rr.Code, rr.Body)
+	}
+	if len(products.products) != 1 {
+		t.Error("an import with invalid rows must save nothing")
+	}
+
+	if rr := upload("", "produk.csv", csv); rr.Code != http.StatusOK || products.products["prod-K-1"].Price != 18000 || len(products.products) != 2 {
+		t.Errorf("expected K-1 updated and T-1 created, got %d: %s", rr.Code, rr.Body)
+	}
+
+	if rr := upload("", "produk.txt", csv); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unsupported file type, got %d", rr.Code)
+	}
+}
+
+func TestExportProductsHandler(t *testing.T) {
+	store := &fakeProductStore{products: map[string]repository.Product{
+		"prod-1": {ID: "prod-1", SKU: "K-1", CategoryID: "cat-1", Name: "Kopi Susu", Price: 18000, Active: true, Version: 1},
+		"prod-2": {ID: "prod-2", SKU: "T-1", CategoryID: "cat-1", Name: "Es Teh", Price: 8000, Position: 1, Version: 1},
+	}}
+	s := &Server{products: store}
+	r := gin.New()
+	r.GET("/products/export", s.ExportProductsHandler)
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/products/export"+query, nil))
+		return rr
+	}
+
+	rr := get("?active=true")
+	want := "sku,name,category,price,position,color,icon,active,id,category_id,picture,version\n" +
+		"K-1,Kopi Susu,Minuman,18000,0,,,true,prod-1,cat-1,,1\n"
+	if rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("unexpected CSV export %d:\n%s", rr.Code, rr.Body)
+	}
+	if got := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="products-`) {
+		t.Errorf("expected a download, got Content-Disposition %q", got)
+	}
+
+	rr = get("?format=ndjson")
+	if lines := strings.Count(rr.Body.String(), "\n"); rr.Code != http.StatusOK || lines != 2 || rr.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("expected 2 NDJSON lines, got %d %q", rr.Code, rr.Body)
+	}
+	if rr := get("?format=pdf"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rr.Code)
+	}
+
+	// gagal sebelum baris pertama masih bisa dibalas sebagai error biasa
+	store.exportErr = errors.New("connection reset")
+	empty := &fakeProductStore{products: map[string]repository.Product{}, exportErr: store.exportErr}
+	s.products = empty
+	if rr := get(""); rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("expected a plain 500, got %d %v", rr.Code, rr.Header())
+	}
+
+	// setelah data terkirim, respons diputus supaya file terpotong tidak
+	// terlihat lengkap
+	for i := range 500 {
+		id := fmt.Sprintf("prod-%03d", i)
+		store.products[id] = repository.Product{ID: id, Name: "Produk " + id, Price: 1000}
+	}
+	s.products = store
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Errorf("expected the handler to abort the response, got %v", err)
+		}
+	}()
+	get("")
+}
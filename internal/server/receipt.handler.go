@@ -0,0 +1,372 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/order"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/scope"
+	"maspos-be-go/internal/server/dto"
+)
+
+// @Summary Get outlet receipt template
+// @Description Outlets without a template get the default: outlet name and address as header, "Terima kasih" as footer, 80 mm paper.
+// @Tags Receipt
+// @Produce json
+// @Param id path string true "Outlet ID"
+// @Success 200 {object} receipt.Template
+// @Failure 404 {object} map[string]string
+// @Router /outlets/{id}/receipt-template [get]
+func (s *Server) GetReceiptTemplateHandler(c *gin.Context) {
+	t, err := s.receipts.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		receiptTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// @Summary Update outlet receipt template
+// @Description An outlet may change its own template; the head office may change any outlet's.
+// @Tags Receipt
+// @Accept json
+// @Param id path string true "Outlet ID"
+// @Param body body dto.ReceiptTemplateRequest true "Header and footer lines, paper width"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /outlets/{id}/receipt-template [put]
+func (s *Server) UpdateReceiptTemplateHandler(c *gin.Context) {
+	var req dto.ReceiptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t := receipt.Template{Header: req.Header, Footer: req.Footer, Paper: req.Paper}
+	if err := s.receipts.Put(c.Request.Context(), c.Param("id"), t); err != nil {
+		receiptTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Receipt template updated"})
+}
+
+func receiptTemplateError(c *gin.Context, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Outlet not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// @Summary Preview receipt
+// @Description Preview only: renders the sale sent in the body, which is not checked against any stored order, with the receipt template of the active outlet. Receipts of completed orders are printed with GET /orders/{id}/receipt. escpos returns printer commands ready to be sent to a thermal printer; html and pdf are meant for email.
+// @Tags Receipt
+// @Accept json
+// @Produce application/vnd.escpos,text/plain,text/html,application/pdf
+// @Param body body dto.ReceiptRequest true "Sale to preview"
+// @Param format query string false "Output format, default escpos" Enums(escpos, text, html, pdf)
+// @Param paper query int false "Paper width in mm, default from the outlet template" Enums(58, 80)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Router /receipts/render [post]
+func (s *Server) RenderReceiptHandler(c *gin.Context) {
+	var req dto.ReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	outlet := scope.Outlet(c.Request.Context())
+	if outlet == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": repository.ErrOutletRequired.Error()})
+		return
+	}
+	s.renderReceipt(c, outlet, receiptFromRequest(req))
+}
+
+// @Summary Print order receipt
+// @Description Renders a completed order with the receipt template of the order's outlet: its lines with modifiers and discounts, taxes, tenders, change and cashier. escpos returns printer commands ready to be sent to a thermal printer; html and pdf are meant for email.
+// @Tags Order
+// @Produce application/vnd.escpos,text/plain,text/html,application/pdf
+// @Param id path string true "Order ID"
+// @Param format query string false "Output format, default escpos" Enums(escpos, text, html, pdf)
+// @Param paper query int false "Paper width in mm, default from the outlet template" Enums(58, 80)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orders/{id}/receipt [get]
+func (s *Server) GetOrderReceiptHandler(c *gin.Context) {
+	o, err := s.orders.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	s.renderReceipt(c, o.OutletID, receiptFromOrder(o))
+}
+
+// renderReceipt menulis struk dengan template outlet dalam format dan
+// lebar kertas dari query.
+func (s *Server) renderReceipt(c *gin.Context, outletID string, r receipt.Receipt) {
+	format := c.DefaultQuery("format", receipt.FormatESCPOS)
+	contentType, err := receipt.ContentType(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	t, err := s.receipts.Get(c.Request.Context(), outletID)
+	if err != nil {
+		receiptTemplateError(c, err)
+		return
+	}
+	paper := t.Paper
+	if p := c.Query("paper"); p != "" {
+		if paper, err = strconv.Atoi(p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": receipt.ErrUnknownPaper.Error()})
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := receipt.Render(&buf, format, paper, r, *t); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// receiptFromOrder menyusun struk dari order yang tersimpan. Pembayaran
+// poin dicetak dengan jumlah poinnya.
+func receiptFromOrder(o *repository.Order) receipt.Receipt {
+	r := receipt.Receipt{Number: o.Number, Time: o.CreatedAt, Cashier: o.Cashier, Taxes: []receipt.Tax{}, Tenders: []receipt.Tender{}}
+	for _, l := range o.Lines {
+		line := receipt.Line{Name: l.Name, Quantity: l.Quantity, UnitPrice: l.UnitPrice, Discount: l.Discount, Note: l.Note}
+		for _, m := range l.Modifiers {
+			line.Modifiers = append(line.Modifiers, receipt.Modifier{Name: m.Name, Price: m.Price})
+		}
+		r.Lines = append(r.Lines, line)
+	}
+	for _, t := range o.Taxes {
+		r.Taxes = append(r.Taxes, receipt.Tax{Name: t.Name, Amount: t.Amount})
+	}
+	for _, t := range o.Tenders {
+		method := t.Method
+		if t.Method == order.TenderLoyalty {
+			method = fmt.Sprintf("%s (%d poin)", t.Method, t.Points)
+		}
+		r.Tenders = append(r.Tenders, receipt.Tender{Method: method, Amount: t.Amount})
+	}
+	return r
+}
+
+func receiptFromRequest(req dto.ReceiptRequest) receipt.Receipt {
+	r := receipt.Receipt{
+		Number:  req.Number,
+		Time:    time.Now(),
+		Cashier: req.Cashier,
+		Lines:   req.Lines,
+		Taxes:   req.Taxes,
+		Tenders: req.Tenders,
+	}
+	if req.Time != nil {
+		r.Time = *req.Time
+	}
+	return r
+}
+
+// mailEnabled menjawab 503 bila SMTP belum dikonfigurasi, supaya struk
+// tidak menumpuk di antrean yang tidak pernah dikirim.
+func (s *Server) mailEnabled(c *gin.Context) bool {
+	if s.cfg == nil || !s.cfg.Mail.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "email receipts are not configured"})
+		return false
+	}
+	return true
+}
+
+// @Summary Email order receipt
+// @Description Queues the receipt of a completed order to be emailed as HTML with a PDF attachment, rendered from the stored order. Without email the receipt goes to the order's customer. Failed deliveries are retried in the background.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body dto.ReceiptEmailRequest false "Other address than the customer's"
+// @Success 202 {object} dto.ReceiptEmailResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /orders/{id}/receipt-emails [post]
+func (s *Server) EmailOrderReceiptHandler(c *gin.Context) {
+	var req dto.ReceiptEmailRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !s.mailEnabled(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	o, err := s.orders.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	email := req.Email
+	if email == "" && o.CustomerID != nil {
+		customer, err := s.customers.GetByID(ctx, *o.CustomerID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if customer != nil {
+			email = customer.Email
+		}
+	}
+	if email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required when the order has no customer email"})
+		return
+	}
+	s.enqueueOrderReceipt(c, o, email)
+}
+
+// @Summary Resend order receipt
+// @Description Queues the receipt of the order again, rendered from the stored order, to email or to the address of its latest delivery. Earlier deliveries are kept.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body dto.ReceiptResendRequest false "Other address"
+// @Success 202 {object} dto.ReceiptEmailResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /orders/{id}/receipt-emails/resend [post]
+func (s *Server) ResendOrderReceiptHandler(c *gin.Context) {
+	var req dto.ReceiptResendRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !s.mailEnabled(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	o, err := s.orders.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		orderError(c, err)
+		return
+	}
+	email := req.Email
+	if email == "" {
+		sent, err := s.emails.GetAll(ctx, repository.ReceiptEmailFilter{OrderID: o.ID})
+		if err != nil {
+			receiptEmailError(c, err)
+			return
+		}
+		if len(sent) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order receipt was never emailed"})
+			return
+		}
+		email = sent[0].Email
+	}
+	s.enqueueOrderReceipt(c, o, email)
+}
+
+func (s *Server) enqueueOrderReceipt(c *gin.Context, o *repository.Order, email string) {
+	id, err := s.emails.Enqueue(c.Request.Context(), o, email, receiptFromOrder(o), currentUserID(c))
+	if err != nil {
+		receiptEmailError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, dto.ReceiptEmailResponse{ID: id, Status: repository.EmailPending})
+}
+
+// @Summary Get order receipt emails
+// @Description Deliveries of the order's receipt with their status, newest first.
+// @Tags Order
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} repository.ReceiptEmail
+// @Router /orders/{id}/receipt-emails [get]
+func (s *Server) GetOrderReceiptEmailsHandler(c *gin.Context) {
+	emails, err := s.emails.GetAll(c.Request.Context(), repository.ReceiptEmailFilter{OrderID: c.Param("id")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if emails == nil {
+		emails = []repository.ReceiptEmail{}
+	}
+	c.JSON(http.StatusOK, emails)
+}
+
+// @Summary Get receipt emails
+// @Description Deliveries with their status, newest first. Inside an outlet only its deliveries are listed.
+// @Tags Receipt
+// @Produce json
+// @Param number query string false "Only deliveries of this receipt number"
+// @Param order_id query string false "Only deliveries of this order"
+// @Success 200 {array} repository.ReceiptEmail
+// @Router /receipts/emails [get]
+func (s *Server) GetReceiptEmailsHandler(c *gin.Context) {
+	f := repository.ReceiptEmailFilter{Number: c.Query("number"), OrderID: c.Query("order_id")}
+	emails, err := s.emails.GetAll(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if emails == nil {
+		emails = []repository.ReceiptEmail{}
+	}
+	c.JSON(http.StatusOK, emails)
+}
+
+// @Summary Resend receipt email
+// @Description Queues the receipt of an earlier delivery again, to a new address when email is given. The earlier delivery is kept.
+// @Tags Receipt
+// @Accept json
+// @Produce json
+// @Param id path string true "Receipt email ID"
+// @Param body body dto.ReceiptResendRequest false "Other address"
+// @Success 202 {object} dto.ReceiptEmailResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /receipts/emails/{id}/resend [post]
+func (s *Server) ResendReceiptEmailHandler(c *gin.Context) {
+	var req dto.ReceiptResendRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !s.mailEnabled(c) {
+		return
+	}
+	id, err := s.emails.Resend(c.Request.Context(), c.Param("id"), req.Email, currentUserID(c))
+	if err != nil {
+		receiptEmailError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, dto.ReceiptEmailResponse{ID: id, Status: repository.EmailPending})
+}
+
+func receiptEmailError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Receipt email not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
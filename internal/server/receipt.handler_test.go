@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/scope"
+)
+
+// fakeReceiptTemplateStore meniru ReceiptTemplateRepository: outlet hanya
+// melihat templatenya sendiri dan outlet tanpa template mendapat bawaan.
+type fakeReceiptTemplateStore struct {
+	outlets   map[string]repository.Outlet
+	templates map[string]receipt.Template
+}
+
+func (f *fakeReceiptTemplateStore) visible(ctx context.Context, outletID string) (repository.Outlet, error) {
+	o, ok := f.outlets[outletID]
+	if !ok || (scope.Outlet(ctx) != "" && scope.Outlet(ctx) != outletID) {
+		return o, sql.ErrNoRows
+	}
+	return o, nil
+}
+
+func (f *fakeReceiptTemplateStore) Get(ctx context.Context, outletID string) (*receipt.Template, error) {
+	o, err := f.visible(ctx, outletID)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := f.templates[outletID]; ok {
+		return &t, nil
+	}
+	return &receipt.Template{Header: []string{o.Name, o.Address}, Footer: []string{"Terima kasih"}, Paper: receipt.Paper80}, nil
+}
+
+func (f *fakeReceiptTemplateStore) Put(ctx context.Context, outletID string, t receipt.Template) error {
+	if _, err := f.visible(ctx, outletID); err != nil {
+		return err
+	}
+	f.templates[outletID] = t
+	return nil
+}
+
+// fakeReceiptEmailStore meniru antrean ReceiptEmailRepository tanpa
+// worker: semua pengiriman tetap pending.
+type fakeReceiptEmailStore struct {
+	emails []repository.ReceiptEmail
+}
+
+func (f *fakeReceiptEmailStore) Enqueue(ctx context.Context, o *repository.Order, email string, r receipt.Receipt, userID *int) (string, error) {
+	id := fmt.Sprintf("email-%d", len(f.emails)+1)
+	orderID := o.ID
+	f.emails = append(f.emails, repository.ReceiptEmail{ID: id, OutletID: o.OutletID, OrderID: &orderID, Number: r.Number,
+		Email: email, Receipt: &r, Status: repository.EmailPending})
+	return id, nil
+}
+
+// GetAll mengembalikan pengiriman terbaru lebih dulu, seperti repository.
+func (f *fakeReceiptEmailStore) GetAll(ctx context.Context, filter repository.ReceiptEmailFilter) ([]repository.ReceiptEmail, error) {
+	var res []repository.ReceiptEmail
+	for i := len(f.emails) - 1; i >= 0; i-- {
+		e := f.emails[i]
+		if (scope.Outlet(ctx) == "" || e.OutletID == scope.Outlet(ctx)) && (filter.Number == "" || e.Number == filter.Number) &&
+			(filter.OrderID == "" || e.OrderID != nil && *e.OrderID == filter.OrderID) {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeReceiptEmailStore) Resend(ctx context.Context, id, email string, userID *int) (string, error) {
+	for _, e := range f.emails {
+		if e.ID != id || (scope.Outlet(ctx) != "" && e.OutletID != scope.Outlet(ctx)) {
+			continue
+		}
+		if email == "" {
+			email = e.Email
+		}
+		return f.Enqueue(ctx, &repository.Order{ID: *e.OrderID, OutletID: e.OutletID}, email, *e.Receipt, userID)
+	}
+	return "", sql.ErrNoRows
+}
+
+func receiptRouter(s *Server) *gin.Engine {
+	r := gin.New()
+	r.Use(asHeadOffice(), s.outletScope())
+	r.GET("/outlets/:id/receipt-template", s.GetReceiptTemplateHandler)
+	r.PUT("/outlets/:id/receipt-template", s.UpdateReceiptTemplateHandler)
+	r.POST("/receipts/render", s.RenderReceiptHandler)
+	r.GET("/receipts/emails", s.GetReceiptEmailsHandler)
+	r.POST("/receipts/emails/:id/resend", s.ResendReceiptEmailHandler)
+	return r
+}
+
+func TestReceiptTemplateAndRender(t *testing.T) {
+	outlets := map[string]repository.Outlet{
+		outletKemang: {ID: outletKemang, Name: "Kopi Senja Kemang", Address: "Jl. Kemang Raya No. 8"},
+		outletDepok:  {ID: outletDepok, Name: "Kopi Senja Depok"},
+	}
+	s := &Server{
+		outlets:  &fakeOutletStore{outlets: outlets},
+		receipts: &fakeReceiptTemplateStore{outlets: outlets, templates: map[string]receipt.Template{}},
+	}
+	r := receiptRouter(s)
+
+	do := func(method, path, outlet, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if outlet != "" {
+			req.Header.Set(outletHeader, outlet)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	expectCode := func(rr *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if rr.Code != code {
+			t.Fatalf("expected %d, got %d: %s", code, rr.Code, rr.Body)
+		}
+	}
+
+	rr := do(http.MethodGet, "/outlets/"+outletKemang+"/receipt-template", "", "")
+	expectCode(rr, http.StatusOK)
+	var tmpl receipt.Template
+	json.Unmarshal(rr.Body.Bytes(), &tmpl)
+	if tmpl.Paper != 80 || tmpl.Header[0] != "Kopi Senja Kemang" || tmpl.Footer[0] != "Terima kasih" {
+		t.Errorf("unexpected default template: %+v", tmpl)
+	}
+
+	path := "/outlets/" + outletDepok + "/receipt-template"
+	expectCode(do(http.MethodPut, path, outletDepok, `{"header":["Kopi Senja"],"footer":[],"paper":76}`), http.StatusBadRequest)
+	expectCode(do(http.MethodPut, path, outletDepok, `{"header":["Kopi\nSenja"],"footer":[],"paper":58}`), http.StatusBadRequest)
+	// outlet lain tidak boleh mengubah template Depok
+	expectCode(do(http.MethodPut, path, outletKemang, `{"header":["Kopi Senja"],"paper":58}`), http.StatusNotFound)
+	expectCode(do(http.MethodPut, path, outletDepok, `{"header":["KOPI SENJA","Depok"],"footer":["Wifi: senja2026"],"paper":58}`), http.StatusOK)
+
+	sale := `{"number":"INV-1","time":"2026-01-15T09:30:00+07:00","cashier":"Rina",
+		"lines":[{"name":"Es Kopi Susu","quantity":2,"unit_price":18000,"modifiers":[{"name":"Extra shot","price":5000}]}],
+		"taxes":[{"name":"PB1 10%","amount":4600}],
+		"tenders":[{"method":"Tunai","amount":60000}]}`
+
+	// kantor pusat tidak punya printer struk
+	expectCode(do(http.MethodPost, "/receipts/render", "", sale), http.StatusBadRequest)
+	expectCode(do(http.MethodPost, "/receipts/render?format=docx", outletDepok, sale), http.StatusBadRequest)
+	expectCode(do(http.MethodPost, "/receipts/render?paper=76", outletDepok, sale), http.StatusBadRequest)
+	expectCode(do(http.MethodPost, "/receipts/render", outletDepok, `{"number":"INV-1","lines":[]}`), http.StatusBadRequest)
+	expectCode(do(http.MethodPost, "/receipts/render", outletDepok, `{"number":"INV-1","lines":[{"name":"Es Kopi Susu","quantity":0}]}`), http.StatusBadRequest)
+
+	rr = do(http.MethodPost, "/receipts/render?format=text", outletDepok, sale)
+	expectCode(rr, http.StatusOK)
+	if ct := rr.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	text := rr.Body.String()
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if !strings.Contains(lines[0], "KOPI SENJA") || !strings.Contains(text, "Wifi: senja2026") {
+		t.Errorf("expected the Depok template on the receipt:\n%s", text)
+	}
+	for _, l := range lines {
+		if len([]rune(l)) > 32 {
+			t.Errorf("expected the 58 mm width of the template, got %q", l)
+		}
+	}
+	if !strings.Contains(text, "50.600") || !strings.Contains(text, "9.400") {
+		t.Errorf("expected total 50.600 and change 9.400:\n%s", text)
+	}
+
+	// paper di query menimpa kertas template; escpos adalah format bawaan
+	rr = do(http.MethodPost, "/receipts/render?paper=80", outletDepok, sale)
+	expectCode(rr, http.StatusOK)
+	if ct := rr.Header().Get("Content-Type"); ct != "application/vnd.escpos" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+	if !bytes.HasPrefix(rr.Body.Bytes(), []byte{0x1b, '@'}) || !bytes.Contains(rr.Body.Bytes(), []byte(strings.Repeat("-", 48))) {
+		t.Errorf("expected 80 mm ESC/POS output, got %q", rr.Body.Bytes())
+	}
+}
+
+func TestReceiptEmail(t *testing.T) {
+	s, r, shiftID := orderTestServer(t)
+	store := &fakeReceiptEmailStore{}
+	s.emails = store
+	const customer = "7c0a1e2b-3d4f-4a5b-8c6d-7e8f9a0b1c02"
+	s.customers = &fakeCustomerStore{customers: map[string]repository.Customer{
+		customer: {ID: customer, Name: "Budi", Email: "budi@example.com"},
+	}}
+	r.POST("/orders/:id/receipt-emails", s.EmailOrderReceiptHandler)
+	r.GET("/orders/:id/receipt-emails", s.GetOrderReceiptEmailsHandler)
+	r.POST("/orders/:id/receipt-emails/resend", s.ResendOrderReceiptHandler)
+	r.GET("/receipts/emails", s.GetReceiptEmailsHandler)
+	r.POST("/receipts/emails/:id/resend", s.ResendReceiptEmailHandler)
+
+	checkout := func(customerID string) repository.Order {
+		t.Helper()
+		body := `{"shift_id":"` + shiftID + `","customer_id":"` + customerID + `",
+			"lines":[{"product_id":"` + productKopiSusu + `","quantity":2}],"tenders":[{"method":"cash","amount":40000}]}`
+		rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+		}
+		var o repository.Order
+		json.Unmarshal(rr.Body.Bytes(), &o)
+		return o
+	}
+	expectCode := func(rr *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if rr.Code != code {
+			t.Fatalf("expected %d, got %d: %s", code, rr.Code, rr.Body)
+		}
+	}
+	o := checkout(customer)
+	send := "/orders/" + o.ID + "/receipt-emails"
+
+	// tanpa SMTP struk tidak diantrekan
+	expectCode(sendJSON(r, http.MethodPost, send, outletKemang, ""), http.StatusServiceUnavailable)
+
+	s.cfg = &config.Config{Mail: config.Mail{SMTPHost: "smtp.example.com"}}
+	expectCode(sendJSON(r, http.MethodPost, send, outletKemang, `{"email":"bukan email"}`), http.StatusBadRequest)
+	expectCode(sendJSON(r, http.MethodPost, send, outletDepok, ""), http.StatusNotFound)
+	expectCode(sendJSON(r, http.MethodPost, "/orders/"+o.ID+"/receipt-emails/resend", outletKemang, ""), http.StatusNotFound)
+
+	// tanpa email struk dikirim ke pelanggan order; isinya dari order
+	rr := sendJSON(r, http.MethodPost, send, outletKemang, "")
+	expectCode(rr, http.StatusAccepted)
+	var queued struct{ ID, Status string }
+	json.Unmarshal(rr.Body.Bytes(), &queued)
+	first := store.emails[0]
+	if queued.Status != repository.EmailPending || first.Email != "budi@example.com" || first.Number != o.Number ||
+		first.Receipt.Lines[0].Name != "Kopi Susu" || first.Receipt.Total() != o.Total {
+		t.Errorf("unexpected delivery: %+v", first)
+	}
+
+	resend := "/orders/" + o.ID + "/receipt-emails/resend"
+	expectCode(sendJSON(r, http.MethodPost, resend, outletKemang, `{"email":"sari@example.com"}`), http.StatusAccepted)
+	expectCode(sendJSON(r, http.MethodPost, resend, outletKemang, ""), http.StatusAccepted)
+	expectCode(sendJSON(r, http.MethodPost, "/receipts/emails/"+queued.ID+"/resend", outletDepok, ""), http.StatusNotFound)
+	expectCode(sendJSON(r, http.MethodPost, "/receipts/emails/"+queued.ID+"/resend", outletKemang, ""), http.StatusAccepted)
+
+	rr = sendJSON(r, http.MethodGet, send, outletKemang, "")
+	expectCode(rr, http.StatusOK)
+	var list []repository.ReceiptEmail
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	// terbaru lebih dulu; kirim ulang tanpa email memakai alamat terakhir
+	if len(list) != 4 || list[3].Email != "budi@example.com" || list[2].Email != "sari@example.com" ||
+		list[1].Email != "sari@example.com" || list[0].Email != "budi@example.com" {
+		t.Errorf("expected the original and three resends, got %+v", list)
+	}
+	if strings.Contains(rr.Body.String(), "Kopi Susu") {
+		t.Errorf("expected the list without the receipt itself: %s", rr.Body)
+	}
+	rr = sendJSON(r, http.MethodGet, "/receipts/emails", outletDepok, "")
+	expectCode(rr, http.StatusOK)
+	if rr.Body.String() != "[]" {
+		t.Errorf("expected Depok to see no deliveries, got %s", rr.Body)
+	}
+
+	// order tanpa pelanggan butuh alamat
+	anonymous := checkout("")
+	expectCode(sendJSON(r, http.MethodPost, "/orders/"+anonymous.ID+"/receipt-emails", outletKemang, ""), http.StatusBadRequest)
+	expectCode(sendJSON(r, http.MethodPost, "/orders/"+anonymous.ID+"/receipt-emails", outletKemang, `{"email":"tamu@example.com"}`), http.StatusAccepted)
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/order"
+	"maspos-be-go/internal/scope"
+	"maspos-be-go/internal/server/dto"
+	"maspos-be-go/internal/utils"
+)
+
+func refundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrShiftClosed), errors.Is(err, repository.ErrDayClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrRefundLine), errors.Is(err, repository.ErrRefundShift),
+		errors.Is(err, repository.ErrRefundTender), errors.Is(err, repository.ErrInsufficientCash),
+		errors.Is(err, order.ErrRefundQuantity):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// refundApprover memeriksa kredensial supervisor yang menyetujui refund.
+// Hanya supervisor yang ditugaskan ke outlet aktif dan user kantor pusat
+// yang boleh menyetujui. Password yang salah dihitung seperti login yang
+// gagal, jadi kunci login email itu juga berlaku di sini.
+func (s *Server) refundApprover(c *gin.Context, a dto.ApprovalRequest) (*repository.User, bool) {
+	ctx := c.Request.Context()
+	lockKey := loginLockKey(scope.Tenant(ctx), a.Email)
+	if s.loginLocked(c, lockKey) {
+		return nil, false
+	}
+	user, err := s.users.GetByEmail(ctx, a.Email)
+	if err != nil || utils.CheckPassword(a.Password, user.Password) != nil {
+		s.loginFailed(c, lockKey)
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid approval email or password"})
+		return nil, false
+	}
+	s.loginReset(c, lockKey)
+	if user.HeadOffice {
+		return user, true
+	}
+	if !user.Supervisor {
+		c.JSON(http.StatusForbidden, gin.H{"error": "refunds must be approved by a supervisor"})
+		return nil, false
+	}
+	outlets, err := s.outlets.IDsForUser(ctx, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if outlet := scope.Outlet(ctx); outlet == "" || !slices.Contains(outlets, outlet) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "the supervisor is not assigned to this outlet"})
+		return nil, false
+	}
+	return user, true
+}
+
+// @Summary Refund order lines
+// @Description Returns some or all units of the lines of an order. Line amounts are prorated from the line total after discount and the order tax is refunded in proportion. A line cannot be refunded beyond the units not refunded yet. The refund is paid with one method of the order and cannot exceed what was paid with it; cash comes out of the drawer of the given open shift of the order's outlet, a loyalty refund gives the redeemed points back. Points earned on the order are taken back in proportion, up to the customer's balance. Goods go back to stock when restock is set, by default for wrong_item and changed_mind. A supervisor of the outlet or a head office user approves with their email and password; wrong passwords count towards the login lockout of that email.
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param body body dto.RefundRequest true "Lines, method and approval"
+// @Success 201 {object} repository.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /orders/{id}/refunds [post]
+func (s *Server) CreateRefundHandler(c *gin.Context) {
+	var req dto.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	approver, ok := s.refundApprover(c, req.Approval)
+	if !ok {
+		return
+	}
+	in := repository.NewRefund{
+		OrderID:    c.Param("id"),
+		ShiftID:    req.ShiftID,
+		Reason:     req.Reason,
+		Method:     req.Method,
+		Reference:  req.Reference,
+		Note:       req.Note,
+		ApprovedBy: &approver.ID,
+		CreatedBy:  currentUserID(c),
+	}
+	for _, l := range req.Lines {
+		in.Lines = append(in.Lines, repository.NewRefundLine{OrderLineID: l.OrderLineID, Quantity: l.Quantity, Restock: l.Restock})
+	}
+
+	f, err := s.refunds.Create(c.Request.Context(), in)
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	var cash float64
+	if f.Method == order.TenderCash {
+		cash = f.Amount
+	}
+	s.metrics.Refunded(f.Method, cash)
+	c.JSON(http.StatusCreated, f)
+}
+
+// @Summary Get order refunds
+// @Description Refunds of an order without their lines, oldest first.
+// @Tags Order
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} repository.Refund
+// @Router /orders/{id}/refunds [get]
+func (s *Server) GetOrderRefundsHandler(c *gin.Context) {
+	refunds, err := s.refunds.GetByOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	if refunds == nil {
+		refunds = []repository.Refund{}
+	}
+	c.JSON(http.StatusOK, refunds)
+}
+
+// @Summary Get refund
+// @Description The refund document with its lines.
+// @Tags Order
+// @Produce json
+// @Param id path string true "Refund ID"
+// @Success 200 {object} repository.Refund
+// @Failure 404 {object} map[string]string
+// @Router /refunds/{id} [get]
+func (s *Server) GetRefundHandler(c *gin.Context) {
+	f, err := s.refunds.GetByID(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refund not found"})
+		return
+	}
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
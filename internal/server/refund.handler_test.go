@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"maspos-be-go/internal/config"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/metrics"
+	"maspos-be-go/internal/order"
+	"maspos-be-go/internal/ratelimit"
+	"maspos-be-go/internal/utils"
+)
+
+// fakeRefundStore meniru RefundRepository: jumlah per baris dibatasi sisa
+// yang belum direfund, nilainya proporsional, dan refund tunai keluar dari
+// laci shift.
+type fakeRefundStore struct {
+	orders  *fakeOrderStore
+	refunds []*repository.Refund
+	stock   map[string]float64
+}
+
+func (f *fakeRefundStore) Create(ctx context.Context, in repository.NewRefund) (*repository.Refund, error) {
+	o, err := f.orders.GetByID(ctx, in.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := f.orders.shifts.visible(ctx, in.ShiftID)
+	if err != nil || sh.OutletID != o.OutletID {
+		return nil, repository.ErrRefundShift
+	}
+	done := map[string]float64{}
+	var doneSubtotal, doneMethod float64
+	for _, r := range f.refunds {
+		if r.OrderID != o.ID {
+			continue
+		}
+		doneSubtotal += r.Subtotal
+		if r.Method == in.Method {
+			doneMethod += r.Amount
+		}
+		for _, l := range r.Lines {
+			done[l.OrderLineID] += l.Quantity
+		}
+	}
+
+	r := &repository.Refund{ID: fmt.Sprintf("refund-%d", len(f.refunds)+1), Number: fmt.Sprintf("RF-%06d", len(f.refunds)+1),
+		OrderID: o.ID, OrderNumber: o.Number, OutletID: o.OutletID, ShiftID: sh.ID, Reason: in.Reason, Method: in.Method,
+		ApprovedBy: in.ApprovedBy, CreatedBy: in.CreatedBy, CreatedAt: time.Now()}
+	for _, nl := range in.Lines {
+		var line *repository.OrderLine
+		for i := range o.Lines {
+			if o.Lines[i].ID == nl.OrderLineID {
+				line = &o.Lines[i]
+			}
+		}
+		if line == nil {
+			return nil, repository.ErrRefundLine
+		}
+		before := done[line.ID]
+		if before+nl.Quantity > line.Quantity {
+			return nil, order.ErrRefundQuantity
+		}
+		done[line.ID] += nl.Quantity
+		restock := order.Restocks(in.Reason)
+		if nl.Restock != nil {
+			restock = *nl.Restock
+		}
+		amount := order.Share(line.Total, line.Quantity, before, nl.Quantity)
+		r.Lines = append(r.Lines, repository.RefundLine{OrderLineID: line.ID, ProductID: line.ProductID, Name: line.Name,
+			Quantity: nl.Quantity, Amount: amount, Restock: restock})
+		r.Subtotal += amount
+	}
+	r.Tax = order.Share(o.Tax, o.Subtotal, doneSubtotal, r.Subtotal)
+	r.Amount = math.Round((r.Subtotal+r.Tax)*100) / 100
+
+	paid := order.Order{Tenders: o.Tenders}.PaidWith(in.Method)
+	if in.Method == order.TenderCash {
+		paid -= o.Change
+	}
+	if r.Amount > paid-doneMethod {
+		return nil, repository.ErrRefundTender
+	}
+	for _, l := range r.Lines {
+		if l.Restock {
+			f.stock[l.ProductID] += l.Quantity
+		}
+	}
+	if in.Method == order.TenderCash {
+		f.orders.shifts.movements[sh.ID] = append(f.orders.shifts.movements[sh.ID],
+			repository.CashMovement{Type: repository.CashRefund, Amount: r.Amount, Reference: r.Number})
+	}
+	f.refunds = append(f.refunds, r)
+	return r, nil
+}
+
+func (f *fakeRefundStore) GetByID(ctx context.Context, id string) (*repository.Refund, error) {
+	for _, r := range f.refunds {
+		if r.ID == id {
+			if _, err := f.orders.GetByID(ctx, r.OrderID); err != nil {
+				return nil, err
+			}
+			return r, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRefundStore) GetByOrder(ctx context.Context, orderID string) ([]repository.Refund, error) {
+	var res []repository.Refund
+	for _, r := range f.refunds {
+		if r.OrderID == orderID {
+			res = append(res, *r)
+		}
+	}
+	return res, nil
+}
+
+func TestRefundOrderLines(t *testing.T) {
+	s, r, shiftID := orderTestServer(t)
+	hash, err := utils.HashPassword("rahasia123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.users = &fakeUserStore{users: map[string]repository.User{
+		"spv@example.com":       {ID: 7, Email: "spv@example.com", Password: hash, Supervisor: true},
+		"kasir@example.com":     {ID: 8, Email: "kasir@example.com", Password: hash},
+		"spv.depok@example.com": {ID: 9, Email: "spv.depok@example.com", Password: hash, Supervisor: true},
+	}}
+	s.outlets.(*fakeOutletStore).users = map[int][]string{7: {outletKemang}, 9: {outletDepok}}
+	s.cfg = &config.Config{RateLimit: config.RateLimit{Login: config.Lockout{
+		MaxFailures: 2, Window: time.Minute, BaseDelay: time.Minute, MaxDelay: time.Hour,
+	}}}
+	s.limiter = ratelimit.NewMemory()
+	refunds := &fakeRefundStore{orders: s.orders.(*fakeOrderStore), stock: map[string]float64{}}
+	s.refunds = refunds
+	s.metrics = metrics.New(nil, "")
+	r.POST("/orders/:id/refunds", s.CreateRefundHandler)
+	r.GET("/orders/:id/refunds", s.GetOrderRefundsHandler)
+	r.GET("/refunds/:id", s.GetRefundHandler)
+
+	// 3 x 18.000 dengan diskon 1.000 dan pajak 5.300, dibayar kartu dan tunai
+	body := `{"shift_id":"` + shiftID + `","lines":[{"product_id":"` + productKopiSusu + `","quantity":3,"discount":1000}],
+		"taxes":[{"name":"PB1 10%","amount":5300}],
+		"tenders":[{"method":"card","amount":30000},{"method":"cash","amount":30000}]}`
+	rr := sendJSON(r, http.MethodPost, "/orders", outletKemang, body)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var o repository.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &o); err != nil {
+		t.Fatal(err)
+	}
+	line := o.Lines[0].ID
+
+	refund := func(method, approver, lines string) *http.Response {
+		body := `{"shift_id":"` + shiftID + `","reason":"changed_mind","method":"` + method + `","lines":` + lines + `,
+			"approval":{"email":"` + approver + `","password":"rahasia123"}}`
+		return sendJSON(r, http.MethodPost, "/orders/"+o.ID+"/refunds", outletKemang, body).Result()
+	}
+	one := `[{"order_line_id":"` + line + `","quantity":1}]`
+
+	if res := refund("cash", "kasir@example.com", one); res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 without a supervisor, got %d", res.StatusCode)
+	}
+	if res := refund("cash", "nobody@example.com", one); res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for an unknown approver, got %d", res.StatusCode)
+	}
+	if res := refund("cash", "spv.depok@example.com", one); res.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for a supervisor of another outlet, got %d", res.StatusCode)
+	}
+
+	// password supervisor yang salah dihitung ke kunci login email itu
+	guess := func(email string) int {
+		body := `{"shift_id":"` + shiftID + `","reason":"changed_mind","method":"cash","lines":` + one + `,
+			"approval":{"email":"` + email + `","password":"tebakan"}}`
+		return sendJSON(r, http.MethodPost, "/orders/"+o.ID+"/refunds", outletKemang, body).Code
+	}
+	if code := guess("spv.depok@example.com"); code != http.StatusForbidden {
+		t.Errorf("expected 403 for a wrong password, got %d", code)
+	}
+	guess("spv.depok@example.com")
+	if code := guess("spv.depok@example.com"); code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 after repeated wrong passwords, got %d", code)
+	}
+	if res := refund("qris", "spv@example.com", one); res.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a method the order was not paid with, got %d", res.StatusCode)
+	}
+	if res := refund("cash", "spv@example.com", `[{"order_line_id":"`+line+`","quantity":4}]`); res.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for more units than sold, got %d", res.StatusCode)
+	}
+
+	// satu unit: 53.000 / 3 = 17.666,67 ditambah pajaknya 1.766,67
+	rr = sendJSON(r, http.MethodPost, "/orders/"+o.ID+"/refunds", outletKemang, `{"shift_id":"`+shiftID+`","reason":"damaged",
+		"method":"cash","lines":`+one+`,"approval":{"email":"spv@example.com","password":"rahasia123"}}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body)
+	}
+	var f repository.Refund
+	if err := json.Unmarshal(rr.Body.Bytes(), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Subtotal != 17666.67 || f.Tax != 1766.67 || f.ApprovedBy == nil || *f.ApprovedBy != 7 {
+		t.Errorf("expected 17666.67 + 1766.67 approved by 7, got %v + %v by %v", f.Subtotal, f.Tax, f.ApprovedBy)
+	}
+	if f.Lines[0].Restock || refunds.stock[productKopiSusu] != 0 {
+		t.Errorf("expected damaged goods to stay out of stock")
+	}
+
+	// tunai yang tersisa 8.866,66 tidak cukup untuk unit kedua
+	if res := refund("cash", "spv@example.com", one); res.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 beyond the cash paid, got %d", res.StatusCode)
+	}
+	if res := refund("card", "spv@example.com", one); res.StatusCode != http.StatusCreated {
+		t.Errorf("expected the card refund to pass, got %d", res.StatusCode)
+	}
+	if refunds.stock[productKopiSusu] != 1 {
+		t.Errorf("expected changed_mind to restock 1 unit, got %v", refunds.stock[productKopiSusu])
+	}
+
+	rr = sendJSON(r, http.MethodGet, "/shifts/"+shiftID, outletKemang, "")
+	var rep repository.ShiftReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Refunds != 19433.34 {
+		t.Errorf("expected the cash refund in the shift, got %v", rep.Refunds)
+	}
+
+	rr = sendJSON(r, http.MethodGet, "/orders/"+o.ID+"/refunds", outletKemang, "")
+	var list []repository.Refund
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("expected 2 refunds, got %d", len(list))
+	}
+	if rr := sendJSON(r, http.MethodGet, "/refunds/"+f.ID, outletDepok, ""); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 from another outlet, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`maspos_orders_completed_total 1`,
+		`maspos_order_revenue_total 58300`,
+		`maspos_cash_amount_total{type="sale"} 28300`,
+		`maspos_cash_amount_total{type="refund"} 19433.34`,
+		`maspos_refunds_total{method="card"} 1`,
+	} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("expected metrics output to contain %q", want)
+		}
+	}
+}
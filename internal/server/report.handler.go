@@ -0,0 +1,396 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	_ "time/tzdata" // zona waktu laporan tidak bergantung pada image
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/server/dto"
+)
+
+const (
+	reportDateLayout = "2006-01-02"
+	// defaultReportTZ dipakai bila ?tz= kosong; tanggal dan jam transaksi
+	// dihitung menurut zona waktu ini.
+	defaultReportTZ = "Asia/Jakarta"
+	// maxReportDays membatasi rentang satu laporan.
+	maxReportDays = 366
+	// defaultTopProducts adalah jumlah produk terlaris bila ?limit= kosong.
+	defaultTopProducts = 20
+)
+
+var salesColumns = []string{"orders", "gross", "discounts", "refund_count", "refunds", "net", "tax", "total", "average_ticket"}
+
+func salesRow(s repository.Sales) []any {
+	return []any{s.Orders, s.Gross, s.Discounts, s.RefundCount, s.Refunds, s.Net, s.Tax, s.Total, s.AverageTicket}
+}
+
+var itemSalesColumns = []string{"id", "name", "quantity", "refunded_quantity", "gross", "discounts", "refunds", "net"}
+
+func itemSalesRows(items []repository.ItemSales) [][]any {
+	rows := make([][]any, len(items))
+	for i, it := range items {
+		rows[i] = []any{it.ID, it.Name, it.Quantity, it.RefundedQuantity, it.Gross, it.Discounts, it.Refunds, it.Net}
+	}
+	return rows
+}
+
+// reportFilter membaca ?from=&to=&tz=. from dan to adalah tanggal yang
+// keduanya ikut dihitung; bila kosong dipakai hari ini. Respons 400 sudah
+// dikirim bila ok false.
+func reportFilter(c *gin.Context) (f repository.ReportFilter, ok bool) {
+	f.TZ = c.DefaultQuery("tz", defaultReportTZ)
+	loc, err := time.LoadLocation(f.TZ)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tz must be an IANA time zone such as Asia/Jakarta"})
+		return f, false
+	}
+	f.From, f.To, ok = reportDates(c, loc)
+	return f, ok
+}
+
+// reportDates membaca ?from=&to= di zona waktu loc. to yang dikembalikan
+// adalah awal hari setelah tanggal to.
+func reportDates(c *gin.Context, loc *time.Location) (from, to time.Time, ok bool) {
+	today := time.Now().In(loc).Format(reportDateLayout)
+
+	from, err := time.ParseInLocation(reportDateLayout, c.DefaultQuery("from", today), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date such as 2026-01-31"})
+		return from, to, false
+	}
+	to, err = time.ParseInLocation(reportDateLayout, c.DefaultQuery("to", today), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date such as 2026-01-31"})
+		return from, to, false
+	}
+	to = to.AddDate(0, 0, 1)
+	switch {
+	case !to.After(from):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return from, to, false
+	case to.After(from.AddDate(0, 0, maxReportDays)):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("a report covers at most %d days", maxReportDays)})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// reportAsFile mengirim laporan lewat streamExport bila ?format= bukan json.
+func reportAsFile(c *gin.Context, name string, columns []string, rows [][]any) bool {
+	if c.DefaultQuery("format", "json") == "json" {
+		return false
+	}
+	streamExport(c, name, columns, func(write func([]any) error) error {
+		for _, row := range rows {
+			if err := write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return true
+}
+
+// @Summary Sales summary
+// @Description Sales of completed orders in total and per day: gross, discounts, refunds (by refund date, without tax), net, tax, total and average ticket. Inside an outlet only that outlet is counted.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today" example(2026-01-01)
+// @Param to query string false "Last day, default today" example(2026-01-31)
+// @Param tz query string false "Time zone of the days, default Asia/Jakarta"
+// @Param format query string false "json (default), csv, ndjson or xlsx; files list the days"
+// @Success 200 {object} dto.SalesSummaryResponse
+// @Failure 400 {object} map[string]string
+// @Router /reports/sales/summary [get]
+func (s *Server) SalesSummaryHandler(c *gin.Context) {
+	f, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	days, err := s.reports.SalesByDay(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows := make([][]any, len(days))
+	for i, d := range days {
+		rows[i] = append([]any{d.Date}, salesRow(d.Sales)...)
+	}
+	if reportAsFile(c, "sales-daily", append([]string{"date"}, salesColumns...), rows) {
+		return
+	}
+
+	totals, err := s.reports.SalesTotal(ctx, f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if days == nil {
+		days = []repository.DailySales{}
+	}
+	c.JSON(http.StatusOK, dto.SalesSummaryResponse{
+		From:     f.From.Format(reportDateLayout),
+		To:       f.To.AddDate(0, 0, -1).Format(reportDateLayout),
+		Timezone: f.TZ,
+		Totals:   totals,
+		Days:     days,
+	})
+}
+
+// @Summary Sales by hour of day
+// @Description All 24 hours, summed over the period. Inside an outlet only that outlet is counted.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today"
+// @Param to query string false "Last day, default today"
+// @Param tz query string false "Time zone of the hours, default Asia/Jakarta"
+// @Param format query string false "json (default), csv, ndjson or xlsx"
+// @Success 200 {array} repository.HourlySales
+// @Failure 400 {object} map[string]string
+// @Router /reports/sales/hourly [get]
+func (s *Server) SalesHourlyHandler(c *gin.Context) {
+	f, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	hours, err := s.reports.SalesByHour(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows := make([][]any, len(hours))
+	for i, h := range hours {
+		rows[i] = append([]any{h.Hour}, salesRow(h.Sales)...)
+	}
+	if reportAsFile(c, "sales-hourly", append([]string{"hour"}, salesColumns...), rows) {
+		return
+	}
+	c.JSON(http.StatusOK, hours)
+}
+
+// @Summary Sales by cashier
+// @Description Totals per user who created the orders and refunds, highest net first. Inside an outlet only that outlet is counted.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today"
+// @Param to query string false "Last day, default today"
+// @Param tz query string false "Time zone of the days, default Asia/Jakarta"
+// @Param format query string false "json (default), csv, ndjson or xlsx"
+// @Success 200 {array} repository.CashierSales
+// @Failure 400 {object} map[string]string
+// @Router /reports/sales/cashiers [get]
+func (s *Server) SalesCashiersHandler(c *gin.Context) {
+	f, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	cashiers, err := s.reports.SalesByCashier(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows := make([][]any, len(cashiers))
+	for i, cs := range cashiers {
+		var id any
+		if cs.UserID != nil {
+			id = *cs.UserID
+		}
+		rows[i] = append([]any{id, cs.Name}, salesRow(cs.Sales)...)
+	}
+	if reportAsFile(c, "sales-cashiers", append([]string{"user_id", "name"}, salesColumns...), rows) {
+		return
+	}
+	if cashiers == nil {
+		cashiers = []repository.CashierSales{}
+	}
+	c.JSON(http.StatusOK, cashiers)
+}
+
+// @Summary Top products
+// @Description Best selling products by net, from the order lines. Gross is before line discounts; order discounts are not split over the lines. Refunded units count on the refund date. Inside an outlet only that outlet is counted.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today"
+// @Param to query string false "Last day, default today"
+// @Param tz query string false "Time zone of the days, default Asia/Jakarta"
+// @Param limit query int false "Number of products, 1 to 500, default 20"
+// @Param format query string false "json (default), csv, ndjson or xlsx"
+// @Success 200 {array} repository.ItemSales
+// @Failure 400 {object} map[string]string
+// @Router /reports/sales/products [get]
+func (s *Server) SalesProductsHandler(c *gin.Context) {
+	f, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTopProducts)))
+	if err != nil || limit < 1 || limit > maxOrderLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+	products, err := s.reports.SalesByProduct(c.Request.Context(), f, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reportAsFile(c, "sales-products", itemSalesColumns, itemSalesRows(products)) {
+		return
+	}
+	if products == nil {
+		products = []repository.ItemSales{}
+	}
+	c.JSON(http.StatusOK, products)
+}
+
+// @Summary Sales by category
+// @Description Sales per product category, highest net first, from the order lines. Lines without a category have an empty id. Inside an outlet only that outlet is counted.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today"
+// @Param to query string false "Last day, default today"
+// @Param tz query string false "Time zone of the days, default Asia/Jakarta"
+// @Param format query string false "json (default), csv, ndjson or xlsx"
+// @Success 200 {array} repository.ItemSales
+// @Failure 400 {object} map[string]string
+// @Router /reports/sales/categories [get]
+func (s *Server) SalesCategoriesHandler(c *gin.Context) {
+	f, ok := reportFilter(c)
+	if !ok {
+		return
+	}
+	categories, err := s.reports.SalesByCategory(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reportAsFile(c, "sales-categories", itemSalesColumns, itemSalesRows(categories)) {
+		return
+	}
+	if categories == nil {
+		categories = []repository.ItemSales{}
+	}
+	c.JSON(http.StatusOK, categories)
+}
+
+// @Summary Daily sales rollups
+// @Description Sales per outlet and day with the same figures as /reports/sales/summary, read from rollups of orders and refunds kept up to date in the background. Fast over long periods, but orders and refunds after as_of are not counted yet. Days are in the rollup time zone. Inside an outlet only that outlet is listed.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today" example(2026-01-01)
+// @Param to query string false "Last day, default today" example(2026-12-31)
+// @Param format query string false "json (default), csv, ndjson or xlsx"
+// @Success 200 {object} dto.SalesRollupResponse
+// @Failure 400 {object} map[string]string
+// @Router /reports/sales-rollups [get]
+func (s *Server) SalesRollupsHandler(c *gin.Context) {
+	cp, from, last, ok := s.rollupDates(c)
+	if !ok {
+		return
+	}
+	days, err := s.rollups.Daily(c.Request.Context(), from, last)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	rows := make([][]any, len(days))
+	for i, d := range days {
+		rows[i] = append([]any{d.Date, d.OutletID}, salesRow(d.Sales)...)
+	}
+	if reportAsFile(c, "sales-rollups", append([]string{"date", "outlet_id"}, salesColumns...), rows) {
+		return
+	}
+	if days == nil {
+		days = []repository.OutletDailySales{}
+	}
+	c.JSON(http.StatusOK, dto.SalesRollupResponse{
+		From:     from,
+		To:       last,
+		Timezone: cp.Timezone,
+		AsOf:     cp.Position,
+		Totals:   repository.SumSales(days),
+		Days:     days,
+	})
+}
+
+// @Summary Product sales rollups
+// @Description Best selling products by net over the period, read from per product rollups kept up to date in the background with the daily sales rollups. Fast over long periods, but orders and refunds after as_of are not counted yet. Days are in the rollup time zone. Deleted products are listed together with an empty id. Inside an outlet only that outlet is counted.
+// @Tags Report
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "First day, default today" example(2026-01-01)
+// @Param to query string false "Last day, default today" example(2026-12-31)
+// @Param limit query int false "Number of products, 1 to 500, default 20"
+// @Param format query string false "json (default), csv, ndjson or xlsx"
+// @Success 200 {object} dto.ProductRollupResponse
+// @Failure 400 {object} map[string]string
+// @Router /reports/product-rollups [get]
+func (s *Server) ProductRollupsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultTopProducts)))
+	if err != nil || limit < 1 || limit > maxOrderLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+	cp, from, last, ok := s.rollupDates(c)
+	if !ok {
+		return
+	}
+	products, err := s.rollups.Products(c.Request.Context(), from, last, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reportAsFile(c, "product-rollups", itemSalesColumns, itemSalesRows(products)) {
+		return
+	}
+	if products == nil {
+		products = []repository.ItemSales{}
+	}
+	c.JSON(http.StatusOK, dto.ProductRollupResponse{
+		From:     from,
+		To:       last,
+		Timezone: cp.Timezone,
+		AsOf:     cp.Position,
+		Products: products,
+	})
+}
+
+// rollupDates membaca checkpoint rollup dan ?from=&to= di zona waktu
+// rollup. from dan last adalah tanggal pertama dan terakhir sebagai
+// YYYY-MM-DD. Respons sudah dikirim bila ok false.
+func (s *Server) rollupDates(c *gin.Context) (cp *repository.SalesRollupCheckpoint, from, last string, ok bool) {
+	cp, err := s.rollups.Checkpoint(c.Request.Context())
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		cp = &repository.SalesRollupCheckpoint{Timezone: defaultReportTZ}
+		if s.cfg != nil {
+			cp.Timezone = s.cfg.Rollup.Timezone
+		}
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, "", "", false
+	}
+	loc, err := time.LoadLocation(cp.Timezone)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, "", "", false
+	}
+	start, end, ok := reportDates(c, loc)
+	if !ok {
+		return nil, "", "", false
+	}
+	return cp, start.Format(reportDateLayout), end.AddDate(0, 0, -1).Format(reportDateLayout), true
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/server/dto"
+)
+
+// fakeReportStore mencatat filter terakhir dan mengembalikan angka tetap.
+type fakeReportStore struct {
+	filter repository.ReportFilter
+	limit  int
+}
+
+var (
+	ordersJan15 = repository.Sales{Orders: 3, Gross: 100000, Discounts: 10000, RefundCount: 1, Refunds: 18000, Net: 72000, Tax: 7200, Total: 79200, AverageTicket: 30000}
+	ordersJan16 = repository.Sales{Orders: 1, Gross: 25000, Net: 25000, Tax: 2500, Total: 27500, AverageTicket: 25000}
+)
+
+func (f *fakeReportStore) SalesTotal(ctx context.Context, filter repository.ReportFilter) (repository.Sales, error) {
+	f.filter = filter
+	return repository.Sales{Orders: 4, Gross: 125000, Discounts: 10000, RefundCount: 1, Refunds: 18000, Net: 97000, Tax: 9700, Total: 106700, AverageTicket: 28750}, nil
+}
+
+func (f *fakeReportStore) SalesByDay(ctx context.Context, filter repository.ReportFilter) ([]repository.DailySales, error) {
+	f.filter = filter
+	return []repository.DailySales{{Date: "2026-01-15", Sales: ordersJan15}, {Date: "2026-01-16", Sales: ordersJan16}}, nil
+}
+
+func (f *fakeReportStore) SalesByHour(ctx context.Context, filter repository.ReportFilter) ([]repository.HourlySales, error) {
+	f.filter = filter
+	hours := make([]repository.HourlySales, 24)
+	for h := range hours {
+		hours[h].Hour = h
+	}
+	hours[9].Sales = ordersJan15
+	return hours, nil
+}
+
+func (f *fakeReportStore) SalesByCashier(ctx context.Context, filter repository.ReportFilter) ([]repository.CashierSales, error) {
+	f.filter = filter
+	rina := 7
+	return []repository.CashierSales{{UserID: &rina, Name: "Rina", Sales: ordersJan15}}, nil
+}
+
+func (f *fakeReportStore) SalesByProduct(ctx context.Context, filter repository.ReportFilter, limit int) ([]repository.ItemSales, error) {
+	f.filter, f.limit = filter, limit
+	return []repository.ItemSales{
+		{ID: productKopiSusu, Name: "Kopi Susu", Quantity: 5, RefundedQuantity: 1, Gross: 90000, Discounts: 2000, Refunds: 17600, Net: 70400},
+		{ID: productCroissant, Name: "Croissant", Quantity: 1, Gross: 22000, Net: 22000},
+	}[:min(limit, 2)], nil
+}
+
+func (f *fakeReportStore) SalesByCategory(ctx context.Context, filter repository.ReportFilter) ([]repository.ItemSales, error) {
+	f.filter = filter
+	return []repository.ItemSales{{ID: categoryKopi, Name: "Kopi", Quantity: 5, Gross: 90000, Net: 90000}, {Name: "", Quantity: 2, Gross: 10000, Net: 10000}}, nil
+}
+
+func TestSalesReports(t *testing.T) {
+	store := &fakeReportStore{}
+	s := &Server{reports: store}
+	r := gin.New()
+	r.GET("/reports/sales/summary", s.SalesSummaryHandler)
+	r.GET("/reports/sales/hourly", s.SalesHourlyHandler)
+	r.GET("/reports/sales/cashiers", s.SalesCashiersHandler)
+	r.GET("/reports/sales/products", s.SalesProductsHandler)
+	r.GET("/reports/sales/categories", s.SalesCategoriesHandler)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	for _, q := range []string{"from=2026-13-01", "to=kemarin", "from=2026-01-16&to=2026-01-15", "from=2025-01-01&to=2026-01-15", "tz=Mars/Olympus"} {
+		if rr := get("/reports/sales/summary?" + q); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", q, rr.Code, rr.Body)
+		}
+	}
+
+	get("/reports/sales/summary?from=2026-01-15&to=2026-01-16")
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	if want := time.Date(2026, 1, 15, 0, 0, 0, 0, jakarta); !store.filter.From.Equal(want) {
+		t.Errorf("from = %s, want %s", store.filter.From, want)
+	}
+	// to mencakup seluruh hari terakhir
+	if want := time.Date(2026, 1, 17, 0, 0, 0, 0, jakarta); !store.filter.To.Equal(want) || store.filter.TZ != "Asia/Jakarta" {
+		t.Errorf("to = %s in %s, want %s", store.filter.To, store.filter.TZ, want)
+	}
+
+	rr := get("/reports/sales/summary?from=2026-01-15&to=2026-01-16&tz=Asia/Makassar")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	if store.filter.TZ != "Asia/Makassar" || store.filter.From.Format(time.RFC3339) != "2026-01-15T00:00:00+08:00" {
+		t.Errorf("expected the days in Asia/Makassar, got %s", store.filter.From)
+	}
+	var summary dto.SalesSummaryResponse
+	json.Unmarshal(rr.Body.Bytes(), &summary)
+	if summary.Totals.Gross != 125000 || summary.Totals.Discounts != 10000 || summary.Totals.Tax != 9700 || len(summary.Days) != 2 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	rr = get("/reports/sales/summary?format=csv")
+	want := "date,orders,gross,discounts,refund_count,refunds,net,tax,total,average_ticket\n" +
+		"2026-01-15,3,100000,10000,1,18000,72000,7200,79200,30000\n" +
+		"2026-01-16,1,25000,0,0,0,25000,2500,27500,25000\n"
+	if rr.Body.String() != want {
+		t.Errorf("unexpected CSV:\n%s", rr.Body)
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	rr = get("/reports/sales/hourly")
+	var hours []repository.HourlySales
+	json.Unmarshal(rr.Body.Bytes(), &hours)
+	if len(hours) != 24 || hours[9].Orders != 3 {
+		t.Errorf("expected 24 hours with orders at 09, got %+v", hours)
+	}
+	if rr := get("/reports/sales/hourly?format=pdf"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rr.Code)
+	}
+
+	rr = get("/reports/sales/cashiers?format=csv")
+	if lines := strings.Split(rr.Body.String(), "\n"); lines[1] != "7,Rina,3,100000,10000,1,18000,72000,7200,79200,30000" {
+		t.Errorf("unexpected cashier CSV:\n%s", rr.Body)
+	}
+
+	for _, q := range []string{"limit=0", "limit=501", "limit=semua"} {
+		if rr := get("/reports/sales/products?" + q); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", q, rr.Code)
+		}
+	}
+	rr = get("/reports/sales/products")
+	var products []repository.ItemSales
+	json.Unmarshal(rr.Body.Bytes(), &products)
+	if store.limit != defaultTopProducts || len(products) != 2 || products[0].RefundedQuantity != 1 {
+		t.Errorf("unexpected products with limit %d: %+v", store.limit, products)
+	}
+	rr = get("/reports/sales/products?limit=1&format=csv")
+	want = "id,name,quantity,refunded_quantity,gross,discounts,refunds,net\n" +
+		productKopiSusu + ",Kopi Susu,5,1,90000,2000,17600,70400\n"
+	if rr.Body.String() != want {
+		t.Errorf("unexpected product CSV:\n%s", rr.Body)
+	}
+
+	rr = get("/reports/sales/categories")
+	var categories []repository.ItemSales
+	json.Unmarshal(rr.Body.Bytes(), &categories)
+	if len(categories) != 2 || categories[0].ID != categoryKopi || categories[1].ID != "" {
+		t.Errorf("unexpected categories: %+v", categories)
+	}
+}
+
+// fakeSalesRollupStore mengembalikan rollup tetap dan mencatat rentang
+// tanggal terakhir.
+type fakeSalesRollupStore struct {
+	checkpoint *repository.SalesRollupCheckpoint
+	from, to   string
+	limit      int
+}
+
+func (f *fakeSalesRollupStore) Checkpoint(ctx context.Context) (*repository.SalesRollupCheckpoint, error) {
+	if f.checkpoint == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.checkpoint, nil
+}
+
+func (f *fakeSalesRollupStore) Daily(ctx context.Context, from, to string) ([]repository.OutletDailySales, error) {
+	f.from, f.to = from, to
+	if f.checkpoint == nil {
+		return nil, nil
+	}
+	return []repository.OutletDailySales{
+		{OutletID: outletKemang, Date: "2026-01-15", Sales: ordersJan15},
+		{OutletID: outletDepok, Date: "2026-01-16", Sales: ordersJan16},
+	}, nil
+}
+
+func (f *fakeSalesRollupStore) Products(ctx context.Context, from, to string, limit int) ([]repository.ItemSales, error) {
+	f.from, f.to, f.limit = from, to, limit
+	if f.checkpoint == nil {
+		return nil, nil
+	}
+	return []repository.ItemSales{
+		{ID: productKopiSusu, Name: "Kopi Susu", Quantity: 40, RefundedQuantity: 1, Gross: 720000, Discounts: 5000, Refunds: 18000, Net: 697000},
+		{Quantity: 2, Gross: 30000, Net: 30000},
+	}, nil
+}
+
+func TestProductRollups(t *testing.T) {
+	store := &fakeSalesRollupStore{}
+	s := &Server{rollups: store}
+	r := gin.New()
+	r.GET("/reports/product-rollups", s.ProductRollupsHandler)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/reports/product-rollups")
+	var resp dto.ProductRollupResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusOK || resp.AsOf != nil || resp.Products == nil || len(resp.Products) != 0 {
+		t.Errorf("expected an empty list before the first build, got %d: %s", rr.Code, rr.Body)
+	}
+	if rr := get("/reports/product-rollups?limit=501"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a limit above 500, got %d", rr.Code)
+	}
+
+	asOf := time.Date(2026, 1, 16, 23, 0, 0, 0, time.UTC)
+	store.checkpoint = &repository.SalesRollupCheckpoint{Timezone: "Asia/Makassar", Position: &asOf}
+	rr = get("/reports/product-rollups?from=2026-01-01&to=2026-01-16&limit=5")
+	resp = dto.ProductRollupResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if store.from != "2026-01-01" || store.to != "2026-01-16" || store.limit != 5 {
+		t.Errorf("range = %s..%s limit %d", store.from, store.to, store.limit)
+	}
+	if resp.Timezone != "Asia/Makassar" || resp.AsOf == nil || len(resp.Products) != 2 || resp.Products[0].Net != 697000 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	rr = get("/reports/product-rollups?format=csv")
+	if lines := strings.Split(rr.Body.String(), "\n"); lines[0] != strings.Join(itemSalesColumns, ",") || lines[2] != ",,2,0,30000,0,0,30000" {
+		t.Errorf("unexpected csv:\n%s", rr.Body)
+	}
+}
+
+func TestSalesRollups(t *testing.T) {
+	store := &fakeSalesRollupStore{}
+	s := &Server{rollups: store}
+	r := gin.New()
+	r.GET("/reports/sales-rollups", s.SalesRollupsHandler)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/reports/sales-rollups?from=2026-01-15&to=2026-01-16")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 before the first build, got %d: %s", rr.Code, rr.Body)
+	}
+	var resp dto.SalesRollupResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.AsOf != nil || resp.Timezone != "Asia/Jakarta" || len(resp.Days) != 0 {
+		t.Errorf("unexpected response without rollups: %+v", resp)
+	}
+
+	asOf := time.Date(2026, 1, 16, 23, 0, 0, 0, time.UTC)
+	store.checkpoint = &repository.SalesRollupCheckpoint{Timezone: "Asia/Makassar", Position: &asOf}
+	if rr := get("/reports/sales-rollups?from=2026-01-16&to=2026-01-15"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+	rr = get("/reports/sales-rollups?from=2026-01-15&to=2026-01-16")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	resp = dto.SalesRollupResponse{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if store.from != "2026-01-15" || store.to != "2026-01-16" {
+		t.Errorf("range = %s..%s", store.from, store.to)
+	}
+	if resp.Timezone != "Asia/Makassar" || resp.AsOf == nil || !resp.AsOf.Equal(asOf) || len(resp.Days) != 2 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	// totalnya sama dengan SalesTotal laporan penjualan untuk hari yang sama
+	if tot := resp.Totals; tot.Orders != 4 || tot.Net != 97000 || tot.Tax != 9700 || tot.Total != 106700 || tot.AverageTicket != 28750 {
+		t.Errorf("unexpected totals: %+v", tot)
+	}
+
+	rr = get("/reports/sales-rollups?from=2026-01-15&to=2026-01-16&format=csv")
+	want := "date,outlet_id,orders,gross,discounts,refund_count,refunds,net,tax,total,average_ticket\n" +
+		"2026-01-15," + outletKemang + ",3,100000,10000,1,18000,72000,7200,79200,30000\n" +
+		"2026-01-16," + outletDepok + ",1,25000,0,0,0,25000,2500,27500,25000\n"
+	if rr.Code != http.StatusOK || rr.Body.String() != want {
+		t.Errorf("unexpected csv (%d):\n%s", rr.Code, rr.Body)
+	}
+}
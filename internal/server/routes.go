@@ -11,38 +11,141 @@ import (
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
-	r := gin.Default()
+	if s.cfg.Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	// alamat klien dipakai sebagai kunci rate limit, jadi X-Forwarded-For hanya
+	// dipercaya dari proxy yang dikonfigurasi (sudah divalidasi oleh config)
+	r.SetTrustedProxies(s.cfg.TrustedProxies)
+	r.Use(s.tracing(), s.identify(), s.requestLogger(), s.instrument(), s.recovery(), s.rateLimit())
 
 	// ===== BASIC ROUTES =====
 	r.GET("/", s.HelloWorldHandler)
 	r.GET("/health", s.healthHandler)
+	r.GET("/livez", s.livezHandler)
+	r.GET("/readyz", s.readyzHandler)
+	r.GET("/metrics", gin.WrapH(s.metrics.Handler()))
 	r.Static("/uploads", "./uploads")
 
 	// ===== SWAGGER ROUTE =====
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	auth := r.Group("/auth")
+	// login dan registrasi belum punya token, jadi tenant-nya diambil dari
+	// subdomain atau DEFAULT_TENANT
+	auth := r.Group("/auth", s.tenantScope())
 	{
 
 		auth.POST("/register", s.RegisterHandler)
 		auth.POST("/login", s.LoginHandler)
 	
 	}
-	cat := r.Group("/categories")
+	// route data hanya untuk user yang login; request tanpa token ditolak
+	// sebelum tenant dan outlet-nya ditentukan
+	api := r.Group("", s.requireAuth(), s.tenantScope(), s.outletScope())
+	cat := api.Group("/categories", s.idempotent())
     {
         cat.POST("", s.CreateCategoryHandler)      // Create
         cat.GET("", s.GetAllCategoriesHandler)     // Get All
+        cat.GET("/tree", s.GetCategoryTreeHandler) // Full tree
+        cat.POST("/reorder", s.ReorderCategoriesHandler) // Bulk reorder
+        cat.GET("/export", s.ExportCategoriesHandler) // CSV/NDJSON/XLSX
         cat.GET("/:id", s.GetCategoryByIDHandler)  // Get By ID
+        cat.GET("/:id/tree", s.GetCategorySubtreeHandler) // Subtree
         cat.PATCH("/:id", s.UpdateCategoryHandler) // Update
         cat.DELETE("/:id", s.DeleteCategoryHandler) // Delete
     }
-	prod := r.Group("/products")
+	prod := api.Group("/products", s.idempotent())
     {
         prod.POST("", s.CreateProductHandler)       // Create
         prod.GET("", s.GetAllProductsHandler)      // Read All
+        prod.POST("/reorder", s.ReorderProductsHandler) // Bulk reorder
+        prod.POST("/import", s.ImportProductsHandler)   // CSV/XLSX import
+        prod.GET("/export", s.ExportProductsHandler)    // CSV/NDJSON/XLSX
         prod.GET("/:id", s.GetProductByIDHandler)  // Read One
         prod.PATCH("/:id", s.UpdateProductHandler) // Update
         prod.DELETE("/:id", s.DeleteProductHandler)// Delete
+        prod.PUT("/:id/outlet-price", s.SetProductOutletPriceHandler)       // Outlet price override
+        prod.DELETE("/:id/outlet-price", s.DeleteProductOutletPriceHandler) // Back to product price
     }
+	out := api.Group("/outlets", s.idempotent())
+	{
+		out.POST("", s.CreateOutletHandler)
+		out.GET("", s.GetAllOutletsHandler)
+		out.GET("/:id", s.GetOutletByIDHandler)
+		out.PUT("/:id", s.UpdateOutletHandler)
+		out.DELETE("/:id", s.DeleteOutletHandler)
+		out.PUT("/:id/users/:userID", s.AssignOutletUserHandler)
+		out.DELETE("/:id/users/:userID", s.UnassignOutletUserHandler)
+		out.GET("/:id/receipt-template", s.GetReceiptTemplateHandler)
+		out.PUT("/:id/receipt-template", s.UpdateReceiptTemplateHandler)
+	}
+	shift := api.Group("/shifts", s.idempotent())
+	{
+		shift.POST("", s.OpenShiftHandler)
+		shift.GET("", s.GetAllShiftsHandler)
+		shift.GET("/:id", s.GetShiftReportHandler)
+		shift.POST("/:id/cash-movements", s.AddCashMovementHandler)
+		shift.POST("/:id/close", s.CloseShiftHandler)
+	}
+	ord := api.Group("/orders", s.idempotent())
+	{
+		ord.POST("", s.CreateOrderHandler)
+		ord.GET("", s.GetAllOrdersHandler)
+		ord.GET("/:id", s.GetOrderByIDHandler)
+		ord.GET("/:id/receipt", s.GetOrderReceiptHandler)
+		ord.POST("/:id/receipt-emails", s.EmailOrderReceiptHandler)
+		ord.GET("/:id/receipt-emails", s.GetOrderReceiptEmailsHandler)
+		ord.POST("/:id/receipt-emails/resend", s.ResendOrderReceiptHandler)
+		ord.POST("/:id/refunds", s.CreateRefundHandler)
+		ord.GET("/:id/refunds", s.GetOrderRefundsHandler)
+	}
+	ref := api.Group("/refunds")
+	{
+		ref.GET("/:id", s.GetRefundHandler)
+	}
+	rep := api.Group("/reports")
+	{
+		rep.GET("/sales/summary", s.SalesSummaryHandler)
+		rep.GET("/sales/hourly", s.SalesHourlyHandler)
+		rep.GET("/sales/cashiers", s.SalesCashiersHandler)
+		rep.GET("/sales/products", s.SalesProductsHandler)
+		rep.GET("/sales/categories", s.SalesCategoriesHandler)
+		rep.GET("/sales-rollups", s.SalesRollupsHandler)
+		rep.GET("/product-rollups", s.ProductRollupsHandler)
+	}
+	zrep := api.Group("/z-reports", s.idempotent())
+	{
+		zrep.POST("", s.CloseDayHandler)
+		zrep.GET("", s.GetAllZReportsHandler)
+		zrep.GET("/:id", s.GetZReportHandler)
+		zrep.GET("/:id/print", s.PrintZReportHandler)
+	}
+	rcpt := api.Group("/receipts", s.idempotent())
+	{
+		rcpt.POST("/render", s.RenderReceiptHandler)
+		rcpt.GET("/emails", s.GetReceiptEmailsHandler)
+		rcpt.POST("/emails/:id/resend", s.ResendReceiptEmailHandler)
+	}
+	cust := api.Group("/customers", s.idempotent())
+	{
+		cust.POST("", s.CreateCustomerHandler)
+		cust.GET("", s.GetAllCustomersHandler)
+		cust.GET("/:id", s.GetCustomerByIDHandler)
+		cust.PATCH("/:id", s.UpdateCustomerHandler)
+		cust.DELETE("/:id", s.DeleteCustomerHandler)
+		cust.GET("/:id/purchases", s.GetCustomerPurchasesHandler)
+
+		cust.GET("/:id/loyalty", s.GetCustomerLoyaltyHandler)
+		cust.POST("/:id/loyalty/redeem", s.RedeemLoyaltyHandler)
+		cust.POST("/:id/loyalty/adjust", s.AdjustLoyaltyHandler)
+	}
+	loy := api.Group("/loyalty", s.idempotent())
+	{
+		loy.GET("/settings", s.GetLoyaltySettingsHandler)
+		loy.PUT("/settings", s.UpdateLoyaltySettingsHandler)
+		loy.POST("/entries/:id/reverse", s.ReverseLoyaltyEntryHandler)
+		loy.POST("/expire", s.ExpireLoyaltyPointsHandler)
+	}
 	return r
 }
 
@@ -56,5 +159,27 @@ func (s *Server) HelloWorldHandler(c *gin.Context) {
 }
 
 func (s *Server) healthHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, s.db.Health())
+	stats := s.db.Health()
+	if stats["status"] != "up" {
+		c.JSON(http.StatusServiceUnavailable, stats)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// livezHandler hanya menandakan proses masih hidup; tidak menyentuh dependensi
+// apa pun agar restart tidak dipicu oleh gangguan database sesaat.
+func (s *Server) livezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyzHandler menjalankan health check (dengan cache) dan mengembalikan 503
+// beserta detailnya bila ada dependensi yang bermasalah.
+func (s *Server) readyzHandler(c *gin.Context) {
+	report := s.health.Check(c.Request.Context())
+	if !report.Up() {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
 }
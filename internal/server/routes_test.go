@@ -1,10 +1,14 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/health"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHelloWorldHandler(t *testing.T) {
@@ -30,3 +34,25 @@ func TestHelloWorldHandler(t *testing.T) {
 		t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
 	}
 }
+
+func TestReadyzHandler(t *testing.T) {
+	down := health.CheckerFunc("database", func(ctx context.Context) (map[string]string, error) {
+		return nil, errors.New("db down")
+	})
+	s := &Server{health: health.New(time.Second, down)}
+	r := gin.New()
+	r.GET("/readyz", s.readyzHandler)
+	r.GET("/livez", s.livezHandler)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when a dependency is down, got %v", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected livez to stay 200 while dependencies are down, got %v", rr.Code)
+	}
+}
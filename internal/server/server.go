@@ -2,28 +2,87 @@ package server
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
-	"strconv"
+	"sync"
 	"time"
 
-	_ "github.com/joho/godotenv/autoload"
-
+	"maspos-be-go/internal/config"
 	"maspos-be-go/internal/database"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/health"
+	"maspos-be-go/internal/metrics"
+	"maspos-be-go/internal/ratelimit"
 )
 
 type Server struct {
-	port int
+	port   int
+	cfg    *config.Config
+	logger *slog.Logger
+
+	db      database.Service
+	health  *health.Health
+	metrics *metrics.Metrics
+	limiter ratelimit.Store
+
+	tenants    TenantStore
+	users      UserStore
+	categories CategoryStore
+	products   ProductStore
+	outlets    OutletStore
+	customers  CustomerStore
+	loyalty    LoyaltyStore
+	shifts     ShiftStore
+	orders     OrderStore
+	refunds    RefundStore
+	receipts   ReceiptTemplateStore
+	emails     ReceiptEmailStore
+	reports    ReportStore
+	rollups    SalesRollupStore
+	zReports   ZReportStore
 
-	db database.Service
+	idempotency IdempotencyStore
+
+	// tenantIDs menyimpan ID tenant per slug (lihat tenantID)
+	tenantIDs sync.Map
 }
 
-func NewServer() *http.Server {
-	port, _ := strconv.Atoi(os.Getenv("PORT"))
+// NewServer membangun HTTP server di atas koneksi database yang sudah dibuka.
+// Repository dibuat sekali di sini lalu dipakai bersama oleh semua handler.
+// m dibagi dengan worker latar belakang supaya metriknya ikut di /metrics.
+func NewServer(cfg *config.Config, db database.Service, logger *slog.Logger, m *metrics.Metrics) *http.Server {
 	NewServer := &Server{
-		port: port,
+		port:   cfg.Port,
+		cfg:    cfg,
+		logger: logger,
+
+		db: db,
+		health: health.New(
+			cfg.Health.CacheTTL,
+			health.Database(db.DB()),
+			health.Storage("uploads"),
+			health.DiskSpace("uploads", uint64(cfg.Health.MinFreeDiskMB)<<20),
+		),
+		metrics: m,
+		limiter: newLimiter(cfg.RateLimit.Backend, db),
 
-		db: database.New(),
+		tenants:    repository.NewTenantRepository(db.DB()),
+		users:      repository.NewUserRepository(db.DB()),
+		categories: repository.NewCategoryRepository(db.DB()),
+		products:   repository.NewProductRepository(db.DB()),
+		outlets:    repository.NewOutletRepository(db.DB()),
+		customers:  repository.NewCustomerRepository(db.DB()),
+		loyalty:    repository.NewLoyaltyRepository(db.DB()),
+		shifts:     repository.NewShiftRepository(db.DB()),
+		orders:     repository.NewOrderRepository(db.DB()),
+		refunds:    repository.NewRefundRepository(db.DB()),
+		receipts:   repository.NewReceiptTemplateRepository(db.DB()),
+		emails:     repository.NewReceiptEmailRepository(db.DB()),
+		reports:    repository.NewReportRepository(db.DB()),
+		rollups:    repository.NewSalesRollupRepository(db.DB()),
+		zReports:   repository.NewZReportRepository(db.DB()),
+
+		idempotency: repository.NewIdempotencyRepository(db.DB()),
 	}
 
 	// Declare Server config
@@ -33,7 +92,20 @@ func NewServer() *http.Server {
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
 	return server
 }
+
+// newLimiter memilih backend rate limit; nil berarti pembatasan dimatikan.
+func newLimiter(backend string, db database.Service) ratelimit.Store {
+	switch backend {
+	case "memory":
+		return ratelimit.NewMemory()
+	case "postgres":
+		return repository.NewRateLimitRepository(db.DB())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/logger"
+	"maspos-be-go/internal/server/dto"
+)
+
+// shiftError memetakan error ShiftRepository ke status HTTP.
+func shiftError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrShiftOpen), errors.Is(err, repository.ErrShiftClosed),
+		errors.Is(err, repository.ErrDayClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrInsufficientCash):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrOutletRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shift not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Summary Open cashier shift
+// @Description Opens a shift on a register of the active outlet with the opening cash float. A register has at most one open shift, and no shift can be opened once the business day of the outlet is closed.
+// @Tags Shift
+// @Accept json
+// @Produce json
+// @Param body body dto.ShiftOpenRequest true "Register and opening float"
+// @Success 201 {object} dto.ShiftResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /shifts [post]
+func (s *Server) OpenShiftHandler(c *gin.Context) {
+	var req dto.ShiftOpenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := s.shifts.Open(c.Request.Context(), req.Register, req.OpeningFloat, req.Note, currentUserID(c))
+	if err != nil {
+		shiftError(c, err)
+		return
+	}
+	s.metrics.ShiftOpened()
+	c.JSON(http.StatusCreated, dto.ShiftResponse{ID: id, Register: req.Register})
+}
+
+// @Summary Get shifts
+// @Description Newest first. Inside an outlet only its shifts are listed.
+// @Tags Shift
+// @Produce json
+// @Param register query string false "Only this register"
+// @Param status query string false "open to list only open shifts" Enums(open)
+// @Success 200 {array} repository.Shift
+// @Router /shifts [get]
+func (s *Server) GetAllShiftsHandler(c *gin.Context) {
+	f := repository.ShiftFilter{Register: c.Query("register")}
+	switch c.Query("status") {
+	case "":
+	case "open":
+		f.OpenOnly = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open"})
+		return
+	}
+	shifts, err := s.shifts.GetAll(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if shifts == nil {
+		shifts = []repository.Shift{}
+	}
+	c.JSON(http.StatusOK, shifts)
+}
+
+// @Summary Get shift report
+// @Description Cash totals and movements of a shift. For an open shift expected_cash is what the drawer should hold now; for a closed shift it is the stored close report with counted cash and variance.
+// @Tags Shift
+// @Produce json
+// @Param id path string true "Shift ID"
+// @Success 200 {object} repository.ShiftReport
+// @Failure 404 {object} map[string]string
+// @Router /shifts/{id} [get]
+func (s *Server) GetShiftReportHandler(c *gin.Context) {
+	report, err := s.shifts.Report(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		shiftError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Record pay-in or pay-out
+// @Description Cash added to or taken from the drawer outside of sales, with a required note. A pay-out cannot exceed the cash expected in the drawer.
+// @Tags Shift
+// @Accept json
+// @Produce json
+// @Param id path string true "Shift ID"
+// @Param body body dto.CashMovementRequest true "Cash movement"
+// @Success 201 {object} dto.CashMovementResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /shifts/{id}/cash-movements [post]
+func (s *Server) AddCashMovementHandler(c *gin.Context) {
+	var req dto.CashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.addCashMovement(c, repository.CashMovement{Type: req.Type, Amount: req.Amount, Note: req.Note})
+}
+
+func (s *Server) addCashMovement(c *gin.Context, m repository.CashMovement) {
+	ctx := c.Request.Context()
+	m.CreatedBy = currentUserID(c)
+	id, err := s.shifts.AddMovement(ctx, c.Param("id"), m)
+	if err != nil {
+		shiftError(c, err)
+		return
+	}
+	report, err := s.shifts.Report(ctx, c.Param("id"))
+	if err != nil {
+		shiftError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, dto.CashMovementResponse{ID: id, ExpectedCash: *report.ExpectedCash})
+}
+
+// @Summary Close cashier shift
+// @Description Stores the counted cash, the expected cash (opening float + cash sales - cash refunds + pay-ins - pay-outs) and their variance, and returns the shift report.
+// @Tags Shift
+// @Accept json
+// @Produce json
+// @Param id path string true "Shift ID"
+// @Param body body dto.ShiftCloseRequest true "Counted cash"
+// @Success 200 {object} repository.ShiftReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /shifts/{id}/close [post]
+func (s *Server) CloseShiftHandler(c *gin.Context) {
+	var req dto.ShiftCloseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report, err := s.shifts.Close(c.Request.Context(), c.Param("id"), *req.CountedCash, req.Note, currentUserID(c))
+	if err != nil {
+		shiftError(c, err)
+		return
+	}
+	s.metrics.ShiftClosed()
+	logger.FromContext(c.Request.Context()).Info("shift closed",
+		"shift_id", report.ID, "register", report.Register, "variance", *report.Variance)
+	c.JSON(http.StatusOK, report)
+}
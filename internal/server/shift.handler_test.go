@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/scope"
+)
+
+// fakeShiftStore meniru ShiftRepository di memori, termasuk perhitungan
+// kas yang seharusnya ada.
+type fakeShiftStore struct {
+	shifts    map[string]*repository.Shift
+	movements map[string][]repository.CashMovement
+}
+
+func newFakeShiftStore() *fakeShiftStore {
+	return &fakeShiftStore{shifts: map[string]*repository.Shift{}, movements: map[string][]repository.CashMovement{}}
+}
+
+func (f *fakeShiftStore) visible(ctx context.Context, id string) (*repository.Shift, error) {
+	sh, ok := f.shifts[id]
+	if !ok || (scope.Outlet(ctx) != "" && scope.Outlet(ctx) != sh.OutletID) {
+		return nil, sql.ErrNoRows
+	}
+	return sh, nil
+}
+
+func (f *fakeShiftStore) Open(ctx context.Context, register string, openingFloat float64, note string, userID *int) (string, error) {
+	outlet := scope.Outlet(ctx)
+	if outlet == "" {
+		return "", repository.ErrOutletRequired
+	}
+	for _, sh := range f.shifts {
+		if sh.OutletID == outlet && sh.Register == register && sh.ClosedAt == nil {
+			return "", repository.ErrShiftOpen
+		}
+	}
+	id := fmt.Sprintf("5a1e0000-0000-4000-8000-%012d", len(f.shifts)+1)
+	f.shifts[id] = &repository.Shift{ID: id, OutletID: outlet, Register: register, OpeningFloat: openingFloat, OpenNote: note, OpenedBy: userID}
+	return id, nil
+}
+
+func (f *fakeShiftStore) GetAll(ctx context.Context, filter repository.ShiftFilter) ([]repository.Shift, error) {
+	var res []repository.Shift
+	for id := range f.shifts {
+		sh, err := f.visible(ctx, id)
+		if err != nil || (filter.Register != "" && sh.Register != filter.Register) || (filter.OpenOnly && sh.ClosedAt != nil) {
+			continue
+		}
+		res = append(res, *sh)
+	}
+	return res, nil
+}
+
+func (f *fakeShiftStore) Report(ctx context.Context, id string) (*repository.ShiftReport, error) {
+	sh, err := f.visible(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rep := &repository.ShiftReport{Shift: *sh, Movements: f.movements[id]}
+	for _, m := range rep.Movements {
+		switch m.Type {
+		case repository.CashSale:
+			rep.CashSales += m.Amount
+		case repository.CashRefund:
+			rep.Refunds += m.Amount
+		case repository.CashPayIn:
+			rep.PayIns += m.Amount
+		case repository.CashPayOut:
+			rep.PayOuts += m.Amount
+		}
+	}
+	if rep.ExpectedCash == nil {
+		expected := rep.Expected()
+		rep.ExpectedCash = &expected
+	}
+	return rep, nil
+}
+
+func (f *fakeShiftStore) AddMovement(ctx context.Context, shiftID string, m repository.CashMovement) (string, error) {
+	sh, err := f.visible(ctx, shiftID)
+	if err != nil {
+		return "", err
+	}
+	if sh.ClosedAt != nil {
+		return "", repository.ErrShiftClosed
+	}
+	if m.Type == repository.CashRefund || m.Type == repository.CashPayOut {
+		rep, _ := f.Report(ctx, shiftID)
+		if m.Amount > rep.Expected() {
+			return "", repository.ErrInsufficientCash
+		}
+	}
+	m.ID = fmt.Sprintf("mov-%d", len(f.movements[shiftID])+1)
+	f.movements[shiftID] = append(f.movements[shiftID], m)
+	return m.ID, nil
+}
+
+func (f *fakeShiftStore) Close(ctx context.Context, id string, counted float64, note string, userID *int) (*repository.ShiftReport, error) {
+	rep, err := f.Report(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rep.ClosedAt != nil {
+		return nil, repository.ErrShiftClosed
+	}
+	sh := f.shifts[id]
+	now := time.Now()
+	expected := rep.Expected()
+	variance := counted - expected
+	sh.ClosedAt, sh.ClosedBy, sh.CloseNote = &now, userID, note
+	sh.ExpectedCash, sh.CountedCash, sh.Variance = &expected, &counted, &variance
+	return f.Report(ctx, id)
+}
+
+func shiftRouter(s *Server) *gin.Engine {
+	r := gin.New()
+	r.Use(asHeadOffice(), s.outletScope())
+	r.POST("/shifts", s.OpenShiftHandler)
+	r.GET("/shifts", s.GetAllShiftsHandler)
+	r.GET("/shifts/:id", s.GetShiftReportHandler)
+	r.POST("/shifts/:id/cash-movements", s.AddCashMovementHandler)
+	r.POST("/shifts/:id/close", s.CloseShiftHandler)
+	return r
+}
+
+func TestShiftLifecycle(t *testing.T) {
+	store := newFakeShiftStore()
+	s := &Server{shifts: store, outlets: &fakeOutletStore{outlets: map[string]repository.Outlet{
+		outletKemang: {ID: outletKemang}, outletDepok: {ID: outletDepok},
+	}}}
+	r := shiftRouter(s)
+
+	do := func(method, path, outlet, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if outlet != "" {
+			req.Header.Set(outletHeader, outlet)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	expectCode := func(rr *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if rr.Code != code {
+			t.Fatalf("expected %d, got %d: %s", code, rr.Code, rr.Body)
+		}
+	}
+
+	// kantor pusat tidak punya laci kas
+	expectCode(do(http.MethodPost, "/shifts", "", `{"register":"kasir-1","opening_float":500000}`), http.StatusBadRequest)
+
+	rr := do(http.MethodPost, "/shifts", outletKemang, `{"register":"kasir-1","opening_float":500000}`)
+	expectCode(rr, http.StatusCreated)
+	var opened struct{ ID string }
+	json.Unmarshal(rr.Body.Bytes(), &opened)
+
+	expectCode(do(http.MethodPost, "/shifts", outletKemang, `{"register":"kasir-1","opening_float":100000}`), http.StatusConflict)
+	// register dengan nama sama di outlet lain tidak bentrok
+	expectCode(do(http.MethodPost, "/shifts", outletDepok, `{"register":"kasir-1","opening_float":0}`), http.StatusCreated)
+
+	base := "/shifts/" + opened.ID
+	store.AddMovement(scope.WithOutlet(context.Background(), outletKemang), opened.ID,
+		repository.CashMovement{Type: repository.CashSale, Amount: 38000, Reference: "INV-1"})
+	expectCode(do(http.MethodPost, base+"/cash-movements", outletKemang, `{"type":"pay_in","amount":100000,"note":"Tambahan receh"}`), http.StatusCreated)
+	expectCode(do(http.MethodPost, base+"/cash-movements", outletKemang, `{"type":"pay_out","amount":25000}`), http.StatusBadRequest)
+	rr = do(http.MethodPost, base+"/cash-movements", outletKemang, `{"type":"pay_out","amount":25000,"note":"Beli galon"}`)
+	expectCode(rr, http.StatusCreated)
+	var movement struct {
+		ExpectedCash float64 `json:"expected_cash"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &movement)
+	if movement.ExpectedCash != 613000 {
+		t.Errorf("expected cash 500000 + 38000 + 100000 - 25000 = 613000, got %v", movement.ExpectedCash)
+	}
+
+	// shift outlet lain tidak terlihat
+	expectCode(do(http.MethodGet, base, outletDepok, ""), http.StatusNotFound)
+
+	expectCode(do(http.MethodPost, base+"/close", outletKemang, `{"note":"lupa hitung"}`), http.StatusBadRequest)
+	rr = do(http.MethodPost, base+"/close", outletKemang, `{"counted_cash":610000,"note":"Kurang 3000"}`)
+	expectCode(rr, http.StatusOK)
+	var report repository.ShiftReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.CashSales != 38000 || report.PayIns != 100000 || report.PayOuts != 25000 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if report.ExpectedCash == nil || *report.ExpectedCash != 613000 || report.Variance == nil || *report.Variance != -3000 {
+		t.Errorf("expected 613000 expected cash and -3000 variance, got %v and %v", report.ExpectedCash, report.Variance)
+	}
+
+	expectCode(do(http.MethodPost, base+"/close", outletKemang, `{"counted_cash":613000}`), http.StatusConflict)
+	expectCode(do(http.MethodPost, base+"/cash-movements", outletKemang, `{"type":"pay_in","amount":1000,"note":"Telat"}`), http.StatusConflict)
+	// register bisa dibuka lagi setelah shift sebelumnya ditutup
+	expectCode(do(http.MethodPost, "/shifts", outletKemang, `{"register":"kasir-1","opening_float":500000}`), http.StatusCreated)
+
+	rr = do(http.MethodGet, "/shifts?status=open", "", "")
+	expectCode(rr, http.StatusOK)
+	var open []repository.Shift
+	json.Unmarshal(rr.Body.Bytes(), &open)
+	if len(open) != 2 {
+		t.Errorf("expected the head office to see 2 open shifts, got %d", len(open))
+	}
+	expectCode(do(http.MethodGet, "/shifts?status=closed", "", ""), http.StatusBadRequest)
+}
+
+func TestShiftReportExpected(t *testing.T) {
+	rep := repository.ShiftReport{
+		Shift:     repository.Shift{OpeningFloat: 200000.10},
+		CashSales: 0.3, Refunds: 0.1, PayIns: 0, PayOuts: 0.1,
+	}
+	if got := rep.Expected(); got != 200000.2 {
+		t.Errorf("Expected() = %v, want 200000.2", got)
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/loyalty"
+	"maspos-be-go/internal/receipt"
+)
+
+// Interface berikut adalah kebutuhan handler terhadap lapisan repository.
+// Implementasi aslinya ada di package repository; test cukup memasang fake.
+
+type UserStore interface {
+	Create(ctx context.Context, name, email, password string) error
+	GetByEmail(ctx context.Context, email string) (*repository.User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+}
+
+type TenantStore interface {
+	GetBySlug(ctx context.Context, slug string) (*repository.Tenant, error)
+}
+
+type CategoryStore interface {
+	Create(ctx context.Context, c repository.Category) (string, error)
+	GetAll(ctx context.Context, f repository.CategoryFilter) ([]repository.Category, error)
+	GetSubtree(ctx context.Context, rootID string) ([]repository.Category, error)
+	GetByID(ctx context.Context, id string) (*repository.Category, error)
+	Update(ctx context.Context, id string, changes repository.CategoryChanges, version int) (int, error)
+	Delete(ctx context.Context, id string, version int) error
+	Reorder(ctx context.Context, positions []repository.Position) error
+	Export(ctx context.Context, f repository.CategoryFilter, fn func(repository.CategoryExport) error) error
+}
+
+type ProductStore interface {
+	Create(ctx context.Context, p repository.Product) (string, error)
+	GetAll(ctx context.Context, f repository.ProductFilter) ([]repository.Product, error)
+	GetByID(ctx context.Context, id string) (*repository.Product, error)
+	Update(ctx context.Context, id string, changes repository.ProductChanges, version int) (int, error)
+	Delete(ctx context.Context, id string, version int) error
+	Reorder(ctx context.Context, positions []repository.Position) error
+	Import(ctx context.Context, items []repository.ProductImport, dryRun bool) (repository.ImportResult, error)
+	Export(ctx context.Context, f repository.ProductFilter, fn func(repository.ProductExport) error) error
+	SetOutletPrice(ctx context.Context, id string, price float64) (int, error)
+	DeleteOutletPrice(ctx context.Context, id string) (int, error)
+}
+
+type OutletStore interface {
+	Create(ctx context.Context, o repository.Outlet) (string, error)
+	GetAll(ctx context.Context) ([]repository.Outlet, error)
+	GetByID(ctx context.Context, id string) (*repository.Outlet, error)
+	Update(ctx context.Context, o repository.Outlet) error
+	Delete(ctx context.Context, id string) error
+	AssignUser(ctx context.Context, outletID string, userID int) error
+	UnassignUser(ctx context.Context, outletID string, userID int) error
+	IDsForUser(ctx context.Context, userID int) ([]string, error)
+}
+
+type CustomerStore interface {
+	Create(ctx context.Context, name, phone, email string, birthday *time.Time, notes string) (string, error)
+	GetAll(ctx context.Context, search string) ([]repository.Customer, error)
+	GetByID(ctx context.Context, id string) (*repository.Customer, error)
+	GetByPhone(ctx context.Context, phone string) (*repository.Customer, error)
+	Update(ctx context.Context, id, name, phone, email string, birthday *time.Time, notes string) error
+	Delete(ctx context.Context, id string) error
+}
+
+type LoyaltyStore interface {
+	GetRules(ctx context.Context) (loyalty.Rules, error)
+	UpdateRules(ctx context.Context, rules loyalty.Rules) error
+	Balance(ctx context.Context, customerID string) (int64, error)
+	GetEntries(ctx context.Context, customerID string) ([]repository.LoyaltyEntry, error)
+	Adjust(ctx context.Context, customerID string, points int64, note string) (string, error)
+	Redeem(ctx context.Context, customerID string, points int64, reference string) (string, error)
+	Reverse(ctx context.Context, entryID, note string) (string, error)
+	ExpireDue(ctx context.Context, now time.Time) (int, error)
+}
+
+type ShiftStore interface {
+	Open(ctx context.Context, register string, openingFloat float64, note string, userID *int) (string, error)
+	GetAll(ctx context.Context, f repository.ShiftFilter) ([]repository.Shift, error)
+	Report(ctx context.Context, id string) (*repository.ShiftReport, error)
+	AddMovement(ctx context.Context, shiftID string, m repository.CashMovement) (string, error)
+	Close(ctx context.Context, id string, counted float64, note string, userID *int) (*repository.ShiftReport, error)
+}
+
+type OrderStore interface {
+	Create(ctx context.Context, in repository.NewOrder) (*repository.Order, error)
+	GetAll(ctx context.Context, f repository.OrderFilter) ([]repository.Order, error)
+	GetByID(ctx context.Context, id string) (*repository.Order, error)
+	CustomerHistory(ctx context.Context, customerID string, limit int) (*repository.PurchaseHistory, error)
+}
+
+type RefundStore interface {
+	Create(ctx context.Context, in repository.NewRefund) (*repository.Refund, error)
+	GetByID(ctx context.Context, id string) (*repository.Refund, error)
+	GetByOrder(ctx context.Context, orderID string) ([]repository.Refund, error)
+}
+
+type ReceiptTemplateStore interface {
+	Get(ctx context.Context, outletID string) (*receipt.Template, error)
+	Put(ctx context.Context, outletID string, t receipt.Template) error
+}
+
+type ReceiptEmailStore interface {
+	Enqueue(ctx context.Context, o *repository.Order, email string, r receipt.Receipt, userID *int) (string, error)
+	GetAll(ctx context.Context, f repository.ReceiptEmailFilter) ([]repository.ReceiptEmail, error)
+	Resend(ctx context.Context, id, email string, userID *int) (string, error)
+}
+
+type ReportStore interface {
+	SalesTotal(ctx context.Context, f repository.ReportFilter) (repository.Sales, error)
+	SalesByDay(ctx context.Context, f repository.ReportFilter) ([]repository.DailySales, error)
+	SalesByHour(ctx context.Context, f repository.ReportFilter) ([]repository.HourlySales, error)
+	SalesByCashier(ctx context.Context, f repository.ReportFilter) ([]repository.CashierSales, error)
+	SalesByProduct(ctx context.Context, f repository.ReportFilter, limit int) ([]repository.ItemSales, error)
+	SalesByCategory(ctx context.Context, f repository.ReportFilter) ([]repository.ItemSales, error)
+}
+
+type SalesRollupStore interface {
+	Checkpoint(ctx context.Context) (*repository.SalesRollupCheckpoint, error)
+	Daily(ctx context.Context, from, to string) ([]repository.OutletDailySales, error)
+	Products(ctx context.Context, from, to string, limit int) ([]repository.ItemSales, error)
+}
+
+type ZReportStore interface {
+	Close(ctx context.Context, date time.Time, userID *int) (*repository.ZReport, error)
+	GetAll(ctx context.Context) ([]repository.ZReport, error)
+	GetByID(ctx context.Context, id string) (*repository.ZReport, error)
+}
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, scope, key, fingerprint string, lockFor, ttl time.Duration) (*repository.IdempotencyRecord, error)
+	Complete(ctx context.Context, scope, key string, status int, contentType, etag string, body []byte) error
+	Release(ctx context.Context, scope, key string) error
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/server/dto"
+)
+
+// zReportError memetakan error ZReportRepository ke status HTTP.
+func zReportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrDayClosed), errors.Is(err, repository.ErrShiftsOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrOutletRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Z-report not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Summary Close business day
+// @Description Closes a business day of the active outlet and stores its Z-report with the next number of the outlet. The report covers every cash movement since the previous Z-report up to the end of the day. Every shift opened before the end of the day must be closed first; afterwards no shift can be opened and no cash can be recorded in the closed day. Z-reports cannot be changed or deleted.
+// @Tags Report
+// @Accept json
+// @Produce json
+// @Param body body dto.CloseDayRequest false "Day to close, default today"
+// @Success 201 {object} repository.ZReport
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /z-reports [post]
+func (s *Server) CloseDayHandler(c *gin.Context) {
+	var req dto.CloseDayRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Timezone == "" {
+		req.Timezone = defaultReportTZ
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timezone must be an IANA time zone such as Asia/Jakarta"})
+		return
+	}
+	today := time.Now().In(loc).Format(reportDateLayout)
+	if req.Date == "" {
+		req.Date = today
+	}
+	if req.Date > today {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a day cannot be closed before it starts"})
+		return
+	}
+	date, err := time.ParseInLocation(reportDateLayout, req.Date, loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be a date such as 2026-01-31"})
+		return
+	}
+
+	z, err := s.zReports.Close(c.Request.Context(), date, currentUserID(c))
+	if err != nil {
+		zReportError(c, err)
+		return
+	}
+	s.metrics.ZReportClosed()
+	c.JSON(http.StatusCreated, z)
+}
+
+// @Summary Get Z-reports
+// @Description Closed business days, newest first. Inside an outlet only its Z-reports are listed.
+// @Tags Report
+// @Produce json
+// @Success 200 {array} repository.ZReport
+// @Router /z-reports [get]
+func (s *Server) GetAllZReportsHandler(c *gin.Context) {
+	reports, err := s.zReports.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reports == nil {
+		reports = []repository.ZReport{}
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// @Summary Get Z-report
+// @Tags Report
+// @Produce json
+// @Param id path string true "Z-report ID"
+// @Success 200 {object} repository.ZReport
+// @Failure 404 {object} map[string]string
+// @Router /z-reports/{id} [get]
+func (s *Server) GetZReportHandler(c *gin.Context) {
+	z, err := s.zReports.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		zReportError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, z)
+}
+
+// @Summary Print Z-report
+// @Description Reprints a Z-report with the receipt template header of its outlet. The content is the snapshot stored when the day was closed.
+// @Tags Report
+// @Produce application/vnd.escpos,text/plain,text/html,application/pdf
+// @Param id path string true "Z-report ID"
+// @Param format query string false "Output format, default escpos" Enums(escpos, text, html, pdf)
+// @Param paper query int false "Paper width in mm, default from the outlet template" Enums(58, 80)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /z-reports/{id}/print [get]
+func (s *Server) PrintZReportHandler(c *gin.Context) {
+	format := c.DefaultQuery("format", receipt.FormatESCPOS)
+	contentType, err := receipt.ContentType(format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	z, err := s.zReports.GetByID(ctx, c.Param("id"))
+	if err != nil {
+		zReportError(c, err)
+		return
+	}
+	t, err := s.receipts.Get(ctx, z.OutletID)
+	if err != nil {
+		receiptTemplateError(c, err)
+		return
+	}
+	paper := t.Paper
+	if p := c.Query("paper"); p != "" {
+		if paper, err = strconv.Atoi(p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": receipt.ErrUnknownPaper.Error()})
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := receipt.RenderSummary(&buf, format, paper, zReportSummary(z), *t); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// zReportSummary menyusun cetakan Z-report dalam bahasa Indonesia, dengan
+// waktu menurut zona waktu laporan.
+func zReportSummary(z *repository.ZReport) receipt.Summary {
+	loc, err := time.LoadLocation(z.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	const layout = "02/01/2006 15:04"
+	date := z.BusinessDate
+	if d, err := time.Parse(reportDateLayout, z.BusinessDate); err == nil {
+		date = d.Format("02/01/2006")
+	}
+	from := "-"
+	if z.PeriodStart != nil {
+		from = z.PeriodStart.In(loc).Format(layout)
+	}
+
+	rows := []receipt.SummaryRow{
+		{Label: "Periode dari", Value: from},
+		{Label: "Periode sampai", Value: z.PeriodEnd.In(loc).Format(layout)},
+		{Label: "Ditutup", Value: z.ClosedAt.In(loc).Format(layout)},
+		{},
+		{Label: fmt.Sprintf("Penjualan kotor (%d)", z.Orders), Value: receipt.Money(z.Gross)},
+		{Label: "Diskon", Value: receipt.Money(z.Discounts)},
+		{Label: fmt.Sprintf("Refund (%d)", z.RefundCount), Value: receipt.Money(z.Refunds)},
+		{Label: "Penjualan bersih", Value: receipt.Money(z.Net), Bold: true},
+		{Label: "Pajak", Value: receipt.Money(z.Tax)},
+		{Label: "Total", Value: receipt.Money(z.Total), Bold: true},
+		{},
+		{Label: "Pembayaran"},
+	}
+	for _, t := range z.Tenders {
+		rows = append(rows, receipt.SummaryRow{Label: fmt.Sprintf("  %s (%d)", t.Method, t.Count), Value: receipt.Money(t.Amount)})
+	}
+	rows = append(rows, receipt.SummaryRow{Label: "Refund"})
+	for _, t := range z.RefundTenders {
+		rows = append(rows, receipt.SummaryRow{Label: fmt.Sprintf("  %s (%d)", t.Method, t.Count), Value: receipt.Money(t.Amount)})
+	}
+	rows = append(rows,
+		receipt.SummaryRow{},
+		receipt.SummaryRow{Label: "Pay-in", Value: receipt.Money(z.PayIns)},
+		receipt.SummaryRow{Label: "Pay-out", Value: receipt.Money(z.PayOuts)},
+		receipt.SummaryRow{},
+	)
+	for _, sh := range z.Shifts {
+		rows = append(rows,
+			receipt.SummaryRow{Label: "Shift " + sh.Register, Value: sh.ClosedAt.In(loc).Format(layout)},
+			receipt.SummaryRow{Label: "  Selisih kas", Value: receipt.Money(sh.Variance)},
+		)
+	}
+	rows = append(rows,
+		receipt.SummaryRow{Label: "Kas seharusnya", Value: receipt.Money(z.ExpectedCash)},
+		receipt.SummaryRow{Label: "Kas dihitung", Value: receipt.Money(z.CountedCash)},
+		receipt.SummaryRow{Label: "Selisih kas", Value: receipt.Money(z.Variance), Bold: true},
+	)
+	return receipt.Summary{
+		Title:    "LAPORAN TUTUP HARI",
+		Subtitle: []string{fmt.Sprintf("Z-%04d", z.Number), date},
+		Rows:     rows,
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"maspos-be-go/internal/database/repository"
+	"maspos-be-go/internal/receipt"
+	"maspos-be-go/internal/scope"
+)
+
+// fakeZReportStore menutup hari berurutan per outlet seperti
+// ZReportRepository, tanpa menghitung order dan mutasi kas.
+type fakeZReportStore struct {
+	reports []repository.ZReport
+}
+
+func (f *fakeZReportStore) Close(ctx context.Context, date time.Time, userID *int) (*repository.ZReport, error) {
+	outlet := scope.Outlet(ctx)
+	if outlet == "" {
+		return nil, repository.ErrOutletRequired
+	}
+	z := repository.ZReport{
+		ID:           "z" + strconv.Itoa(len(f.reports)+1),
+		OutletID:     outlet,
+		Number:       1,
+		BusinessDate: date.Format(time.DateOnly),
+		Timezone:     date.Location().String(),
+		PeriodEnd:    date.AddDate(0, 0, 1),
+		ZReportTotals: repository.ZReportTotals{
+			Sales: repository.Sales{Orders: 3, Gross: 92000, Discounts: 2000, RefundCount: 1, Refunds: 18000,
+				Net: 72000, Tax: 7200, Total: 79200, AverageTicket: 30000},
+			Tenders:       []repository.TenderTotal{{Method: "cash", Count: 2, Amount: 59400}, {Method: "qris", Count: 1, Amount: 39600}},
+			RefundTenders: []repository.TenderTotal{{Method: "cash", Count: 1, Amount: 19800}},
+			Shifts:        []repository.ZReportShift{{ID: "s1", Register: "Kasir 1", ClosedAt: date.Add(22 * time.Hour), Variance: -500}},
+			Variance:      -500,
+		},
+		ClosedBy: userID,
+		ClosedAt: time.Now(),
+	}
+	for _, prev := range f.reports {
+		if prev.OutletID != outlet {
+			continue
+		}
+		if z.BusinessDate <= prev.BusinessDate {
+			return nil, repository.ErrDayClosed
+		}
+		z.Number = prev.Number + 1
+		z.PeriodStart = &prev.PeriodEnd
+	}
+	f.reports = append(f.reports, z)
+	return &z, nil
+}
+
+func (f *fakeZReportStore) GetAll(ctx context.Context) ([]repository.ZReport, error) {
+	var res []repository.ZReport
+	for _, z := range f.reports {
+		if scope.Outlet(ctx) == "" || scope.Outlet(ctx) == z.OutletID {
+			res = append(res, z)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeZReportStore) GetByID(ctx context.Context, id string) (*repository.ZReport, error) {
+	for _, z := range f.reports {
+		if z.ID == id && (scope.Outlet(ctx) == "" || scope.Outlet(ctx) == z.OutletID) {
+			return &z, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestZReports(t *testing.T) {
+	outlets := map[string]repository.Outlet{
+		outletKemang: {ID: outletKemang, Name: "Kopi Senja Kemang"},
+		outletDepok:  {ID: outletDepok, Name: "Kopi Senja Depok"},
+	}
+	store := &fakeZReportStore{}
+	s := &Server{
+		outlets:  &fakeOutletStore{outlets: outlets},
+		receipts: &fakeReceiptTemplateStore{outlets: outlets, templates: map[string]receipt.Template{}},
+		zReports: store,
+	}
+	r := gin.New()
+	r.Use(asHeadOffice(), s.outletScope())
+	r.POST("/z-reports", s.CloseDayHandler)
+	r.GET("/z-reports", s.GetAllZReportsHandler)
+	r.GET("/z-reports/:id", s.GetZReportHandler)
+	r.GET("/z-reports/:id/print", s.PrintZReportHandler)
+
+	do := func(method, path, outlet, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if outlet != "" {
+			req.Header.Set(outletHeader, outlet)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+	expectCode := func(rr *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if rr.Code != code {
+			t.Fatalf("expected %d, got %d: %s", code, rr.Code, rr.Body)
+		}
+	}
+
+	expectCode(do(http.MethodPost, "/z-reports", "", `{"date":"2026-01-15"}`), http.StatusBadRequest)
+	for _, body := range []string{`{"date":"15/01/2026"}`, `{"timezone":"Mars/Olympus"}`, `{"date":"2999-01-01"}`} {
+		expectCode(do(http.MethodPost, "/z-reports", outletKemang, body), http.StatusBadRequest)
+	}
+
+	rr := do(http.MethodPost, "/z-reports", outletKemang, `{"date":"2026-01-15"}`)
+	expectCode(rr, http.StatusCreated)
+	var z repository.ZReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &z); err != nil {
+		t.Fatal(err)
+	}
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	if z.Number != 1 || z.BusinessDate != "2026-01-15" || !z.PeriodEnd.Equal(time.Date(2026, 1, 16, 0, 0, 0, 0, jakarta)) {
+		t.Errorf("unexpected Z-report: %+v", z)
+	}
+	expectCode(do(http.MethodPost, "/z-reports", outletKemang, `{"date":"2026-01-15"}`), http.StatusConflict)
+	expectCode(do(http.MethodPost, "/z-reports", outletKemang, ""), http.StatusCreated)
+
+	rr = do(http.MethodGet, "/z-reports", outletDepok, "")
+	expectCode(rr, http.StatusOK)
+	if strings.TrimSpace(rr.Body.String()) != "[]" {
+		t.Errorf("another outlet sees Z-reports: %s", rr.Body)
+	}
+	expectCode(do(http.MethodGet, "/z-reports/"+z.ID, outletDepok, ""), http.StatusNotFound)
+	expectCode(do(http.MethodGet, "/z-reports/"+z.ID, "", ""), http.StatusOK)
+
+	rr = do(http.MethodGet, "/z-reports/"+z.ID+"/print?format=text&paper=58", "", "")
+	expectCode(rr, http.StatusOK)
+	for _, want := range []string{"Kopi Senja Kemang", "LAPORAN TUTUP HARI", "Z-0001", "15/01/2026", "Penjualan bersih", "72.000",
+		"Pajak", "7.200", "qris (1)", "39.600", "cash (1)", "19.800", "Shift Kasir 1", "-500"} {
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("print lacks %q:\n%s", want, rr.Body)
+		}
+	}
+	expectCode(do(http.MethodGet, "/z-reports/"+z.ID+"/print?format=docx", "", ""), http.StatusBadRequest)
+}
@@ -1,20 +1,67 @@
 package utils
 
 import (
+	"strconv"
 	"time"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Gunakan secret key yang aman, idealnya dari .env
-var secretKey = []byte("maspos-secret-key-12345")
-
-func GenerateToken(email string) (string, error) {
+// GenerateToken membuat JWT untuk user yang login, ditandatangani dengan
+// secret dari konfigurasi dan berlaku selama ttl. tenantID adalah merchant
+// tempat user login; outlets adalah outlet tempat user ditugaskan. headOffice
+// memberi hak kantor pusat dan hanya diisi untuk user yang ditandai sebagai
+// kantor pusat di database.
+func GenerateToken(userID int, email, tenantID string, outlets []string, headOffice bool, secret []byte, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
+		"sub":   strconv.Itoa(userID),
 		"email": email,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(),
+		"exp":   time.Now().Add(ttl).Unix(),
 		"iat":   time.Now().Unix(),
 	}
+	if tenantID != "" {
+		claims["tenant"] = tenantID
+	}
+	if len(outlets) > 0 {
+		claims["outlets"] = outlets
+	}
+	if headOffice {
+		claims["head_office"] = true
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secretKey)
-}
\ No newline at end of file
+	return token.SignedString(secret)
+}
+
+// ParseToken memverifikasi tanda tangan dan masa berlaku token lalu
+// mengembalikan klaimnya.
+func ParseToken(tokenString string, secret []byte) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (any, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	return claims, err
+}
+
+// TokenTenant mengembalikan klaim tenant dari token yang sudah diparse.
+func TokenTenant(claims jwt.MapClaims) string {
+	id, _ := claims["tenant"].(string)
+	return id
+}
+
+// TokenOutlets mengembalikan klaim outlets dari token yang sudah diparse.
+func TokenOutlets(claims jwt.MapClaims) []string {
+	list, _ := claims["outlets"].([]any)
+	outlets := make([]string, 0, len(list))
+	for _, v := range list {
+		if id, ok := v.(string); ok {
+			outlets = append(outlets, id)
+		}
+	}
+	return outlets
+}
+
+// TokenHeadOffice melaporkan apakah token membawa klaim kantor pusat.
+func TokenHeadOffice(claims jwt.MapClaims) bool {
+	headOffice, _ := claims["head_office"].(bool)
+	return headOffice
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// NormalizePhone converts an Indonesian phone number written as 0812-3456-789,
+// +62 812 3456 789 or 812345678 into the canonical 62xxxxxxxxx form used to
+// deduplicate customers.
+func NormalizePhone(phone string) (string, error) {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+
+	switch {
+	case strings.HasPrefix(digits, "62"):
+	case strings.HasPrefix(digits, "0"):
+		digits = "62" + digits[1:]
+	case strings.HasPrefix(digits, "8"):
+		digits = "62" + digits
+	}
+
+	if len(digits) < 9 || len(digits) > 15 {
+		return "", ErrInvalidPhone
+	}
+	return digits, nil
+}
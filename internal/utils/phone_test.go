@@ -0,0 +1,25 @@
+package utils
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	cases := map[string]string{
+		"0812-3456-7890":    "6281234567890",
+		"+62 812 3456 7890": "6281234567890",
+		"81234567890":       "6281234567890",
+		"6281234567890":     "6281234567890",
+	}
+	for in, want := range cases {
+		got, err := NormalizePhone(in)
+		if err != nil {
+			t.Fatalf("NormalizePhone(%q) returned error: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if _, err := NormalizePhone("12-34"); err != ErrInvalidPhone {
+		t.Errorf("expected ErrInvalidPhone for short number, got %v", err)
+	}
+}
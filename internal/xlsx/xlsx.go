@@ -0,0 +1,213 @@
+// Package xlsx membaca dan menulis lembar kerja Office Open XML (.xlsx)
+// sebagai tabel. Hanya fitur yang dibutuhkan impor dan ekspor katalog yang
+// didukung: lembar pertama, shared string, inline string, angka dan
+// boolean. Rumus dibaca dari nilai cache-nya; format tanggal tidak
+// diterjemahkan.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var ErrNoSheet = errors.New("xlsx: workbook has no worksheet")
+
+// ReadRows returns the cells of the first worksheet, one slice per row.
+// Empty rows are kept so that row numbers match the spreadsheet; trailing
+// empty cells are trimmed.
+func ReadRows(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	sheet, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	return readSheet(sheet, shared)
+}
+
+type workbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Rels []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// firstSheet mencari lembar pertama sesuai urutan di workbook.xml, bukan
+// sekadar sheet1.xml, karena lembar bisa diurutkan ulang di Excel.
+func firstSheet(files map[string]*zip.File) (*zip.File, error) {
+	var wb workbook
+	if err := decodeFile(files["xl/workbook.xml"], &wb); err != nil {
+		return nil, err
+	}
+	var rels relationships
+	if err := decodeFile(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return nil, err
+	}
+	if len(wb.Sheets) == 0 {
+		return nil, ErrNoSheet
+	}
+	for _, rel := range rels.Rels {
+		if rel.ID != wb.Sheets[0].ID {
+			continue
+		}
+		name := path.Join("xl", rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			name = strings.TrimPrefix(rel.Target, "/")
+		}
+		if f, ok := files[name]; ok {
+			return f, nil
+		}
+	}
+	return nil, ErrNoSheet
+}
+
+// richText adalah <si> atau <is>: teks biasa di <t> atau potongan <r><t>.
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+func readSharedStrings(f *zip.File) ([]string, error) {
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+	if err := decodeFile(f, &sst); err != nil {
+		return nil, err
+	}
+	res := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		res[i] = si.String()
+	}
+	return res, nil
+}
+
+type sheetData struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string   `xml:"r,attr"`
+			T      string   `xml:"t,attr"`
+			V      string   `xml:"v"`
+			Inline richText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readSheet(f *zip.File, shared []string) ([][]string, error) {
+	var data sheetData
+	if err := decodeFile(f, &data); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range data.Rows {
+		// baris tanpa atribut r mengikuti baris sebelumnya
+		n := row.R
+		if n == 0 {
+			n = len(rows) + 1
+		}
+		for len(rows) < n {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.R != "" {
+				var err error
+				if col, err = columnIndex(c.R); err != nil {
+					return nil, err
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch c.T {
+			case "s":
+				var i int
+				if _, err := fmt.Sscan(c.V, &i); err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("xlsx: cell %s: bad shared string index %q", c.R, c.V)
+				}
+				cells[col] = shared[i]
+			case "inlineStr":
+				cells[col] = c.Inline.String()
+			case "b":
+				cells[col] = map[string]string{"1": "true", "0": "false"}[c.V]
+			default:
+				cells[col] = c.V
+			}
+		}
+		for len(cells) > 0 && cells[len(cells)-1] == "" {
+			cells = cells[:len(cells)-1]
+		}
+		rows[n-1] = cells
+	}
+	return rows, nil
+}
+
+// columnIndex mengubah referensi sel seperti "AB12" menjadi indeks kolom
+// berbasis nol (27).
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("xlsx: bad cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+func decodeFile(f *zip.File, v any) error {
+	if f == nil {
+		return errors.New("xlsx: not a workbook")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("xlsx: %w", err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("xlsx: %s: %w", f.Name, err)
+	}
+	return nil
+}
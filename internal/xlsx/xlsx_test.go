@@ -0,0 +1,106 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func workbookFile(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadRows(t *testing.T) {
+	r := workbookFile(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Produk" sheetId="2" r:id="rId7"/><sheet name="Lain" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+			<Relationship Id="rId7" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<si><t>sku</t></si><si><t>name</t></si><si><r><t>Kopi </t></r><r><t>Susu</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1"><v>wrong sheet</v></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>price</t></is></c></row>
+			<row r="3"><c r="A3"><v>1001</v></c><c r="B3" t="s"><v>2</v></c><c r="C3" t="b"><v>1</v></c><c r="D3"><v>18000</v></c></row>
+		</sheetData></worksheet>`,
+	})
+
+	rows, err := ReadRows(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"sku", "name", "", "price"},
+		nil,
+		{"1001", "Kopi Susu", "true", "18000"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q, want %q", rows, want)
+	}
+}
+
+func TestReadRowsNotAWorkbook(t *testing.T) {
+	r := bytes.NewReader([]byte("sku,name\n1001,Kopi\n"))
+	if _, err := ReadRows(r, r.Size()); err == nil {
+		t.Error("expected an error for a CSV file")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for ref, want := range map[string]int{"A1": 0, "Z9": 25, "AA10": 26, "AB12": 27} {
+		if got, err := columnIndex(ref); err != nil || got != want {
+			t.Errorf("columnIndex(%q) = %d, %v; want %d", ref, got, err, want)
+		}
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Produk & Harga")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteRow([]any{"sku", "name", "price", "active"})
+	w.WriteRow([]any{"K-1", "Kopi <Susu> & Gula ", 18000.5, true})
+	w.WriteRow([]any{"K-2", nil, 7, false})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadRows(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"sku", "name", "price", "active"},
+		{"K-1", "Kopi <Susu> & Gula ", "18000.5", "true"},
+		{"K-2", "", "7", "false"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q, want %q", rows, want)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}